@@ -16,6 +16,7 @@ require (
 	github.com/golang/protobuf v1.4.2
 	github.com/iwind/TeaGo v0.0.0-20210125103732-4d79fc3c3d0b
 	github.com/lionsoul2014/ip2region v2.2.0-release+incompatible
+	github.com/miekg/dns v1.1.31
 	github.com/mozillazg/go-pinyin v0.18.0
 	github.com/pkg/sftp v1.12.0
 	github.com/shirou/gopsutil v2.20.9+incompatible
@@ -0,0 +1,177 @@
+package accesslogs
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/iwind/TeaGo/logs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 磁盘缓冲区
+// 写入存储失败的日志按批次保存为文件，每行一条JSON格式的日志，存储恢复后按照写入顺序重新发送
+type DiskBuffer struct {
+	dir     string
+	maxSize int64
+
+	seq    int64
+	locker sync.Mutex
+}
+
+// 获取新对象
+// maxSize 缓冲区最大尺寸，超出后会删除最早的文件
+func NewDiskBuffer(dir string, maxSize int64) *DiskBuffer {
+	return &DiskBuffer{
+		dir:     dir,
+		maxSize: maxSize,
+	}
+}
+
+// 保存一批日志
+func (this *DiskBuffer) Push(accessLogs []*pb.HTTPAccessLog) error {
+	if len(accessLogs) == 0 {
+		return nil
+	}
+
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	for _, accessLog := range accessLogs {
+		err := encoder.Encode(accessLog)
+		if err != nil {
+			return err
+		}
+	}
+
+	this.locker.Lock()
+	defer this.locker.Unlock()
+
+	err := os.MkdirAll(this.dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	// 先写入临时文件再改名，防止读取到不完整的文件
+	this.seq++
+	name := fmt.Sprintf("%020d-%06d", time.Now().UnixNano(), this.seq%1000000)
+	tmpPath := filepath.Join(this.dir, name+".tmp")
+	err = ioutil.WriteFile(tmpPath, buf.Bytes(), 0644)
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	err = os.Rename(tmpPath, filepath.Join(this.dir, name+".log"))
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	this.shrink()
+	return nil
+}
+
+// 读取最早的一批日志，没有日志时返回空路径
+func (this *DiskBuffer) Peek() (path string, accessLogs []*pb.HTTPAccessLog, err error) {
+	this.locker.Lock()
+	files := this.files()
+	this.locker.Unlock()
+
+	if len(files) == 0 {
+		return "", nil, nil
+	}
+	path = files[0]
+
+	fp, err := os.Open(path)
+	if err != nil {
+		return "", nil, err
+	}
+	defer func() {
+		_ = fp.Close()
+	}()
+
+	scanner := bufio.NewScanner(fp)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		accessLog := &pb.HTTPAccessLog{}
+		err = json.Unmarshal(line, accessLog)
+		if err != nil {
+			// 文件已损坏，直接删除，防止阻塞后续的日志
+			_ = fp.Close()
+			_ = os.Remove(path)
+			return "", nil, errors.New("remove broken buffer file '" + path + "': " + err.Error())
+		}
+		accessLogs = append(accessLogs, accessLog)
+	}
+	err = scanner.Err()
+	if err != nil {
+		return "", nil, err
+	}
+	return path, accessLogs, nil
+}
+
+// 删除已经发送的日志文件
+func (this *DiskBuffer) Remove(path string) error {
+	this.locker.Lock()
+	defer this.locker.Unlock()
+
+	err := os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// 缓冲区中的文件数量
+func (this *DiskBuffer) Len() int {
+	this.locker.Lock()
+	defer this.locker.Unlock()
+	return len(this.files())
+}
+
+// 按照写入顺序列出所有文件
+func (this *DiskBuffer) files() []string {
+	matches, err := filepath.Glob(filepath.Join(this.dir, "*.log"))
+	if err != nil {
+		return nil
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+// 超出最大尺寸时删除最早的文件
+func (this *DiskBuffer) shrink() {
+	if this.maxSize <= 0 {
+		return
+	}
+	files := this.files()
+	sizes := make([]int64, len(files))
+	var total int64
+	for index, file := range files {
+		stat, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		sizes[index] = stat.Size()
+		total += stat.Size()
+	}
+	for index, file := range files {
+		if total <= this.maxSize || index == len(files)-1 {
+			break
+		}
+		if os.Remove(file) == nil {
+			total -= sizes[index]
+			logs.Println("[ACCESS_LOG]buffer '" + this.dir + "' is full, drop '" + filepath.Base(file) + "'")
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package accesslogs
+
+import (
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestDiskBuffer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "accesslogs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	buffer := NewDiskBuffer(dir, 0)
+	for _, requestId := range []string{"1", "2", "3"} {
+		err = buffer.Push([]*pb.HTTPAccessLog{testAccessLog(requestId)})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if buffer.Len() != 3 {
+		t.Fatal("should have 3 files")
+	}
+
+	// 按写入顺序读取
+	for _, requestId := range []string{"1", "2", "3"} {
+		path, accessLogs, err := buffer.Peek()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(accessLogs) != 1 || accessLogs[0].RequestId != requestId {
+			t.Fatal("should read '" + requestId + "'")
+		}
+		err = buffer.Remove(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	path, _, err := buffer.Peek()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(path) > 0 {
+		t.Fatal("buffer should be empty")
+	}
+}
+
+func TestDiskBuffer_MaxSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "accesslogs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	buffer := NewDiskBuffer(dir, 1)
+	for _, requestId := range []string{"1", "2", "3"} {
+		err = buffer.Push([]*pb.HTTPAccessLog{testAccessLog(requestId)})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 只保留最新的文件
+	if buffer.Len() != 1 {
+		t.Fatal("should keep only 1 file")
+	}
+	_, accessLogs, err := buffer.Peek()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accessLogs) != 1 || accessLogs[0].RequestId != "3" {
+		t.Fatal("should keep the latest file")
+	}
+}
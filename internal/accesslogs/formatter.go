@@ -0,0 +1,118 @@
+package accesslogs
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/iwind/TeaGo/maps"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+type FormatType = string
+
+const (
+	FormatTypeJSON     FormatType = "json"     // 每行一个JSON
+	FormatTypeTemplate FormatType = "template" // 自定义模板
+)
+
+var templateVarReg = regexp.MustCompile(`\$\{(\w+)\}`)
+
+// 访问日志格式化
+// 模板中可以使用 ${字段名} 引用访问日志中的字段，比如 ${remoteAddr} ${host} ${requestPath} ${status}，字段名和JSON格式中的字段名相同
+type Formatter struct {
+	format   FormatType
+	template string
+}
+
+// 从参数中读取格式设置
+// 参数：
+//   - format 格式，json或者template，默认为json
+//   - template 模板，format为template时有效
+func NewFormatter(params maps.Map) (*Formatter, error) {
+	formatter := &Formatter{
+		format:   params.GetString("format"),
+		template: params.GetString("template"),
+	}
+	switch formatter.format {
+	case "":
+		formatter.format = FormatTypeJSON
+	case FormatTypeJSON:
+	case FormatTypeTemplate:
+		if len(formatter.template) == 0 {
+			return nil, errors.New("'template' should not be empty")
+		}
+	default:
+		return nil, errors.New("invalid format '" + formatter.format + "'")
+	}
+	return formatter, nil
+}
+
+// 格式化单条日志，结果中不包含换行符
+func (this *Formatter) Format(accessLog *pb.HTTPAccessLog) ([]byte, error) {
+	data, err := json.Marshal(accessLog)
+	if err != nil {
+		return nil, err
+	}
+	if this.format != FormatTypeTemplate {
+		return data, nil
+	}
+
+	m := map[string]interface{}{}
+	err = json.Unmarshal(data, &m)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(formatAccessLogVariables(this.template, m, "-")), nil
+}
+
+// 将字符串中的 ${字段名} 替换为访问日志中对应字段的值，不存在的字段替换为 emptyValue
+func formatAccessLogVariables(source string, m map[string]interface{}, emptyValue string) string {
+	return templateVarReg.ReplaceAllStringFunc(source, func(s string) string {
+		value, ok := m[s[2:len(s)-1]]
+		if !ok || value == nil {
+			return emptyValue
+		}
+		switch v := value.(type) {
+		case string:
+			return v
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			if v {
+				return "true"
+			}
+			return "false"
+		}
+		valueJSON, err := json.Marshal(value)
+		if err != nil {
+			return emptyValue
+		}
+		return string(valueJSON)
+	})
+}
+
+// 生成用于匹配条件的变量格式化函数，不存在的字段替换为空字符串
+func accessLogVariableFormatter(accessLog *pb.HTTPAccessLog) (func(source string) string, error) {
+	data, err := json.Marshal(accessLog)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]interface{}{}
+	err = json.Unmarshal(data, &m)
+	if err != nil {
+		return nil, err
+	}
+	return func(source string) string {
+		return formatAccessLogVariables(source, m, "")
+	}, nil
+}
+
+// 日志产生的时间
+func accessLogTime(accessLog *pb.HTTPAccessLog) time.Time {
+	if accessLog.Timestamp > 0 {
+		return time.Unix(accessLog.Timestamp, 0)
+	}
+	return time.Now()
+}
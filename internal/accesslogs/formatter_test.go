@@ -0,0 +1,63 @@
+package accesslogs
+
+import (
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/iwind/TeaGo/maps"
+	"testing"
+)
+
+func testAccessLog(requestId string) *pb.HTTPAccessLog {
+	return &pb.HTTPAccessLog{
+		RequestId:     requestId,
+		ServerId:      1,
+		RemoteAddr:    "127.0.0.1",
+		Host:          "example.com",
+		RequestMethod: "GET",
+		RequestPath:   "/hello",
+		Status:        200,
+		Timestamp:     1614556800,
+	}
+}
+
+func TestFormatter_Format(t *testing.T) {
+	formatter, err := NewFormatter(maps.Map{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := formatter.Format(testAccessLog("1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(string(data))
+
+	formatter, err = NewFormatter(maps.Map{
+		"format":   "template",
+		"template": "${remoteAddr} ${host} \"${requestMethod} ${requestPath}\" ${status} ${none}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = formatter.Format(testAccessLog("1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `127.0.0.1 example.com "GET /hello" 200 -` {
+		t.Fatal("unexpected result: " + string(data))
+	}
+
+	_, err = NewFormatter(maps.Map{"format": "template"})
+	if err == nil {
+		t.Fatal("empty template should fail")
+	}
+}
+
+func TestAccessLogVariableFormatter(t *testing.T) {
+	formatter, err := accessLogVariableFormatter(testAccessLog("1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := formatter("${host}${requestPath} ${status} [${none}]")
+	if result != "example.com/hello 200 []" {
+		t.Fatal("unexpected result: " + result)
+	}
+}
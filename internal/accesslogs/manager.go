@@ -0,0 +1,239 @@
+package accesslogs
+
+import (
+	"encoding/json"
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/TeaOSLab/EdgeAPI/internal/utils"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/TeaOSLab/EdgeCommon/pkg/serverconfigs/shared"
+	"github.com/iwind/TeaGo/Tea"
+	"github.com/iwind/TeaGo/dbs"
+	"github.com/iwind/TeaGo/logs"
+	"github.com/iwind/TeaGo/maps"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var SharedManager = NewManager()
+
+func init() {
+	dbs.OnReadyDone(func() {
+		go SharedManager.Start()
+	})
+}
+
+// 策略对应的写入器
+type policyWriter struct {
+	writer      *Writer
+	signature   string
+	serverIdMap map[int64]bool                 // 在访问日志设置中选择了此策略的服务，用户策略只包含用户自己的服务
+	conds       *shared.HTTPRequestCondsConfig // 写入日志的条件
+}
+
+// 访问日志存储管理器
+// 定期从数据库中加载启用的访问日志策略，并将收到的访问日志分发到每个策略的写入器
+type Manager struct {
+	writers map[int64]*policyWriter // policyId => writer
+	locker  sync.RWMutex
+}
+
+// 获取新对象
+func NewManager() *Manager {
+	return &Manager{
+		writers: map[int64]*policyWriter{},
+	}
+}
+
+// 启动
+func (this *Manager) Start() {
+	ticker := utils.NewTicker(1 * time.Minute)
+	for {
+		err := this.Load()
+		if err != nil {
+			logs.Println("[ACCESS_LOG]load policies failed: " + err.Error())
+		}
+		if !ticker.Wait() {
+			break
+		}
+	}
+}
+
+// 加载策略
+// 策略的类型或者选项变化后会重新创建写入器，已删除或者停用的策略会被关闭
+func (this *Manager) Load() error {
+	policies, err := models.SharedHTTPAccessLogPolicyDAO.FindAllEnabledAccessLogPolicies(nil)
+	if err != nil {
+		return err
+	}
+
+	newWriters := map[int64]*policyWriter{}
+	closingWriters := []*Writer{}
+
+	this.locker.RLock()
+	oldWriters := this.writers
+	this.locker.RUnlock()
+
+	for _, policy := range policies {
+		policyId := int64(policy.Id)
+		if policy.IsOn != 1 || FindStorage(policy.Type) == nil {
+			continue
+		}
+
+		serverIdMap, err := this.findPolicyServerIdMap(policy)
+		if err != nil {
+			return err
+		}
+
+		// 条件
+		policyConfig, err := models.SharedHTTPAccessLogPolicyDAO.ComposeAccessLogPolicyConfig(nil, policyId)
+		if err != nil {
+			return err
+		}
+		if policyConfig == nil {
+			continue
+		}
+		conds := policyConfig.Conds
+		if conds != nil {
+			err = conds.Init()
+			if err != nil {
+				logs.Println("[ACCESS_LOG]init conds of policy '" + strconv.FormatInt(policyId, 10) + "' failed: " + err.Error())
+				continue
+			}
+		}
+
+		signature := policy.Type + "@" + policy.Options
+		oldWriter, ok := oldWriters[policyId]
+		if ok && oldWriter.signature == signature {
+			newWriters[policyId] = &policyWriter{
+				writer:      oldWriter.writer,
+				signature:   signature,
+				serverIdMap: serverIdMap,
+				conds:       conds,
+			}
+			continue
+		}
+
+		writer, err := this.createWriter(policy)
+		if err != nil {
+			logs.Println("[ACCESS_LOG]init policy '" + strconv.FormatInt(policyId, 10) + "' failed: " + err.Error())
+			continue
+		}
+		writer.Start()
+		newWriters[policyId] = &policyWriter{
+			writer:      writer,
+			signature:   signature,
+			serverIdMap: serverIdMap,
+			conds:       conds,
+		}
+	}
+
+	for policyId, oldWriter := range oldWriters {
+		newWriter, ok := newWriters[policyId]
+		if !ok || newWriter.writer != oldWriter.writer {
+			closingWriters = append(closingWriters, oldWriter.writer)
+		}
+	}
+
+	this.locker.Lock()
+	this.writers = newWriters
+	this.locker.Unlock()
+
+	// 关闭旧的写入器，队列中剩余的日志会保存到缓冲区
+	for _, writer := range closingWriters {
+		err := writer.Close()
+		if err != nil {
+			logs.Println("[ACCESS_LOG]" + err.Error())
+		}
+	}
+
+	return nil
+}
+
+// 查找在访问日志设置中选择了某个策略的服务
+// 用户策略只包含用户自己的服务
+func (this *Manager) findPolicyServerIdMap(policy *models.HTTPAccessLogPolicy) (map[int64]bool, error) {
+	webIds, err := models.SharedHTTPWebDAO.FindAllWebIdsWithHTTPAccessLogPolicyId(nil, int64(policy.Id))
+	if err != nil {
+		return nil, err
+	}
+	servers, err := models.SharedServerDAO.FindAllEnabledServersWithWebIds(nil, webIds)
+	if err != nil {
+		return nil, err
+	}
+	serverIdMap := map[int64]bool{}
+	for _, server := range servers {
+		if policy.UserId > 0 && server.UserId != policy.UserId {
+			continue
+		}
+		serverIdMap[int64(server.Id)] = true
+	}
+	return serverIdMap, nil
+}
+
+// 分发访问日志
+// 每个策略只接收选择了此策略的服务的日志，并且日志需要符合策略设置的条件
+func (this *Manager) Write(accessLogs []*pb.HTTPAccessLog) {
+	if len(accessLogs) == 0 {
+		return
+	}
+
+	this.locker.RLock()
+	defer this.locker.RUnlock()
+
+	formatters := map[int]func(source string) string{} // 日志索引 => 条件变量格式化函数
+	for _, w := range this.writers {
+		policyAccessLogs := []*pb.HTTPAccessLog{}
+		for index, accessLog := range accessLogs {
+			if !w.serverIdMap[accessLog.ServerId] {
+				continue
+			}
+			if w.conds != nil {
+				formatter, ok := formatters[index]
+				if !ok {
+					var err error
+					formatter, err = accessLogVariableFormatter(accessLog)
+					if err != nil {
+						logs.Println("[ACCESS_LOG]" + err.Error())
+						continue
+					}
+					formatters[index] = formatter
+				}
+				if !w.conds.MatchRequest(formatter) || !w.conds.MatchResponse(formatter) {
+					continue
+				}
+			}
+			policyAccessLogs = append(policyAccessLogs, accessLog)
+		}
+		if len(policyAccessLogs) > 0 {
+			w.writer.Write(policyAccessLogs)
+		}
+	}
+}
+
+// 根据策略创建写入器
+// 写入器的批次、重试和缓冲区设置和存储选项放在一起：
+//   - bufferMaxSize 磁盘缓冲区最大尺寸（MB），默认1024
+func (this *Manager) createWriter(policy *models.HTTPAccessLogPolicy) (*Writer, error) {
+	options := maps.Map{}
+	if models.IsNotNull(policy.Options) {
+		err := json.Unmarshal([]byte(policy.Options), &options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	storage := FindStorage(policy.Type)
+	err := storage.Init(options)
+	if err != nil {
+		return nil, err
+	}
+
+	bufferMaxSize := options.GetInt64("bufferMaxSize") * 1024 * 1024
+	if bufferMaxSize <= 0 {
+		bufferMaxSize = 1024 * 1024 * 1024
+	}
+	policyId := strconv.FormatInt(int64(policy.Id), 10)
+	buffer := NewDiskBuffer(Tea.Root+"/data/accesslogs/policy-"+policyId, bufferMaxSize)
+	return NewWriter("policy "+policyId, storage, buffer, options), nil
+}
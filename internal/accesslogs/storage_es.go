@@ -0,0 +1,134 @@
+package accesslogs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/iwind/TeaGo/maps"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ElasticSearch存储
+// 使用Bulk API批量写入
+type ESStorage struct {
+	endpoint    string
+	index       string
+	mappingType string
+	username    string
+	password    string
+
+	client *http.Client
+}
+
+// 初始化
+// 参数：
+//   - endpoint 服务地址，比如 http://127.0.0.1:9200
+//   - index 索引名称，其中的 ${date} 会被替换为日志的日期 YYYYMMDD
+//   - mappingType 文档类型，仅ElasticSearch 7以下的版本需要
+//   - username、password 认证信息，可以为空
+func (this *ESStorage) Init(params maps.Map) error {
+	this.endpoint = strings.TrimRight(params.GetString("endpoint"), "/")
+	if len(this.endpoint) == 0 {
+		return errors.New("'endpoint' should not be empty")
+	}
+	if !strings.HasPrefix(this.endpoint, "http://") && !strings.HasPrefix(this.endpoint, "https://") {
+		this.endpoint = "http://" + this.endpoint
+	}
+	this.index = params.GetString("index")
+	if len(this.index) == 0 {
+		return errors.New("'index' should not be empty")
+	}
+	this.mappingType = params.GetString("mappingType")
+	this.username = params.GetString("username")
+	this.password = params.GetString("password")
+	this.client = &http.Client{
+		Timeout: 30 * time.Second,
+	}
+	return nil
+}
+
+// 写入日志
+func (this *ESStorage) Write(accessLogs []*pb.HTTPAccessLog) error {
+	buf := &bytes.Buffer{}
+	for _, accessLog := range accessLogs {
+		action := maps.Map{
+			"_index": strings.Replace(this.index, "${date}", accessLogTime(accessLog).Format("20060102"), -1),
+		}
+		if len(this.mappingType) > 0 {
+			action["_type"] = this.mappingType
+		}
+		if len(accessLog.RequestId) > 0 {
+			// 使用请求ID作为文档ID，重试时不会产生重复的文档
+			action["_id"] = accessLog.RequestId
+		}
+		actionJSON, err := json.Marshal(maps.Map{"index": action})
+		if err != nil {
+			return err
+		}
+		docJSON, err := json.Marshal(accessLog)
+		if err != nil {
+			return err
+		}
+		buf.Write(actionJSON)
+		buf.WriteByte('\n')
+		buf.Write(docJSON)
+		buf.WriteByte('\n')
+	}
+
+	req, err := http.NewRequest(http.MethodPost, this.endpoint+"/_bulk", buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("User-Agent", "GoEdge-API")
+	if len(this.username) > 0 || len(this.password) > 0 {
+		req.SetBasicAuth(this.username, this.password)
+	}
+	resp, err := this.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	respData, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.New("invalid response status code '" + strconv.Itoa(resp.StatusCode) + "': " + string(respData))
+	}
+
+	// 检查每条日志是否写入成功
+	result := &struct {
+		Errors bool                                   `json:"errors"`
+		Items  []map[string]*struct{ Error maps.Map } `json:"items"`
+	}{}
+	err = json.Unmarshal(respData, result)
+	if err != nil {
+		return errors.New("decode response failed: " + err.Error())
+	}
+	if result.Errors {
+		for _, item := range result.Items {
+			for _, itemResult := range item {
+				if itemResult != nil && itemResult.Error != nil {
+					errJSON, _ := json.Marshal(itemResult.Error)
+					return errors.New("bulk index failed: " + string(errJSON))
+				}
+			}
+		}
+		return errors.New("bulk index failed")
+	}
+	return nil
+}
+
+// 关闭
+func (this *ESStorage) Close() error {
+	this.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,73 @@
+package accesslogs
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/iwind/TeaGo/maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestESStorage_Write(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		username, password, _ := req.BasicAuth()
+		if req.URL.Path != "/_bulk" || username != "elastic" || password != "123456" {
+			writer.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		countLines := 0
+		scanner := bufio.NewScanner(req.Body)
+		for scanner.Scan() {
+			if countLines%2 == 0 {
+				action := map[string]map[string]string{}
+				_ = json.Unmarshal(scanner.Bytes(), &action)
+				if action["index"]["_index"] != "edge-20210301" {
+					_, _ = writer.Write([]byte(`{"errors":true,"items":[{"index":{"status":400,"error":{"type":"invalid_index_name_exception"}}}]}`))
+					return
+				}
+			}
+			countLines++
+		}
+		if countLines != 4 {
+			writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = writer.Write([]byte(`{"errors":false,"items":[]}`))
+	}))
+	defer server.Close()
+
+	storage := &ESStorage{}
+	err := storage.Init(maps.Map{
+		"endpoint": server.URL,
+		"index":    "edge-${date}",
+		"username": "elastic",
+		"password": "123456",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	accessLogs := []*pb.HTTPAccessLog{testAccessLog("1"), testAccessLog("2")}
+	for _, accessLog := range accessLogs {
+		accessLog.Timestamp = 1614600000 // 2021-03-01 12:00:00 UTC
+	}
+	err = storage.Write(accessLogs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 写入失败
+	_ = storage.Init(maps.Map{
+		"endpoint": server.URL,
+		"index":    "other",
+		"username": "elastic",
+		"password": "123456",
+	})
+	err = storage.Write(accessLogs)
+	if err == nil {
+		t.Fatal("bulk errors should be returned")
+	}
+	t.Log(err)
+}
@@ -0,0 +1,166 @@
+package accesslogs
+
+import (
+	"bytes"
+	"errors"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/iwind/TeaGo/maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 本地文件存储
+// 文件超过最大尺寸或者日期变化时会被重命名为 文件名.YYYYMMDD-HHIISS，并只保留最近的若干个文件
+type FileStorage struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+
+	formatter *Formatter
+
+	fp     *os.File
+	size   int64
+	day    string
+	locker sync.Mutex
+}
+
+// 初始化
+// 参数：
+//   - path 文件路径
+//   - maxSize 单个文件最大尺寸（MB），默认100
+//   - maxFiles 保留的历史文件数量，默认10
+//   - format、template 日志格式
+func (this *FileStorage) Init(params maps.Map) error {
+	this.path = params.GetString("path")
+	if len(this.path) == 0 {
+		return errors.New("'path' should not be empty")
+	}
+	this.maxSize = params.GetInt64("maxSize") * 1024 * 1024
+	if this.maxSize <= 0 {
+		this.maxSize = 100 * 1024 * 1024
+	}
+	this.maxFiles = params.GetInt("maxFiles")
+	if this.maxFiles <= 0 {
+		this.maxFiles = 10
+	}
+
+	formatter, err := NewFormatter(params)
+	if err != nil {
+		return err
+	}
+	this.formatter = formatter
+	return nil
+}
+
+// 写入日志
+func (this *FileStorage) Write(accessLogs []*pb.HTTPAccessLog) error {
+	buf := &bytes.Buffer{}
+	for _, accessLog := range accessLogs {
+		data, err := this.formatter.Format(accessLog)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	this.locker.Lock()
+	defer this.locker.Unlock()
+
+	err := this.rotateIfNeeded(int64(buf.Len()))
+	if err != nil {
+		return err
+	}
+	n, err := this.fp.Write(buf.Bytes())
+	this.size += int64(n)
+	if err != nil {
+		// 关闭文件，下次写入时重新打开
+		_ = this.fp.Close()
+		this.fp = nil
+		return err
+	}
+	return nil
+}
+
+// 关闭
+func (this *FileStorage) Close() error {
+	this.locker.Lock()
+	defer this.locker.Unlock()
+
+	if this.fp != nil {
+		err := this.fp.Close()
+		this.fp = nil
+		return err
+	}
+	return nil
+}
+
+// 检查是否需要切换文件
+func (this *FileStorage) rotateIfNeeded(writeSize int64) error {
+	today := time.Now().Format("20060102")
+	if this.fp == nil {
+		err := this.open()
+		if err != nil {
+			return err
+		}
+	}
+	if this.size == 0 || (this.day == today && this.size+writeSize <= this.maxSize) {
+		this.day = today
+		return nil
+	}
+
+	_ = this.fp.Close()
+	this.fp = nil
+	// 同一秒内多次切换时在文件名后加序号，防止覆盖
+	newPath := this.path + "." + time.Now().Format("20060102-150405")
+	for i := 1; ; i++ {
+		_, err := os.Stat(newPath)
+		if os.IsNotExist(err) {
+			break
+		}
+		newPath = this.path + "." + time.Now().Format("20060102-150405") + "-" + strconv.Itoa(i)
+	}
+	err := os.Rename(this.path, newPath)
+	if err != nil {
+		return err
+	}
+	this.clean()
+	return this.open()
+}
+
+// 打开文件
+func (this *FileStorage) open() error {
+	err := os.MkdirAll(filepath.Dir(this.path), 0755)
+	if err != nil {
+		return err
+	}
+	fp, err := os.OpenFile(this.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	stat, err := fp.Stat()
+	if err != nil {
+		_ = fp.Close()
+		return err
+	}
+	this.fp = fp
+	this.size = stat.Size()
+	this.day = stat.ModTime().Format("20060102")
+	return nil
+}
+
+// 删除多余的历史文件
+func (this *FileStorage) clean() {
+	matches, err := filepath.Glob(this.path + ".*")
+	if err != nil || len(matches) <= this.maxFiles {
+		return
+	}
+	sort.Strings(matches)
+	for _, match := range matches[:len(matches)-this.maxFiles] {
+		_ = os.Remove(match)
+	}
+}
@@ -0,0 +1,60 @@
+package accesslogs
+
+import (
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/iwind/TeaGo/maps"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileStorage_Write(t *testing.T) {
+	dir, err := ioutil.TempDir("", "accesslogs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	path := filepath.Join(dir, "logs", "access.log")
+	storage := &FileStorage{}
+	err = storage.Init(maps.Map{
+		"path":     path,
+		"maxFiles": 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = storage.Close()
+	}()
+
+	err = storage.Write([]*pb.HTTPAccessLog{testAccessLog("1"), testAccessLog("2")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(data), "\n") != 2 {
+		t.Fatal("should have 2 lines: " + string(data))
+	}
+
+	// 超出尺寸后切换文件
+	storage.maxSize = 1
+	for i := 0; i < 4; i++ {
+		err = storage.Write([]*pb.HTTPAccessLog{testAccessLog("3")})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	matches, _ := filepath.Glob(path + ".*")
+	t.Log(matches)
+	if len(matches) != 2 {
+		t.Fatal("should keep 2 rotated files")
+	}
+}
@@ -0,0 +1,116 @@
+package accesslogs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/iwind/TeaGo/maps"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HTTP POST存储
+// 默认以JSON数组格式发送一批日志，也可以使用每行一条日志的格式
+type HTTPStorage struct {
+	url     string
+	headers map[string]string
+	lines   bool
+
+	formatter *Formatter
+	client    *http.Client
+}
+
+// 初始化
+// 参数：
+//   - url 接收日志的URL
+//   - headers 附加的Header，可以为空
+//   - format、template 日志格式，设置为template时每行发送一条日志
+//   - lines 是否每行发送一条日志，format为json时有效
+func (this *HTTPStorage) Init(params maps.Map) error {
+	this.url = params.GetString("url")
+	if len(this.url) == 0 {
+		return errors.New("'url' should not be empty")
+	}
+
+	this.headers = map[string]string{}
+	headers, ok := params.Get("headers").(map[string]interface{})
+	if !ok {
+		headers, _ = params.Get("headers").(maps.Map)
+	}
+	for k, v := range headers {
+		s, ok := v.(string)
+		if ok {
+			this.headers[k] = s
+		}
+	}
+
+	formatter, err := NewFormatter(params)
+	if err != nil {
+		return err
+	}
+	this.formatter = formatter
+	this.lines = params.GetBool("lines") || formatter.format == FormatTypeTemplate
+
+	this.client = &http.Client{
+		Timeout: 30 * time.Second,
+	}
+	return nil
+}
+
+// 写入日志
+func (this *HTTPStorage) Write(accessLogs []*pb.HTTPAccessLog) error {
+	buf := &bytes.Buffer{}
+	contentType := "application/json; charset=utf-8"
+	if this.lines {
+		contentType = "text/plain; charset=utf-8"
+		if this.formatter.format == FormatTypeJSON {
+			contentType = "application/x-ndjson"
+		}
+		for _, accessLog := range accessLogs {
+			data, err := this.formatter.Format(accessLog)
+			if err != nil {
+				return err
+			}
+			buf.Write(data)
+			buf.WriteByte('\n')
+		}
+	} else {
+		data, err := json.Marshal(accessLogs)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, this.url, buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "GoEdge-API")
+	for k, v := range this.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := this.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respData, _ := ioutil.ReadAll(resp.Body)
+		return errors.New("invalid response status code '" + strconv.Itoa(resp.StatusCode) + "': " + string(respData))
+	}
+	_, _ = ioutil.ReadAll(resp.Body)
+	return nil
+}
+
+// 关闭
+func (this *HTTPStorage) Close() error {
+	this.client.CloseIdleConnections()
+	return nil
+}
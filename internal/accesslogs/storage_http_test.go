@@ -0,0 +1,79 @@
+package accesslogs
+
+import (
+	"encoding/json"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/iwind/TeaGo/maps"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTPStorage_Write(t *testing.T) {
+	bodies := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("X-Token") != "abc" {
+			writer.WriteHeader(http.StatusForbidden)
+			return
+		}
+		data, _ := ioutil.ReadAll(req.Body)
+		bodies <- string(data)
+	}))
+	defer server.Close()
+
+	// JSON数组
+	storage := &HTTPStorage{}
+	err := storage.Init(maps.Map{
+		"url": server.URL,
+		"headers": map[string]interface{}{
+			"X-Token": "abc",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = storage.Write([]*pb.HTTPAccessLog{testAccessLog("1"), testAccessLog("2")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := []*pb.HTTPAccessLog{}
+	err = json.Unmarshal([]byte(<-bodies), &result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 2 || result[1].RequestId != "2" {
+		t.Fatal("unexpected body")
+	}
+
+	// 模板
+	err = storage.Init(maps.Map{
+		"url":      server.URL,
+		"format":   "template",
+		"template": "${requestId} ${status}",
+		"headers": map[string]interface{}{
+			"X-Token": "abc",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = storage.Write([]*pb.HTTPAccessLog{testAccessLog("1"), testAccessLog("2")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := <-bodies
+	if body != "1 200\n2 200\n" {
+		t.Fatal("unexpected body: " + strings.TrimSpace(body))
+	}
+
+	// 错误的Header
+	_ = storage.Init(maps.Map{
+		"url": server.URL,
+	})
+	err = storage.Write([]*pb.HTTPAccessLog{testAccessLog("1")})
+	if err == nil {
+		t.Fatal("should fail")
+	}
+}
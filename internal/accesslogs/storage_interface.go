@@ -0,0 +1,18 @@
+package accesslogs
+
+import (
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/iwind/TeaGo/maps"
+)
+
+// 访问日志存储接口
+type StorageInterface interface {
+	// 初始化
+	Init(params maps.Map) error
+
+	// 写入一批访问日志，返回错误时整批日志会被重试
+	Write(accessLogs []*pb.HTTPAccessLog) error
+
+	// 关闭
+	Close() error
+}
@@ -0,0 +1,197 @@
+package accesslogs
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/iwind/TeaGo/maps"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type SyslogNetwork = string
+
+const (
+	SyslogNetworkUDP SyslogNetwork = "udp"
+	SyslogNetworkTCP SyslogNetwork = "tcp"
+	SyslogNetworkTLS SyslogNetwork = "tls"
+)
+
+// Syslog存储
+// 使用RFC 5424格式，TCP和TLS使用RFC 6587中的Octet Counting分帧，UDP每条日志一个数据包
+type SyslogStorage struct {
+	network            SyslogNetwork
+	addr               string
+	facility           int
+	severity           int
+	appName            string
+	hostname           string
+	insecureSkipVerify bool
+	timeout            time.Duration
+
+	formatter *Formatter
+
+	conn   net.Conn
+	locker sync.Mutex
+}
+
+// 初始化
+// 参数：
+//   - network 协议，udp、tcp或tls，默认为udp
+//   - addr 服务器地址，比如 127.0.0.1:514
+//   - facility 设施代码，默认为16（local0）
+//   - severity 级别代码，默认为6（info）
+//   - appName 应用名称，默认为edge-access
+//   - insecureSkipVerify 使用TLS时是否忽略证书校验
+//   - format、template 日志消息格式
+func (this *SyslogStorage) Init(params maps.Map) error {
+	this.network = params.GetString("network")
+	switch this.network {
+	case "":
+		this.network = SyslogNetworkUDP
+	case SyslogNetworkUDP, SyslogNetworkTCP, SyslogNetworkTLS:
+	default:
+		return errors.New("invalid network '" + this.network + "'")
+	}
+
+	this.addr = params.GetString("addr")
+	if len(this.addr) == 0 {
+		return errors.New("'addr' should not be empty")
+	}
+
+	this.facility = 16
+	if params.Has("facility") {
+		this.facility = params.GetInt("facility")
+	}
+	if this.facility < 0 || this.facility > 23 {
+		return errors.New("'facility' should be between 0 and 23")
+	}
+	this.severity = 6
+	if params.Has("severity") {
+		this.severity = params.GetInt("severity")
+	}
+	if this.severity < 0 || this.severity > 7 {
+		return errors.New("'severity' should be between 0 and 7")
+	}
+
+	this.appName = params.GetString("appName")
+	if len(this.appName) == 0 {
+		this.appName = "edge-access"
+	}
+	this.hostname, _ = os.Hostname()
+	if len(this.hostname) == 0 {
+		this.hostname = "-"
+	}
+	this.insecureSkipVerify = params.GetBool("insecureSkipVerify")
+	this.timeout = 10 * time.Second
+
+	formatter, err := NewFormatter(params)
+	if err != nil {
+		return err
+	}
+	this.formatter = formatter
+	return nil
+}
+
+// 写入日志
+func (this *SyslogStorage) Write(accessLogs []*pb.HTTPAccessLog) error {
+	this.locker.Lock()
+	defer this.locker.Unlock()
+
+	if this.conn == nil {
+		err := this.connect()
+		if err != nil {
+			return err
+		}
+	}
+
+	_ = this.conn.SetWriteDeadline(time.Now().Add(this.timeout))
+
+	buf := &bytes.Buffer{}
+	for _, accessLog := range accessLogs {
+		msg, err := this.message(accessLog)
+		if err != nil {
+			return err
+		}
+		if this.network == SyslogNetworkUDP {
+			_, err = this.conn.Write(msg)
+			if err != nil {
+				this.reset()
+				return err
+			}
+			continue
+		}
+		buf.WriteString(strconv.Itoa(len(msg)))
+		buf.WriteByte(' ')
+		buf.Write(msg)
+	}
+
+	if buf.Len() > 0 {
+		_, err := this.conn.Write(buf.Bytes())
+		if err != nil {
+			this.reset()
+			return err
+		}
+	}
+	return nil
+}
+
+// 关闭
+func (this *SyslogStorage) Close() error {
+	this.locker.Lock()
+	defer this.locker.Unlock()
+
+	if this.conn != nil {
+		err := this.conn.Close()
+		this.conn = nil
+		return err
+	}
+	return nil
+}
+
+// 构造单条消息
+// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+func (this *SyslogStorage) message(accessLog *pb.HTTPAccessLog) ([]byte, error) {
+	body, err := this.formatter.Format(accessLog)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("<" + strconv.Itoa(this.facility*8+this.severity) + ">1 ")
+	buf.WriteString(accessLogTime(accessLog).Format(time.RFC3339))
+	buf.WriteString(" " + this.hostname + " " + this.appName + " " + strconv.Itoa(os.Getpid()) + " access - ")
+	buf.Write(body)
+	return buf.Bytes(), nil
+}
+
+// 连接服务器
+func (this *SyslogStorage) connect() error {
+	var conn net.Conn
+	var err error
+	switch this.network {
+	case SyslogNetworkTLS:
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: this.timeout}, "tcp", this.addr, &tls.Config{
+			InsecureSkipVerify: this.insecureSkipVerify,
+		})
+	default:
+		conn, err = net.DialTimeout(this.network, this.addr, this.timeout)
+	}
+	if err != nil {
+		return err
+	}
+	this.conn = conn
+	return nil
+}
+
+// 出错后关闭连接，下次写入时重新连接
+func (this *SyslogStorage) reset() {
+	if this.conn != nil {
+		_ = this.conn.Close()
+		this.conn = nil
+	}
+}
@@ -0,0 +1,115 @@
+package accesslogs
+
+import (
+	"bufio"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/iwind/TeaGo/maps"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var syslogMessageReg = regexp.MustCompile(`^<134>1 \S+ \S+ edge-access \d+ access - \{.+\}$`)
+
+func TestSyslogStorage_UDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	storage := &SyslogStorage{}
+	err = storage.Init(maps.Map{
+		"network":  "udp",
+		"addr":     conn.LocalAddr().String(),
+		"facility": 16,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = storage.Close()
+	}()
+
+	err = storage.Write([]*pb.HTTPAccessLog{testAccessLog("1"), testAccessLog("2")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 4096)
+	for i := 0; i < 2; i++ {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Log(string(buf[:n]))
+		if !syslogMessageReg.Match(buf[:n]) {
+			t.Fatal("invalid message: " + string(buf[:n]))
+		}
+	}
+}
+
+func TestSyslogStorage_TCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = listener.Close()
+	}()
+
+	messages := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() {
+			_ = conn.Close()
+		}()
+
+		// Octet Counting: 长度 空格 消息
+		reader := bufio.NewReader(conn)
+		for i := 0; i < 2; i++ {
+			lengthString, err := reader.ReadString(' ')
+			if err != nil {
+				return
+			}
+			length, _ := strconv.Atoi(strings.TrimSpace(lengthString))
+			msg := make([]byte, length)
+			_, err = reader.Read(msg)
+			if err != nil {
+				return
+			}
+			messages <- string(msg)
+		}
+	}()
+
+	storage := &SyslogStorage{}
+	err = storage.Init(maps.Map{
+		"network": "tcp",
+		"addr":    listener.Addr().String(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = storage.Close()
+	}()
+
+	err = storage.Write([]*pb.HTTPAccessLog{testAccessLog("1"), testAccessLog("2")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		msg := <-messages
+		t.Log(msg)
+		if !syslogMessageReg.MatchString(msg) {
+			t.Fatal("invalid message: " + msg)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package accesslogs
+
+import "github.com/iwind/TeaGo/maps"
+
+type StorageType = string
+
+// 存储类型代号
+const (
+	StorageTypeFile   StorageType = "file"
+	StorageTypeSyslog StorageType = "syslog"
+	StorageTypeES     StorageType = "es"
+	StorageTypeHTTP   StorageType = "http"
+)
+
+// 所有的存储类型
+var AllStorageTypes = []maps.Map{
+	{
+		"name": "本地文件",
+		"code": StorageTypeFile,
+	},
+	{
+		"name": "Syslog",
+		"code": StorageTypeSyslog,
+	},
+	{
+		"name": "ElasticSearch",
+		"code": StorageTypeES,
+	},
+	{
+		"name": "HTTP POST",
+		"code": StorageTypeHTTP,
+	},
+}
+
+// 查找存储实例
+func FindStorage(storageType StorageType) StorageInterface {
+	switch storageType {
+	case StorageTypeFile:
+		return &FileStorage{}
+	case StorageTypeSyslog:
+		return &SyslogStorage{}
+	case StorageTypeES:
+		return &ESStorage{}
+	case StorageTypeHTTP:
+		return &HTTPStorage{}
+	}
+	return nil
+}
+
+// 查找存储类型名称
+func FindStorageTypeName(storageType StorageType) string {
+	for _, t := range AllStorageTypes {
+		if t.GetString("code") == storageType {
+			return t.GetString("name")
+		}
+	}
+	return ""
+}
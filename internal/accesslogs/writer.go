@@ -0,0 +1,218 @@
+package accesslogs
+
+import (
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/iwind/TeaGo/logs"
+	"github.com/iwind/TeaGo/maps"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 访问日志写入器
+// 为每个存储策略维护一个队列，日志按批次写入存储，写入失败时重试，重试仍失败时保存到磁盘缓冲区，存储恢复后再按顺序重新发送
+type Writer struct {
+	name    string
+	storage StorageInterface
+	buffer  *DiskBuffer
+
+	queue         chan *pb.HTTPAccessLog
+	batchSize     int
+	flushInterval time.Duration
+	maxRetries    int
+	retryInterval time.Duration
+	drainInterval time.Duration
+
+	sendLocker sync.Mutex
+	done       chan bool
+	closeOnce  sync.Once
+	wg         sync.WaitGroup
+}
+
+// 获取新对象
+// 参数：
+//   - batchSize 每批最多写入的日志数量，默认1000
+//   - flushInterval 最长等待多少秒后写入一批日志，默认1
+//   - maxRetries 每批日志的最大重试次数，默认3
+//   - queueSize 内存队列长度，默认10000，超出后直接保存到磁盘缓冲区
+func NewWriter(name string, storage StorageInterface, buffer *DiskBuffer, params maps.Map) *Writer {
+	writer := &Writer{
+		name:          name,
+		storage:       storage,
+		buffer:        buffer,
+		batchSize:     params.GetInt("batchSize"),
+		flushInterval: time.Duration(params.GetInt("flushInterval")) * time.Second,
+		maxRetries:    3,
+		retryInterval: 1 * time.Second,
+		drainInterval: 5 * time.Second,
+		done:          make(chan bool),
+	}
+	if writer.batchSize <= 0 {
+		writer.batchSize = 1000
+	}
+	if writer.flushInterval <= 0 {
+		writer.flushInterval = 1 * time.Second
+	}
+	if params.Has("maxRetries") {
+		writer.maxRetries = params.GetInt("maxRetries")
+		if writer.maxRetries < 0 {
+			writer.maxRetries = 0
+		}
+	}
+	queueSize := params.GetInt("queueSize")
+	if queueSize <= 0 {
+		queueSize = 10000
+	}
+	writer.queue = make(chan *pb.HTTPAccessLog, queueSize)
+	return writer
+}
+
+// 启动
+func (this *Writer) Start() {
+	this.wg.Add(2)
+	go this.loop()
+	go this.drainLoop()
+}
+
+// 写入日志，不会阻塞
+func (this *Writer) Write(accessLogs []*pb.HTTPAccessLog) {
+	var overflow []*pb.HTTPAccessLog
+	for _, accessLog := range accessLogs {
+		select {
+		case this.queue <- accessLog:
+		default:
+			overflow = append(overflow, accessLog)
+		}
+	}
+	if len(overflow) > 0 {
+		err := this.buffer.Push(overflow)
+		if err != nil {
+			logs.Println("[ACCESS_LOG]" + this.name + ": drop " + strconv.Itoa(len(overflow)) + " access logs: " + err.Error())
+		}
+	}
+}
+
+// 关闭，队列中剩余的日志会被写入存储或者磁盘缓冲区
+func (this *Writer) Close() error {
+	this.closeOnce.Do(func() {
+		close(this.done)
+	})
+	this.wg.Wait()
+	return this.storage.Close()
+}
+
+// 批量写入
+func (this *Writer) loop() {
+	defer this.wg.Done()
+
+	ticker := time.NewTicker(this.flushInterval)
+	defer ticker.Stop()
+
+	batch := []*pb.HTTPAccessLog{}
+	for {
+		select {
+		case accessLog := <-this.queue:
+			batch = append(batch, accessLog)
+			if len(batch) >= this.batchSize {
+				this.flush(batch)
+				batch = []*pb.HTTPAccessLog{}
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				this.flush(batch)
+				batch = []*pb.HTTPAccessLog{}
+			}
+		case <-this.done:
+			for len(this.queue) > 0 {
+				batch = append(batch, <-this.queue)
+			}
+			if len(batch) > 0 {
+				this.flush(batch)
+			}
+			return
+		}
+	}
+}
+
+// 写入一批日志
+func (this *Writer) flush(batch []*pb.HTTPAccessLog) {
+	// 缓冲区中还有日志时直接放入缓冲区，以保证日志的顺序
+	if this.buffer.Len() == 0 {
+		err := this.send(batch, this.maxRetries)
+		if err == nil {
+			return
+		}
+		logs.Println("[ACCESS_LOG]" + this.name + ": write failed: " + err.Error())
+	}
+
+	err := this.buffer.Push(batch)
+	if err != nil {
+		logs.Println("[ACCESS_LOG]" + this.name + ": drop " + strconv.Itoa(len(batch)) + " access logs: " + err.Error())
+	}
+}
+
+// 重新发送缓冲区中的日志
+func (this *Writer) drainLoop() {
+	defer this.wg.Done()
+
+	ticker := time.NewTicker(this.drainInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			this.drain()
+		case <-this.done:
+			return
+		}
+	}
+}
+
+// 按顺序发送缓冲区中的日志，直到缓冲区为空或者发送失败
+func (this *Writer) drain() {
+	for {
+		select {
+		case <-this.done:
+			return
+		default:
+		}
+
+		path, accessLogs, err := this.buffer.Peek()
+		if err != nil {
+			logs.Println("[ACCESS_LOG]" + this.name + ": " + err.Error())
+			return
+		}
+		if len(path) == 0 {
+			return
+		}
+		if len(accessLogs) > 0 {
+			err = this.send(accessLogs, 0)
+			if err != nil {
+				return
+			}
+		}
+		err = this.buffer.Remove(path)
+		if err != nil {
+			logs.Println("[ACCESS_LOG]" + this.name + ": " + err.Error())
+			return
+		}
+	}
+}
+
+// 发送日志，失败时按照指数退避重试
+func (this *Writer) send(accessLogs []*pb.HTTPAccessLog, maxRetries int) error {
+	for i := 0; ; i++ {
+		this.sendLocker.Lock()
+		err := this.storage.Write(accessLogs)
+		this.sendLocker.Unlock()
+		if err == nil || i >= maxRetries {
+			return err
+		}
+
+		select {
+		case <-time.After(this.retryInterval << uint(i)):
+		case <-this.done:
+			return err
+		}
+	}
+}
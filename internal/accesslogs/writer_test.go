@@ -0,0 +1,97 @@
+package accesslogs
+
+import (
+	"errors"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/iwind/TeaGo/maps"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testStorage struct {
+	isDown     bool
+	requestIds []string
+	locker     sync.Mutex
+}
+
+func (this *testStorage) Init(params maps.Map) error {
+	return nil
+}
+
+func (this *testStorage) Write(accessLogs []*pb.HTTPAccessLog) error {
+	this.locker.Lock()
+	defer this.locker.Unlock()
+	if this.isDown {
+		return errors.New("storage is down")
+	}
+	for _, accessLog := range accessLogs {
+		this.requestIds = append(this.requestIds, accessLog.RequestId)
+	}
+	return nil
+}
+
+func (this *testStorage) Close() error {
+	return nil
+}
+
+func (this *testStorage) setDown(isDown bool) {
+	this.locker.Lock()
+	this.isDown = isDown
+	this.locker.Unlock()
+}
+
+func (this *testStorage) written() []string {
+	this.locker.Lock()
+	defer this.locker.Unlock()
+	return append([]string{}, this.requestIds...)
+}
+
+func TestWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "accesslogs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	storage := &testStorage{isDown: true}
+	writer := NewWriter("test", storage, NewDiskBuffer(dir, 0), maps.Map{
+		"batchSize":  2,
+		"maxRetries": 1,
+	})
+	writer.retryInterval = 10 * time.Millisecond
+	writer.drainInterval = 50 * time.Millisecond
+	writer.Start()
+	defer func() {
+		_ = writer.Close()
+	}()
+
+	// 存储不可用时保存到缓冲区
+	writer.Write([]*pb.HTTPAccessLog{testAccessLog("1"), testAccessLog("2"), testAccessLog("3"), testAccessLog("4")})
+	time.Sleep(200 * time.Millisecond)
+	if writer.buffer.Len() != 2 {
+		t.Fatal("should have 2 batches in buffer, but got", writer.buffer.Len())
+	}
+
+	// 存储恢复后按顺序重新发送
+	storage.setDown(false)
+	writer.Write([]*pb.HTTPAccessLog{testAccessLog("5"), testAccessLog("6")})
+	time.Sleep(300 * time.Millisecond)
+	requestIds := storage.written()
+	t.Log(requestIds)
+	if len(requestIds) != 6 {
+		t.Fatal("should write 6 access logs")
+	}
+	for index, requestId := range []string{"1", "2", "3", "4", "5", "6"} {
+		if requestIds[index] != requestId {
+			t.Fatal("access logs should be written in order")
+		}
+	}
+	if writer.buffer.Len() != 0 {
+		t.Fatal("buffer should be empty")
+	}
+}
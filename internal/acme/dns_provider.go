@@ -5,31 +5,54 @@ import (
 	"github.com/TeaOSLab/EdgeAPI/internal/errors"
 	"github.com/go-acme/lego/v4/challenge/dns01"
 	"strings"
+	"sync"
 )
 
 type DNSProvider struct {
-	raw dnsclients.ProviderInterface
+	raw       dnsclients.ProviderInterface
+	dnsDomain string
+	onLog     LogCallback
+
+	records map[string]*dnsclients.Record // domain@token => record
+	locker  sync.Mutex
 }
 
-func NewDNSProvider(raw dnsclients.ProviderInterface) *DNSProvider {
-	return &DNSProvider{raw: raw}
+func NewDNSProvider(raw dnsclients.ProviderInterface, dnsDomain string) *DNSProvider {
+	return &DNSProvider{
+		raw:       raw,
+		dnsDomain: dnsDomain,
+		records:   map[string]*dnsclients.Record{},
+	}
 }
 
-func (this *DNSProvider) Present(domain, token, keyAuth string) error {
-	fqdn, value := dns01.GetRecord(domain, keyAuth)
+// 设置日志回调
+func (this *DNSProvider) OnLog(onLog LogCallback) {
+	this.onLog = onLog
+}
 
-	// 设置记录
-	index := strings.Index(fqdn, "."+domain)
-	if index < 0 {
-		return errors.New("invalid fqdn value")
+// 添加TXT记录
+// 同一个记录名下可能同时存在多个验证值（比如 example.com 和 *.example.com），所以这里只添加不修改
+func (this *DNSProvider) Present(domain, token, keyAuth string) error {
+	// 泛域名和主域名使用同一个记录名
+	fqdn, value := dns01.GetRecord(strings.TrimPrefix(domain, "*."), keyAuth)
+	recordName, err := this.recordName(fqdn)
+	if err != nil {
+		return err
 	}
-	recordName := fqdn[:index]
-	record, err := this.raw.QueryRecord(domain, recordName, dnsclients.RecordTypeTXT)
+
+	record, err := this.findRecord(recordName, value)
 	if err != nil {
-		return errors.New("query DNS record failed: " + err.Error())
+		this.log(false, "查询TXT记录 '"+dns01.UnFqdn(fqdn)+"' 失败："+err.Error())
+		return errors.New("query DNS records failed: " + err.Error())
 	}
-	if record == nil {
-		err = this.raw.AddRecord(domain, &dnsclients.Record{
+	if record != nil {
+		// 已经存在的记录不是由当前任务添加的，清理时不能删除
+		if !this.isOwnRecord(record) {
+			this.log(true, "TXT记录 '"+dns01.UnFqdn(fqdn)+"' 已存在，值为 '"+value+"'")
+			return nil
+		}
+	} else {
+		err = this.raw.AddRecord(this.dnsDomain, &dnsclients.Record{
 			Id:    "",
 			Name:  recordName,
 			Type:  dnsclients.RecordTypeTXT,
@@ -37,23 +60,100 @@ func (this *DNSProvider) Present(domain, token, keyAuth string) error {
 			Route: this.raw.DefaultRoute(),
 		})
 		if err != nil {
+			this.log(false, "添加TXT记录 '"+dns01.UnFqdn(fqdn)+"' 失败："+err.Error())
 			return errors.New("create DNS record failed: " + err.Error())
 		}
-	} else {
-		err = this.raw.UpdateRecord(domain, record, &dnsclients.Record{
-			Name:  recordName,
-			Type:  dnsclients.RecordTypeTXT,
-			Value: value,
-			Route: this.raw.DefaultRoute(),
-		})
+
+		// 重新查询以获取记录ID，没有ID的话CleanUp()无法删除记录
+		record, err = this.findRecord(recordName, value)
 		if err != nil {
-			return errors.New("update DNS record failed: " + err.Error())
+			this.log(false, "查询TXT记录 '"+dns01.UnFqdn(fqdn)+"' 失败："+err.Error())
+			return errors.New("query DNS records failed: " + err.Error())
+		}
+		if record == nil || len(record.Id) == 0 {
+			this.log(false, "添加TXT记录 '"+dns01.UnFqdn(fqdn)+"' 后无法找到记录")
+			return errors.New("can not find DNS record '" + dns01.UnFqdn(fqdn) + "' after creating")
 		}
 	}
 
+	this.locker.Lock()
+	this.records[domain+"@"+token] = record
+	this.locker.Unlock()
+
+	this.log(true, "添加TXT记录 '"+dns01.UnFqdn(fqdn)+"'，值为 '"+value+"'")
+
 	return nil
 }
 
+// 删除Present()中添加的TXT记录
+// 只删除当前任务添加的记录，已经存在的记录会保留
 func (this *DNSProvider) CleanUp(domain, token, keyAuth string) error {
+	key := domain + "@" + token
+
+	this.locker.Lock()
+	record, ok := this.records[key]
+	this.locker.Unlock()
+	if !ok {
+		return nil
+	}
+
+	fullName := record.Name + "." + this.dnsDomain
+	err := this.raw.DeleteRecord(this.dnsDomain, record)
+	if err != nil {
+		this.log(false, "删除TXT记录 '"+fullName+"' 失败："+err.Error())
+		return errors.New("delete DNS record failed: " + err.Error())
+	}
+
+	this.locker.Lock()
+	delete(this.records, key)
+	this.locker.Unlock()
+
+	this.log(true, "删除TXT记录 '"+fullName+"'，值为 '"+record.Value+"'")
+
 	return nil
 }
+
+// 判断记录是否为当前任务添加的
+func (this *DNSProvider) isOwnRecord(record *dnsclients.Record) bool {
+	if len(record.Id) == 0 {
+		return false
+	}
+	this.locker.Lock()
+	defer this.locker.Unlock()
+	for _, r := range this.records {
+		if r.Id == record.Id {
+			return true
+		}
+	}
+	return false
+}
+
+// 从完整域名中分析记录名
+func (this *DNSProvider) recordName(fqdn string) (string, error) {
+	name := dns01.UnFqdn(fqdn)
+	if !strings.HasSuffix(name, "."+this.dnsDomain) {
+		return "", errors.New("invalid fqdn value '" + fqdn + "' for domain '" + this.dnsDomain + "'")
+	}
+	return strings.TrimSuffix(name, "."+this.dnsDomain), nil
+}
+
+// 查找记录名和值都相同的TXT记录
+func (this *DNSProvider) findRecord(recordName string, value string) (*dnsclients.Record, error) {
+	records, err := this.raw.GetRecords(this.dnsDomain)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if record.Type == dnsclients.RecordTypeTXT && record.Name == recordName && strings.Trim(record.Value, "\"") == value {
+			return record, nil
+		}
+	}
+	return nil, nil
+}
+
+// 记录日志
+func (this *DNSProvider) log(isOk bool, message string) {
+	if this.onLog != nil {
+		this.onLog(isOk, message)
+	}
+}
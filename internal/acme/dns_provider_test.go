@@ -0,0 +1,192 @@
+package acme
+
+import (
+	"errors"
+	"github.com/TeaOSLab/EdgeAPI/internal/dnsclients"
+	"github.com/iwind/TeaGo/maps"
+	"strconv"
+	"testing"
+)
+
+func TestDNSProvider_PresentAndCleanUp(t *testing.T) {
+	raw := &testMemoryDNSProvider{}
+	raw.records = append(raw.records, &dnsclients.Record{
+		Id:    "1",
+		Name:  "www",
+		Type:  dnsclients.RecordTypeA,
+		Value: "192.168.1.100",
+	})
+
+	logMessages := []string{}
+	provider := NewDNSProvider(raw, "example.com")
+	provider.OnLog(func(isOk bool, message string) {
+		logMessages = append(logMessages, message)
+	})
+
+	// example.com 和 *.example.com 使用同一个记录名
+	err := provider.Present("example.com", "token1", "keyAuth1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = provider.Present("*.example.com", "token2", "keyAuth2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = provider.Present("a.b.example.com", "token3", "keyAuth3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(raw.records) != 4 {
+		t.Fatal("expect 4 records, but got", len(raw.records))
+	}
+	if raw.records[1].Name != "_acme-challenge" || raw.records[2].Name != "_acme-challenge" || raw.records[3].Name != "_acme-challenge.a.b" {
+		t.Fatalf("invalid record names: %s, %s, %s", raw.records[1].Name, raw.records[2].Name, raw.records[3].Name)
+	}
+	if raw.records[1].Value == raw.records[2].Value {
+		t.Fatal("values should be different")
+	}
+
+	for _, args := range [][]string{{"example.com", "token1", "keyAuth1"}, {"*.example.com", "token2", "keyAuth2"}, {"a.b.example.com", "token3", "keyAuth3"}} {
+		err = provider.CleanUp(args[0], args[1], args[2])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(raw.records) != 1 || raw.records[0].Id != "1" {
+		t.Fatalf("only the challenge records should be deleted, but got %d records", len(raw.records))
+	}
+	if len(logMessages) != 6 {
+		t.Fatal("expect 6 log messages, but got", len(logMessages))
+	}
+	for _, message := range logMessages {
+		t.Log(message)
+	}
+
+	// 重复清理
+	err = provider.CleanUp("example.com", "token1", "keyAuth1")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDNSProvider_ExistingRecord(t *testing.T) {
+	raw := &testMemoryDNSProvider{}
+	provider := NewDNSProvider(raw, "example.com")
+	err := provider.Present("example.com", "token1", "keyAuth1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 其他任务使用新的Provider，遇到已经存在的记录时不应该接管
+	otherProvider := NewDNSProvider(raw, "example.com")
+	err = otherProvider.Present("example.com", "token1", "keyAuth1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = otherProvider.CleanUp("example.com", "token1", "keyAuth1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(raw.records) != 1 {
+		t.Fatal("existing record should not be deleted")
+	}
+
+	err = provider.CleanUp("example.com", "token1", "keyAuth1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(raw.records) != 0 {
+		t.Fatal("created record should be deleted")
+	}
+}
+
+func TestDNSProvider_InvalidDomain(t *testing.T) {
+	provider := NewDNSProvider(&testMemoryDNSProvider{}, "example.com")
+	err := provider.Present("example.org", "token1", "keyAuth1")
+	if err == nil {
+		t.Fatal("should fail for domain out of dns domain")
+	}
+	t.Log(err)
+}
+
+func TestDNSProvider_QueryFailed(t *testing.T) {
+	raw := &testMemoryDNSProvider{getRecordsErr: errors.New("api error")}
+	provider := NewDNSProvider(raw, "example.com")
+	err := provider.Present("example.com", "token1", "keyAuth1")
+	if err == nil {
+		t.Fatal("should fail when querying records failed")
+	}
+	t.Log(err)
+	if len(raw.records) != 0 {
+		t.Fatal("should not add record when querying records failed")
+	}
+
+	// 没有添加记录，清理时不应该删除任何记录
+	err = provider.CleanUp("example.com", "token1", "keyAuth1")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 内存中的DNS服务商，仅用于测试
+type testMemoryDNSProvider struct {
+	records       []*dnsclients.Record
+	lastId        int
+	getRecordsErr error
+}
+
+func (this *testMemoryDNSProvider) Auth(params maps.Map) error {
+	return nil
+}
+
+func (this *testMemoryDNSProvider) GetRecords(domain string) (records []*dnsclients.Record, err error) {
+	if this.getRecordsErr != nil {
+		return nil, this.getRecordsErr
+	}
+	return this.records, nil
+}
+
+func (this *testMemoryDNSProvider) GetRoutes(domain string) (routes []*dnsclients.Route, err error) {
+	return nil, nil
+}
+
+func (this *testMemoryDNSProvider) QueryRecord(domain string, name string, recordType dnsclients.RecordType) (*dnsclients.Record, error) {
+	for _, record := range this.records {
+		if record.Name == name && record.Type == recordType {
+			return record, nil
+		}
+	}
+	return nil, nil
+}
+
+func (this *testMemoryDNSProvider) AddRecord(domain string, newRecord *dnsclients.Record) error {
+	this.lastId++
+	newRecord.Id = "record" + strconv.Itoa(this.lastId)
+	this.records = append(this.records, newRecord)
+	return nil
+}
+
+func (this *testMemoryDNSProvider) UpdateRecord(domain string, record *dnsclients.Record, newRecord *dnsclients.Record) error {
+	for index, r := range this.records {
+		if r.Id == record.Id {
+			newRecord.Id = record.Id
+			this.records[index] = newRecord
+		}
+	}
+	return nil
+}
+
+func (this *testMemoryDNSProvider) DeleteRecord(domain string, record *dnsclients.Record) error {
+	result := []*dnsclients.Record{}
+	for _, r := range this.records {
+		if r.Id != record.Id {
+			result = append(result, r)
+		}
+	}
+	this.records = result
+	return nil
+}
+
+func (this *testMemoryDNSProvider) DefaultRoute() string {
+	return "default"
+}
@@ -0,0 +1,74 @@
+package acme
+
+import (
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/iwind/TeaGo/maps"
+)
+
+type KeyType = string
+
+// 证书私钥类型
+const (
+	KeyTypeRSA2048 KeyType = "RSA2048"
+	KeyTypeRSA4096 KeyType = "RSA4096"
+	KeyTypeEC256   KeyType = "EC256"
+	KeyTypeEC384   KeyType = "EC384"
+)
+
+// 默认私钥类型
+const DefaultKeyType = KeyTypeRSA2048
+
+// 所有私钥类型
+var AllKeyTypes = []maps.Map{
+	{
+		"name": "RSA 2048",
+		"code": KeyTypeRSA2048,
+	},
+	{
+		"name": "RSA 4096",
+		"code": KeyTypeRSA4096,
+	},
+	{
+		"name": "ECDSA P-256",
+		"code": KeyTypeEC256,
+	},
+	{
+		"name": "ECDSA P-384",
+		"code": KeyTypeEC384,
+	},
+}
+
+// 判断私钥类型是否有效
+func IsValidKeyType(keyType KeyType) bool {
+	_, ok := findCertCryptoKeyType(keyType)
+	return ok
+}
+
+// 判断是否为ECDSA私钥类型
+func IsECKeyType(keyType KeyType) bool {
+	return keyType == KeyTypeEC256 || keyType == KeyTypeEC384
+}
+
+// 双证书中另外一个证书的私钥类型
+// RSA证书对应ECDSA P-256证书，ECDSA证书对应RSA 2048证书
+func DualKeyType(keyType KeyType) KeyType {
+	if IsECKeyType(keyType) {
+		return KeyTypeRSA2048
+	}
+	return KeyTypeEC256
+}
+
+// 转换为lego中的私钥类型
+func findCertCryptoKeyType(keyType KeyType) (certcrypto.KeyType, bool) {
+	switch keyType {
+	case KeyTypeRSA2048:
+		return certcrypto.RSA2048, true
+	case KeyTypeRSA4096:
+		return certcrypto.RSA4096, true
+	case KeyTypeEC256:
+		return certcrypto.EC256, true
+	case KeyTypeEC384:
+		return certcrypto.EC384, true
+	}
+	return "", false
+}
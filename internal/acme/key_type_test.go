@@ -0,0 +1,38 @@
+package acme
+
+import "testing"
+
+func TestTask_AllKeyTypes(t *testing.T) {
+	{
+		task := &Task{}
+		keyTypes := task.AllKeyTypes()
+		if len(keyTypes) != 1 || keyTypes[0] != DefaultKeyType {
+			t.Fatal("invalid key types:", keyTypes)
+		}
+	}
+	{
+		task := &Task{KeyType: KeyTypeRSA4096, DualCerts: true}
+		keyTypes := task.AllKeyTypes()
+		if len(keyTypes) != 2 || keyTypes[0] != KeyTypeRSA4096 || keyTypes[1] != KeyTypeEC256 {
+			t.Fatal("invalid key types:", keyTypes)
+		}
+	}
+	{
+		task := &Task{KeyType: KeyTypeEC384, DualCerts: true}
+		keyTypes := task.AllKeyTypes()
+		if len(keyTypes) != 2 || keyTypes[0] != KeyTypeEC384 || keyTypes[1] != KeyTypeRSA2048 {
+			t.Fatal("invalid key types:", keyTypes)
+		}
+	}
+}
+
+func TestIsValidKeyType(t *testing.T) {
+	for _, keyType := range AllKeyTypes {
+		if !IsValidKeyType(keyType.GetString("code")) {
+			t.Fatal("key type should be valid:", keyType.GetString("code"))
+		}
+	}
+	if IsValidKeyType("RSA1024") {
+		t.Fatal("'RSA1024' should be invalid")
+	}
+}
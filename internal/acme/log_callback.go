@@ -0,0 +1,4 @@
+package acme
+
+// 任务执行过程中的日志回调
+type LogCallback func(isOk bool, message string)
@@ -0,0 +1,48 @@
+package acme
+
+import "github.com/iwind/TeaGo/maps"
+
+// 默认的ACME服务目录地址
+const DefaultDirectoryURL = "https://acme-v02.api.letsencrypt.org/directory"
+
+// 常用的ACME服务商
+// requireEAB 表示注册账号时是否需要提供External Account Binding信息
+var AllProviders = []maps.Map{
+	{
+		"name":         "Let's Encrypt",
+		"code":         "letsencrypt",
+		"directoryURL": DefaultDirectoryURL,
+		"requireEAB":   false,
+	},
+	{
+		"name":         "ZeroSSL",
+		"code":         "zerossl",
+		"directoryURL": "https://acme.zerossl.com/v2/DV90",
+		"requireEAB":   true,
+	},
+	{
+		"name":         "Google Trust Services",
+		"code":         "google",
+		"directoryURL": "https://dv.acme-v02.api.pki.goog/directory",
+		"requireEAB":   true,
+	},
+	{
+		"name":         "Buypass",
+		"code":         "buypass",
+		"directoryURL": "https://api.buypass.com/acme/directory",
+		"requireEAB":   false,
+	},
+}
+
+// 根据目录地址查找服务商名称
+func FindProviderNameWithDirectoryURL(directoryURL string) string {
+	if len(directoryURL) == 0 {
+		directoryURL = DefaultDirectoryURL
+	}
+	for _, provider := range AllProviders {
+		if provider.GetString("directoryURL") == directoryURL {
+			return provider.GetString("name")
+		}
+	}
+	return directoryURL
+}
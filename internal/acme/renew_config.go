@@ -0,0 +1,47 @@
+package acme
+
+// 自动续期配置在系统设置中的代号
+const SettingCodeACMERenewConfig = "acmeRenewConfig"
+
+// 自动续期配置
+type RenewConfig struct {
+	BeforeDays        int   `yaml:"beforeDays" json:"beforeDays"`               // 在证书到期前多少天开始续期
+	MinBackoffSeconds int64 `yaml:"minBackoffSeconds" json:"minBackoffSeconds"` // 失败后最小重试间隔
+	MaxBackoffSeconds int64 `yaml:"maxBackoffSeconds" json:"maxBackoffSeconds"` // 失败后最大重试间隔
+	MaxTasksEveryLoop int   `yaml:"maxTasksEveryLoop" json:"maxTasksEveryLoop"` // 每轮最多执行的任务数
+}
+
+// 默认的自动续期配置
+func DefaultRenewConfig() *RenewConfig {
+	return &RenewConfig{
+		BeforeDays:        30,
+		MinBackoffSeconds: 3600,
+		MaxBackoffSeconds: 86400,
+		MaxTasksEveryLoop: 10,
+	}
+}
+
+// 计算第N次失败后的重试间隔
+// 每失败一次间隔时间翻倍，但不超过最大间隔
+func (this *RenewConfig) BackoffSeconds(failures int) int64 {
+	if failures <= 0 {
+		return 0
+	}
+	minSeconds := this.MinBackoffSeconds
+	if minSeconds <= 0 {
+		minSeconds = 3600
+	}
+	maxSeconds := this.MaxBackoffSeconds
+	if maxSeconds < minSeconds {
+		maxSeconds = minSeconds
+	}
+
+	seconds := minSeconds
+	for i := 1; i < failures; i++ {
+		seconds *= 2
+		if seconds >= maxSeconds {
+			return maxSeconds
+		}
+	}
+	return seconds
+}
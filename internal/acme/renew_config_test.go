@@ -0,0 +1,24 @@
+package acme
+
+import "testing"
+
+func TestRenewConfig_BackoffSeconds(t *testing.T) {
+	config := DefaultRenewConfig()
+	for _, c := range []struct {
+		failures int
+		seconds  int64
+	}{
+		{0, 0},
+		{1, 3600},
+		{2, 7200},
+		{3, 14400},
+		{5, 57600},
+		{6, 86400},
+		{100, 86400},
+	} {
+		seconds := config.BackoffSeconds(c.failures)
+		if seconds != c.seconds {
+			t.Fatal("failures:", c.failures, "expect:", c.seconds, "but got:", seconds)
+		}
+	}
+}
@@ -2,20 +2,30 @@ package acme
 
 import (
 	"github.com/TeaOSLab/EdgeAPI/internal/errors"
-	"github.com/go-acme/lego/v4/certcrypto"
 	"github.com/go-acme/lego/v4/certificate"
 	"github.com/go-acme/lego/v4/lego"
 	acmelog "github.com/go-acme/lego/v4/log"
 	"github.com/go-acme/lego/v4/registration"
 	"io/ioutil"
 	"log"
+	"net/http"
 )
 
+// 申请到的证书
+type Cert struct {
+	KeyType  KeyType
+	CertData []byte
+	KeyData  []byte
+}
+
 type Request struct {
 	debug bool
 
 	task   *Task
 	onAuth AuthCallback
+	onLog  LogCallback
+
+	httpClient *http.Client // 为空时使用lego默认的客户端
 }
 
 func NewRequest(task *Task) *Request {
@@ -32,69 +42,88 @@ func (this *Request) OnAuth(onAuth AuthCallback) {
 	this.onAuth = onAuth
 }
 
+func (this *Request) OnLog(onLog LogCallback) {
+	this.onLog = onLog
+}
+
+// 申请主证书
 func (this *Request) Run() (certData []byte, keyData []byte, err error) {
+	cert, err := this.obtain(this.task.MainKeyType())
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert.CertData, cert.KeyData, nil
+}
+
+// 申请任务中所有私钥类型的证书，第一个为主证书
+func (this *Request) RunAll() (certs []*Cert, err error) {
+	for _, keyType := range this.task.AllKeyTypes() {
+		cert, err := this.obtain(keyType)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	return
+}
+
+// 检查任务参数
+func (this *Request) validate() error {
+	if this.task.User == nil {
+		return errors.New("'user' must not be nil")
+	}
+
 	switch this.task.AuthType {
 	case AuthTypeDNS:
-		return this.runDNS()
+		if this.task.DNSProvider == nil {
+			return errors.New("'dnsProvider' must not be nil")
+		}
+		if len(this.task.DNSDomain) == 0 {
+			return errors.New("'dnsDomain' must not be empty")
+		}
+		if len(this.task.Domains) == 0 {
+			return errors.New("'domains' must not be empty")
+		}
 	case AuthTypeHTTP:
-		return this.runHTTP()
 	default:
-		err = errors.New("invalid task type '" + this.task.AuthType + "'")
-		return
+		return errors.New("invalid task type '" + this.task.AuthType + "'")
 	}
+
+	return nil
 }
 
-func (this *Request) runDNS() (certData []byte, keyData []byte, err error) {
+// 申请某个私钥类型的证书
+func (this *Request) obtain(keyType KeyType) (*Cert, error) {
 	if !this.debug {
 		acmelog.Logger = log.New(ioutil.Discard, "", log.LstdFlags)
 	}
 
-	if this.task.User == nil {
-		err = errors.New("'user' must not be nil")
-		return
-	}
-	if this.task.DNSProvider == nil {
-		err = errors.New("'dnsProvider' must not be nil")
-		return
-	}
-	if len(this.task.DNSDomain) == 0 {
-		err = errors.New("'dnsDomain' must not be empty")
-		return
-	}
-	if len(this.task.Domains) == 0 {
-		err = errors.New("'domains' must not be empty")
-		return
+	err := this.validate()
+	if err != nil {
+		return nil, err
 	}
 
-	config := lego.NewConfig(this.task.User)
-	config.Certificate.KeyType = certcrypto.RSA2048
-
-	client, err := lego.NewClient(config)
+	client, err := this.newClient(keyType)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	// 注册用户
-	resource := this.task.User.GetRegistration()
-	if resource != nil {
-		resource, err = client.Registration.QueryRegistration()
-		if err != nil {
-			return nil, nil, err
-		}
-	} else {
-		resource, err := client.Registration.Register(registration.RegisterOptions{TermsOfServiceAgreed: true})
-		if err != nil {
-			return nil, nil, err
-		}
-		err = this.task.User.Register(resource)
-		if err != nil {
-			return nil, nil, err
-		}
+	err = this.register(client)
+	if err != nil {
+		return nil, err
 	}
 
-	err = client.Challenge.SetDNS01Provider(NewDNSProvider(this.task.DNSProvider))
+	switch this.task.AuthType {
+	case AuthTypeDNS:
+		dnsProvider := NewDNSProvider(this.task.DNSProvider, this.task.DNSDomain)
+		dnsProvider.OnLog(this.onLog)
+		err = client.Challenge.SetDNS01Provider(dnsProvider)
+	case AuthTypeHTTP:
+		err = client.Challenge.SetHTTP01Provider(NewHTTPProvider(this.onAuth))
+	}
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	// 申请证书
@@ -104,62 +133,54 @@ func (this *Request) runDNS() (certData []byte, keyData []byte, err error) {
 	}
 	certResource, err := client.Certificate.Obtain(request)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	return certResource.Certificate, certResource.PrivateKey, nil
+	return &Cert{
+		KeyType:  keyType,
+		CertData: certResource.Certificate,
+		KeyData:  certResource.PrivateKey,
+	}, nil
 }
 
-func (this *Request) runHTTP() (certData []byte, keyData []byte, err error) {
-	if !this.debug {
-		acmelog.Logger = log.New(ioutil.Discard, "", log.LstdFlags)
-	}
-
-	if this.task.User == nil {
-		err = errors.New("'user' must not be nil")
-		return
+// 创建ACME客户端
+func (this *Request) newClient(keyType KeyType) (*lego.Client, error) {
+	certCryptoKeyType, ok := findCertCryptoKeyType(keyType)
+	if !ok {
+		return nil, errors.New("invalid key type '" + keyType + "'")
 	}
 
 	config := lego.NewConfig(this.task.User)
-	config.Certificate.KeyType = certcrypto.RSA2048
-
-	client, err := lego.NewClient(config)
-	if err != nil {
-		return nil, nil, err
+	config.CADirURL = this.task.User.DirectoryURL()
+	config.Certificate.KeyType = certCryptoKeyType
+	if this.httpClient != nil {
+		config.HTTPClient = this.httpClient
 	}
 
-	// 注册用户
+	return lego.NewClient(config)
+}
+
+// 注册用户
+// 如果用户已经注册过则只查询注册信息，如果设置了External Account Binding则使用EAB注册
+func (this *Request) register(client *lego.Client) error {
 	resource := this.task.User.GetRegistration()
 	if resource != nil {
-		resource, err = client.Registration.QueryRegistration()
-		if err != nil {
-			return nil, nil, err
-		}
-	} else {
-		resource, err := client.Registration.Register(registration.RegisterOptions{TermsOfServiceAgreed: true})
-		if err != nil {
-			return nil, nil, err
-		}
-		err = this.task.User.Register(resource)
-		if err != nil {
-			return nil, nil, err
-		}
+		_, err := client.Registration.QueryRegistration()
+		return err
 	}
 
-	err = client.Challenge.SetHTTP01Provider(NewHTTPProvider(this.onAuth))
-	if err != nil {
-		return nil, nil, err
-	}
-
-	// 申请证书
-	request := certificate.ObtainRequest{
-		Domains: this.task.Domains,
-		Bundle:  true,
+	var err error
+	if this.task.User.HasExternalAccountBinding() {
+		resource, err = client.Registration.RegisterWithExternalAccountBinding(registration.RegisterEABOptions{
+			TermsOfServiceAgreed: true,
+			Kid:                  this.task.User.eabKid,
+			HmacEncoded:          this.task.User.eabHmacKey,
+		})
+	} else {
+		resource, err = client.Registration.Register(registration.RegisterOptions{TermsOfServiceAgreed: true})
 	}
-	certResource, err := client.Certificate.Obtain(request)
 	if err != nil {
-		return nil, nil, err
+		return err
 	}
-
-	return certResource.Certificate, certResource.PrivateKey, nil
+	return this.task.User.Register(resource)
 }
@@ -0,0 +1,187 @@
+package acme
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/go-acme/lego/v4/registration"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testEABKid = "kid-1"
+const testEABHmacKey = "dGVzdC1obWFjLWtleS1mb3ItZWFiLW9ubHktMTIzNDU2Nzg5MA" // base64url
+
+func TestRequest_Register_EAB(t *testing.T) {
+	server, lastPayload := testACMEServer(t, true)
+	defer server.Close()
+
+	registered := false
+	user := testACMEUser(t, func(resource *registration.Resource) error {
+		registered = true
+		return nil
+	})
+	user.SetDirectoryURL(server.URL + "/directory")
+	user.SetExternalAccountBinding(testEABKid, testEABHmacKey)
+
+	req := NewRequest(&Task{User: user, AuthType: AuthTypeHTTP, Domains: []string{"example.com"}})
+	req.httpClient = server.Client()
+	client, err := req.newClient(DefaultKeyType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = req.register(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !registered || user.GetRegistration() == nil {
+		t.Fatal("user should be registered")
+	}
+	if user.GetRegistration().URI != server.URL+"/acct/1" {
+		t.Fatal("invalid account uri:", user.GetRegistration().URI)
+	}
+	t.Log(string(*lastPayload))
+}
+
+func TestRequest_Register_EABRequired(t *testing.T) {
+	server, _ := testACMEServer(t, true)
+	defer server.Close()
+
+	user := testACMEUser(t, func(resource *registration.Resource) error {
+		return nil
+	})
+	user.SetDirectoryURL(server.URL + "/directory")
+
+	req := NewRequest(&Task{User: user, AuthType: AuthTypeHTTP, Domains: []string{"example.com"}})
+	req.httpClient = server.Client()
+	client, err := req.newClient(DefaultKeyType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = req.register(client)
+	if err == nil {
+		t.Fatal("register without EAB should fail")
+	}
+	t.Log(err)
+}
+
+func TestRequest_Register_WithoutEAB(t *testing.T) {
+	server, _ := testACMEServer(t, false)
+	defer server.Close()
+
+	user := testACMEUser(t, func(resource *registration.Resource) error {
+		return nil
+	})
+	user.SetDirectoryURL(server.URL + "/directory")
+
+	req := NewRequest(&Task{User: user, AuthType: AuthTypeHTTP, Domains: []string{"example.com"}})
+	req.httpClient = server.Client()
+	client, err := req.newClient(DefaultKeyType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = req.register(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testACMEUser(t *testing.T, registerFunc func(resource *registration.Resource) error) *User {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewUser("test@example.com", privateKey, registerFunc)
+}
+
+// 模拟的ACME服务，只实现了注册账号需要的接口
+func testACMEServer(t *testing.T, requireEAB bool) (server *httptest.Server, lastPayload *[]byte) {
+	lastPayload = new([]byte)
+	mux := http.NewServeMux()
+	server = httptest.NewTLSServer(mux)
+
+	writeJSON := func(writer http.ResponseWriter, status int, contentType string, v interface{}) {
+		data, _ := json.Marshal(v)
+		writer.Header().Set("Content-Type", contentType)
+		writer.WriteHeader(status)
+		_, _ = writer.Write(data)
+	}
+
+	mux.HandleFunc("/", func(writer http.ResponseWriter, req *http.Request) {
+		writer.Header().Set("Replay-Nonce", "nonce-"+base64.RawURLEncoding.EncodeToString([]byte(req.URL.Path)))
+		writer.Header().Set("Cache-Control", "no-store")
+
+		switch req.URL.Path {
+		case "/directory":
+			writeJSON(writer, http.StatusOK, "application/json", map[string]interface{}{
+				"newNonce":   server.URL + "/new-nonce",
+				"newAccount": server.URL + "/new-account",
+				"newOrder":   server.URL + "/new-order",
+				"revokeCert": server.URL + "/revoke-cert",
+				"keyChange":  server.URL + "/key-change",
+				"meta": map[string]interface{}{
+					"externalAccountRequired": requireEAB,
+				},
+			})
+		case "/new-nonce":
+			writer.WriteHeader(http.StatusOK)
+		case "/new-account":
+			body := struct {
+				Protected string `json:"protected"`
+				Payload   string `json:"payload"`
+			}{}
+			err := json.NewDecoder(req.Body).Decode(&body)
+			if err != nil {
+				writeJSON(writer, http.StatusBadRequest, "application/problem+json", map[string]interface{}{
+					"type":   "urn:ietf:params:acme:error:malformed",
+					"detail": err.Error(),
+				})
+				return
+			}
+			payload, err := base64.RawURLEncoding.DecodeString(body.Payload)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			*lastPayload = payload
+
+			account := struct {
+				Contact                []string `json:"contact"`
+				ExternalAccountBinding *struct {
+					Protected string `json:"protected"`
+				} `json:"externalAccountBinding"`
+			}{}
+			_ = json.Unmarshal(payload, &account)
+			if requireEAB {
+				if account.ExternalAccountBinding == nil {
+					writeJSON(writer, http.StatusBadRequest, "application/problem+json", map[string]interface{}{
+						"type":   "urn:ietf:params:acme:error:externalAccountRequired",
+						"detail": "external account binding is required",
+					})
+					return
+				}
+				eabProtected, _ := base64.RawURLEncoding.DecodeString(account.ExternalAccountBinding.Protected)
+				if !strings.Contains(string(eabProtected), `"kid":"`+testEABKid+`"`) {
+					writeJSON(writer, http.StatusUnauthorized, "application/problem+json", map[string]interface{}{
+						"type":   "urn:ietf:params:acme:error:unauthorized",
+						"detail": "invalid eab kid",
+					})
+					return
+				}
+			}
+
+			writer.Header().Set("Location", server.URL+"/acct/1")
+			writeJSON(writer, http.StatusCreated, "application/json", map[string]interface{}{
+				"status":  "valid",
+				"contact": account.Contact,
+			})
+		default:
+			http.NotFound(writer, req)
+		}
+	})
+	return
+}
@@ -14,7 +14,28 @@ type Task struct {
 	AuthType AuthType
 	Domains  []string
 
+	// 证书私钥相关
+	KeyType   KeyType // 私钥类型，为空时使用 DefaultKeyType
+	DualCerts bool    // 是否同时申请RSA和ECDSA两个证书
+
 	// DNS相关
 	DNSProvider dnsclients.ProviderInterface
 	DNSDomain   string
 }
+
+// 主证书私钥类型
+func (this *Task) MainKeyType() KeyType {
+	if len(this.KeyType) == 0 {
+		return DefaultKeyType
+	}
+	return this.KeyType
+}
+
+// 需要申请的所有证书的私钥类型
+func (this *Task) AllKeyTypes() []KeyType {
+	mainKeyType := this.MainKeyType()
+	if this.DualCerts {
+		return []KeyType{mainKeyType, DualKeyType(mainKeyType)}
+	}
+	return []KeyType{mainKeyType}
+}
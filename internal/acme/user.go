@@ -11,6 +11,10 @@ type User struct {
 	resource     *registration.Resource
 	key          crypto.PrivateKey
 	registerFunc func(resource *registration.Resource) error
+
+	directoryURL string
+	eabKid       string
+	eabHmacKey   string
 }
 
 func NewUser(email string, key crypto.PrivateKey, registerFunc func(resource *registration.Resource) error) *User {
@@ -47,3 +51,28 @@ func (this *User) Register(resource *registration.Resource) error {
 	this.resource = resource
 	return this.registerFunc(resource)
 }
+
+// 设置ACME服务目录地址，为空时使用 DefaultDirectoryURL
+func (this *User) SetDirectoryURL(directoryURL string) {
+	this.directoryURL = directoryURL
+}
+
+// ACME服务目录地址
+func (this *User) DirectoryURL() string {
+	if len(this.directoryURL) == 0 {
+		return DefaultDirectoryURL
+	}
+	return this.directoryURL
+}
+
+// 设置External Account Binding信息
+// hmacKey 为服务商提供的Base64URL编码的HMAC密钥
+func (this *User) SetExternalAccountBinding(kid string, hmacKey string) {
+	this.eabKid = kid
+	this.eabHmacKey = hmacKey
+}
+
+// 是否设置了External Account Binding
+func (this *User) HasExternalAccountBinding() bool {
+	return len(this.eabKid) > 0 && len(this.eabHmacKey) > 0
+}
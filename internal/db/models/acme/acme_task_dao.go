@@ -118,7 +118,7 @@ func (this *ACMETaskDAO) ListEnabledACMETasks(tx *dbs.Tx, adminId int64, userId
 }
 
 // 创建任务
-func (this *ACMETaskDAO) CreateACMETask(tx *dbs.Tx, adminId int64, userId int64, authType acme.AuthType, acmeUserId int64, dnsProviderId int64, dnsDomain string, domains []string, autoRenew bool) (int64, error) {
+func (this *ACMETaskDAO) CreateACMETask(tx *dbs.Tx, adminId int64, userId int64, authType acme.AuthType, acmeUserId int64, dnsProviderId int64, dnsDomain string, domains []string, autoRenew bool, keyType acme.KeyType, dualCerts bool) (int64, error) {
 	op := NewACMETaskOperator()
 	op.AdminId = adminId
 	op.UserId = userId
@@ -138,6 +138,13 @@ func (this *ACMETaskDAO) CreateACMETask(tx *dbs.Tx, adminId int64, userId int64,
 	}
 
 	op.AutoRenew = autoRenew
+
+	if len(keyType) == 0 {
+		keyType = acme.DefaultKeyType
+	}
+	op.KeyType = keyType
+	op.DualCerts = dualCerts
+
 	op.IsOn = true
 	op.State = ACMETaskStateEnabled
 	err := this.Save(tx, op)
@@ -148,7 +155,7 @@ func (this *ACMETaskDAO) CreateACMETask(tx *dbs.Tx, adminId int64, userId int64,
 }
 
 // 修改任务
-func (this *ACMETaskDAO) UpdateACMETask(tx *dbs.Tx, acmeTaskId int64, acmeUserId int64, dnsProviderId int64, dnsDomain string, domains []string, autoRenew bool) error {
+func (this *ACMETaskDAO) UpdateACMETask(tx *dbs.Tx, acmeTaskId int64, acmeUserId int64, dnsProviderId int64, dnsDomain string, domains []string, autoRenew bool, keyType acme.KeyType, dualCerts bool) error {
 	if acmeTaskId <= 0 {
 		return errors.New("invalid acmeTaskId")
 	}
@@ -170,6 +177,13 @@ func (this *ACMETaskDAO) UpdateACMETask(tx *dbs.Tx, acmeTaskId int64, acmeUserId
 	}
 
 	op.AutoRenew = autoRenew
+
+	if len(keyType) == 0 {
+		keyType = acme.DefaultKeyType
+	}
+	op.KeyType = keyType
+	op.DualCerts = dualCerts
+
 	err := this.Save(tx, op)
 	return err
 }
@@ -195,6 +209,58 @@ func (this *ACMETaskDAO) UpdateACMETaskCert(tx *dbs.Tx, taskId int64, certId int
 	return err
 }
 
+// 设置任务关联的另外一个证书
+func (this *ACMETaskDAO) UpdateACMETaskDualCert(tx *dbs.Tx, taskId int64, certId int64) error {
+	if taskId <= 0 {
+		return errors.New("invalid taskId")
+	}
+
+	op := NewACMETaskOperator()
+	op.Id = taskId
+	op.DualCertId = certId
+	err := this.Save(tx, op)
+	return err
+}
+
+// 设置签发证书的ACME服务目录地址
+func (this *ACMETaskDAO) UpdateACMETaskCADirectoryURL(tx *dbs.Tx, taskId int64, directoryURL string) error {
+	if taskId <= 0 {
+		return errors.New("invalid taskId")
+	}
+
+	op := NewACMETaskOperator()
+	op.Id = taskId
+	op.CaDirectoryURL = directoryURL
+	err := this.Save(tx, op)
+	return err
+}
+
+// 记录续期失败
+func (this *ACMETaskDAO) UpdateACMETaskRenewFailed(tx *dbs.Tx, taskId int64, failures int, nextRenewAt int64) error {
+	if taskId <= 0 {
+		return errors.New("invalid taskId")
+	}
+	_, err := this.Query(tx).
+		Pk(taskId).
+		Set("renewFailures", failures).
+		Set("nextRenewAt", nextRenewAt).
+		Update()
+	return err
+}
+
+// 重置续期失败次数
+func (this *ACMETaskDAO) ResetACMETaskRenewFailures(tx *dbs.Tx, taskId int64) error {
+	if taskId <= 0 {
+		return errors.New("invalid taskId")
+	}
+	_, err := this.Query(tx).
+		Pk(taskId).
+		Set("renewFailures", 0).
+		Set("nextRenewAt", 0).
+		Update()
+	return err
+}
+
 // 执行任务并记录日志
 func (this *ACMETaskDAO) RunTask(tx *dbs.Tx, taskId int64) (isOk bool, errMsg string, resultCertId int64) {
 	isOk, errMsg, resultCertId = this.runTaskWithoutLog(tx, taskId)
@@ -258,6 +324,8 @@ func (this *ACMETaskDAO) runTaskWithoutLog(tx *dbs.Tx, taskId int64) (isOk bool,
 			return
 		}
 	}
+	remoteUser.SetDirectoryURL(user.DirectoryURL)
+	remoteUser.SetExternalAccountBinding(user.EabKid, user.EabHmacKey)
 
 	var acmeTask *acme.Task = nil
 	if task.AuthType == acme.AuthTypeDNS {
@@ -293,12 +361,16 @@ func (this *ACMETaskDAO) runTaskWithoutLog(tx *dbs.Tx, taskId int64) (isOk bool,
 			DNSProvider: providerInterface,
 			DNSDomain:   task.DnsDomain,
 			Domains:     task.DecodeDomains(),
+			KeyType:     task.KeyType,
+			DualCerts:   task.DualCerts == 1,
 		}
 	} else if task.AuthType == acme.AuthTypeHTTP {
 		acmeTask = &acme.Task{
-			User:     remoteUser,
-			AuthType: acme.AuthTypeHTTP,
-			Domains:  task.DecodeDomains(),
+			User:      remoteUser,
+			AuthType:  acme.AuthTypeHTTP,
+			Domains:   task.DecodeDomains(),
+			KeyType:   task.KeyType,
+			DualCerts: task.DualCerts == 1,
 		}
 	}
 
@@ -309,25 +381,74 @@ func (this *ACMETaskDAO) runTaskWithoutLog(tx *dbs.Tx, taskId int64) (isOk bool,
 			logs.Println("[ACME]write authentication to database error: " + err.Error())
 		}
 	})
-	certData, keyData, err := acmeRequest.Run()
+	acmeRequest.OnLog(func(isOk bool, message string) {
+		err := SharedACMETaskLogDAO.CreateACMETaskLog(tx, taskId, isOk, message)
+		if err != nil {
+			logs.Println("[ACME]write task log to database error: " + err.Error())
+		}
+	})
+	certs, err := acmeRequest.RunAll()
 	if err != nil {
 		errMsg = "证书生成失败：" + err.Error()
 		return
 	}
 
+	// 记录签发证书的CA
+	caDirectoryURL := remoteUser.DirectoryURL()
+	err = this.UpdateACMETaskCADirectoryURL(tx, taskId, caDirectoryURL)
+	if err != nil {
+		errMsg = "证书生成成功，但是记录签发证书的CA时出错：" + err.Error()
+		return
+	}
+	err = SharedACMETaskLogDAO.CreateACMETaskLog(tx, taskId, true, "证书由 '"+acme.FindProviderNameWithDirectoryURL(caDirectoryURL)+"' 签发")
+	if err != nil {
+		logs.Println("[ACME]write task log to database error: " + err.Error())
+	}
+
+	// 保存主证书
+	resultCertId, errMsg = this.saveTaskCert(tx, task, certs[0], false)
+	if len(errMsg) > 0 {
+		return
+	}
+
+	// 保存双证书中的另外一个证书，并加入到使用主证书的SSL策略中
+	if len(certs) > 1 {
+		dualCertId, dualErrMsg := this.saveTaskCert(tx, task, certs[1], true)
+		if len(dualErrMsg) > 0 {
+			errMsg = dualErrMsg
+			return
+		}
+		err = models.SharedSSLPolicyDAO.AddCertToPoliciesWithCertId(tx, resultCertId, dualCertId)
+		if err != nil {
+			errMsg = "证书生成成功，但是添加双证书到SSL策略时出错：" + err.Error()
+			return
+		}
+	}
+
+	isOk = true
+	return
+}
+
+// 保存任务生成的证书
+// 如果任务已经关联了证书，则直接在原有证书上修改，这样所有使用此证书的SSL策略都可以自动更新
+func (this *ACMETaskDAO) saveTaskCert(tx *dbs.Tx, task *ACMETask, acmeCert *acme.Cert, isDual bool) (resultCertId int64, errMsg string) {
 	// 分析证书
 	sslConfig := &sslconfigs.SSLCertConfig{
-		CertData: certData,
-		KeyData:  keyData,
+		CertData: acmeCert.CertData,
+		KeyData:  acmeCert.KeyData,
 	}
-	err = sslConfig.Init()
+	err := sslConfig.Init()
 	if err != nil {
 		errMsg = "证书生成成功，但是分析证书信息时发生错误：" + err.Error()
 		return
 	}
 
-	// 保存证书
-	resultCertId = int64(task.CertId)
+	taskId := int64(task.Id)
+	if isDual {
+		resultCertId = int64(task.DualCertId)
+	} else {
+		resultCertId = int64(task.CertId)
+	}
 	if resultCertId > 0 {
 		cert, err := models.SharedSSLCertDAO.FindEnabledSSLCert(tx, resultCertId)
 		if err != nil {
@@ -335,43 +456,59 @@ func (this *ACMETaskDAO) runTaskWithoutLog(tx *dbs.Tx, taskId int64) (isOk bool,
 			return
 		}
 		if cert == nil {
-			errMsg = "证书已被管理员或用户删除"
-
-			// 禁用
-			err = SharedACMETaskDAO.DisableACMETask(tx, taskId)
+			// 双证书中另外一个证书被删除时重新创建
+			if isDual {
+				resultCertId = 0
+			} else {
+				errMsg = "证书已被管理员或用户删除"
+
+				// 禁用
+				err = SharedACMETaskDAO.DisableACMETask(tx, taskId)
+				if err != nil {
+					errMsg = "禁用失效的ACME任务出错：" + err.Error()
+				}
+
+				return
+			}
+		} else {
+			err = models.SharedSSLCertDAO.UpdateCert(tx, resultCertId, cert.IsOn == 1, cert.Name, cert.Description, cert.ServerName, cert.IsCA == 1, acmeCert.CertData, acmeCert.KeyData, sslConfig.TimeBeginAt, sslConfig.TimeEndAt, sslConfig.DNSNames, sslConfig.CommonNames)
 			if err != nil {
-				errMsg = "禁用失效的ACME任务出错：" + err.Error()
+				errMsg = "证书生成成功，但是修改数据库中的证书信息时出错：" + err.Error()
 			}
-
 			return
 		}
+	}
 
-		err = models.SharedSSLCertDAO.UpdateCert(tx, resultCertId, cert.IsOn == 1, cert.Name, cert.Description, cert.ServerName, cert.IsCA == 1, certData, keyData, sslConfig.TimeBeginAt, sslConfig.TimeEndAt, sslConfig.DNSNames, sslConfig.CommonNames)
-		if err != nil {
-			errMsg = "证书生成成功，但是修改数据库中的证书信息时出错：" + err.Error()
-			return
-		}
-	} else {
-		resultCertId, err = models.SharedSSLCertDAO.CreateCert(tx, int64(task.AdminId), int64(task.UserId), true, task.DnsDomain+"免费证书", "免费申请的证书", "", false, certData, keyData, sslConfig.TimeBeginAt, sslConfig.TimeEndAt, sslConfig.DNSNames, sslConfig.CommonNames)
-		if err != nil {
-			errMsg = "证书生成成功，但是保存到数据库失败：" + err.Error()
-			return
+	certName := task.DnsDomain + "免费证书"
+	if task.DualCerts == 1 {
+		if acme.IsECKeyType(acmeCert.KeyType) {
+			certName += "（ECDSA）"
+		} else {
+			certName += "（RSA）"
 		}
+	}
+	resultCertId, err = models.SharedSSLCertDAO.CreateCert(tx, int64(task.AdminId), int64(task.UserId), true, certName, "免费申请的证书", "", false, acmeCert.CertData, acmeCert.KeyData, sslConfig.TimeBeginAt, sslConfig.TimeEndAt, sslConfig.DNSNames, sslConfig.CommonNames)
+	if err != nil {
+		errMsg = "证书生成成功，但是保存到数据库失败：" + err.Error()
+		return
+	}
 
-		err = models.SharedSSLCertDAO.UpdateCertACME(tx, resultCertId, int64(task.Id))
-		if err != nil {
-			errMsg = "证书生成成功，修改证书ACME信息时出错：" + err.Error()
-			return
-		}
+	err = models.SharedSSLCertDAO.UpdateCertACME(tx, resultCertId, taskId)
+	if err != nil {
+		errMsg = "证书生成成功，修改证书ACME信息时出错：" + err.Error()
+		return
+	}
 
-		// 设置成功
+	// 设置成功
+	if isDual {
+		err = SharedACMETaskDAO.UpdateACMETaskDualCert(tx, taskId, resultCertId)
+	} else {
 		err = SharedACMETaskDAO.UpdateACMETaskCert(tx, taskId, resultCertId)
-		if err != nil {
-			errMsg = "证书生成成功，设置任务关联的证书时出错：" + err.Error()
-			return
-		}
+	}
+	if err != nil {
+		errMsg = "证书生成成功，设置任务关联的证书时出错：" + err.Error()
+		return
 	}
 
-	isOk = true
 	return
 }
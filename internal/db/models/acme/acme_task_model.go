@@ -2,35 +2,47 @@ package acme
 
 // ACME任务
 type ACMETask struct {
-	Id            uint64 `field:"id"`            // ID
-	AdminId       uint32 `field:"adminId"`       // 管理员ID
-	UserId        uint32 `field:"userId"`        // 用户ID
-	IsOn          uint8  `field:"isOn"`          // 是否启用
-	AcmeUserId    uint32 `field:"acmeUserId"`    // ACME用户ID
-	DnsDomain     string `field:"dnsDomain"`     // DNS主域名
-	DnsProviderId uint64 `field:"dnsProviderId"` // DNS服务商
-	Domains       string `field:"domains"`       // 证书域名
-	CreatedAt     uint64 `field:"createdAt"`     // 创建时间
-	State         uint8  `field:"state"`         // 状态
-	CertId        uint64 `field:"certId"`        // 生成的证书ID
-	AutoRenew     uint8  `field:"autoRenew"`     // 是否自动更新
-	AuthType      string `field:"authType"`      // 认证类型
+	Id             uint64 `field:"id"`             // ID
+	AdminId        uint32 `field:"adminId"`        // 管理员ID
+	UserId         uint32 `field:"userId"`         // 用户ID
+	IsOn           uint8  `field:"isOn"`           // 是否启用
+	AcmeUserId     uint32 `field:"acmeUserId"`     // ACME用户ID
+	DnsDomain      string `field:"dnsDomain"`      // DNS主域名
+	DnsProviderId  uint64 `field:"dnsProviderId"`  // DNS服务商
+	Domains        string `field:"domains"`        // 证书域名
+	CreatedAt      uint64 `field:"createdAt"`      // 创建时间
+	State          uint8  `field:"state"`          // 状态
+	CertId         uint64 `field:"certId"`         // 生成的证书ID
+	AutoRenew      uint8  `field:"autoRenew"`      // 是否自动更新
+	AuthType       string `field:"authType"`       // 认证类型
+	RenewFailures  uint32 `field:"renewFailures"`  // 连续续期失败次数
+	NextRenewAt    uint64 `field:"nextRenewAt"`    // 下次可以尝试续期的时间
+	KeyType        string `field:"keyType"`        // 私钥类型
+	DualCerts      uint8  `field:"dualCerts"`      // 是否同时申请RSA和ECDSA证书
+	DualCertId     uint64 `field:"dualCertId"`     // 生成的另外一个证书ID
+	CaDirectoryURL string `field:"caDirectoryURL"` // 签发证书的ACME服务目录地址
 }
 
 type ACMETaskOperator struct {
-	Id            interface{} // ID
-	AdminId       interface{} // 管理员ID
-	UserId        interface{} // 用户ID
-	IsOn          interface{} // 是否启用
-	AcmeUserId    interface{} // ACME用户ID
-	DnsDomain     interface{} // DNS主域名
-	DnsProviderId interface{} // DNS服务商
-	Domains       interface{} // 证书域名
-	CreatedAt     interface{} // 创建时间
-	State         interface{} // 状态
-	CertId        interface{} // 生成的证书ID
-	AutoRenew     interface{} // 是否自动更新
-	AuthType      interface{} // 认证类型
+	Id             interface{} // ID
+	AdminId        interface{} // 管理员ID
+	UserId         interface{} // 用户ID
+	IsOn           interface{} // 是否启用
+	AcmeUserId     interface{} // ACME用户ID
+	DnsDomain      interface{} // DNS主域名
+	DnsProviderId  interface{} // DNS服务商
+	Domains        interface{} // 证书域名
+	CreatedAt      interface{} // 创建时间
+	State          interface{} // 状态
+	CertId         interface{} // 生成的证书ID
+	AutoRenew      interface{} // 是否自动更新
+	AuthType       interface{} // 认证类型
+	RenewFailures  interface{} // 连续续期失败次数
+	NextRenewAt    interface{} // 下次可以尝试续期的时间
+	KeyType        interface{} // 私钥类型
+	DualCerts      interface{} // 是否同时申请RSA和ECDSA证书
+	DualCertId     interface{} // 生成的另外一个证书ID
+	CaDirectoryURL interface{} // 签发证书的ACME服务目录地址
 }
 
 func NewACMETaskOperator() *ACMETaskOperator {
@@ -70,7 +70,8 @@ func (this *ACMEUserDAO) FindEnabledACMEUser(tx *dbs.Tx, id int64) (*ACMEUser, e
 }
 
 // 创建用户
-func (this *ACMEUserDAO) CreateACMEUser(tx *dbs.Tx, adminId int64, userId int64, email string, description string) (int64, error) {
+// directoryURL 为空时使用Let's Encrypt；eabKid 和 eabHmacKey 用于需要External Account Binding的CA，比如ZeroSSL
+func (this *ACMEUserDAO) CreateACMEUser(tx *dbs.Tx, adminId int64, userId int64, email string, description string, directoryURL string, eabKid string, eabHmacKey string) (int64, error) {
 	// 生成私钥
 	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	if err != nil {
@@ -88,6 +89,9 @@ func (this *ACMEUserDAO) CreateACMEUser(tx *dbs.Tx, adminId int64, userId int64,
 	op.UserId = userId
 	op.Email = email
 	op.Description = description
+	op.DirectoryURL = directoryURL
+	op.EabKid = eabKid
+	op.EabHmacKey = eabHmacKey
 	op.PrivateKey = privateKeyText
 	op.State = ACMEUserStateEnabled
 	err = this.Save(tx, op)
@@ -98,14 +102,34 @@ func (this *ACMEUserDAO) CreateACMEUser(tx *dbs.Tx, adminId int64, userId int64,
 }
 
 // 修改用户信息
-func (this *ACMEUserDAO) UpdateACMEUser(tx *dbs.Tx, acmeUserId int64, description string) error {
+// eabHmacKey为空时保留原有的HMAC Key，因为读取用户信息时不会返回HMAC Key
+// 如果ACME服务或者EAB Kid有变化，则清除已有的注册信息，下次申请证书时重新注册
+func (this *ACMEUserDAO) UpdateACMEUser(tx *dbs.Tx, acmeUserId int64, description string, directoryURL string, eabKid string, eabHmacKey string) error {
 	if acmeUserId <= 0 {
 		return errors.New("invalid acmeUserId")
 	}
+	user, err := this.FindEnabledACMEUser(tx, acmeUserId)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("can not find acme user")
+	}
+
 	op := NewACMEUserOperator()
 	op.Id = acmeUserId
 	op.Description = description
-	err := this.Save(tx, op)
+	op.DirectoryURL = directoryURL
+	op.EabKid = eabKid
+	if len(eabKid) == 0 {
+		op.EabHmacKey = ""
+	} else if len(eabHmacKey) > 0 {
+		op.EabHmacKey = eabHmacKey
+	}
+	if user.DirectoryURL != directoryURL || user.EabKid != eabKid {
+		op.Registration = ""
+	}
+	err = this.Save(tx, op)
 	return err
 }
 
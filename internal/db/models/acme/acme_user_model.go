@@ -1,6 +1,5 @@
 package acme
 
-//
 type ACMEUser struct {
 	Id           uint64 `field:"id"`           // ID
 	AdminId      uint32 `field:"adminId"`      // 管理员ID
@@ -11,6 +10,9 @@ type ACMEUser struct {
 	State        uint8  `field:"state"`        // 状态
 	Description  string `field:"description"`  // 备注介绍
 	Registration string `field:"registration"` // 注册信息
+	DirectoryURL string `field:"directoryURL"` // ACME服务目录地址
+	EabKid       string `field:"eabKid"`       // EAB Key ID
+	EabHmacKey   string `field:"eabHmacKey"`   // EAB HMAC密钥
 }
 
 type ACMEUserOperator struct {
@@ -23,6 +25,9 @@ type ACMEUserOperator struct {
 	State        interface{} // 状态
 	Description  interface{} // 备注介绍
 	Registration interface{} // 注册信息
+	DirectoryURL interface{} // ACME服务目录地址
+	EabKid       interface{} // EAB Key ID
+	EabHmacKey   interface{} // EAB HMAC密钥
 }
 
 func NewACMEUserOperator() *ACMEUserOperator {
@@ -30,10 +30,11 @@ func init() {
 }
 
 // 生成AccessToken
-func (this *APIAccessTokenDAO) GenerateAccessToken(tx *dbs.Tx, userId int64) (token string, expiresAt int64, err error) {
+// 每个AccessKey只保留一个AccessToken，并复制AccessKey的Scopes
+func (this *APIAccessTokenDAO) GenerateAccessToken(tx *dbs.Tx, accessKey *UserAccessKey) (token string, expiresAt int64, err error) {
 	// 查询以前的
 	accessToken, err := this.Query(tx).
-		Attr("userId", userId).
+		Attr("accessKeyId", accessKey.Id).
 		Find()
 	if err != nil {
 		return "", 0, err
@@ -48,8 +49,11 @@ func (this *APIAccessTokenDAO) GenerateAccessToken(tx *dbs.Tx, userId int64) (to
 		op.Id = accessToken.(*APIAccessToken).Id
 	}
 
-	op.UserId = userId
+	op.AdminId = accessKey.AdminId
+	op.UserId = accessKey.UserId
+	op.AccessKeyId = accessKey.Id
 	op.Token = token
+	op.Scopes = JSONBytes([]byte(accessKey.Scopes))
 	op.CreatedAt = time.Now().Unix()
 	op.ExpiredAt = expiresAt
 	err = this.Save(tx, op)
@@ -66,3 +70,12 @@ func (this *APIAccessTokenDAO) FindAccessToken(tx *dbs.Tx, token string) (*APIAc
 	}
 	return one.(*APIAccessToken), nil
 }
+
+// 删除某个AccessKey的所有AccessToken
+// AccessKey被禁用或修改Scopes后需要调用，以便让已经发放的AccessToken立即失效
+func (this *APIAccessTokenDAO) DeleteAccessTokensWithAccessKeyId(tx *dbs.Tx, accessKeyId int64) error {
+	_, err := this.Query(tx).
+		Attr("accessKeyId", accessKeyId).
+		Delete()
+	return err
+}
@@ -2,19 +2,25 @@ package models
 
 // API访问令牌
 type APIAccessToken struct {
-	Id        uint64 `field:"id"`        // ID
-	UserId    uint32 `field:"userId"`    // 用户ID
-	Token     string `field:"token"`     // 令牌
-	CreatedAt uint64 `field:"createdAt"` // 创建时间
-	ExpiredAt uint64 `field:"expiredAt"` // 过期时间
+	Id          uint64 `field:"id"`          // ID
+	AdminId     uint32 `field:"adminId"`     // 管理员ID
+	UserId      uint32 `field:"userId"`      // 用户ID
+	AccessKeyId uint32 `field:"accessKeyId"` // AccessKey ID
+	Token       string `field:"token"`       // 令牌
+	Scopes      string `field:"scopes"`      // 允许访问的服务和方法，为空表示不限制
+	CreatedAt   uint64 `field:"createdAt"`   // 创建时间
+	ExpiredAt   uint64 `field:"expiredAt"`   // 过期时间
 }
 
 type APIAccessTokenOperator struct {
-	Id        interface{} // ID
-	UserId    interface{} // 用户ID
-	Token     interface{} // 令牌
-	CreatedAt interface{} // 创建时间
-	ExpiredAt interface{} // 过期时间
+	Id          interface{} // ID
+	AdminId     interface{} // 管理员ID
+	UserId      interface{} // 用户ID
+	AccessKeyId interface{} // AccessKey ID
+	Token       interface{} // 令牌
+	Scopes      interface{} // 允许访问的服务和方法，为空表示不限制
+	CreatedAt   interface{} // 创建时间
+	ExpiredAt   interface{} // 过期时间
 }
 
 func NewAPIAccessTokenOperator() *APIAccessTokenOperator {
@@ -1 +1,49 @@
 package models
+
+import (
+	"encoding/json"
+	"path"
+	"strings"
+)
+
+// 解析允许访问的服务和方法
+func (this *APIAccessToken) DecodeScopes() []string {
+	result := []string{}
+	if IsNotNull(this.Scopes) {
+		_ = json.Unmarshal([]byte(this.Scopes), &result)
+	}
+	return result
+}
+
+// 是否限制了可以访问的服务和方法
+func (this *APIAccessToken) HasScopes() bool {
+	return len(this.DecodeScopes()) > 0
+}
+
+// 判断是否允许访问某个服务的方法，没有设置任何Scope时表示不限制
+// Scope格式：
+//   - ServerService 允许访问服务的所有方法
+//   - ServerService.FindEnabledServer 只允许访问某个方法
+//   - ServerService.Find* 支持使用通配符
+//   - * 允许访问所有服务
+func (this *APIAccessToken) AllowMethod(serviceName string, methodName string) bool {
+	scopes := this.DecodeScopes()
+	if len(scopes) == 0 {
+		return true
+	}
+	fullName := serviceName + "." + methodName
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if len(scope) == 0 {
+			continue
+		}
+		if !strings.Contains(scope, ".") {
+			scope += ".*"
+		}
+		ok, _ := path.Match(scope, fullName)
+		if ok {
+			return true
+		}
+	}
+	return false
+}
@@ -202,6 +202,15 @@ func (this *APINodeDAO) FindAllEnabledAndOnAPINodes(tx *dbs.Tx) (result []*APINo
 	return
 }
 
+// 计算可用而且启用的API节点数量
+func (this *APINodeDAO) CountAllEnabledAndOnAPINodes(tx *dbs.Tx) (int64, error) {
+	return this.Query(tx).
+		Attr("clusterId", 0). // 非集群专用
+		Attr("isOn", true).
+		State(APINodeStateEnabled).
+		Count()
+}
+
 // 计算API节点数量
 func (this *APINodeDAO) CountAllEnabledAPINodes(tx *dbs.Tx) (int64, error) {
 	return this.Query(tx).
@@ -92,7 +92,7 @@ func (this *DBNodeDAO) ListEnabledNodes(tx *dbs.Tx, offset int64, size int64) (r
 }
 
 // 创建节点
-func (this *DBNodeDAO) CreateDBNode(tx *dbs.Tx, isOn bool, name string, description string, host string, port int32, database string, username string, password string, charset string) (int64, error) {
+func (this *DBNodeDAO) CreateDBNode(tx *dbs.Tx, isOn bool, name string, description string, host string, port int32, database string, username string, password string, charset string, weight int32) (int64, error) {
 	op := NewDBNodeOperator()
 	op.State = NodeStateEnabled
 	op.IsOn = isOn
@@ -104,6 +104,7 @@ func (this *DBNodeDAO) CreateDBNode(tx *dbs.Tx, isOn bool, name string, descript
 	op.Username = username
 	op.Password = password
 	op.Charset = charset
+	op.Weight = weight
 	err := this.Save(tx, op)
 	if err != nil {
 		return 0, err
@@ -112,7 +113,7 @@ func (this *DBNodeDAO) CreateDBNode(tx *dbs.Tx, isOn bool, name string, descript
 }
 
 // 修改节点
-func (this *DBNodeDAO) UpdateNode(tx *dbs.Tx, nodeId int64, isOn bool, name string, description string, host string, port int32, database string, username string, password string, charset string) error {
+func (this *DBNodeDAO) UpdateNode(tx *dbs.Tx, nodeId int64, isOn bool, name string, description string, host string, port int32, database string, username string, password string, charset string, weight int32) error {
 	if nodeId <= 0 {
 		return errors.New("invalid nodeId")
 	}
@@ -127,6 +128,7 @@ func (this *DBNodeDAO) UpdateNode(tx *dbs.Tx, nodeId int64, isOn bool, name stri
 	op.Username = username
 	op.Password = password
 	op.Charset = charset
+	op.Weight = weight
 	err := this.Save(tx, op)
 	return err
 }
@@ -3,11 +3,13 @@ package models
 import (
 	"fmt"
 	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"github.com/TeaOSLab/EdgeAPI/internal/utils/hashring"
 	"github.com/iwind/TeaGo/dbs"
 	"github.com/iwind/TeaGo/lists"
 	"github.com/iwind/TeaGo/logs"
 	timeutil "github.com/iwind/TeaGo/utils/time"
 	"hash/crc32"
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
@@ -137,6 +139,22 @@ func (this *DBNodeInitializer) Start() {
 		logs.Println("[DB_NODE]" + err.Error())
 	}
 
+	// 重新写入暂存的访问日志和记录失败的分片位置
+	go func() {
+		flushTicker := time.NewTicker(10 * time.Second)
+		for range flushTicker.C {
+			err := flushAccessLogBuffer()
+			if err != nil {
+				logs.Println("[DB_NODE]flush access logs failed: " + err.Error())
+			}
+
+			err = retryAccessLogShards()
+			if err != nil {
+				logs.Println("[DB_NODE]retry updating access log shards failed: " + err.Error())
+			}
+		}
+	}()
+
 	// 定时运行
 	ticker := time.NewTicker(60 * time.Second)
 	for range ticker.C {
@@ -159,6 +177,13 @@ func (this *DBNodeInitializer) loop() error {
 		nodeIds = append(nodeIds, int64(node.Id))
 	}
 
+	// 重新构建分片Hash环，节点变化时生成重新平衡计划
+	ring := resetAccessLogRing(dbNodes)
+	err = this.checkRebalance(ring)
+	if err != nil {
+		logs.Println("[DB_NODE]check access log rebalance failed: " + err.Error())
+	}
+
 	// 关掉老的
 	accessLogLocker.Lock()
 	closingDbs := []*dbs.DB{}
@@ -257,3 +282,48 @@ func (this *DBNodeInitializer) loop() error {
 
 	return nil
 }
+
+// 检查数据库节点是否有变化，如果有变化则生成重新平衡计划
+// 计划中列出主节点发生变化的服务，这些服务新的访问日志会写入新的节点，已有的日志仍然保留在原节点上并可以查询
+func (this *DBNodeInitializer) checkRebalance(ring *hashring.Ring) error {
+	lockerKey := "http_access_log_rebalance"
+	isOk, err := SharedSysLockerDAO.Lock(nil, lockerKey, 60)
+	if err != nil {
+		return err
+	}
+	if !isOk {
+		return nil
+	}
+	defer func() {
+		_ = SharedSysLockerDAO.Unlock(nil, lockerKey)
+	}()
+
+	newNodes := ring.Weights()
+	oldNodes := map[int64]int{}
+	latestPlan, err := SharedHTTPAccessLogRebalancePlanDAO.FindLatestPlan(nil)
+	if err != nil {
+		return err
+	}
+	if latestPlan != nil {
+		oldNodes = latestPlan.DecodeNewNodes()
+	}
+	if reflect.DeepEqual(oldNodes, newNodes) {
+		return nil
+	}
+
+	oldRing := hashring.NewRing()
+	for nodeId, weight := range oldNodes {
+		oldRing.Add(nodeId, weight)
+	}
+	serverIds, err := SharedServerDAO.FindAllEnabledServerIds(nil)
+	if err != nil {
+		return err
+	}
+	moves := computeAccessLogShardMoves(oldRing, ring, serverIds)
+	err = SharedHTTPAccessLogRebalancePlanDAO.CreatePlan(nil, oldNodes, newNodes, moves)
+	if err != nil {
+		return err
+	}
+	logs.Println("[DB_NODE]db nodes changed, " + strconv.Itoa(len(moves)) + " servers will write access logs to new db nodes")
+	return nil
+}
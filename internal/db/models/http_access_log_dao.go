@@ -42,24 +42,68 @@ func NewHTTPAccessLogDAO() *HTTPAccessLogDAO {
 }
 
 // 创建访问日志
+// 按照服务ID分片写入数据库节点，参考 http_access_log_sharding.go
 func (this *HTTPAccessLogDAO) CreateHTTPAccessLogs(tx *dbs.Tx, accessLogs []*pb.HTTPAccessLog) error {
-	dao := randomAccessLogDAO()
-	if dao == nil {
-		dao = &HTTPAccessLogDAOWrapper{
+	if len(accessLogs) == 0 {
+		return nil
+	}
+
+	// 没有数据库节点时写入当前数据库
+	if !hasAccessLogDBNodes() {
+		return this.CreateHTTPAccessLogsWithDAO(tx, &HTTPAccessLogDAOWrapper{
 			DAO:    SharedHTTPAccessLogDAO,
 			NodeId: 0,
+		}, accessLogs)
+	}
+
+	serverIds := []int64{}
+	serverLogsMap := map[int64][]*pb.HTTPAccessLog{} // serverId => access logs
+	for _, accessLog := range accessLogs {
+		_, ok := serverLogsMap[accessLog.ServerId]
+		if !ok {
+			serverIds = append(serverIds, accessLog.ServerId)
+		}
+		serverLogsMap[accessLog.ServerId] = append(serverLogsMap[accessLog.ServerId], accessLog)
+	}
+
+	failedLogs := []*pb.HTTPAccessLog{}
+	for _, serverId := range serverIds {
+		leftLogs := this.createShardedAccessLogs(tx, serverId, serverLogsMap[serverId])
+		failedLogs = append(failedLogs, leftLogs...)
+	}
+	if len(failedLogs) > 0 {
+		return pushAccessLogBuffer(failedLogs)
+	}
+	return nil
+}
+
+// 写入某个服务的访问日志，主节点失败时依次尝试Hash环上的其他节点，返回所有节点都写入失败的日志
+func (this *HTTPAccessLogDAO) createShardedAccessLogs(tx *dbs.Tx, serverId int64, accessLogs []*pb.HTTPAccessLog) (failedLogs []*pb.HTTPAccessLog) {
+	for _, daoWrapper := range findAccessLogShardDAOs(serverId) {
+		count, err := this.createHTTPAccessLogsWithDAO(tx, daoWrapper, accessLogs)
+		if err == nil {
+			return nil
 		}
+		logs.Println("[DB_NODE]write access logs to db node '" + strconv.FormatInt(daoWrapper.NodeId, 10) + "' failed: " + err.Error())
+		markAccessLogDBNodeDown(daoWrapper.NodeId)
+		accessLogs = accessLogs[count:]
 	}
-	return this.CreateHTTPAccessLogsWithDAO(tx, dao, accessLogs)
+	return accessLogs
 }
 
 // 使用特定的DAO创建访问日志
 func (this *HTTPAccessLogDAO) CreateHTTPAccessLogsWithDAO(tx *dbs.Tx, daoWrapper *HTTPAccessLogDAOWrapper, accessLogs []*pb.HTTPAccessLog) error {
+	_, err := this.createHTTPAccessLogsWithDAO(tx, daoWrapper, accessLogs)
+	return err
+}
+
+// 使用特定的DAO创建访问日志，返回成功写入的日志数量
+func (this *HTTPAccessLogDAO) createHTTPAccessLogsWithDAO(tx *dbs.Tx, daoWrapper *HTTPAccessLogDAOWrapper, accessLogs []*pb.HTTPAccessLog) (count int, err error) {
 	if daoWrapper == nil {
-		return errors.New("dao should not be nil")
+		return 0, errors.New("dao should not be nil")
 	}
 	if len(accessLogs) == 0 {
-		return nil
+		return 0, nil
 	}
 
 	dao := daoWrapper.DAO
@@ -70,7 +114,7 @@ func (this *HTTPAccessLogDAO) CreateHTTPAccessLogsWithDAO(tx *dbs.Tx, daoWrapper
 		day := timeutil.Format("Ymd", time.Unix(accessLog.Timestamp, 0))
 		table, err := findAccessLogTable(dao.Instance, day, false)
 		if err != nil {
-			return err
+			return count, err
 		}
 
 		fields := map[string]interface{}{}
@@ -86,7 +130,7 @@ func (this *HTTPAccessLogDAO) CreateHTTPAccessLogsWithDAO(tx *dbs.Tx, daoWrapper
 
 		content, err := json.Marshal(accessLog)
 		if err != nil {
-			return err
+			return count, err
 		}
 		fields["content"] = content
 
@@ -96,23 +140,31 @@ func (this *HTTPAccessLogDAO) CreateHTTPAccessLogsWithDAO(tx *dbs.Tx, daoWrapper
 			Insert()
 		if err != nil {
 			// 是否为 Error 1146: Table 'xxx.xxx' doesn't exist  如果是，则创建表之后重试
-			if strings.Contains(err.Error(), "1146") {
-				table, err = findAccessLogTable(dao.Instance, day, true)
-				if err != nil {
-					return err
-				}
-				_, err = dao.Query(tx).
-					Table(table).
-					Sets(fields).
-					Insert()
-				if err != nil {
-					return err
-				}
+			if !strings.Contains(err.Error(), "1146") {
+				return count, err
+			}
+			table, err = findAccessLogTable(dao.Instance, day, true)
+			if err != nil {
+				return count, err
+			}
+			_, err = dao.Query(tx).
+				Table(table).
+				Sets(fields).
+				Insert()
+			if err != nil {
+				return count, err
 			}
 		}
+		count++
+
+		// 记录分片位置，失败时会稍后重试，在此之前查询时会查询所有节点
+		err = updateAccessLogShard(accessLog.ServerId, day, daoWrapper.NodeId)
+		if err != nil {
+			logs.Println("[DB_NODE]update access log shard failed, will retry later: " + err.Error())
+		}
 	}
 
-	return nil
+	return count, nil
 }
 
 // 读取往前的 单页访问日志
@@ -153,18 +205,10 @@ func (this *HTTPAccessLogDAO) listAccessLogs(tx *dbs.Tx, lastRequestId string, s
 		}
 	}
 
-	accessLogLocker.RLock()
-	daoList := []*HTTPAccessLogDAOWrapper{}
-	for _, daoWrapper := range accessLogDAOMapping {
-		daoList = append(daoList, daoWrapper)
-	}
-	accessLogLocker.RUnlock()
-
-	if len(daoList) == 0 {
-		daoList = []*HTTPAccessLogDAOWrapper{{
-			DAO:    SharedHTTPAccessLogDAO,
-			NodeId: 0,
-		}}
+	// 指定服务时只查询服务的日志所在的数据库节点
+	daoList, err := findAccessLogQueryDAOs(serverId, day)
+	if err != nil {
+		return
 	}
 
 	locker := sync.Mutex{}
@@ -284,19 +328,7 @@ func (this *HTTPAccessLogDAO) FindAccessLogWithRequestId(tx *dbs.Tx, requestId s
 		return nil, errors.New("invalid requestId")
 	}
 
-	accessLogLocker.RLock()
-	daoList := []*HTTPAccessLogDAOWrapper{}
-	for _, daoWrapper := range accessLogDAOMapping {
-		daoList = append(daoList, daoWrapper)
-	}
-	accessLogLocker.RUnlock()
-
-	if len(daoList) == 0 {
-		daoList = []*HTTPAccessLogDAOWrapper{{
-			DAO:    SharedHTTPAccessLogDAO,
-			NodeId: 0,
-		}}
-	}
+	daoList := findAllAccessLogDAOs()
 
 	count := len(daoList)
 	wg := &sync.WaitGroup{}
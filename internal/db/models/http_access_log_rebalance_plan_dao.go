@@ -0,0 +1,81 @@
+package models
+
+import (
+	"encoding/json"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/iwind/TeaGo/Tea"
+	"github.com/iwind/TeaGo/dbs"
+	"time"
+)
+
+type HTTPAccessLogRebalancePlanDAO dbs.DAO
+
+func NewHTTPAccessLogRebalancePlanDAO() *HTTPAccessLogRebalancePlanDAO {
+	return dbs.NewDAO(&HTTPAccessLogRebalancePlanDAO{
+		DAOObject: dbs.DAOObject{
+			DB:     Tea.Env,
+			Table:  "edgeHTTPAccessLogRebalancePlans",
+			Model:  new(HTTPAccessLogRebalancePlan),
+			PkName: "id",
+		},
+	}).(*HTTPAccessLogRebalancePlanDAO)
+}
+
+var SharedHTTPAccessLogRebalancePlanDAO *HTTPAccessLogRebalancePlanDAO
+
+func init() {
+	dbs.OnReady(func() {
+		SharedHTTPAccessLogRebalancePlanDAO = NewHTTPAccessLogRebalancePlanDAO()
+	})
+}
+
+// 创建计划
+func (this *HTTPAccessLogRebalancePlanDAO) CreatePlan(tx *dbs.Tx, oldNodes map[int64]int, newNodes map[int64]int, moves []*HTTPAccessLogShardMove) error {
+	oldNodesJSON, err := json.Marshal(oldNodes)
+	if err != nil {
+		return err
+	}
+	newNodesJSON, err := json.Marshal(newNodes)
+	if err != nil {
+		return err
+	}
+	if moves == nil {
+		moves = []*HTTPAccessLogShardMove{}
+	}
+	movesJSON, err := json.Marshal(moves)
+	if err != nil {
+		return err
+	}
+
+	op := NewHTTPAccessLogRebalancePlanOperator()
+	op.OldNodes = oldNodesJSON
+	op.NewNodes = newNodesJSON
+	op.Moves = movesJSON
+	op.CountMoves = len(moves)
+	op.CreatedAt = time.Now().Unix()
+	return this.Save(tx, op)
+}
+
+// 查找最新的计划
+func (this *HTTPAccessLogRebalancePlanDAO) FindLatestPlan(tx *dbs.Tx) (*HTTPAccessLogRebalancePlan, error) {
+	one, err := this.Query(tx).
+		DescPk().
+		Find()
+	if err != nil || one == nil {
+		return nil, err
+	}
+	return one.(*HTTPAccessLogRebalancePlan), nil
+}
+
+// 列出最近的计划
+func (this *HTTPAccessLogRebalancePlanDAO) FindLatestPlans(tx *dbs.Tx, size int64) (result []*HTTPAccessLogRebalancePlan, err error) {
+	if size <= 0 {
+		size = 10
+	}
+	_, err = this.Query(tx).
+		DescPk().
+		Limit(size).
+		Slice(&result).
+		FindAll()
+	return
+}
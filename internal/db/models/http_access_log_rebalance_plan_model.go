@@ -0,0 +1,24 @@
+package models
+
+// 访问日志分片重新平衡计划
+type HTTPAccessLogRebalancePlan struct {
+	Id         uint32 `field:"id"`         // ID
+	OldNodes   string `field:"oldNodes"`   // 原数据库节点权重
+	NewNodes   string `field:"newNodes"`   // 新数据库节点权重
+	Moves      string `field:"moves"`      // 迁移的服务
+	CountMoves uint32 `field:"countMoves"` // 迁移的服务数
+	CreatedAt  uint64 `field:"createdAt"`  // 创建时间
+}
+
+type HTTPAccessLogRebalancePlanOperator struct {
+	Id         interface{} // ID
+	OldNodes   interface{} // 原数据库节点权重
+	NewNodes   interface{} // 新数据库节点权重
+	Moves      interface{} // 迁移的服务
+	CountMoves interface{} // 迁移的服务数
+	CreatedAt  interface{} // 创建时间
+}
+
+func NewHTTPAccessLogRebalancePlanOperator() *HTTPAccessLogRebalancePlanOperator {
+	return &HTTPAccessLogRebalancePlanOperator{}
+}
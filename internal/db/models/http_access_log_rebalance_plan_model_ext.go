@@ -0,0 +1,30 @@
+package models
+
+import "encoding/json"
+
+// 重新平衡时迁移的单个服务
+type HTTPAccessLogShardMove struct {
+	ServerId   int64 `json:"serverId"`
+	FromNodeId int64 `json:"fromNodeId"` // 原数据库节点ID
+	ToNodeId   int64 `json:"toNodeId"`   // 新数据库节点ID
+}
+
+// 解析迁移的服务
+func (this *HTTPAccessLogRebalancePlan) DecodeMoves() []*HTTPAccessLogShardMove {
+	result := []*HTTPAccessLogShardMove{}
+	if len(this.Moves) == 0 || this.Moves == "null" {
+		return result
+	}
+	_ = json.Unmarshal([]byte(this.Moves), &result)
+	return result
+}
+
+// 解析新的数据库节点权重
+func (this *HTTPAccessLogRebalancePlan) DecodeNewNodes() map[int64]int {
+	result := map[int64]int{}
+	if len(this.NewNodes) == 0 || this.NewNodes == "null" {
+		return result
+	}
+	_ = json.Unmarshal([]byte(this.NewNodes), &result)
+	return result
+}
@@ -0,0 +1,398 @@
+package models
+
+import (
+	"bytes"
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"github.com/iwind/TeaGo/dbs"
+	"github.com/iwind/TeaGo/lists"
+	"github.com/iwind/TeaGo/logs"
+	timeutil "github.com/iwind/TeaGo/utils/time"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 单次搜索最多可以跨越的天数
+const accessLogSearchMaxDays = 31
+
+// 访问日志搜索条件
+type HTTPAccessLogSearchQuery struct {
+	ServerId int64
+	UserId   int64 // 只搜索某个用户的服务
+
+	StartDay  string // 开始日期 YYYYMMDD
+	EndDay    string // 结束日期 YYYYMMDD，为空时和开始日期相同
+	StartTime int64  // 开始时间戳，可选
+	EndTime   int64  // 结束时间戳，可选
+
+	IP           string                // 客户端IP或者CIDR
+	Host         string                // 域名
+	PathPrefix   string                // URL路径前缀
+	PathRegexp   string                // URL路径正则表达式
+	StatusRanges []*HTTPAccessLogRange // 状态码范围
+	UserAgent    string                // User-Agent包含的字符串
+	Method       string                // 请求方法
+	HasError     bool
+
+	FirewallPolicyId    int64
+	FirewallRuleGroupId int64
+	FirewallRuleSetId   int64
+	HasFirewallPolicy   bool
+
+	LastRequestId string // 上一页最后一条日志的请求ID
+	Size          int64
+	Reverse       bool // 是否从旧到新
+}
+
+// 数值范围
+type HTTPAccessLogRange struct {
+	From int64
+	To   int64
+}
+
+// 解析后的IP范围
+type accessLogIPRange struct {
+	from []byte
+	to   []byte
+}
+
+// 检查搜索条件，返回需要搜索的日期
+// 日期按照搜索顺序排列，从新到旧搜索时从结束日期开始
+func (this *HTTPAccessLogSearchQuery) days() ([]string, error) {
+	dayReg := regexp.MustCompile(`^\d{8}$`)
+	if !dayReg.MatchString(this.StartDay) {
+		return nil, errors.New("invalid start day '" + this.StartDay + "', should be YYYYMMDD")
+	}
+	endDay := this.EndDay
+	if len(endDay) == 0 {
+		endDay = this.StartDay
+	}
+	if !dayReg.MatchString(endDay) {
+		return nil, errors.New("invalid end day '" + endDay + "', should be YYYYMMDD")
+	}
+	if endDay < this.StartDay {
+		return nil, errors.New("end day should not be earlier than start day")
+	}
+
+	startTime, err := time.ParseInLocation("20060102", this.StartDay, time.Local)
+	if err != nil {
+		return nil, errors.New("invalid start day '" + this.StartDay + "'")
+	}
+
+	result := []string{}
+	for t := startTime; ; t = t.AddDate(0, 0, 1) {
+		day := timeutil.Format("Ymd", t)
+		if day > endDay {
+			break
+		}
+		if len(result) >= accessLogSearchMaxDays {
+			return nil, errors.New("can not search more than " + strconv.Itoa(accessLogSearchMaxDays) + " days at one time")
+		}
+		result = append(result, day)
+	}
+
+	if !this.Reverse {
+		lists.Reverse(result)
+	}
+	return result, nil
+}
+
+// 解析IP或者CIDR
+func parseAccessLogIPRange(s string) (*accessLogIPRange, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		_, ipNet, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, errors.New("invalid CIDR '" + s + "'")
+		}
+		from := ipNet.IP
+		if ip4 := from.To4(); ip4 != nil {
+			from = ip4
+		}
+		to := make([]byte, len(from))
+		for i := range from {
+			to[i] = from[i] | ^ipNet.Mask[i]
+		}
+		return &accessLogIPRange{
+			from: from,
+			to:   to,
+		}, nil
+	}
+
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, errors.New("invalid ip '" + s + "'")
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	return &accessLogIPRange{
+		from: ip,
+		to:   ip,
+	}, nil
+}
+
+// 判断IP是否在范围内
+func (this *accessLogIPRange) Contains(ip net.IP) bool {
+	if len(this.from) == net.IPv4len {
+		ip = ip.To4()
+	} else {
+		ip = ip.To16()
+	}
+	if len(ip) != len(this.from) {
+		return false
+	}
+	return bytes.Compare(ip, this.from) >= 0 && bytes.Compare(ip, this.to) <= 0
+}
+
+// 转义LIKE中的特殊字符
+func escapeAccessLogLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// 搜索访问日志
+// 日志按照请求ID排序，请求ID以时间戳开头并且唯一，所以可以跨越数据库节点和日期稳定地分页
+func (this *HTTPAccessLogDAO) SearchAccessLogs(tx *dbs.Tx, query *HTTPAccessLogSearchQuery) (result []*HTTPAccessLog, nextLastRequestId string, hasMore bool, err error) {
+	if query == nil {
+		return nil, "", false, errors.New("query should not be nil")
+	}
+	days, err := query.days()
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	// 限制能查询的最大条数，防止占用内存过多
+	size := query.Size
+	if size <= 0 {
+		size = 20
+	}
+	if size > 1000 {
+		size = 1000
+	}
+
+	var ipRange *accessLogIPRange
+	if len(query.IP) > 0 {
+		ipRange, err = parseAccessLogIPRange(query.IP)
+		if err != nil {
+			return nil, "", false, err
+		}
+	}
+	if len(query.PathRegexp) > 0 {
+		_, err = regexp.Compile(query.PathRegexp)
+		if err != nil {
+			return nil, "", false, errors.New("invalid path regexp: " + err.Error())
+		}
+	}
+
+	serverIds := []int64{}
+	if query.ServerId <= 0 && query.UserId > 0 {
+		serverIds, err = SharedServerDAO.FindAllEnabledServerIdsWithUserId(tx, query.UserId)
+		if err != nil {
+			return nil, "", false, err
+		}
+		if len(serverIds) == 0 {
+			return nil, "", false, nil
+		}
+	}
+
+	// 逐天查询，直到凑够一页，多查询一条用来判断是否还有更多
+	lastRequestId := query.LastRequestId
+	for _, day := range days {
+		dayResult, err := this.searchAccessLogsInDay(tx, query, day, lastRequestId, size+1-int64(len(result)), serverIds, ipRange)
+		if err != nil {
+			return nil, "", false, err
+		}
+		result = append(result, dayResult...)
+		if len(result) > 0 {
+			lastRequestId = result[len(result)-1].RequestId
+		}
+		if int64(len(result)) > size {
+			break
+		}
+	}
+
+	if int64(len(result)) > size {
+		hasMore = true
+		result = result[:size]
+	}
+	if len(result) == 0 {
+		return nil, query.LastRequestId, false, nil
+	}
+	nextLastRequestId = result[len(result)-1].RequestId
+
+	if query.Reverse {
+		lists.Reverse(result)
+	}
+	return result, nextLastRequestId, hasMore, nil
+}
+
+// 在某一天的日志中搜索
+func (this *HTTPAccessLogDAO) searchAccessLogsInDay(tx *dbs.Tx, query *HTTPAccessLogSearchQuery, day string, lastRequestId string, size int64, serverIds []int64, ipRange *accessLogIPRange) (result []*HTTPAccessLog, err error) {
+	if size <= 0 {
+		return nil, nil
+	}
+
+	daoList, err := findAccessLogQueryDAOs(query.ServerId, day)
+	if err != nil {
+		return nil, err
+	}
+
+	locker := sync.Mutex{}
+	wg := &sync.WaitGroup{}
+	wg.Add(len(daoList))
+	var lastErr error
+	for _, daoWrapper := range daoList {
+		go func(daoWrapper *HTTPAccessLogDAOWrapper) {
+			defer wg.Done()
+
+			dao := daoWrapper.DAO
+
+			tableName, exists, err := findAccessLogTableName(dao.Instance, day)
+			if err != nil {
+				logs.Println("[DB_NODE]" + err.Error())
+				locker.Lock()
+				lastErr = errors.New("query db node '" + strconv.FormatInt(daoWrapper.NodeId, 10) + "' failed: " + err.Error())
+				locker.Unlock()
+				return
+			}
+			if !exists {
+				return
+			}
+
+			q := dao.Query(tx).
+				Table(tableName).
+				Reuse(false)
+			this.buildSearchQuery(q, query, lastRequestId, serverIds, ipRange)
+			ones, err := q.
+				Limit(size).
+				FindAll()
+			if err != nil {
+				logs.Println("[DB_NODE]" + err.Error())
+				locker.Lock()
+				lastErr = errors.New("query db node '" + strconv.FormatInt(daoWrapper.NodeId, 10) + "' failed: " + err.Error())
+				locker.Unlock()
+				return
+			}
+
+			locker.Lock()
+			for _, one := range ones {
+				result = append(result, one.(*HTTPAccessLog))
+			}
+			locker.Unlock()
+		}(daoWrapper)
+	}
+	wg.Wait()
+
+	// 任一节点出错时都返回错误，否则分页时会跳过出错节点中的日志
+	if lastErr != nil {
+		return nil, lastErr
+	}
+
+	// 合并各个节点的结果
+	sort.Slice(result, func(i, j int) bool {
+		if !query.Reverse {
+			return result[i].RequestId > result[j].RequestId
+		}
+		return result[i].RequestId < result[j].RequestId
+	})
+	if int64(len(result)) > size {
+		result = result[:size]
+	}
+	return result, nil
+}
+
+// 构造查询条件
+func (this *HTTPAccessLogDAO) buildSearchQuery(q *dbs.Query, query *HTTPAccessLogSearchQuery, lastRequestId string, serverIds []int64, ipRange *accessLogIPRange) {
+	if query.ServerId > 0 {
+		q.Attr("serverId", query.ServerId)
+	} else if len(serverIds) > 0 {
+		q.Attr("serverId", serverIds)
+	}
+
+	// 时间
+	if query.StartTime > 0 {
+		q.Gte("createdAt", query.StartTime)
+	}
+	if query.EndTime > 0 {
+		q.Lte("createdAt", query.EndTime)
+	}
+
+	// 状态码
+	if query.HasError {
+		q.Where("status>=400")
+	}
+	if len(query.StatusRanges) > 0 {
+		conds := []string{}
+		for index, statusRange := range query.StatusRanges {
+			from := "statusFrom" + strconv.Itoa(index)
+			to := "statusTo" + strconv.Itoa(index)
+			conds = append(conds, "(status BETWEEN :"+from+" AND :"+to+")")
+			q.Param(from, statusRange.From)
+			q.Param(to, statusRange.To)
+		}
+		q.Where("(" + strings.Join(conds, " OR ") + ")")
+	}
+
+	// WAF
+	if query.FirewallPolicyId > 0 {
+		q.Attr("firewallPolicyId", query.FirewallPolicyId)
+	}
+	if query.FirewallRuleGroupId > 0 {
+		q.Attr("firewallRuleGroupId", query.FirewallRuleGroupId)
+	}
+	if query.FirewallRuleSetId > 0 {
+		q.Attr("firewallRuleSetId", query.FirewallRuleSetId)
+	}
+	if query.HasFirewallPolicy {
+		q.Where("firewallPolicyId>0")
+	}
+
+	// 日志内容
+	if ipRange != nil {
+		q.Where("LENGTH(INET6_ATON(JSON_UNQUOTE(JSON_EXTRACT(content, '$.remoteAddr'))))=:ipLength").
+			Where("INET6_ATON(JSON_UNQUOTE(JSON_EXTRACT(content, '$.remoteAddr'))) BETWEEN :ipFrom AND :ipTo").
+			Param("ipLength", len(ipRange.from)).
+			Param("ipFrom", []byte(ipRange.from)).
+			Param("ipTo", []byte(ipRange.to))
+	}
+	if len(query.Host) > 0 {
+		q.Where("JSON_UNQUOTE(JSON_EXTRACT(content, '$.host'))=:host").
+			Param("host", query.Host)
+	}
+	if len(query.PathPrefix) > 0 {
+		q.Where("JSON_UNQUOTE(JSON_EXTRACT(content, '$.requestPath')) LIKE :pathPrefix").
+			Param("pathPrefix", escapeAccessLogLike(query.PathPrefix)+"%")
+	}
+	if len(query.PathRegexp) > 0 {
+		q.Where("JSON_UNQUOTE(JSON_EXTRACT(content, '$.requestPath')) REGEXP :pathRegexp").
+			Param("pathRegexp", query.PathRegexp)
+	}
+	if len(query.UserAgent) > 0 {
+		q.Where("JSON_UNQUOTE(JSON_EXTRACT(content, '$.userAgent')) LIKE :userAgent").
+			Param("userAgent", "%"+escapeAccessLogLike(query.UserAgent)+"%")
+	}
+	if len(query.Method) > 0 {
+		q.Where("JSON_UNQUOTE(JSON_EXTRACT(content, '$.requestMethod'))=:method").
+			Param("method", strings.ToUpper(query.Method))
+	}
+
+	// 分页
+	if len(lastRequestId) > 0 {
+		if !query.Reverse {
+			q.Where("requestId<:requestId").
+				Param("requestId", lastRequestId)
+		} else {
+			q.Where("requestId>:requestId").
+				Param("requestId", lastRequestId)
+		}
+	}
+	if !query.Reverse {
+		q.Desc("requestId")
+	} else {
+		q.Asc("requestId")
+	}
+}
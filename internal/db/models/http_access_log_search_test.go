@@ -0,0 +1,65 @@
+package models
+
+import (
+	"net"
+	"testing"
+)
+
+func TestParseAccessLogIPRange(t *testing.T) {
+	for _, testCase := range []struct {
+		s        string
+		ip       string
+		contains bool
+	}{
+		{"192.168.1.0/24", "192.168.1.100", true},
+		{"192.168.1.0/24", "192.168.2.1", false},
+		{"192.168.1.10", "192.168.1.10", true},
+		{"192.168.1.10", "192.168.1.11", false},
+		{"2001:db8::/32", "2001:db8::1", true},
+		{"2001:db8::/32", "2001:db9::1", false},
+		{"2001:db8::/32", "192.168.1.1", false},
+	} {
+		ipRange, err := parseAccessLogIPRange(testCase.s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ipRange.Contains(net.ParseIP(testCase.ip)) != testCase.contains {
+			t.Fatal(testCase.s, testCase.ip, "should be", testCase.contains)
+		}
+	}
+
+	_, err := parseAccessLogIPRange("192.168.1.0/33")
+	if err == nil {
+		t.Fatal("invalid CIDR should fail")
+	}
+}
+
+func TestHTTPAccessLogSearchQuery_Days(t *testing.T) {
+	query := &HTTPAccessLogSearchQuery{
+		StartDay: "20210228",
+		EndDay:   "20210302",
+	}
+	days, err := query.days()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(days)
+	if len(days) != 3 || days[0] != "20210302" || days[2] != "20210228" {
+		t.Fatal("unexpected days", days)
+	}
+
+	query.Reverse = true
+	days, err = query.days()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if days[0] != "20210228" {
+		t.Fatal("unexpected days", days)
+	}
+
+	query.EndDay = "20210501"
+	_, err = query.days()
+	if err == nil {
+		t.Fatal("too many days should fail")
+	}
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/iwind/TeaGo/Tea"
+	"github.com/iwind/TeaGo/dbs"
+	"github.com/iwind/TeaGo/maps"
+	"time"
+)
+
+type HTTPAccessLogShardDAO dbs.DAO
+
+func NewHTTPAccessLogShardDAO() *HTTPAccessLogShardDAO {
+	return dbs.NewDAO(&HTTPAccessLogShardDAO{
+		DAOObject: dbs.DAOObject{
+			DB:     Tea.Env,
+			Table:  "edgeHTTPAccessLogShards",
+			Model:  new(HTTPAccessLogShard),
+			PkName: "id",
+		},
+	}).(*HTTPAccessLogShardDAO)
+}
+
+var SharedHTTPAccessLogShardDAO *HTTPAccessLogShardDAO
+
+func init() {
+	dbs.OnReady(func() {
+		SharedHTTPAccessLogShardDAO = NewHTTPAccessLogShardDAO()
+	})
+}
+
+// 记录服务某天的访问日志写入的数据库节点
+func (this *HTTPAccessLogShardDAO) UpdateShard(tx *dbs.Tx, serverId int64, day string, dbNodeId int64) error {
+	_, _, err := this.Query(tx).
+		InsertOrUpdate(maps.Map{
+			"serverId":  serverId,
+			"day":       day,
+			"dbNodeId":  dbNodeId,
+			"createdAt": time.Now().Unix(),
+		}, maps.Map{
+			"dbNodeId": dbNodeId,
+		})
+	return err
+}
+
+// 查找服务某天的访问日志所在的数据库节点
+func (this *HTTPAccessLogShardDAO) FindShardDBNodeIds(tx *dbs.Tx, serverId int64, day string) (result []int64, err error) {
+	ones, err := this.Query(tx).
+		Attr("serverId", serverId).
+		Attr("day", day).
+		Result("dbNodeId").
+		FindAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, one := range ones {
+		result = append(result, int64(one.(*HTTPAccessLogShard).DbNodeId))
+	}
+	return
+}
+
+// 删除某天以前的记录
+func (this *HTTPAccessLogShardDAO) DeleteShardsBeforeDay(tx *dbs.Tx, day string) error {
+	_, err := this.Query(tx).
+		Where("day<:day").
+		Param("day", day).
+		Delete()
+	return err
+}
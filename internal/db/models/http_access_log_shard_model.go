@@ -0,0 +1,22 @@
+package models
+
+// 访问日志分片位置
+type HTTPAccessLogShard struct {
+	Id        uint64 `field:"id"`        // ID
+	ServerId  uint32 `field:"serverId"`  // 服务ID
+	Day       string `field:"day"`       // 日期YYYYMMDD
+	DbNodeId  uint32 `field:"dbNodeId"`  // 数据库节点ID
+	CreatedAt uint64 `field:"createdAt"` // 创建时间
+}
+
+type HTTPAccessLogShardOperator struct {
+	Id        interface{} // ID
+	ServerId  interface{} // 服务ID
+	Day       interface{} // 日期YYYYMMDD
+	DbNodeId  interface{} // 数据库节点ID
+	CreatedAt interface{} // 创建时间
+}
+
+func NewHTTPAccessLogShardOperator() *HTTPAccessLogShardOperator {
+	return &HTTPAccessLogShardOperator{}
+}
@@ -0,0 +1,264 @@
+package models
+
+import (
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"github.com/TeaOSLab/EdgeAPI/internal/utils/hashring"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/iwind/TeaGo/logs"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 访问日志分片
+// 按照服务ID对数据库节点做带权重的一致性Hash，同一个服务的访问日志写入同一个数据库节点；
+// 节点不可用时写入Hash环上的下一个节点，所有节点都不可用时暂存在内存中，稍后重试；
+// 每个服务每天的日志实际写入的节点都会记录在 edgeHTTPAccessLogShards 中，查询时只需要查询这些节点
+
+var accessLogRing = hashring.NewRing()
+var accessLogDownMap = map[int64]int64{} // dbNodeId => 恢复时间
+
+// 数据库节点出错后暂停写入的时间
+const accessLogDBNodeDownSeconds = 30
+
+// 已经记录过的分片位置，serverId@day@dbNodeId => true
+var accessLogShardCacheMap = map[string]bool{}
+var accessLogShardCacheDay = ""
+var accessLogShardLocker = &sync.Mutex{}
+
+// 记录失败、等待重试的分片位置，serverId@day@dbNodeId => 分片
+// 在重试成功之前，查询这些服务当天的日志时需要查询所有节点
+var accessLogPendingShardMap = map[string]*HTTPAccessLogShard{}
+
+// 暂存的访问日志
+const accessLogBufferMaxSize = 100000
+
+var accessLogBuffer = []*pb.HTTPAccessLog{}
+var accessLogBufferLocker = &sync.Mutex{}
+
+// 分片的Key
+func accessLogShardKey(serverId int64) string {
+	return strconv.FormatInt(serverId, 10)
+}
+
+// 是否有可以写入访问日志的数据库节点
+func hasAccessLogDBNodes() bool {
+	accessLogLocker.RLock()
+	defer accessLogLocker.RUnlock()
+	return accessLogRing.Len() > 0
+}
+
+// 查找服务的访问日志可以写入的数据库节点，第一个为主节点，其余的用于故障转移
+// 暂时不可用的节点会被跳过
+func findAccessLogShardDAOs(serverId int64) []*HTTPAccessLogDAOWrapper {
+	accessLogLocker.RLock()
+	defer accessLogLocker.RUnlock()
+
+	now := time.Now().Unix()
+	result := []*HTTPAccessLogDAOWrapper{}
+	for _, nodeId := range accessLogRing.LookupN(accessLogShardKey(serverId), accessLogRing.Len()) {
+		if accessLogDownMap[nodeId] > now {
+			continue
+		}
+		dao, ok := accessLogDAOMapping[nodeId]
+		if !ok {
+			continue
+		}
+		result = append(result, dao)
+	}
+	return result
+}
+
+// 标记数据库节点暂时不可用
+func markAccessLogDBNodeDown(nodeId int64) {
+	accessLogLocker.Lock()
+	accessLogDownMap[nodeId] = time.Now().Unix() + accessLogDBNodeDownSeconds
+	accessLogLocker.Unlock()
+}
+
+// 查找服务某天的访问日志所在的数据库节点
+// 没有记录时（比如在启用分片之前写入的日志）或者有尚未记录成功的分片时返回所有节点
+func findAccessLogQueryDAOs(serverId int64, day string) ([]*HTTPAccessLogDAOWrapper, error) {
+	allDAOs := findAllAccessLogDAOs()
+	if serverId <= 0 {
+		return allDAOs, nil
+	}
+	if hasPendingAccessLogShards(serverId, day) {
+		return allDAOs, nil
+	}
+
+	nodeIds, err := SharedHTTPAccessLogShardDAO.FindShardDBNodeIds(nil, serverId, day)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodeIds) == 0 {
+		return allDAOs, nil
+	}
+
+	result := []*HTTPAccessLogDAOWrapper{}
+	for _, dao := range allDAOs {
+		for _, nodeId := range nodeIds {
+			if dao.NodeId == nodeId {
+				result = append(result, dao)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// 所有可以查询的数据库节点
+func findAllAccessLogDAOs() []*HTTPAccessLogDAOWrapper {
+	accessLogLocker.RLock()
+	daoList := []*HTTPAccessLogDAOWrapper{}
+	for _, daoWrapper := range accessLogDAOMapping {
+		daoList = append(daoList, daoWrapper)
+	}
+	accessLogLocker.RUnlock()
+
+	if len(daoList) == 0 {
+		daoList = []*HTTPAccessLogDAOWrapper{{
+			DAO:    SharedHTTPAccessLogDAO,
+			NodeId: 0,
+		}}
+	}
+	return daoList
+}
+
+// 记录分片位置
+func updateAccessLogShard(serverId int64, day string, dbNodeId int64) error {
+	cacheKey := strconv.FormatInt(serverId, 10) + "@" + day + "@" + strconv.FormatInt(dbNodeId, 10)
+
+	accessLogShardLocker.Lock()
+	if accessLogShardCacheDay != day {
+		// 日期变化后清空缓存，防止缓存无限增长
+		if day > accessLogShardCacheDay {
+			accessLogShardCacheMap = map[string]bool{}
+			accessLogShardCacheDay = day
+		}
+	}
+	_, ok := accessLogShardCacheMap[cacheKey]
+	accessLogShardLocker.Unlock()
+	if ok {
+		return nil
+	}
+
+	err := SharedHTTPAccessLogShardDAO.UpdateShard(nil, serverId, day, dbNodeId)
+	if err != nil {
+		// 稍后重试
+		accessLogShardLocker.Lock()
+		accessLogPendingShardMap[cacheKey] = &HTTPAccessLogShard{
+			ServerId: uint32(serverId),
+			Day:      day,
+			DbNodeId: uint32(dbNodeId),
+		}
+		accessLogShardLocker.Unlock()
+		return err
+	}
+
+	accessLogShardLocker.Lock()
+	if day == accessLogShardCacheDay {
+		accessLogShardCacheMap[cacheKey] = true
+	}
+	accessLogShardLocker.Unlock()
+	return nil
+}
+
+// 是否有尚未记录成功的分片
+func hasPendingAccessLogShards(serverId int64, day string) bool {
+	accessLogShardLocker.Lock()
+	defer accessLogShardLocker.Unlock()
+	for _, shard := range accessLogPendingShardMap {
+		if int64(shard.ServerId) == serverId && shard.Day == day {
+			return true
+		}
+	}
+	return false
+}
+
+// 重试记录失败的分片位置
+func retryAccessLogShards() error {
+	accessLogShardLocker.Lock()
+	pendingMap := accessLogPendingShardMap
+	accessLogPendingShardMap = map[string]*HTTPAccessLogShard{}
+	accessLogShardLocker.Unlock()
+
+	var lastErr error
+	for cacheKey, shard := range pendingMap {
+		if lastErr != nil {
+			// 数据库仍然不可用，剩余的等下次重试
+			accessLogShardLocker.Lock()
+			accessLogPendingShardMap[cacheKey] = shard
+			accessLogShardLocker.Unlock()
+			continue
+		}
+		lastErr = updateAccessLogShard(int64(shard.ServerId), shard.Day, int64(shard.DbNodeId))
+	}
+	return lastErr
+}
+
+// 暂存写入失败的访问日志
+func pushAccessLogBuffer(accessLogs []*pb.HTTPAccessLog) error {
+	accessLogBufferLocker.Lock()
+	defer accessLogBufferLocker.Unlock()
+
+	if len(accessLogBuffer)+len(accessLogs) > accessLogBufferMaxSize {
+		return errors.New("no available database node to write access logs, and the buffer is full")
+	}
+	accessLogBuffer = append(accessLogBuffer, accessLogs...)
+	return nil
+}
+
+// 重新写入暂存的访问日志
+func flushAccessLogBuffer() error {
+	accessLogBufferLocker.Lock()
+	accessLogs := accessLogBuffer
+	accessLogBuffer = []*pb.HTTPAccessLog{}
+	accessLogBufferLocker.Unlock()
+
+	if len(accessLogs) == 0 {
+		return nil
+	}
+	logs.Println("[DB_NODE]flush " + strconv.Itoa(len(accessLogs)) + " buffered access logs")
+	return SharedHTTPAccessLogDAO.CreateHTTPAccessLogs(nil, accessLogs)
+}
+
+// 根据数据库节点重新构建Hash环，返回新的Hash环
+func resetAccessLogRing(dbNodes []*DBNode) *hashring.Ring {
+	ring := hashring.NewRing()
+	for _, node := range dbNodes {
+		ring.Add(int64(node.Id), int(node.Weight))
+	}
+
+	accessLogLocker.Lock()
+	accessLogRing = ring
+	weights := ring.Weights()
+	for nodeId := range accessLogDownMap {
+		if _, ok := weights[nodeId]; !ok {
+			delete(accessLogDownMap, nodeId)
+		}
+	}
+	accessLogLocker.Unlock()
+	return ring
+}
+
+// 计算从一个Hash环切换到另一个Hash环时需要迁移的服务
+func computeAccessLogShardMoves(oldRing *hashring.Ring, newRing *hashring.Ring, serverIds []int64) []*HTTPAccessLogShardMove {
+	moves := []*HTTPAccessLogShardMove{}
+	if oldRing.Len() == 0 || newRing.Len() == 0 {
+		return moves
+	}
+	for _, serverId := range serverIds {
+		key := accessLogShardKey(serverId)
+		fromNodeId := oldRing.Lookup(key)
+		toNodeId := newRing.Lookup(key)
+		if fromNodeId != toNodeId {
+			moves = append(moves, &HTTPAccessLogShardMove{
+				ServerId:   serverId,
+				FromNodeId: fromNodeId,
+				ToNodeId:   toNodeId,
+			})
+		}
+	}
+	return moves
+}
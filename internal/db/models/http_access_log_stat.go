@@ -0,0 +1,299 @@
+package models
+
+import (
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"github.com/iwind/TeaGo/dbs"
+	timeutil "github.com/iwind/TeaGo/utils/time"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type HTTPAccessLogStatDimension = string
+
+// 访问日志统计维度
+const (
+	HTTPAccessLogStatDimensionURL         HTTPAccessLogStatDimension = "url"         // URL（域名+路径）
+	HTTPAccessLogStatDimensionIP          HTTPAccessLogStatDimension = "ip"          // 客户端IP
+	HTTPAccessLogStatDimensionStatus      HTTPAccessLogStatDimension = "status"      // 状态码
+	HTTPAccessLogStatDimensionReferer     HTTPAccessLogStatDimension = "referer"     // 来源
+	HTTPAccessLogStatDimensionUserAgent   HTTPAccessLogStatDimension = "userAgent"   // User-Agent
+	HTTPAccessLogStatDimensionContentType HTTPAccessLogStatDimension = "contentType" // 内容类型，按流量排序
+)
+
+// 每个维度分组使用的表达式
+var accessLogStatKeyExprMap = map[HTTPAccessLogStatDimension]string{
+	HTTPAccessLogStatDimensionURL:         "CONCAT(IFNULL(JSON_UNQUOTE(JSON_EXTRACT(content, '$.host')), ''), IFNULL(JSON_UNQUOTE(JSON_EXTRACT(content, '$.requestPath')), ''))",
+	HTTPAccessLogStatDimensionIP:          "JSON_UNQUOTE(JSON_EXTRACT(content, '$.remoteAddr'))",
+	HTTPAccessLogStatDimensionStatus:      "status",
+	HTTPAccessLogStatDimensionReferer:     "JSON_UNQUOTE(JSON_EXTRACT(content, '$.referer'))",
+	HTTPAccessLogStatDimensionUserAgent:   "JSON_UNQUOTE(JSON_EXTRACT(content, '$.userAgent'))",
+	HTTPAccessLogStatDimensionContentType: "TRIM(SUBSTRING_INDEX(JSON_UNQUOTE(JSON_EXTRACT(content, '$.contentType')), ';', 1))",
+}
+
+// 单次统计最多可以跨越的天数
+const accessLogStatMaxDays = 31
+
+// 统计结果缓存时间
+const accessLogStatCacheSeconds = 60
+
+// 访问日志统计条件
+type HTTPAccessLogStatQuery struct {
+	ServerId  int64
+	UserId    int64
+	ClusterId int64
+	StartTime int64
+	EndTime   int64
+	Size      int64
+}
+
+// 访问日志统计项
+type HTTPAccessLogStatItem struct {
+	Key   string
+	Count int64 // 请求数
+	Bytes int64 // 流量
+}
+
+type accessLogStatCache struct {
+	items     []*HTTPAccessLogStatItem
+	expiresAt int64
+}
+
+var accessLogStatCacheMap = map[string]*accessLogStatCache{}
+var accessLogStatCacheLocker = &sync.Mutex{}
+
+// 限制同时执行的统计查询数量，所有统计请求共用
+var accessLogStatSem = make(chan bool, 8)
+
+// 统计访问日志
+// 每个数据库节点在一次查询中统计所有日期的日志，然后在所有节点上并行统计后合并结果；
+// 因为每个节点只返回自己的前若干项，所以节点较多时排名靠后的统计项可能略有误差
+func (this *HTTPAccessLogDAO) StatAccessLogs(tx *dbs.Tx, query *HTTPAccessLogStatQuery, dimension HTTPAccessLogStatDimension) ([]*HTTPAccessLogStatItem, error) {
+	keyExpr, ok := accessLogStatKeyExprMap[dimension]
+	if !ok {
+		return nil, errors.New("invalid dimension '" + dimension + "'")
+	}
+	if query.StartTime <= 0 || query.EndTime < query.StartTime {
+		return nil, errors.New("invalid time range")
+	}
+	if query.EndTime-query.StartTime > accessLogStatMaxDays*86400 {
+		return nil, errors.New("can not stat more than " + strconv.Itoa(accessLogStatMaxDays) + " days at one time")
+	}
+
+	size := query.Size
+	if size <= 0 {
+		size = 10
+	}
+	if size > 100 {
+		size = 100
+	}
+
+	// 检查缓存
+	cacheKey := dimension + "@" + strings.Join([]string{
+		strconv.FormatInt(query.ServerId, 10),
+		strconv.FormatInt(query.UserId, 10),
+		strconv.FormatInt(query.ClusterId, 10),
+		strconv.FormatInt(query.StartTime, 10),
+		strconv.FormatInt(query.EndTime, 10),
+		strconv.FormatInt(size, 10),
+	}, "@")
+	now := time.Now().Unix()
+	accessLogStatCacheLocker.Lock()
+	cache, ok := accessLogStatCacheMap[cacheKey]
+	accessLogStatCacheLocker.Unlock()
+	if ok && cache.expiresAt > now {
+		return cache.items, nil
+	}
+
+	// 需要统计的服务
+	var serverIds []int64
+	var err error
+	if query.ServerId > 0 {
+		serverIds = []int64{query.ServerId}
+	} else if query.UserId > 0 {
+		serverIds, err = SharedServerDAO.FindAllEnabledServerIdsWithUserId(tx, query.UserId)
+	} else if query.ClusterId > 0 {
+		serverIds, err = SharedServerDAO.FindAllEnabledServerIdsWithClusterId(tx, query.ClusterId)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if (query.ServerId > 0 || query.UserId > 0 || query.ClusterId > 0) && len(serverIds) == 0 {
+		return []*HTTPAccessLogStatItem{}, nil
+	}
+
+	// 每个节点返回更多的统计项，以减少合并后的误差
+	shardSize := size * 10
+	if shardSize > 1000 {
+		shardSize = 1000
+	}
+
+	orderField := "count"
+	if dimension == HTTPAccessLogStatDimensionContentType {
+		orderField = "bytes"
+	}
+
+	// 每个数据库节点需要统计的日期
+	daoList := []*HTTPAccessLogDAO{}
+	daoDaysMap := map[*HTTPAccessLogDAO][]string{} // dao => [day1, day2, ...]
+	for t := time.Unix(query.StartTime, 0); ; t = t.AddDate(0, 0, 1) {
+		day := timeutil.Format("Ymd", t)
+		if day > timeutil.Format("Ymd", time.Unix(query.EndTime, 0)) {
+			break
+		}
+
+		daoWrappers, err := findAccessLogQueryDAOs(query.ServerId, day)
+		if err != nil {
+			return nil, err
+		}
+		for _, daoWrapper := range daoWrappers {
+			_, ok := daoDaysMap[daoWrapper.DAO]
+			if !ok {
+				daoList = append(daoList, daoWrapper.DAO)
+			}
+			daoDaysMap[daoWrapper.DAO] = append(daoDaysMap[daoWrapper.DAO], day)
+		}
+	}
+
+	wg := &sync.WaitGroup{}
+	locker := &sync.Mutex{}
+	var partials [][]*HTTPAccessLogStatItem
+	var lastErr error
+	for _, dao := range daoList {
+		wg.Add(1)
+		accessLogStatSem <- true
+		go func(dao *HTTPAccessLogDAO, days []string) {
+			defer func() {
+				<-accessLogStatSem
+				wg.Done()
+			}()
+
+			items, err := this.statAccessLogsWithDAO(dao, days, query, serverIds, keyExpr, orderField, shardSize)
+			locker.Lock()
+			if err != nil {
+				lastErr = err
+			} else {
+				partials = append(partials, items)
+			}
+			locker.Unlock()
+		}(dao, daoDaysMap[dao])
+	}
+	wg.Wait()
+	if lastErr != nil {
+		return nil, lastErr
+	}
+
+	items := mergeAccessLogStatItems(partials, orderField == "bytes", size)
+
+	// 保存缓存
+	accessLogStatCacheLocker.Lock()
+	for key, cache := range accessLogStatCacheMap {
+		if cache.expiresAt <= now {
+			delete(accessLogStatCacheMap, key)
+		}
+	}
+	accessLogStatCacheMap[cacheKey] = &accessLogStatCache{
+		items:     items,
+		expiresAt: now + accessLogStatCacheSeconds,
+	}
+	accessLogStatCacheLocker.Unlock()
+
+	return items, nil
+}
+
+// 在单个数据库节点的所有日期的日志中统计
+func (this *HTTPAccessLogDAO) statAccessLogsWithDAO(dao *HTTPAccessLogDAO, days []string, query *HTTPAccessLogStatQuery, serverIds []int64, keyExpr string, orderField string, size int64) ([]*HTTPAccessLogStatItem, error) {
+	tableNames := []string{}
+	for _, day := range days {
+		tableName, exists, err := findAccessLogTableName(dao.Instance, day)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			tableNames = append(tableNames, tableName)
+		}
+	}
+	if len(tableNames) == 0 {
+		return nil, nil
+	}
+
+	sql := buildAccessLogStatSQL(tableNames, serverIds, keyExpr, orderField, size)
+	args := []interface{}{}
+	for range tableNames {
+		args = append(args, query.StartTime, query.EndTime)
+	}
+	ones, _, err := dao.Instance.FindOnes(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*HTTPAccessLogStatItem{}
+	for _, one := range ones {
+		result = append(result, &HTTPAccessLogStatItem{
+			Key:   one.GetString("statKey"),
+			Count: one.GetInt64("count"),
+			Bytes: one.GetInt64("bytes"),
+		})
+	}
+	return result, nil
+}
+
+// 构造统计SQL
+// 使用UNION ALL合并多天的日志表，在同一个查询中分组后再限制数量，每张表需要传入开始和结束时间两个参数
+func buildAccessLogStatSQL(tableNames []string, serverIds []int64, keyExpr string, orderField string, size int64) string {
+	serverCond := ""
+	if len(serverIds) > 0 {
+		serverIdStrings := []string{}
+		for _, serverId := range serverIds {
+			serverIdStrings = append(serverIdStrings, strconv.FormatInt(serverId, 10))
+		}
+		serverCond = " AND serverId IN (" + strings.Join(serverIdStrings, ", ") + ")"
+	}
+
+	subSQLs := []string{}
+	for _, tableName := range tableNames {
+		subSQLs = append(subSQLs, "SELECT "+keyExpr+" AS statKey, IFNULL(JSON_EXTRACT(content, '$.bytesSent'), 0) AS bytesSent FROM `"+tableName+"` WHERE createdAt BETWEEN ? AND ?"+serverCond)
+	}
+	return "SELECT statKey, COUNT(*) AS `count`, SUM(bytesSent) AS `bytes` FROM (" + strings.Join(subSQLs, " UNION ALL ") + ") AS logs GROUP BY statKey ORDER BY `" + orderField + "` DESC LIMIT " + strconv.FormatInt(size, 10)
+}
+
+// 合并多个节点的统计结果
+func mergeAccessLogStatItems(partials [][]*HTTPAccessLogStatItem, orderByBytes bool, size int64) []*HTTPAccessLogStatItem {
+	itemMap := map[string]*HTTPAccessLogStatItem{}
+	for _, items := range partials {
+		for _, item := range items {
+			mergedItem, ok := itemMap[item.Key]
+			if !ok {
+				mergedItem = &HTTPAccessLogStatItem{Key: item.Key}
+				itemMap[item.Key] = mergedItem
+			}
+			mergedItem.Count += item.Count
+			mergedItem.Bytes += item.Bytes
+		}
+	}
+
+	result := []*HTTPAccessLogStatItem{}
+	for _, item := range itemMap {
+		result = append(result, item)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		item1 := result[i]
+		item2 := result[j]
+		if orderByBytes && item1.Bytes != item2.Bytes {
+			return item1.Bytes > item2.Bytes
+		}
+		if item1.Count != item2.Count {
+			return item1.Count > item2.Count
+		}
+		if item1.Bytes != item2.Bytes {
+			return item1.Bytes > item2.Bytes
+		}
+		return item1.Key < item2.Key
+	})
+	if int64(len(result)) > size {
+		result = result[:size]
+	}
+	return result
+}
@@ -0,0 +1,48 @@
+package models
+
+import "testing"
+
+func TestMergeAccessLogStatItems(t *testing.T) {
+	items := mergeAccessLogStatItems([][]*HTTPAccessLogStatItem{
+		{
+			{Key: "/a", Count: 10, Bytes: 100},
+			{Key: "/b", Count: 5, Bytes: 1000},
+		},
+		{
+			{Key: "/b", Count: 6, Bytes: 600},
+			{Key: "/c", Count: 1, Bytes: 10},
+		},
+	}, false, 2)
+	if len(items) != 2 {
+		t.Fatal("should return 2 items")
+	}
+	if items[0].Key != "/b" || items[0].Count != 11 || items[0].Bytes != 1600 {
+		t.Fatal("unexpected first item", items[0])
+	}
+	if items[1].Key != "/a" {
+		t.Fatal("unexpected second item", items[1])
+	}
+
+	// 按流量排序
+	items = mergeAccessLogStatItems([][]*HTTPAccessLogStatItem{
+		{
+			{Key: "text/html", Count: 10, Bytes: 100},
+			{Key: "image/png", Count: 1, Bytes: 1000},
+		},
+	}, true, 10)
+	if items[0].Key != "image/png" {
+		t.Fatal("should be ordered by bytes")
+	}
+}
+
+func TestBuildAccessLogStatSQL(t *testing.T) {
+	sql := buildAccessLogStatSQL([]string{"edgeHTTPAccessLogs_20210101", "edgeHTTPAccessLogs_20210102"}, []int64{1, 2}, "status", "count", 10)
+	t.Log(sql)
+	if sql != "SELECT statKey, COUNT(*) AS `count`, SUM(bytesSent) AS `bytes` FROM ("+
+		"SELECT status AS statKey, IFNULL(JSON_EXTRACT(content, '$.bytesSent'), 0) AS bytesSent FROM `edgeHTTPAccessLogs_20210101` WHERE createdAt BETWEEN ? AND ? AND serverId IN (1, 2)"+
+		" UNION ALL "+
+		"SELECT status AS statKey, IFNULL(JSON_EXTRACT(content, '$.bytesSent'), 0) AS bytesSent FROM `edgeHTTPAccessLogs_20210102` WHERE createdAt BETWEEN ? AND ? AND serverId IN (1, 2)"+
+		") AS logs GROUP BY statKey ORDER BY `count` DESC LIMIT 10" {
+		t.Fatal("unexpected sql")
+	}
+}
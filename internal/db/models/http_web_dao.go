@@ -343,7 +343,7 @@ func (this *HTTPWebDAO) CreateWeb(tx *dbs.Tx, adminId int64, userId int64, rootJ
 }
 
 // 修改Web配置
-func (this *HTTPWebDAO) UpdateWeb(tx *dbs.Tx, webId int64, rootJSON []byte) error {
+func (this *HTTPWebDAO) UpdateWeb(tx *dbs.Tx, webId int64, rootJSON []byte, author *ServerConfigRevisionAuthor) error {
 	if webId <= 0 {
 		return errors.New("invalid webId")
 	}
@@ -355,11 +355,11 @@ func (this *HTTPWebDAO) UpdateWeb(tx *dbs.Tx, webId int64, rootJSON []byte) erro
 		return err
 	}
 
-	return this.NotifyUpdate(tx, webId)
+	return this.NotifyUpdateWithAuthor(tx, webId, author)
 }
 
 // 修改Gzip配置
-func (this *HTTPWebDAO) UpdateWebGzip(tx *dbs.Tx, webId int64, gzipJSON []byte) error {
+func (this *HTTPWebDAO) UpdateWebGzip(tx *dbs.Tx, webId int64, gzipJSON []byte, author *ServerConfigRevisionAuthor) error {
 	if webId <= 0 {
 		return errors.New("invalid webId")
 	}
@@ -371,11 +371,11 @@ func (this *HTTPWebDAO) UpdateWebGzip(tx *dbs.Tx, webId int64, gzipJSON []byte)
 		return err
 	}
 
-	return this.NotifyUpdate(tx, webId)
+	return this.NotifyUpdateWithAuthor(tx, webId, author)
 }
 
 // 修改字符编码
-func (this *HTTPWebDAO) UpdateWebCharset(tx *dbs.Tx, webId int64, charsetJSON []byte) error {
+func (this *HTTPWebDAO) UpdateWebCharset(tx *dbs.Tx, webId int64, charsetJSON []byte, author *ServerConfigRevisionAuthor) error {
 	if webId <= 0 {
 		return errors.New("invalid webId")
 	}
@@ -387,11 +387,11 @@ func (this *HTTPWebDAO) UpdateWebCharset(tx *dbs.Tx, webId int64, charsetJSON []
 		return err
 	}
 
-	return this.NotifyUpdate(tx, webId)
+	return this.NotifyUpdateWithAuthor(tx, webId, author)
 }
 
 // 更改请求Header策略
-func (this *HTTPWebDAO) UpdateWebRequestHeaderPolicy(tx *dbs.Tx, webId int64, headerPolicyJSON []byte) error {
+func (this *HTTPWebDAO) UpdateWebRequestHeaderPolicy(tx *dbs.Tx, webId int64, headerPolicyJSON []byte, author *ServerConfigRevisionAuthor) error {
 	if webId <= 0 {
 		return errors.New("invalid webId")
 	}
@@ -403,11 +403,11 @@ func (this *HTTPWebDAO) UpdateWebRequestHeaderPolicy(tx *dbs.Tx, webId int64, he
 		return err
 	}
 
-	return this.NotifyUpdate(tx, webId)
+	return this.NotifyUpdateWithAuthor(tx, webId, author)
 }
 
 // 更改响应Header策略
-func (this *HTTPWebDAO) UpdateWebResponseHeaderPolicy(tx *dbs.Tx, webId int64, headerPolicyJSON []byte) error {
+func (this *HTTPWebDAO) UpdateWebResponseHeaderPolicy(tx *dbs.Tx, webId int64, headerPolicyJSON []byte, author *ServerConfigRevisionAuthor) error {
 	if webId <= 0 {
 		return errors.New("invalid webId")
 	}
@@ -419,11 +419,11 @@ func (this *HTTPWebDAO) UpdateWebResponseHeaderPolicy(tx *dbs.Tx, webId int64, h
 		return err
 	}
 
-	return this.NotifyUpdate(tx, webId)
+	return this.NotifyUpdateWithAuthor(tx, webId, author)
 }
 
 // 更改特殊页面配置
-func (this *HTTPWebDAO) UpdateWebPages(tx *dbs.Tx, webId int64, pagesJSON []byte) error {
+func (this *HTTPWebDAO) UpdateWebPages(tx *dbs.Tx, webId int64, pagesJSON []byte, author *ServerConfigRevisionAuthor) error {
 	if webId <= 0 {
 		return errors.New("invalid webId")
 	}
@@ -435,11 +435,11 @@ func (this *HTTPWebDAO) UpdateWebPages(tx *dbs.Tx, webId int64, pagesJSON []byte
 		return err
 	}
 
-	return this.NotifyUpdate(tx, webId)
+	return this.NotifyUpdateWithAuthor(tx, webId, author)
 }
 
 // 更改Shutdown配置
-func (this *HTTPWebDAO) UpdateWebShutdown(tx *dbs.Tx, webId int64, shutdownJSON []byte) error {
+func (this *HTTPWebDAO) UpdateWebShutdown(tx *dbs.Tx, webId int64, shutdownJSON []byte, author *ServerConfigRevisionAuthor) error {
 	if webId <= 0 {
 		return errors.New("invalid webId")
 	}
@@ -451,11 +451,11 @@ func (this *HTTPWebDAO) UpdateWebShutdown(tx *dbs.Tx, webId int64, shutdownJSON
 		return err
 	}
 
-	return this.NotifyUpdate(tx, webId)
+	return this.NotifyUpdateWithAuthor(tx, webId, author)
 }
 
 // 更改访问日志策略
-func (this *HTTPWebDAO) UpdateWebAccessLogConfig(tx *dbs.Tx, webId int64, accessLogJSON []byte) error {
+func (this *HTTPWebDAO) UpdateWebAccessLogConfig(tx *dbs.Tx, webId int64, accessLogJSON []byte, author *ServerConfigRevisionAuthor) error {
 	if webId <= 0 {
 		return errors.New("invalid webId")
 	}
@@ -467,11 +467,11 @@ func (this *HTTPWebDAO) UpdateWebAccessLogConfig(tx *dbs.Tx, webId int64, access
 		return err
 	}
 
-	return this.NotifyUpdate(tx, webId)
+	return this.NotifyUpdateWithAuthor(tx, webId, author)
 }
 
 // 更改统计配置
-func (this *HTTPWebDAO) UpdateWebStat(tx *dbs.Tx, webId int64, statJSON []byte) error {
+func (this *HTTPWebDAO) UpdateWebStat(tx *dbs.Tx, webId int64, statJSON []byte, author *ServerConfigRevisionAuthor) error {
 	if webId <= 0 {
 		return errors.New("invalid webId")
 	}
@@ -483,11 +483,11 @@ func (this *HTTPWebDAO) UpdateWebStat(tx *dbs.Tx, webId int64, statJSON []byte)
 		return err
 	}
 
-	return this.NotifyUpdate(tx, webId)
+	return this.NotifyUpdateWithAuthor(tx, webId, author)
 }
 
 // 更改缓存配置
-func (this *HTTPWebDAO) UpdateWebCache(tx *dbs.Tx, webId int64, cacheJSON []byte) error {
+func (this *HTTPWebDAO) UpdateWebCache(tx *dbs.Tx, webId int64, cacheJSON []byte, author *ServerConfigRevisionAuthor) error {
 	if webId <= 0 {
 		return errors.New("invalid webId")
 	}
@@ -499,11 +499,11 @@ func (this *HTTPWebDAO) UpdateWebCache(tx *dbs.Tx, webId int64, cacheJSON []byte
 		return err
 	}
 
-	return this.NotifyUpdate(tx, webId)
+	return this.NotifyUpdateWithAuthor(tx, webId, author)
 }
 
 // 更改防火墙配置
-func (this *HTTPWebDAO) UpdateWebFirewall(tx *dbs.Tx, webId int64, firewallJSON []byte) error {
+func (this *HTTPWebDAO) UpdateWebFirewall(tx *dbs.Tx, webId int64, firewallJSON []byte, author *ServerConfigRevisionAuthor) error {
 	if webId <= 0 {
 		return errors.New("invalid webId")
 	}
@@ -515,11 +515,11 @@ func (this *HTTPWebDAO) UpdateWebFirewall(tx *dbs.Tx, webId int64, firewallJSON
 		return err
 	}
 
-	return this.NotifyUpdate(tx, webId)
+	return this.NotifyUpdateWithAuthor(tx, webId, author)
 }
 
 // 更改路径规则配置
-func (this *HTTPWebDAO) UpdateWebLocations(tx *dbs.Tx, webId int64, locationsJSON []byte) error {
+func (this *HTTPWebDAO) UpdateWebLocations(tx *dbs.Tx, webId int64, locationsJSON []byte, author *ServerConfigRevisionAuthor) error {
 	if webId <= 0 {
 		return errors.New("invalid webId")
 	}
@@ -531,11 +531,11 @@ func (this *HTTPWebDAO) UpdateWebLocations(tx *dbs.Tx, webId int64, locationsJSO
 		return err
 	}
 
-	return this.NotifyUpdate(tx, webId)
+	return this.NotifyUpdateWithAuthor(tx, webId, author)
 }
 
 // 更改跳转到HTTPS设置
-func (this *HTTPWebDAO) UpdateWebRedirectToHTTPS(tx *dbs.Tx, webId int64, redirectToHTTPSJSON []byte) error {
+func (this *HTTPWebDAO) UpdateWebRedirectToHTTPS(tx *dbs.Tx, webId int64, redirectToHTTPSJSON []byte, author *ServerConfigRevisionAuthor) error {
 	if webId <= 0 {
 		return errors.New("invalid webId")
 	}
@@ -547,11 +547,11 @@ func (this *HTTPWebDAO) UpdateWebRedirectToHTTPS(tx *dbs.Tx, webId int64, redire
 		return err
 	}
 
-	return this.NotifyUpdate(tx, webId)
+	return this.NotifyUpdateWithAuthor(tx, webId, author)
 }
 
 // 修改Websocket设置
-func (this *HTTPWebDAO) UpdateWebsocket(tx *dbs.Tx, webId int64, websocketJSON []byte) error {
+func (this *HTTPWebDAO) UpdateWebsocket(tx *dbs.Tx, webId int64, websocketJSON []byte, author *ServerConfigRevisionAuthor) error {
 	if webId <= 0 {
 		return errors.New("invalid webId")
 	}
@@ -563,11 +563,11 @@ func (this *HTTPWebDAO) UpdateWebsocket(tx *dbs.Tx, webId int64, websocketJSON [
 		return err
 	}
 
-	return this.NotifyUpdate(tx, webId)
+	return this.NotifyUpdateWithAuthor(tx, webId, author)
 }
 
 // 修改重写规则设置
-func (this *HTTPWebDAO) UpdateWebRewriteRules(tx *dbs.Tx, webId int64, rewriteRulesJSON []byte) error {
+func (this *HTTPWebDAO) UpdateWebRewriteRules(tx *dbs.Tx, webId int64, rewriteRulesJSON []byte, author *ServerConfigRevisionAuthor) error {
 	if webId <= 0 {
 		return errors.New("invalid webId")
 	}
@@ -579,7 +579,7 @@ func (this *HTTPWebDAO) UpdateWebRewriteRules(tx *dbs.Tx, webId int64, rewriteRu
 		return err
 	}
 
-	return this.NotifyUpdate(tx, webId)
+	return this.NotifyUpdateWithAuthor(tx, webId, author)
 }
 
 // 根据缓存策略ID查找所有的WebId
@@ -673,6 +673,50 @@ func (this *HTTPWebDAO) FindAllWebIdsWithHTTPFirewallPolicyId(tx *dbs.Tx, firewa
 	return result, nil
 }
 
+// 根据访问日志策略ID查找所有的WebId
+func (this *HTTPWebDAO) FindAllWebIdsWithHTTPAccessLogPolicyId(tx *dbs.Tx, accessLogPolicyId int64) ([]int64, error) {
+	ones, err := this.Query(tx).
+		State(HTTPWebStateEnabled).
+		ResultPk().
+		Where(`JSON_CONTAINS(accessLog, :jsonQuery, '$.storagePolicies')`).
+		Param("jsonQuery", types.String(accessLogPolicyId)).
+		FindAll()
+	if err != nil {
+		return nil, err
+	}
+	result := []int64{}
+	for _, one := range ones {
+		webId := int64(one.(*HTTPWeb).Id)
+
+		// 判断是否为Location
+		for {
+			locationId, err := SharedHTTPLocationDAO.FindEnabledLocationIdWithWebId(tx, webId)
+			if err != nil {
+				return nil, err
+			}
+
+			// 如果非Location
+			if locationId == 0 {
+				if !lists.ContainsInt64(result, webId) {
+					result = append(result, webId)
+				}
+				break
+			}
+
+			// 查找包含此Location的Web
+			// TODO 需要支持嵌套的Location查询
+			webId, err = this.FindEnabledWebIdWithLocationId(tx, locationId)
+			if err != nil {
+				return nil, err
+			}
+			if webId == 0 {
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
 // 查找包含某个Location的Web
 func (this *HTTPWebDAO) FindEnabledWebIdWithLocationId(tx *dbs.Tx, locationId int64) (webId int64, err error) {
 	return this.Query(tx).
@@ -779,7 +823,7 @@ func (this *HTTPWebDAO) CheckUserWeb(tx *dbs.Tx, userId int64, webId int64) erro
 }
 
 // 设置主机跳转
-func (this *HTTPWebDAO) UpdateWebHostRedirects(tx *dbs.Tx, webId int64, hostRedirects []*serverconfigs.HTTPHostRedirectConfig) error {
+func (this *HTTPWebDAO) UpdateWebHostRedirects(tx *dbs.Tx, webId int64, hostRedirects []*serverconfigs.HTTPHostRedirectConfig, author *ServerConfigRevisionAuthor) error {
 	if webId <= 0 {
 		return errors.New("invalid ")
 	}
@@ -798,7 +842,7 @@ func (this *HTTPWebDAO) UpdateWebHostRedirects(tx *dbs.Tx, webId int64, hostRedi
 		return err
 	}
 
-	return this.NotifyUpdate(tx, webId)
+	return this.NotifyUpdateWithAuthor(tx, webId, author)
 }
 
 // 查找主机跳转
@@ -815,6 +859,11 @@ func (this *HTTPWebDAO) FindWebHostRedirects(tx *dbs.Tx, webId int64) ([]byte, e
 
 // 通知更新
 func (this *HTTPWebDAO) NotifyUpdate(tx *dbs.Tx, webId int64) error {
+	return this.NotifyUpdateWithAuthor(tx, webId, nil)
+}
+
+// 通知更新，并记录配置的修改者
+func (this *HTTPWebDAO) NotifyUpdateWithAuthor(tx *dbs.Tx, webId int64, author *ServerConfigRevisionAuthor) error {
 	serverId, err := this.FindWebServerId(tx, webId)
 	if err != nil {
 		return err
@@ -822,5 +871,5 @@ func (this *HTTPWebDAO) NotifyUpdate(tx *dbs.Tx, webId int64) error {
 	if serverId == 0 {
 		return nil
 	}
-	return SharedServerDAO.NotifyUpdate(tx, serverId)
+	return SharedServerDAO.NotifyUpdateWithAuthor(tx, serverId, author)
 }
@@ -9,14 +9,14 @@ import (
 func TestHTTPWebDAO_UpdateWebShutdown(t *testing.T) {
 	var tx *dbs.Tx
 	{
-		err := SharedHTTPWebDAO.UpdateWebShutdown(tx, 1, []byte("{}"))
+		err := SharedHTTPWebDAO.UpdateWebShutdown(tx, 1, []byte("{}"), nil)
 		if err != nil {
 			t.Fatal(err)
 		}
 	}
 
 	{
-		err := SharedHTTPWebDAO.UpdateWebShutdown(tx, 1, nil)
+		err := SharedHTTPWebDAO.UpdateWebShutdown(tx, 1, nil, nil)
 		if err != nil {
 			t.Fatal(err)
 		}
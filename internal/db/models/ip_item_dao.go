@@ -2,13 +2,14 @@ package models
 
 import (
 	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"github.com/TeaOSLab/EdgeAPI/internal/iplists"
 	"github.com/TeaOSLab/EdgeAPI/internal/utils"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/iwind/TeaGo/Tea"
 	"github.com/iwind/TeaGo/dbs"
 	"github.com/iwind/TeaGo/lists"
 	"github.com/iwind/TeaGo/types"
-	"math"
+	"net"
 	"time"
 )
 
@@ -56,7 +57,7 @@ func (this *IPItemDAO) EnableIPItem(tx *dbs.Tx, id int64) error {
 }
 
 // 禁用条目
-func (this *IPItemDAO) DisableIPItem(tx *dbs.Tx, id int64) error {
+func (this *IPItemDAO) DisableIPItem(tx *dbs.Tx, id int64, actor *IPItemActor) error {
 	version, err := SharedIPListDAO.IncreaseVersion(tx)
 	if err != nil {
 		return err
@@ -71,6 +72,11 @@ func (this *IPItemDAO) DisableIPItem(tx *dbs.Tx, id int64) error {
 	if err != nil {
 		return err
 	}
+
+	err = this.createHistory(tx, id, IPItemHistoryActionDelete, actor)
+	if err != nil {
+		return err
+	}
 	return this.NotifyUpdate(tx, id)
 }
 
@@ -87,20 +93,20 @@ func (this *IPItemDAO) FindEnabledIPItem(tx *dbs.Tx, id int64) (*IPItem, error)
 }
 
 // 创建IP
-func (this *IPItemDAO) CreateIPItem(tx *dbs.Tx, listId int64, ipFrom string, ipTo string, expiredAt int64, reason string, itemType IPItemType, eventLevel string) (int64, error) {
+func (this *IPItemDAO) CreateIPItem(tx *dbs.Tx, actor *IPItemActor, listId int64, ipFrom string, ipTo string, expiredAt int64, reason string, itemType IPItemType, eventLevel string) (int64, error) {
+	op := NewIPItemOperator()
+	op.ListId = listId
+	err := this.fillIPRange(op, ipFrom, ipTo, itemType)
+	if err != nil {
+		return 0, err
+	}
+
 	version, err := SharedIPListDAO.IncreaseVersion(tx)
 	if err != nil {
 		return 0, err
 	}
 
-	op := NewIPItemOperator()
-	op.ListId = listId
-	op.IpFrom = ipFrom
-	op.IpTo = ipTo
-	op.IpFromLong = utils.IP2Long(ipFrom)
-	op.IpToLong = utils.IP2Long(ipTo)
 	op.Reason = reason
-	op.Type = itemType
 	op.EventLevel = eventLevel
 	op.Version = version
 	if expiredAt < 0 {
@@ -114,6 +120,11 @@ func (this *IPItemDAO) CreateIPItem(tx *dbs.Tx, listId int64, ipFrom string, ipT
 	}
 	itemId := types.Int64(op.Id)
 
+	err = this.createHistory(tx, itemId, IPItemHistoryActionAdd, actor)
+	if err != nil {
+		return 0, err
+	}
+
 	err = this.NotifyUpdate(tx, itemId)
 	if err != nil {
 		return 0, err
@@ -122,7 +133,7 @@ func (this *IPItemDAO) CreateIPItem(tx *dbs.Tx, listId int64, ipFrom string, ipT
 }
 
 // 修改IP
-func (this *IPItemDAO) UpdateIPItem(tx *dbs.Tx, itemId int64, ipFrom string, ipTo string, expiredAt int64, reason string, itemType IPItemType, eventLevel string) error {
+func (this *IPItemDAO) UpdateIPItem(tx *dbs.Tx, actor *IPItemActor, itemId int64, ipFrom string, ipTo string, expiredAt int64, reason string, itemType IPItemType, eventLevel string) error {
 	if itemId <= 0 {
 		return errors.New("invalid itemId")
 	}
@@ -138,19 +149,19 @@ func (this *IPItemDAO) UpdateIPItem(tx *dbs.Tx, itemId int64, ipFrom string, ipT
 		return errors.New("not found")
 	}
 
+	op := NewIPItemOperator()
+	op.Id = itemId
+	err = this.fillIPRange(op, ipFrom, ipTo, itemType)
+	if err != nil {
+		return err
+	}
+
 	version, err := SharedIPListDAO.IncreaseVersion(tx)
 	if err != nil {
 		return err
 	}
 
-	op := NewIPItemOperator()
-	op.Id = itemId
-	op.IpFrom = ipFrom
-	op.IpTo = ipTo
-	op.IpFromLong = utils.IP2Long(ipFrom)
-	op.IpToLong = utils.IP2Long(ipTo)
 	op.Reason = reason
-	op.Type = itemType
 	op.EventLevel = eventLevel
 	if expiredAt < 0 {
 		expiredAt = 0
@@ -162,6 +173,11 @@ func (this *IPItemDAO) UpdateIPItem(tx *dbs.Tx, itemId int64, ipFrom string, ipT
 		return err
 	}
 
+	err = this.createHistory(tx, itemId, IPItemHistoryActionUpdate, actor)
+	if err != nil {
+		return err
+	}
+
 	return this.NotifyUpdate(tx, itemId)
 }
 
@@ -191,8 +207,10 @@ func (this *IPItemDAO) ListIPItemsAfterVersion(tx *dbs.Tx, version int64, size i
 	_, err = this.Query(tx).
 		// 这里不要设置状态参数，因为我们要知道哪些是删除的
 		Gt("version", version).
-		Where("(expiredAt=0 OR expiredAt>:expiredAt)").
+		// 已经过期并被禁用的IP也需要返回，以便节点删除
+		Where("(expiredAt=0 OR expiredAt>:expiredAt OR state=:disabledState)").
 		Param("expiredAt", time.Now().Unix()).
+		Param("disabledState", IPItemStateDisabled).
 		Asc("version").
 		Limit(size).
 		Slice(&result).
@@ -209,17 +227,20 @@ func (this *IPItemDAO) FindItemListId(tx *dbs.Tx, itemId int64) (int64, error) {
 }
 
 // 查找包含某个IP的Item
-func (this *IPItemDAO) FindEnabledItemContainsIP(tx *dbs.Tx, listId int64, ip uint64) (*IPItem, error) {
-	query := this.Query(tx).
-		Attr("listId", listId).
-		State(IPItemStateEnabled)
-	if ip > math.MaxUint32 {
-		query.Where("(type='all' OR ipFromLong=:ip)")
-	} else {
-		query.Where("(type='all' OR ipFromLong=:ip OR (ipToLong>0 AND ipFromLong<=:ip AND ipToLong>=:ip))").
-			Param("ip", ip)
+// IPv4和IPv6都使用16字节的二进制比较，所以可以检查IPv6范围
+func (this *IPItemDAO) FindEnabledItemContainsIP(tx *dbs.Tx, listId int64, ip string) (*IPItem, error) {
+	netIP := net.ParseIP(ip)
+	if netIP == nil {
+		return nil, nil
 	}
-	one, err := query.Find()
+	one, err := this.Query(tx).
+		Attr("listId", listId).
+		State(IPItemStateEnabled).
+		Where("(type='all' OR (ipFromBin<=:ip AND ipToBin>=:ip))").
+		Param("ip", utils.IP2Bytes(netIP)).
+		Where("(expiredAt=0 OR expiredAt>:expiredAt)").
+		Param("expiredAt", time.Now().Unix()).
+		Find()
 	if err != nil {
 		return nil, err
 	}
@@ -229,6 +250,308 @@ func (this *IPItemDAO) FindEnabledItemContainsIP(tx *dbs.Tx, listId int64, ip ui
 	return one.(*IPItem), nil
 }
 
+// 查找名单中所有未过期的IP，用于导出
+func (this *IPItemDAO) FindAllEnabledIPItemsWithListId(tx *dbs.Tx, listId int64) (result []*IPItem, err error) {
+	_, err = this.Query(tx).
+		State(IPItemStateEnabled).
+		Attr("listId", listId).
+		Where("(expiredAt=0 OR expiredAt>:expiredAt)").
+		Param("expiredAt", time.Now().Unix()).
+		AscPk().
+		Slice(&result).
+		FindAll()
+	return
+}
+
+// 批量导入IP
+// 已经存在的IP范围会被跳过；每个IP都会分配单独的版本号，以便节点可以按版本号分页同步
+func (this *IPItemDAO) ImportIPItems(tx *dbs.Tx, actor *IPItemActor, listId int64, entries []*iplists.Entry, defaultExpiredAt int64, defaultReason string) (countCreated int64, countSkipped int64, err error) {
+	if listId <= 0 {
+		return 0, 0, errors.New("invalid listId")
+	}
+
+	existKeys, err := this.findEnabledItemKeys(tx, listId)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	newEntries := []*iplists.Entry{}
+	for _, entry := range entries {
+		key := entry.Key()
+		if existKeys[key] {
+			countSkipped++
+			continue
+		}
+		existKeys[key] = true
+		newEntries = append(newEntries, entry)
+	}
+	if len(newEntries) == 0 {
+		return 0, countSkipped, nil
+	}
+
+	maxVersion, err := SharedIPListDAO.IncreaseVersions(tx, int64(len(newEntries)))
+	if err != nil {
+		return 0, 0, err
+	}
+	version := maxVersion - int64(len(newEntries)) + 1
+	for _, entry := range newEntries {
+		expiredAt := entry.ExpiredAt
+		if expiredAt <= 0 {
+			expiredAt = defaultExpiredAt
+		}
+		reason := entry.Reason
+		if len(reason) == 0 {
+			reason = defaultReason
+		}
+		err = this.createImportedItem(tx, actor, listId, 0, entry, expiredAt, reason, version)
+		if err != nil {
+			return 0, 0, err
+		}
+		version++
+		countCreated++
+	}
+
+	err = SharedIPListDAO.NotifyUpdate(tx, listId, NodeTaskTypeIPItemChanged)
+	if err != nil {
+		return 0, 0, err
+	}
+	return countCreated, countSkipped, nil
+}
+
+// 使用订阅源中的IP替换名单中属于此订阅源的IP
+// 只会增加新出现的IP、删除已经不在订阅源中的IP，不影响手工加入的IP
+func (this *IPItemDAO) SyncFeedIPItems(tx *dbs.Tx, listId int64, feedId int64, entries []*iplists.Entry, reason string) (countAdded int64, countRemoved int64, err error) {
+	if listId <= 0 || feedId <= 0 {
+		return 0, 0, errors.New("invalid listId or feedId")
+	}
+
+	// 空的结果通常是订阅源出错，不能用来清空已有的IP
+	if len(entries) == 0 {
+		return 0, 0, errors.New("feed entries should not be empty")
+	}
+
+	ones, _, err := this.Query(tx).
+		Attr("listId", listId).
+		Attr("feedId", feedId).
+		State(IPItemStateEnabled).
+		Result("id", "ipFrom", "ipTo").
+		FindOnes()
+	if err != nil {
+		return 0, 0, err
+	}
+	existItemMap := map[string]int64{} // key => itemId
+	for _, one := range ones {
+		key := (&iplists.Entry{IPFrom: one.GetString("ipFrom"), IPTo: one.GetString("ipTo")}).Key()
+		existItemMap[key] = one.GetInt64("id")
+	}
+
+	newEntries := []*iplists.Entry{}
+	entryKeys := map[string]bool{}
+	for _, entry := range entries {
+		key := entry.Key()
+		entryKeys[key] = true
+		if _, ok := existItemMap[key]; !ok {
+			newEntries = append(newEntries, entry)
+		}
+	}
+	removedItemIds := []int64{}
+	for key, itemId := range existItemMap {
+		if !entryKeys[key] {
+			removedItemIds = append(removedItemIds, itemId)
+		}
+	}
+
+	count := int64(len(newEntries) + len(removedItemIds))
+	if count == 0 {
+		return 0, 0, nil
+	}
+	maxVersion, err := SharedIPListDAO.IncreaseVersions(tx, count)
+	if err != nil {
+		return 0, 0, err
+	}
+	version := maxVersion - count + 1
+
+	actor := NewIPItemActor(IPItemActorTypeFeed, feedId)
+	for _, entry := range newEntries {
+		err = this.createImportedItem(tx, actor, listId, feedId, entry, 0, reason, version)
+		if err != nil {
+			return 0, 0, err
+		}
+		version++
+		countAdded++
+	}
+	for _, itemId := range removedItemIds {
+		_, err = this.Query(tx).
+			Pk(itemId).
+			Set("state", IPItemStateDisabled).
+			Set("version", version).
+			Update()
+		if err != nil {
+			return 0, 0, err
+		}
+		err = this.createHistory(tx, itemId, IPItemHistoryActionDelete, actor)
+		if err != nil {
+			return 0, 0, err
+		}
+		version++
+		countRemoved++
+	}
+
+	err = SharedIPListDAO.NotifyUpdate(tx, listId, NodeTaskTypeIPItemChanged)
+	if err != nil {
+		return 0, 0, err
+	}
+	return countAdded, countRemoved, nil
+}
+
+// 查找名单中启用并且未过期的IP范围
+func (this *IPItemDAO) findEnabledItemKeys(tx *dbs.Tx, listId int64) (map[string]bool, error) {
+	ones, _, err := this.Query(tx).
+		Attr("listId", listId).
+		State(IPItemStateEnabled).
+		Where("(expiredAt=0 OR expiredAt>:expiredAt)").
+		Param("expiredAt", time.Now().Unix()).
+		Result("ipFrom", "ipTo").
+		FindOnes()
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]bool{}
+	for _, one := range ones {
+		result[(&iplists.Entry{IPFrom: one.GetString("ipFrom"), IPTo: one.GetString("ipTo")}).Key()] = true
+	}
+	return result, nil
+}
+
+// 创建导入的IP，版本号由调用者分配
+func (this *IPItemDAO) createImportedItem(tx *dbs.Tx, actor *IPItemActor, listId int64, feedId int64, entry *iplists.Entry, expiredAt int64, reason string, version int64) error {
+	op := NewIPItemOperator()
+	op.ListId = listId
+	op.FeedId = feedId
+	err := this.fillIPRange(op, entry.IPFrom, entry.IPTo, "")
+	if err != nil {
+		return err
+	}
+	if expiredAt < 0 {
+		expiredAt = 0
+	}
+	op.Reason = reason
+	op.ExpiredAt = expiredAt
+	op.Version = version
+	op.State = IPItemStateEnabled
+	err = this.Save(tx, op)
+	if err != nil {
+		return err
+	}
+	return this.createHistory(tx, types.Int64(op.Id), IPItemHistoryActionAdd, actor)
+}
+
+// 禁用一批已过期的IP，返回禁用的数量
+// 每个IP都会分配新的版本号，以便节点可以同步到删除操作
+func (this *IPItemDAO) DisableExpiredIPItems(tx *dbs.Tx, size int64) (int64, error) {
+	ones, _, err := this.Query(tx).
+		State(IPItemStateEnabled).
+		Gt("expiredAt", 0).
+		Lte("expiredAt", time.Now().Unix()).
+		Result("id", "listId").
+		AscPk().
+		Limit(size).
+		FindOnes()
+	if err != nil {
+		return 0, err
+	}
+	if len(ones) == 0 {
+		return 0, nil
+	}
+
+	maxVersion, err := SharedIPListDAO.IncreaseVersions(tx, int64(len(ones)))
+	if err != nil {
+		return 0, err
+	}
+	version := maxVersion - int64(len(ones)) + 1
+
+	actor := NewIPItemActor(IPItemActorTypeSystem, 0)
+	listIds := []int64{}
+	for _, one := range ones {
+		itemId := one.GetInt64("id")
+		_, err = this.Query(tx).
+			Pk(itemId).
+			Set("state", IPItemStateDisabled).
+			Set("version", version).
+			Update()
+		if err != nil {
+			return 0, err
+		}
+		err = this.createHistory(tx, itemId, IPItemHistoryActionExpire, actor)
+		if err != nil {
+			return 0, err
+		}
+		version++
+
+		listId := one.GetInt64("listId")
+		if !lists.ContainsInt64(listIds, listId) {
+			listIds = append(listIds, listId)
+		}
+	}
+
+	for _, listId := range listIds {
+		err = SharedIPListDAO.NotifyUpdate(tx, listId, NodeTaskTypeIPItemChanged)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(ones)), nil
+}
+
+// 记录IP变更历史
+func (this *IPItemDAO) createHistory(tx *dbs.Tx, itemId int64, action IPItemHistoryAction, actor *IPItemActor) error {
+	one, err := this.Query(tx).
+		Pk(itemId).
+		Find()
+	if err != nil {
+		return err
+	}
+	if one == nil {
+		return nil
+	}
+	return SharedIPItemHistoryDAO.CreateHistory(tx, one.(*IPItem), action, actor)
+}
+
+// 解析并设置IP范围
+// ipFrom可以是单个IP或者CIDR，CIDR会被转换为开始IP和结束IP；除了所有IP类型外，类型会根据IP族自动设置
+func (this *IPItemDAO) fillIPRange(op *IPItemOperator, ipFrom string, ipTo string, itemType IPItemType) error {
+	if itemType == IPItemTypeAll {
+		op.Type = itemType
+		op.IpFrom = ipFrom
+		op.IpTo = ipTo
+		op.IpFromLong = 0
+		op.IpToLong = 0
+		return nil
+	}
+
+	from, to, err := utils.ParseIPRange(ipFrom, ipTo)
+	if err != nil {
+		return err
+	}
+	if from.To4() != nil {
+		op.Type = IPItemTypeIPv4
+	} else {
+		op.Type = IPItemTypeIPv6
+	}
+
+	ipToString := ""
+	if !from.Equal(to) {
+		ipToString = to.String()
+	}
+	op.IpFrom = from.String()
+	op.IpTo = ipToString
+	op.IpFromLong = utils.IP2Long(from.String())
+	op.IpToLong = utils.IP2Long(ipToString)
+	op.IpFromBin = utils.IP2Bytes(from)
+	op.IpToBin = utils.IP2Bytes(to)
+	return nil
+}
+
 // 通知更新
 func (this *IPItemDAO) NotifyUpdate(tx *dbs.Tx, itemId int64) error {
 	// 获取ListId
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"github.com/TeaOSLab/EdgeAPI/internal/utils"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/iwind/TeaGo/Tea"
+	"github.com/iwind/TeaGo/dbs"
+	"net"
+	"time"
+)
+
+type IPItemHistoryAction = string
+
+// IP变更操作
+const (
+	IPItemHistoryActionAdd    IPItemHistoryAction = "add"    // 添加
+	IPItemHistoryActionUpdate IPItemHistoryAction = "update" // 修改
+	IPItemHistoryActionExpire IPItemHistoryAction = "expire" // 过期
+	IPItemHistoryActionDelete IPItemHistoryAction = "delete" // 删除
+)
+
+type IPItemActorType = string
+
+// IP变更操作者类型
+const (
+	IPItemActorTypeAdmin    IPItemActorType = "admin"    // 管理员
+	IPItemActorTypeUser     IPItemActorType = "user"     // 用户
+	IPItemActorTypeAPIToken IPItemActorType = "apiToken" // API访问令牌
+	IPItemActorTypeWAF      IPItemActorType = "waf"      // WAF动作，ID为边缘节点ID
+	IPItemActorTypeFeed     IPItemActorType = "feed"     // 订阅源
+	IPItemActorTypeSystem   IPItemActorType = "system"   // 系统，比如过期清理任务
+)
+
+// IP变更操作者
+type IPItemActor struct {
+	Type IPItemActorType
+	Id   int64
+}
+
+func NewIPItemActor(actorType IPItemActorType, actorId int64) *IPItemActor {
+	return &IPItemActor{
+		Type: actorType,
+		Id:   actorId,
+	}
+}
+
+type IPItemHistoryDAO dbs.DAO
+
+func NewIPItemHistoryDAO() *IPItemHistoryDAO {
+	return dbs.NewDAO(&IPItemHistoryDAO{
+		DAOObject: dbs.DAOObject{
+			DB:     Tea.Env,
+			Table:  "edgeIPItemHistories",
+			Model:  new(IPItemHistory),
+			PkName: "id",
+		},
+	}).(*IPItemHistoryDAO)
+}
+
+var SharedIPItemHistoryDAO *IPItemHistoryDAO
+
+func init() {
+	dbs.OnReady(func() {
+		SharedIPItemHistoryDAO = NewIPItemHistoryDAO()
+	})
+}
+
+// 记录IP变更
+// 历史记录只会增加，不会修改和删除
+func (this *IPItemHistoryDAO) CreateHistory(tx *dbs.Tx, item *IPItem, action IPItemHistoryAction, actor *IPItemActor) error {
+	if item == nil {
+		return nil
+	}
+	if actor == nil {
+		actor = NewIPItemActor(IPItemActorTypeSystem, 0)
+	}
+
+	op := NewIPItemHistoryOperator()
+	op.ItemId = item.Id
+	op.ListId = item.ListId
+	op.Action = action
+	op.ActorType = actor.Type
+	op.ActorId = actor.Id
+	op.IpFrom = item.IpFrom
+	op.IpTo = item.IpTo
+	op.IpFromBin = item.IpFromBin
+	op.IpToBin = item.IpToBin
+	op.Reason = item.Reason
+	op.ExpiredAt = item.ExpiredAt
+	op.CreatedAt = time.Now().Unix()
+	return this.Save(tx, op)
+}
+
+// 计算包含某个IP的历史记录数量
+func (this *IPItemHistoryDAO) CountHistoriesWithIP(tx *dbs.Tx, ip string) (int64, error) {
+	query, ok := this.queryWithIP(tx, ip)
+	if !ok {
+		return 0, nil
+	}
+	return query.Count()
+}
+
+// 列出包含某个IP的历史记录，包括所有名单
+func (this *IPItemHistoryDAO) ListHistoriesWithIP(tx *dbs.Tx, ip string, offset int64, size int64) (result []*IPItemHistory, err error) {
+	query, ok := this.queryWithIP(tx, ip)
+	if !ok {
+		return
+	}
+	_, err = query.
+		DescPk().
+		Offset(offset).
+		Limit(size).
+		Slice(&result).
+		FindAll()
+	return
+}
+
+// 构造查询某个IP的Query
+func (this *IPItemHistoryDAO) queryWithIP(tx *dbs.Tx, ip string) (*dbs.Query, bool) {
+	netIP := net.ParseIP(ip)
+	if netIP == nil {
+		return nil, false
+	}
+	return this.Query(tx).
+		Where("ipFromBin<=:ip AND ipToBin>=:ip").
+		Param("ip", utils.IP2Bytes(netIP)), true
+}
@@ -0,0 +1,38 @@
+package models
+
+// IP变更历史
+type IPItemHistory struct {
+	Id        uint64 `field:"id"`        // ID
+	ItemId    uint64 `field:"itemId"`    // IP ID
+	ListId    uint32 `field:"listId"`    // 名单ID
+	Action    string `field:"action"`    // 操作
+	ActorType string `field:"actorType"` // 操作者类型
+	ActorId   uint64 `field:"actorId"`   // 操作者ID
+	IpFrom    string `field:"ipFrom"`    // 开始IP
+	IpTo      string `field:"ipTo"`      // 结束IP
+	IpFromBin string `field:"ipFromBin"` // 开始IP二进制
+	IpToBin   string `field:"ipToBin"`   // 结束IP二进制
+	Reason    string `field:"reason"`    // 加入说明
+	ExpiredAt uint64 `field:"expiredAt"` // 过期时间
+	CreatedAt uint64 `field:"createdAt"` // 创建时间
+}
+
+type IPItemHistoryOperator struct {
+	Id        interface{} // ID
+	ItemId    interface{} // IP ID
+	ListId    interface{} // 名单ID
+	Action    interface{} // 操作
+	ActorType interface{} // 操作者类型
+	ActorId   interface{} // 操作者ID
+	IpFrom    interface{} // 开始IP
+	IpTo      interface{} // 结束IP
+	IpFromBin interface{} // 开始IP二进制
+	IpToBin   interface{} // 结束IP二进制
+	Reason    interface{} // 加入说明
+	ExpiredAt interface{} // 过期时间
+	CreatedAt interface{} // 创建时间
+}
+
+func NewIPItemHistoryOperator() *IPItemHistoryOperator {
+	return &IPItemHistoryOperator{}
+}
@@ -9,6 +9,8 @@ type IPItem struct {
 	IpTo       string `field:"ipTo"`       // 结束IP
 	IpFromLong uint64 `field:"ipFromLong"` // 开始IP整型
 	IpToLong   uint64 `field:"ipToLong"`   // 结束IP整型
+	IpFromBin  string `field:"ipFromBin"`  // 开始IP二进制
+	IpToBin    string `field:"ipToBin"`    // 结束IP二进制
 	Version    uint64 `field:"version"`    // 版本
 	CreatedAt  uint64 `field:"createdAt"`  // 创建时间
 	UpdatedAt  uint64 `field:"updatedAt"`  // 修改时间
@@ -16,6 +18,7 @@ type IPItem struct {
 	EventLevel string `field:"eventLevel"` // 事件级别
 	State      uint8  `field:"state"`      // 状态
 	ExpiredAt  uint64 `field:"expiredAt"`  // 过期时间
+	FeedId     uint32 `field:"feedId"`     // 订阅源ID
 }
 
 type IPItemOperator struct {
@@ -26,6 +29,8 @@ type IPItemOperator struct {
 	IpTo       interface{} // 结束IP
 	IpFromLong interface{} // 开始IP整型
 	IpToLong   interface{} // 结束IP整型
+	IpFromBin  interface{} // 开始IP二进制
+	IpToBin    interface{} // 结束IP二进制
 	Version    interface{} // 版本
 	CreatedAt  interface{} // 创建时间
 	UpdatedAt  interface{} // 修改时间
@@ -33,6 +38,7 @@ type IPItemOperator struct {
 	EventLevel interface{} // 事件级别
 	State      interface{} // 状态
 	ExpiredAt  interface{} // 过期时间
+	FeedId     interface{} // 订阅源ID
 }
 
 func NewIPItemOperator() *IPItemOperator {
@@ -148,6 +148,12 @@ func (this *IPListDAO) IncreaseVersion(tx *dbs.Tx) (int64, error) {
 	return SharedSysLockerDAO.Increase(tx, "IP_LIST_VERSION", 1000000)
 }
 
+// 一次增加多个版本号，返回增加后的版本号
+// 批量导入时可以为每个IP分配不同的版本号：[返回值-count+1, 返回值]
+func (this *IPListDAO) IncreaseVersions(tx *dbs.Tx, count int64) (int64, error) {
+	return SharedSysLockerDAO.IncreaseBy(tx, "IP_LIST_VERSION", 1000000, count)
+}
+
 // 检查用户权限
 func (this *IPListDAO) CheckUserIPList(tx *dbs.Tx, userId int64, listId int64) error {
 	ok, err := this.Query(tx).
@@ -163,18 +169,38 @@ func (this *IPListDAO) CheckUserIPList(tx *dbs.Tx, userId int64, listId int64) e
 	return ErrNotFound
 }
 
-// 通知更新
-func (this *IPListDAO) NotifyUpdate(tx *dbs.Tx, listId int64, taskType NodeTaskType) error {
-	httpFirewallPolicyIds, err := SharedHTTPFirewallPolicyDAO.FindEnabledFirewallPolicyIdsWithIPListId(tx, listId)
+// 检查节点是否可以使用某个IP名单
+// 只能使用节点所在集群的WAF策略引用的名单
+func (this *IPListDAO) CheckNodeIPList(tx *dbs.Tx, nodeId int64, listId int64) error {
+	clusterId, err := SharedNodeDAO.FindNodeClusterId(tx, nodeId)
 	if err != nil {
 		return err
 	}
+	if clusterId <= 0 {
+		return ErrNotFound
+	}
+	clusterIds, err := this.FindAllClusterIdsWithIPListId(tx, listId)
+	if err != nil {
+		return err
+	}
+	if lists.ContainsInt64(clusterIds, clusterId) {
+		return nil
+	}
+	return ErrNotFound
+}
+
+// 查找引用某个IP名单的所有集群ID
+func (this *IPListDAO) FindAllClusterIdsWithIPListId(tx *dbs.Tx, listId int64) ([]int64, error) {
+	httpFirewallPolicyIds, err := SharedHTTPFirewallPolicyDAO.FindEnabledFirewallPolicyIdsWithIPListId(tx, listId)
+	if err != nil {
+		return nil, err
+	}
 	resultClusterIds := []int64{}
 	for _, policyId := range httpFirewallPolicyIds {
 		// 集群
 		clusterIds, err := SharedNodeClusterDAO.FindAllEnabledNodeClusterIdsWithHTTPFirewallPolicyId(tx, policyId)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		for _, clusterId := range clusterIds {
 			if !lists.ContainsInt64(resultClusterIds, clusterId) {
@@ -185,18 +211,18 @@ func (this *IPListDAO) NotifyUpdate(tx *dbs.Tx, listId int64, taskType NodeTaskT
 		// 服务
 		webIds, err := SharedHTTPWebDAO.FindAllWebIdsWithHTTPFirewallPolicyId(tx, policyId)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if len(webIds) > 0 {
 			for _, webId := range webIds {
 				serverId, err := SharedServerDAO.FindEnabledServerIdWithWebId(tx, webId)
 				if err != nil {
-					return err
+					return nil, err
 				}
 				if serverId > 0 {
 					clusterId, err := SharedServerDAO.FindServerClusterId(tx, serverId)
 					if err != nil {
-						return err
+						return nil, err
 					}
 					if !lists.ContainsInt64(resultClusterIds, clusterId) {
 						resultClusterIds = append(resultClusterIds, clusterId)
@@ -206,6 +232,16 @@ func (this *IPListDAO) NotifyUpdate(tx *dbs.Tx, listId int64, taskType NodeTaskT
 		}
 	}
 
+	return resultClusterIds, nil
+}
+
+// 通知更新
+func (this *IPListDAO) NotifyUpdate(tx *dbs.Tx, listId int64, taskType NodeTaskType) error {
+	resultClusterIds, err := this.FindAllClusterIdsWithIPListId(tx, listId)
+	if err != nil {
+		return err
+	}
+
 	if len(resultClusterIds) > 0 {
 		for _, clusterId := range resultClusterIds {
 			err = SharedNodeTaskDAO.CreateClusterTask(tx, clusterId, taskType)
@@ -0,0 +1,127 @@
+package models
+
+import (
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/iwind/TeaGo/Tea"
+	"github.com/iwind/TeaGo/dbs"
+	"github.com/iwind/TeaGo/types"
+	"time"
+)
+
+const (
+	IPListFeedStateEnabled  = 1 // 已启用
+	IPListFeedStateDisabled = 0 // 已禁用
+)
+
+type IPListFeedDAO dbs.DAO
+
+func NewIPListFeedDAO() *IPListFeedDAO {
+	return dbs.NewDAO(&IPListFeedDAO{
+		DAOObject: dbs.DAOObject{
+			DB:     Tea.Env,
+			Table:  "edgeIPListFeeds",
+			Model:  new(IPListFeed),
+			PkName: "id",
+		},
+	}).(*IPListFeedDAO)
+}
+
+var SharedIPListFeedDAO *IPListFeedDAO
+
+func init() {
+	dbs.OnReady(func() {
+		SharedIPListFeedDAO = NewIPListFeedDAO()
+	})
+}
+
+// 创建订阅源
+func (this *IPListFeedDAO) CreateFeed(tx *dbs.Tx, adminId int64, listId int64, name string, url string, format string, syncInterval int64, reason string, isOn bool) (int64, error) {
+	op := NewIPListFeedOperator()
+	op.AdminId = adminId
+	op.ListId = listId
+	op.Name = name
+	op.Url = url
+	op.Format = format
+	op.SyncInterval = syncInterval
+	op.Reason = reason
+	op.IsOn = isOn
+	op.NextSyncAt = time.Now().Unix()
+	op.State = IPListFeedStateEnabled
+	op.CreatedAt = time.Now().Unix()
+	err := this.Save(tx, op)
+	if err != nil {
+		return 0, err
+	}
+	return types.Int64(op.Id), nil
+}
+
+// 修改订阅源
+// 修改后会立即重新同步
+func (this *IPListFeedDAO) UpdateFeed(tx *dbs.Tx, feedId int64, name string, url string, format string, syncInterval int64, reason string, isOn bool) error {
+	op := NewIPListFeedOperator()
+	op.Id = feedId
+	op.Name = name
+	op.Url = url
+	op.Format = format
+	op.SyncInterval = syncInterval
+	op.Reason = reason
+	op.IsOn = isOn
+	op.NextSyncAt = time.Now().Unix()
+	return this.Save(tx, op)
+}
+
+// 禁用订阅源
+func (this *IPListFeedDAO) DisableFeed(tx *dbs.Tx, feedId int64) error {
+	_, err := this.Query(tx).
+		Pk(feedId).
+		Set("state", IPListFeedStateDisabled).
+		Update()
+	return err
+}
+
+// 查找启用中的订阅源
+func (this *IPListFeedDAO) FindEnabledFeed(tx *dbs.Tx, feedId int64) (*IPListFeed, error) {
+	result, err := this.Query(tx).
+		Pk(feedId).
+		Attr("state", IPListFeedStateEnabled).
+		Find()
+	if result == nil {
+		return nil, err
+	}
+	return result.(*IPListFeed), err
+}
+
+// 查找名单的所有订阅源
+func (this *IPListFeedDAO) FindAllEnabledFeedsWithListId(tx *dbs.Tx, listId int64) (result []*IPListFeed, err error) {
+	_, err = this.Query(tx).
+		State(IPListFeedStateEnabled).
+		Attr("listId", listId).
+		AscPk().
+		Slice(&result).
+		FindAll()
+	return
+}
+
+// 查找需要同步的订阅源
+func (this *IPListFeedDAO) FindAllFeedsToSync(tx *dbs.Tx) (result []*IPListFeed, err error) {
+	_, err = this.Query(tx).
+		State(IPListFeedStateEnabled).
+		Attr("isOn", true).
+		Lte("nextSyncAt", time.Now().Unix()).
+		Asc("nextSyncAt").
+		Slice(&result).
+		FindAll()
+	return
+}
+
+// 保存同步结果
+func (this *IPListFeedDAO) UpdateFeedSyncResult(tx *dbs.Tx, feedId int64, countItems int64, errString string, nextSyncAt int64) error {
+	_, err := this.Query(tx).
+		Pk(feedId).
+		Set("countItems", countItems).
+		Set("error", errString).
+		Set("lastSyncAt", time.Now().Unix()).
+		Set("nextSyncAt", nextSyncAt).
+		Update()
+	return err
+}
@@ -0,0 +1,42 @@
+package models
+
+// IP名单订阅源
+type IPListFeed struct {
+	Id           uint32 `field:"id"`           // ID
+	AdminId      uint32 `field:"adminId"`      // 管理员ID
+	ListId       uint32 `field:"listId"`       // 名单ID
+	Name         string `field:"name"`         // 名称
+	Url          string `field:"url"`          // 订阅地址
+	Format       string `field:"format"`       // 格式
+	SyncInterval uint32 `field:"syncInterval"` // 同步间隔（秒）
+	Reason       string `field:"reason"`       // 加入说明
+	IsOn         uint8  `field:"isOn"`         // 是否启用
+	CountItems   uint32 `field:"countItems"`   // IP数量
+	LastSyncAt   uint64 `field:"lastSyncAt"`   // 上次同步时间
+	NextSyncAt   uint64 `field:"nextSyncAt"`   // 下次同步时间
+	Error        string `field:"error"`        // 最后一次错误
+	CreatedAt    uint64 `field:"createdAt"`    // 创建时间
+	State        uint8  `field:"state"`        // 状态
+}
+
+type IPListFeedOperator struct {
+	Id           interface{} // ID
+	AdminId      interface{} // 管理员ID
+	ListId       interface{} // 名单ID
+	Name         interface{} // 名称
+	Url          interface{} // 订阅地址
+	Format       interface{} // 格式
+	SyncInterval interface{} // 同步间隔（秒）
+	Reason       interface{} // 加入说明
+	IsOn         interface{} // 是否启用
+	CountItems   interface{} // IP数量
+	LastSyncAt   interface{} // 上次同步时间
+	NextSyncAt   interface{} // 下次同步时间
+	Error        interface{} // 最后一次错误
+	CreatedAt    interface{} // 创建时间
+	State        interface{} // 状态
+}
+
+func NewIPListFeedOperator() *IPListFeedOperator {
+	return &IPListFeedOperator{}
+}
@@ -0,0 +1,9 @@
+package models
+
+// 同步间隔，最少10分钟
+func (this *IPListFeed) Interval() int64 {
+	if this.SyncInterval < 600 {
+		return 600
+	}
+	return int64(this.SyncInterval)
+}
@@ -0,0 +1,157 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/iwind/TeaGo/Tea"
+	"github.com/iwind/TeaGo/dbs"
+	"github.com/iwind/TeaGo/types"
+	"time"
+)
+
+const (
+	MessageChannelStateEnabled  = 1 // 已启用
+	MessageChannelStateDisabled = 0 // 已禁用
+)
+
+type MessageChannelDAO dbs.DAO
+
+func NewMessageChannelDAO() *MessageChannelDAO {
+	return dbs.NewDAO(&MessageChannelDAO{
+		DAOObject: dbs.DAOObject{
+			DB:     Tea.Env,
+			Table:  "edgeMessageChannels",
+			Model:  new(MessageChannel),
+			PkName: "id",
+		},
+	}).(*MessageChannelDAO)
+}
+
+var SharedMessageChannelDAO *MessageChannelDAO
+
+func init() {
+	dbs.OnReady(func() {
+		SharedMessageChannelDAO = NewMessageChannelDAO()
+	})
+}
+
+// 启用条目
+func (this *MessageChannelDAO) EnableMessageChannel(tx *dbs.Tx, id int64) error {
+	_, err := this.Query(tx).
+		Pk(id).
+		Set("state", MessageChannelStateEnabled).
+		Update()
+	return err
+}
+
+// 禁用条目
+func (this *MessageChannelDAO) DisableMessageChannel(tx *dbs.Tx, id int64) error {
+	_, err := this.Query(tx).
+		Pk(id).
+		Set("state", MessageChannelStateDisabled).
+		Update()
+	return err
+}
+
+// 查找启用中的条目
+func (this *MessageChannelDAO) FindEnabledMessageChannel(tx *dbs.Tx, id int64) (*MessageChannel, error) {
+	result, err := this.Query(tx).
+		Pk(id).
+		Attr("state", MessageChannelStateEnabled).
+		Find()
+	if result == nil {
+		return nil, err
+	}
+	return result.(*MessageChannel), err
+}
+
+// 创建渠道
+func (this *MessageChannelDAO) CreateMessageChannel(tx *dbs.Tx, adminId int64, name string, channelType string, paramsJSON []byte, levels []string, messageTypes []string, rateLimit int32, dedupSeconds int32, isOn bool) (int64, error) {
+	op := NewMessageChannelOperator()
+	op.AdminId = adminId
+	op.Name = name
+	op.Type = channelType
+	op.Params = JSONBytes(paramsJSON)
+	err := this.fillFilters(op, levels, messageTypes)
+	if err != nil {
+		return 0, err
+	}
+	op.RateLimit = rateLimit
+	op.DedupSeconds = dedupSeconds
+	op.IsOn = isOn
+	op.State = MessageChannelStateEnabled
+	op.CreatedAt = time.Now().Unix()
+	err = this.Save(tx, op)
+	if err != nil {
+		return 0, err
+	}
+	return types.Int64(op.Id), nil
+}
+
+// 修改渠道
+func (this *MessageChannelDAO) UpdateMessageChannel(tx *dbs.Tx, channelId int64, name string, paramsJSON []byte, levels []string, messageTypes []string, rateLimit int32, dedupSeconds int32, isOn bool) error {
+	if channelId <= 0 {
+		return errors.New("invalid channelId")
+	}
+	op := NewMessageChannelOperator()
+	op.Id = channelId
+	op.Name = name
+
+	// 如果留空则表示不修改
+	if len(paramsJSON) > 0 {
+		op.Params = paramsJSON
+	}
+
+	err := this.fillFilters(op, levels, messageTypes)
+	if err != nil {
+		return err
+	}
+	op.RateLimit = rateLimit
+	op.DedupSeconds = dedupSeconds
+	op.IsOn = isOn
+	return this.Save(tx, op)
+}
+
+// 查找所有渠道
+func (this *MessageChannelDAO) FindAllEnabledMessageChannels(tx *dbs.Tx) (result []*MessageChannel, err error) {
+	_, err = this.Query(tx).
+		State(MessageChannelStateEnabled).
+		DescPk().
+		Slice(&result).
+		FindAll()
+	return
+}
+
+// 查找所有启用的渠道，用于发送消息
+func (this *MessageChannelDAO) FindAllEnabledAndOnMessageChannels(tx *dbs.Tx) (result []*MessageChannel, err error) {
+	_, err = this.Query(tx).
+		State(MessageChannelStateEnabled).
+		Attr("isOn", true).
+		AscPk().
+		Slice(&result).
+		FindAll()
+	return
+}
+
+// 设置过滤条件
+func (this *MessageChannelDAO) fillFilters(op *MessageChannelOperator, levels []string, messageTypes []string) error {
+	if levels == nil {
+		levels = []string{}
+	}
+	levelsJSON, err := json.Marshal(levels)
+	if err != nil {
+		return err
+	}
+	op.Levels = levelsJSON
+
+	if messageTypes == nil {
+		messageTypes = []string{}
+	}
+	messageTypesJSON, err := json.Marshal(messageTypes)
+	if err != nil {
+		return err
+	}
+	op.MessageTypes = messageTypesJSON
+	return nil
+}
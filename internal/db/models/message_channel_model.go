@@ -0,0 +1,36 @@
+package models
+
+// 消息通知渠道
+type MessageChannel struct {
+	Id           uint32 `field:"id"`           // ID
+	AdminId      uint32 `field:"adminId"`      // 管理员ID
+	Name         string `field:"name"`         // 名称
+	Type         string `field:"type"`         // 渠道类型
+	Params       string `field:"params"`       // 渠道参数
+	Levels       string `field:"levels"`       // 接收的消息级别，为空表示所有级别
+	MessageTypes string `field:"messageTypes"` // 接收的消息类型，为空表示所有类型
+	RateLimit    uint32 `field:"rateLimit"`    // 每分钟最多发送的消息数，0表示不限制
+	DedupSeconds uint32 `field:"dedupSeconds"` // 相同消息在此时间内不重复发送（秒）
+	IsOn         uint8  `field:"isOn"`         // 是否启用
+	State        uint8  `field:"state"`        // 状态
+	CreatedAt    uint64 `field:"createdAt"`    // 创建时间
+}
+
+type MessageChannelOperator struct {
+	Id           interface{} // ID
+	AdminId      interface{} // 管理员ID
+	Name         interface{} // 名称
+	Type         interface{} // 渠道类型
+	Params       interface{} // 渠道参数
+	Levels       interface{} // 接收的消息级别，为空表示所有级别
+	MessageTypes interface{} // 接收的消息类型，为空表示所有类型
+	RateLimit    interface{} // 每分钟最多发送的消息数，0表示不限制
+	DedupSeconds interface{} // 相同消息在此时间内不重复发送（秒）
+	IsOn         interface{} // 是否启用
+	State        interface{} // 状态
+	CreatedAt    interface{} // 创建时间
+}
+
+func NewMessageChannelOperator() *MessageChannelOperator {
+	return &MessageChannelOperator{}
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/iwind/TeaGo/lists"
+	"github.com/iwind/TeaGo/maps"
+)
+
+// 解析参数
+func (this *MessageChannel) DecodeParams() (maps.Map, error) {
+	if IsNotNull(this.Params) {
+		params := maps.Map{}
+		err := json.Unmarshal([]byte(this.Params), &params)
+		if err != nil {
+			return nil, err
+		}
+		return params, nil
+	}
+	return maps.Map{}, nil
+}
+
+// 解析接收的消息级别
+func (this *MessageChannel) DecodeLevels() []string {
+	return this.decodeStrings(this.Levels)
+}
+
+// 解析接收的消息类型
+func (this *MessageChannel) DecodeMessageTypes() []string {
+	return this.decodeStrings(this.MessageTypes)
+}
+
+// 判断消息是否需要通过此渠道发送
+func (this *MessageChannel) Match(level string, messageType string) bool {
+	levels := this.DecodeLevels()
+	if len(levels) > 0 && !lists.ContainsString(levels, level) {
+		return false
+	}
+	messageTypes := this.DecodeMessageTypes()
+	if len(messageTypes) > 0 && !lists.ContainsString(messageTypes, messageType) {
+		return false
+	}
+	return true
+}
+
+func (this *MessageChannel) decodeStrings(data string) []string {
+	result := []string{}
+	if IsNotNull(data) {
+		_ = json.Unmarshal([]byte(data), &result)
+	}
+	return result
+}
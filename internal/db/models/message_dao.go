@@ -37,6 +37,8 @@ const (
 	MessageTypeLogCapacityOverflow        MessageType = "LogCapacityOverflow"        // 日志超出最大限制
 	MessageTypeServerNamesAuditingSuccess MessageType = "ServerNamesAuditingSuccess" // 服务域名审核成功
 	MessageTypeServerNamesAuditingFailed  MessageType = "ServerNamesAuditingFailed"  // 服务域名审核失败
+	MessageTypeNodeClusterUpgradeHalted   MessageType = "NodeClusterUpgradeHalted"   // 集群升级因失败过多而停止
+	MessageTypeNodeClusterUpgradeDone     MessageType = "NodeClusterUpgradeDone"     // 集群升级完成
 )
 
 type MessageDAO dbs.DAO
@@ -120,6 +122,7 @@ func (this *MessageDAO) CreateMessage(tx *dbs.Tx, adminId int64, userId int64, m
 	}
 	op.State = MessageStateEnabled
 	op.IsRead = false
+	op.CreatedAt = time.Now().Unix()
 	op.Day = timeutil.Format("Ymd")
 	op.Hash = hash
 	err := this.Save(tx, op)
@@ -224,6 +227,26 @@ func (this *MessageDAO) CheckMessageUser(tx *dbs.Tx, messageId int64, adminId in
 	return query.Exist()
 }
 
+// 查找某个ID之后的消息，用于发送通知
+func (this *MessageDAO) FindMessagesAfterId(tx *dbs.Tx, messageId int64, size int64) (result []*Message, err error) {
+	_, err = this.Query(tx).
+		Gt("id", messageId).
+		State(MessageStateEnabled).
+		AscPk().
+		Limit(size).
+		Slice(&result).
+		FindAll()
+	return
+}
+
+// 查找最大的消息ID
+func (this *MessageDAO) FindMaxMessageId(tx *dbs.Tx) (int64, error) {
+	return this.Query(tx).
+		ResultPk().
+		DescPk().
+		FindInt64Col(0)
+}
+
 // 创建消息
 func (this *MessageDAO) createMessage(tx *dbs.Tx, clusterId int64, nodeId int64, messageType MessageType, level string, body string, paramsJSON []byte) (int64, error) {
 	h := md5.New()
@@ -0,0 +1,105 @@
+package models
+
+import (
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/iwind/TeaGo/Tea"
+	"github.com/iwind/TeaGo/dbs"
+	timeutil "github.com/iwind/TeaGo/utils/time"
+	"time"
+)
+
+type MessageDeliveryDAO dbs.DAO
+
+func NewMessageDeliveryDAO() *MessageDeliveryDAO {
+	return dbs.NewDAO(&MessageDeliveryDAO{
+		DAOObject: dbs.DAOObject{
+			DB:     Tea.Env,
+			Table:  "edgeMessageDeliveries",
+			Model:  new(MessageDelivery),
+			PkName: "id",
+		},
+	}).(*MessageDeliveryDAO)
+}
+
+var SharedMessageDeliveryDAO *MessageDeliveryDAO
+
+func init() {
+	dbs.OnReady(func() {
+		SharedMessageDeliveryDAO = NewMessageDeliveryDAO()
+	})
+}
+
+// 记录发送结果
+func (this *MessageDeliveryDAO) CreateDelivery(tx *dbs.Tx, channelId int64, messageId int64, hash string, isOk bool, errString string) error {
+	if len(errString) > 1024 {
+		errString = errString[:1024]
+	}
+	op := NewMessageDeliveryOperator()
+	op.ChannelId = channelId
+	op.MessageId = messageId
+	op.Hash = hash
+	op.IsOk = isOk
+	op.Error = errString
+	op.CreatedAt = time.Now().Unix()
+	op.Day = timeutil.Format("Ymd")
+	return this.Save(tx, op)
+}
+
+// 计算某个渠道在某个时间之后成功发送的消息数量
+func (this *MessageDeliveryDAO) CountChannelDeliveriesSince(tx *dbs.Tx, channelId int64, timestamp int64) (int64, error) {
+	return this.Query(tx).
+		Attr("channelId", channelId).
+		Attr("isOk", true).
+		Gte("createdAt", timestamp).
+		Count()
+}
+
+// 判断某个渠道在某个时间之后是否成功发送过同样的消息
+func (this *MessageDeliveryDAO) ExistsChannelDeliveryWithHash(tx *dbs.Tx, channelId int64, hash string, timestamp int64) (bool, error) {
+	return this.Query(tx).
+		Attr("channelId", channelId).
+		Attr("hash", hash).
+		Attr("isOk", true).
+		Gte("createdAt", timestamp).
+		Exist()
+}
+
+// 计算发送记录数量
+func (this *MessageDeliveryDAO) CountDeliveries(tx *dbs.Tx, channelId int64, messageId int64) (int64, error) {
+	query := this.Query(tx)
+	if channelId > 0 {
+		query.Attr("channelId", channelId)
+	}
+	if messageId > 0 {
+		query.Attr("messageId", messageId)
+	}
+	return query.Count()
+}
+
+// 列出单页发送记录
+func (this *MessageDeliveryDAO) ListDeliveries(tx *dbs.Tx, channelId int64, messageId int64, offset int64, size int64) (result []*MessageDelivery, err error) {
+	query := this.Query(tx)
+	if channelId > 0 {
+		query.Attr("channelId", channelId)
+	}
+	if messageId > 0 {
+		query.Attr("messageId", messageId)
+	}
+	_, err = query.
+		Offset(offset).
+		Limit(size).
+		DescPk().
+		Slice(&result).
+		FindAll()
+	return
+}
+
+// 删除某天之前的发送记录
+func (this *MessageDeliveryDAO) DeleteDeliveriesBeforeDay(tx *dbs.Tx, dayTime time.Time) error {
+	day := timeutil.Format("Ymd", dayTime)
+	_, err := this.Query(tx).
+		Where("day<:day").
+		Param("day", day).
+		Delete()
+	return err
+}
@@ -0,0 +1,28 @@
+package models
+
+// 消息发送记录
+type MessageDelivery struct {
+	Id        uint64 `field:"id"`        // ID
+	ChannelId uint32 `field:"channelId"` // 渠道ID
+	MessageId uint64 `field:"messageId"` // 消息ID
+	Hash      string `field:"hash"`      // 消息内容的Hash
+	IsOk      uint8  `field:"isOk"`      // 是否成功
+	Error     string `field:"error"`     // 错误信息
+	CreatedAt uint64 `field:"createdAt"` // 创建时间
+	Day       string `field:"day"`       // 日期YYYYMMDD
+}
+
+type MessageDeliveryOperator struct {
+	Id        interface{} // ID
+	ChannelId interface{} // 渠道ID
+	MessageId interface{} // 消息ID
+	Hash      interface{} // 消息内容的Hash
+	IsOk      interface{} // 是否成功
+	Error     interface{} // 错误信息
+	CreatedAt interface{} // 创建时间
+	Day       interface{} // 日期YYYYMMDD
+}
+
+func NewMessageDeliveryOperator() *MessageDeliveryOperator {
+	return &MessageDeliveryOperator{}
+}
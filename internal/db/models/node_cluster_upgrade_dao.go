@@ -0,0 +1,178 @@
+package models
+
+import (
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/iwind/TeaGo/Tea"
+	"github.com/iwind/TeaGo/dbs"
+	"github.com/iwind/TeaGo/types"
+	"time"
+)
+
+const (
+	NodeClusterUpgradeStateEnabled  = 1 // 已启用
+	NodeClusterUpgradeStateDisabled = 0 // 已禁用
+)
+
+// 升级状态
+type NodeClusterUpgradeStatus = string
+
+const (
+	NodeClusterUpgradeStatusRunning   NodeClusterUpgradeStatus = "running"   // 升级中
+	NodeClusterUpgradeStatusPaused    NodeClusterUpgradeStatus = "paused"    // 已暂停
+	NodeClusterUpgradeStatusHalted    NodeClusterUpgradeStatus = "halted"    // 失败过多而自动停止
+	NodeClusterUpgradeStatusCancelled NodeClusterUpgradeStatus = "cancelled" // 已取消
+	NodeClusterUpgradeStatusDone      NodeClusterUpgradeStatus = "done"      // 已完成
+)
+
+type NodeClusterUpgradeDAO dbs.DAO
+
+func NewNodeClusterUpgradeDAO() *NodeClusterUpgradeDAO {
+	return dbs.NewDAO(&NodeClusterUpgradeDAO{
+		DAOObject: dbs.DAOObject{
+			DB:     Tea.Env,
+			Table:  "edgeNodeClusterUpgrades",
+			Model:  new(NodeClusterUpgrade),
+			PkName: "id",
+		},
+	}).(*NodeClusterUpgradeDAO)
+}
+
+var SharedNodeClusterUpgradeDAO *NodeClusterUpgradeDAO
+
+func init() {
+	dbs.OnReady(func() {
+		SharedNodeClusterUpgradeDAO = NewNodeClusterUpgradeDAO()
+	})
+}
+
+// 创建升级任务
+func (this *NodeClusterUpgradeDAO) CreateUpgrade(tx *dbs.Tx, adminId int64, clusterId int64, batchSize int32, batchDelay int32, maxFailures int32, nodeTimeout int32, removeFromDNS bool) (int64, error) {
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	if batchDelay < 0 {
+		batchDelay = 0
+	}
+	if maxFailures <= 0 {
+		maxFailures = 1
+	}
+	if nodeTimeout <= 0 {
+		nodeTimeout = 1800
+	}
+
+	op := NewNodeClusterUpgradeOperator()
+	op.AdminId = adminId
+	op.ClusterId = clusterId
+	op.BatchSize = batchSize
+	op.BatchDelay = batchDelay
+	op.MaxFailures = maxFailures
+	op.NodeTimeout = nodeTimeout
+	op.RemoveFromDNS = removeFromDNS
+	op.Status = NodeClusterUpgradeStatusRunning
+	op.Batch = 0
+	op.NextBatchAt = time.Now().Unix()
+	op.CreatedAt = time.Now().Unix()
+	op.UpdatedAt = time.Now().Unix()
+	op.State = NodeClusterUpgradeStateEnabled
+	err := this.Save(tx, op)
+	if err != nil {
+		return 0, err
+	}
+	return types.Int64(op.Id), nil
+}
+
+// 查找升级任务
+func (this *NodeClusterUpgradeDAO) FindEnabledUpgrade(tx *dbs.Tx, upgradeId int64) (*NodeClusterUpgrade, error) {
+	one, err := this.Query(tx).
+		Pk(upgradeId).
+		State(NodeClusterUpgradeStateEnabled).
+		Find()
+	if err != nil || one == nil {
+		return nil, err
+	}
+	return one.(*NodeClusterUpgrade), nil
+}
+
+// 查找集群正在进行或暂停中的升级任务
+func (this *NodeClusterUpgradeDAO) FindActiveUpgradeWithClusterId(tx *dbs.Tx, clusterId int64) (*NodeClusterUpgrade, error) {
+	one, err := this.Query(tx).
+		Attr("clusterId", clusterId).
+		State(NodeClusterUpgradeStateEnabled).
+		Where("(status=:statusRunning OR status=:statusPaused)").
+		Param("statusRunning", NodeClusterUpgradeStatusRunning).
+		Param("statusPaused", NodeClusterUpgradeStatusPaused).
+		DescPk().
+		Find()
+	if err != nil || one == nil {
+		return nil, err
+	}
+	return one.(*NodeClusterUpgrade), nil
+}
+
+// 查找所有正在进行或暂停中的升级任务
+func (this *NodeClusterUpgradeDAO) FindAllActiveUpgrades(tx *dbs.Tx) (result []*NodeClusterUpgrade, err error) {
+	_, err = this.Query(tx).
+		State(NodeClusterUpgradeStateEnabled).
+		Where("(status=:statusRunning OR status=:statusPaused)").
+		Param("statusRunning", NodeClusterUpgradeStatusRunning).
+		Param("statusPaused", NodeClusterUpgradeStatusPaused).
+		AscPk().
+		Slice(&result).
+		FindAll()
+	return
+}
+
+// 查找集群最近的升级任务
+func (this *NodeClusterUpgradeDAO) FindLatestUpgradesWithClusterId(tx *dbs.Tx, clusterId int64, size int64) (result []*NodeClusterUpgrade, err error) {
+	if size <= 0 {
+		size = 10
+	}
+	_, err = this.Query(tx).
+		Attr("clusterId", clusterId).
+		State(NodeClusterUpgradeStateEnabled).
+		DescPk().
+		Limit(size).
+		Slice(&result).
+		FindAll()
+	return
+}
+
+// 修改升级任务状态
+func (this *NodeClusterUpgradeDAO) UpdateUpgradeStatus(tx *dbs.Tx, upgradeId int64, status NodeClusterUpgradeStatus, errString string) error {
+	if len(errString) > 1024 {
+		errString = errString[:1024]
+	}
+	query := this.Query(tx).
+		Pk(upgradeId).
+		Set("status", status).
+		Set("error", errString).
+		Set("updatedAt", time.Now().Unix())
+	switch status {
+	case NodeClusterUpgradeStatusHalted, NodeClusterUpgradeStatusCancelled, NodeClusterUpgradeStatusDone:
+		query.Set("finishedAt", time.Now().Unix())
+	default:
+		query.Set("finishedAt", 0)
+	}
+	_, err := query.Update()
+	return err
+}
+
+// 进入下一个批次
+func (this *NodeClusterUpgradeDAO) UpdateUpgradeBatch(tx *dbs.Tx, upgradeId int64, batch int32) error {
+	_, err := this.Query(tx).
+		Pk(upgradeId).
+		Set("batch", batch).
+		Set("updatedAt", time.Now().Unix()).
+		Update()
+	return err
+}
+
+// 设置下一个批次开始时间
+func (this *NodeClusterUpgradeDAO) UpdateUpgradeNextBatchAt(tx *dbs.Tx, upgradeId int64, nextBatchAt int64) error {
+	_, err := this.Query(tx).
+		Pk(upgradeId).
+		Set("nextBatchAt", nextBatchAt).
+		Set("updatedAt", time.Now().Unix()).
+		Update()
+	return err
+}
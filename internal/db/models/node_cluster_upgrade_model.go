@@ -0,0 +1,44 @@
+package models
+
+// 集群滚动升级
+type NodeClusterUpgrade struct {
+	Id            uint32 `field:"id"`            // ID
+	AdminId       uint32 `field:"adminId"`       // 管理员ID
+	ClusterId     uint32 `field:"clusterId"`     // 集群ID
+	BatchSize     uint32 `field:"batchSize"`     // 每批升级的节点数
+	BatchDelay    uint32 `field:"batchDelay"`    // 批次之间的间隔（秒）
+	MaxFailures   uint32 `field:"maxFailures"`   // 单批允许的最大失败数
+	NodeTimeout   uint32 `field:"nodeTimeout"`   // 单个节点升级超时时间（秒）
+	RemoveFromDNS uint8  `field:"removeFromDNS"` // 升级前是否从DNS中移除
+	Status        string `field:"status"`        // 状态
+	Batch         uint32 `field:"batch"`         // 当前批次
+	NextBatchAt   uint64 `field:"nextBatchAt"`   // 下一批次开始时间
+	Error         string `field:"error"`         // 错误信息
+	CreatedAt     uint64 `field:"createdAt"`     // 创建时间
+	UpdatedAt     uint64 `field:"updatedAt"`     // 修改时间
+	FinishedAt    uint64 `field:"finishedAt"`    // 结束时间
+	State         uint8  `field:"state"`         // 状态
+}
+
+type NodeClusterUpgradeOperator struct {
+	Id            interface{} // ID
+	AdminId       interface{} // 管理员ID
+	ClusterId     interface{} // 集群ID
+	BatchSize     interface{} // 每批升级的节点数
+	BatchDelay    interface{} // 批次之间的间隔（秒）
+	MaxFailures   interface{} // 单批允许的最大失败数
+	NodeTimeout   interface{} // 单个节点升级超时时间（秒）
+	RemoveFromDNS interface{} // 升级前是否从DNS中移除
+	Status        interface{} // 状态
+	Batch         interface{} // 当前批次
+	NextBatchAt   interface{} // 下一批次开始时间
+	Error         interface{} // 错误信息
+	CreatedAt     interface{} // 创建时间
+	UpdatedAt     interface{} // 修改时间
+	FinishedAt    interface{} // 结束时间
+	State         interface{} // 状态
+}
+
+func NewNodeClusterUpgradeOperator() *NodeClusterUpgradeOperator {
+	return &NodeClusterUpgradeOperator{}
+}
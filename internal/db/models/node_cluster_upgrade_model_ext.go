@@ -0,0 +1,6 @@
+package models
+
+// 是否仍在进行中
+func (this *NodeClusterUpgrade) IsRunning() bool {
+	return this.Status == NodeClusterUpgradeStatusRunning
+}
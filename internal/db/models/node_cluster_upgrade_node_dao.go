@@ -0,0 +1,158 @@
+package models
+
+import (
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/iwind/TeaGo/Tea"
+	"github.com/iwind/TeaGo/dbs"
+	"time"
+)
+
+// 节点升级状态
+type NodeClusterUpgradeNodeStatus = string
+
+const (
+	NodeClusterUpgradeNodeStatusPending   NodeClusterUpgradeNodeStatus = "pending"   // 等待升级
+	NodeClusterUpgradeNodeStatusUpgrading NodeClusterUpgradeNodeStatus = "upgrading" // 升级中
+	NodeClusterUpgradeNodeStatusOk        NodeClusterUpgradeNodeStatus = "ok"        // 升级成功
+	NodeClusterUpgradeNodeStatusFailed    NodeClusterUpgradeNodeStatus = "failed"    // 升级失败
+	NodeClusterUpgradeNodeStatusSkipped   NodeClusterUpgradeNodeStatus = "skipped"   // 任务停止后跳过
+)
+
+type NodeClusterUpgradeNodeDAO dbs.DAO
+
+func NewNodeClusterUpgradeNodeDAO() *NodeClusterUpgradeNodeDAO {
+	return dbs.NewDAO(&NodeClusterUpgradeNodeDAO{
+		DAOObject: dbs.DAOObject{
+			DB:     Tea.Env,
+			Table:  "edgeNodeClusterUpgradeNodes",
+			Model:  new(NodeClusterUpgradeNode),
+			PkName: "id",
+		},
+	}).(*NodeClusterUpgradeNodeDAO)
+}
+
+var SharedNodeClusterUpgradeNodeDAO *NodeClusterUpgradeNodeDAO
+
+func init() {
+	dbs.OnReady(func() {
+		SharedNodeClusterUpgradeNodeDAO = NewNodeClusterUpgradeNodeDAO()
+	})
+}
+
+// 添加需要升级的节点
+func (this *NodeClusterUpgradeNodeDAO) CreateUpgradeNode(tx *dbs.Tx, upgradeId int64, clusterId int64, nodeId int64, os string, arch string, oldVersion string, newVersion string) error {
+	op := NewNodeClusterUpgradeNodeOperator()
+	op.UpgradeId = upgradeId
+	op.ClusterId = clusterId
+	op.NodeId = nodeId
+	op.Os = os
+	op.Arch = arch
+	op.OldVersion = oldVersion
+	op.NewVersion = newVersion
+	op.Status = NodeClusterUpgradeNodeStatusPending
+	return this.Save(tx, op)
+}
+
+// 查找升级任务中的所有节点
+func (this *NodeClusterUpgradeNodeDAO) FindAllUpgradeNodes(tx *dbs.Tx, upgradeId int64) (result []*NodeClusterUpgradeNode, err error) {
+	_, err = this.Query(tx).
+		Attr("upgradeId", upgradeId).
+		AscPk().
+		Slice(&result).
+		FindAll()
+	return
+}
+
+// 查找某个状态的节点
+func (this *NodeClusterUpgradeNodeDAO) FindAllUpgradeNodesWithStatus(tx *dbs.Tx, upgradeId int64, status NodeClusterUpgradeNodeStatus) (result []*NodeClusterUpgradeNode, err error) {
+	_, err = this.Query(tx).
+		Attr("upgradeId", upgradeId).
+		Attr("status", status).
+		AscPk().
+		Slice(&result).
+		FindAll()
+	return
+}
+
+// 查找等待升级的节点
+func (this *NodeClusterUpgradeNodeDAO) FindPendingUpgradeNodes(tx *dbs.Tx, upgradeId int64, size int64) (result []*NodeClusterUpgradeNode, err error) {
+	_, err = this.Query(tx).
+		Attr("upgradeId", upgradeId).
+		Attr("status", NodeClusterUpgradeNodeStatusPending).
+		AscPk().
+		Limit(size).
+		Slice(&result).
+		FindAll()
+	return
+}
+
+// 计算某个批次中升级失败的节点数
+func (this *NodeClusterUpgradeNodeDAO) CountFailedUpgradeNodesWithBatch(tx *dbs.Tx, upgradeId int64, batch int32) (int64, error) {
+	return this.Query(tx).
+		Attr("upgradeId", upgradeId).
+		Attr("batch", batch).
+		Attr("status", NodeClusterUpgradeNodeStatusFailed).
+		Count()
+}
+
+// 开始升级节点
+func (this *NodeClusterUpgradeNodeDAO) StartUpgradeNode(tx *dbs.Tx, upgradeNodeId int64, batch int32, wasUp bool, isRemovedFromDNS bool) error {
+	_, err := this.Query(tx).
+		Pk(upgradeNodeId).
+		Set("batch", batch).
+		Set("status", NodeClusterUpgradeNodeStatusUpgrading).
+		Set("wasUp", wasUp).
+		Set("isRemovedFromDNS", isRemovedFromDNS).
+		Set("countTries", 1).
+		Set("startedAt", time.Now().Unix()).
+		Update()
+	return err
+}
+
+// 增加安装尝试次数
+func (this *NodeClusterUpgradeNodeDAO) IncreaseUpgradeNodeTries(tx *dbs.Tx, upgradeNodeId int64) error {
+	_, err := this.Query(tx).
+		Pk(upgradeNodeId).
+		Set("countTries", dbs.SQL("countTries+1")).
+		Update()
+	return err
+}
+
+// 结束升级节点
+func (this *NodeClusterUpgradeNodeDAO) FinishUpgradeNode(tx *dbs.Tx, upgradeNodeId int64, isOk bool, errString string, isRemovedFromDNS bool) error {
+	if len(errString) > 1024 {
+		errString = errString[:1024]
+	}
+	status := NodeClusterUpgradeNodeStatusOk
+	if !isOk {
+		status = NodeClusterUpgradeNodeStatusFailed
+	}
+	_, err := this.Query(tx).
+		Pk(upgradeNodeId).
+		Set("status", status).
+		Set("error", errString).
+		Set("isRemovedFromDNS", isRemovedFromDNS).
+		Set("finishedAt", time.Now().Unix()).
+		Update()
+	return err
+}
+
+// 跳过所有等待升级的节点
+func (this *NodeClusterUpgradeNodeDAO) SkipPendingUpgradeNodes(tx *dbs.Tx, upgradeId int64) error {
+	_, err := this.Query(tx).
+		Attr("upgradeId", upgradeId).
+		Attr("status", NodeClusterUpgradeNodeStatusPending).
+		Set("status", NodeClusterUpgradeNodeStatusSkipped).
+		Update()
+	return err
+}
+
+// 判断节点是否因为升级而被从DNS中移除
+// 健康检查不应该在升级过程中把节点重新加入DNS
+func (this *NodeClusterUpgradeNodeDAO) ExistsNodeRemovedFromDNS(tx *dbs.Tx, nodeId int64) (bool, error) {
+	return this.Query(tx).
+		Attr("nodeId", nodeId).
+		Attr("status", NodeClusterUpgradeNodeStatusUpgrading).
+		Attr("isRemovedFromDNS", true).
+		Exist()
+}
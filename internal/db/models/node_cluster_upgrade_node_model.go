@@ -0,0 +1,44 @@
+package models
+
+// 集群滚动升级中的节点
+type NodeClusterUpgradeNode struct {
+	Id               uint64 `field:"id"`               // ID
+	UpgradeId        uint32 `field:"upgradeId"`        // 升级任务ID
+	ClusterId        uint32 `field:"clusterId"`        // 集群ID
+	NodeId           uint32 `field:"nodeId"`           // 节点ID
+	Os               string `field:"os"`               // 操作系统
+	Arch             string `field:"arch"`             // 架构
+	OldVersion       string `field:"oldVersion"`       // 原版本
+	NewVersion       string `field:"newVersion"`       // 目标版本
+	Batch            uint32 `field:"batch"`            // 批次
+	Status           string `field:"status"`           // 状态
+	CountTries       uint32 `field:"countTries"`       // 安装尝试次数
+	WasUp            uint8  `field:"wasUp"`            // 升级前是否在线
+	IsRemovedFromDNS uint8  `field:"isRemovedFromDNS"` // 是否已从DNS中移除
+	Error            string `field:"error"`            // 错误信息
+	StartedAt        uint64 `field:"startedAt"`        // 开始时间
+	FinishedAt       uint64 `field:"finishedAt"`       // 结束时间
+}
+
+type NodeClusterUpgradeNodeOperator struct {
+	Id               interface{} // ID
+	UpgradeId        interface{} // 升级任务ID
+	ClusterId        interface{} // 集群ID
+	NodeId           interface{} // 节点ID
+	Os               interface{} // 操作系统
+	Arch             interface{} // 架构
+	OldVersion       interface{} // 原版本
+	NewVersion       interface{} // 目标版本
+	Batch            interface{} // 批次
+	Status           interface{} // 状态
+	CountTries       interface{} // 安装尝试次数
+	WasUp            interface{} // 升级前是否在线
+	IsRemovedFromDNS interface{} // 是否已从DNS中移除
+	Error            interface{} // 错误信息
+	StartedAt        interface{} // 开始时间
+	FinishedAt       interface{} // 结束时间
+}
+
+func NewNodeClusterUpgradeNodeOperator() *NodeClusterUpgradeNodeOperator {
+	return &NodeClusterUpgradeNodeOperator{}
+}
@@ -0,0 +1,6 @@
+package models
+
+// 是否已结束
+func (this *NodeClusterUpgradeNode) IsFinished() bool {
+	return this.Status == NodeClusterUpgradeNodeStatusOk || this.Status == NodeClusterUpgradeNodeStatusFailed
+}
@@ -90,6 +90,12 @@ func (this *NodeDAO) DisableNode(tx *dbs.Tx, nodeId int64) (err error) {
 		return err
 	}
 
+	// 删除健康检查结果
+	err = SharedNodeHealthCheckResultDAO.DeleteNodeHealthCheckResults(tx, nodeId)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -614,6 +620,78 @@ func (this *NodeDAO) UpdateNodeConnectedAPINodes(tx *dbs.Tx, nodeId int64, apiNo
 	return err
 }
 
+// 查找节点当前连接的API节点
+func (this *NodeDAO) FindNodeConnectedAPINodeIds(tx *dbs.Tx, nodeId int64) ([]int64, error) {
+	connectedAPINodes, err := this.Query(tx).
+		Pk(nodeId).
+		Result("connectedAPINodes").
+		FindStringCol("")
+	if err != nil {
+		return nil, err
+	}
+	node := &Node{ConnectedAPINodes: connectedAPINodes}
+	return node.DecodeConnectedAPINodeIds()
+}
+
+// 添加节点连接的API节点
+// 在API节点和边缘节点建立Stream连接时调用
+func (this *NodeDAO) AddNodeConnectedAPINode(tx *dbs.Tx, nodeId int64, apiNodeId int64) error {
+	return this.updateNodeConnectedAPINodesWithLock(tx, nodeId, func(apiNodeIds []int64) ([]int64, bool) {
+		for _, id := range apiNodeIds {
+			if id == apiNodeId {
+				return nil, false
+			}
+		}
+		return append(apiNodeIds, apiNodeId), true
+	})
+}
+
+// 删除节点连接的API节点
+// 在API节点和边缘节点的Stream连接断开时调用
+func (this *NodeDAO) RemoveNodeConnectedAPINode(tx *dbs.Tx, nodeId int64, apiNodeId int64) error {
+	return this.updateNodeConnectedAPINodesWithLock(tx, nodeId, func(apiNodeIds []int64) ([]int64, bool) {
+		result := []int64{}
+		for _, id := range apiNodeIds {
+			if id != apiNodeId {
+				result = append(result, id)
+			}
+		}
+		return result, len(result) != len(apiNodeIds)
+	})
+}
+
+// 锁定节点记录后修改连接的API节点
+// 多个API节点可能同时修改同一个节点，需要在事务中先锁定记录再读取和写入，防止互相覆盖
+func (this *NodeDAO) updateNodeConnectedAPINodesWithLock(tx *dbs.Tx, nodeId int64, updateFunc func(apiNodeIds []int64) (newAPINodeIds []int64, changed bool)) error {
+	if nodeId <= 0 {
+		return errors.New("invalid nodeId")
+	}
+	if tx == nil {
+		return this.Instance.RunTx(func(tx *dbs.Tx) error {
+			return this.updateNodeConnectedAPINodesWithLock(tx, nodeId, updateFunc)
+		})
+	}
+
+	// 通过一次不改变数据的UPDATE获得行锁，直到事务结束才会释放
+	_, err := this.Query(tx).
+		Pk(nodeId).
+		Set("connectedAPINodes", dbs.SQL("connectedAPINodes")).
+		Update()
+	if err != nil {
+		return err
+	}
+
+	apiNodeIds, err := this.FindNodeConnectedAPINodeIds(tx, nodeId)
+	if err != nil {
+		return err
+	}
+	newAPINodeIds, changed := updateFunc(apiNodeIds)
+	if !changed {
+		return nil
+	}
+	return this.UpdateNodeConnectedAPINodes(tx, nodeId, newAPINodeIds)
+}
+
 // 根据UniqueId获取ID
 func (this *NodeDAO) FindEnabledNodeIdWithUniqueId(tx *dbs.Tx, uniqueId string) (int64, error) {
 	return this.Query(tx).
@@ -887,6 +965,23 @@ func (this *NodeDAO) UpdateNodeUp(tx *dbs.Tx, nodeId int64, isUp bool, maxUp int
 	return
 }
 
+// 直接设置节点上线|下线状态，并重置计数
+func (this *NodeDAO) UpdateNodeIsUp(tx *dbs.Tx, nodeId int64, isUp bool) error {
+	if nodeId <= 0 {
+		return errors.New("invalid nodeId")
+	}
+	_, err := this.Query(tx).
+		Pk(nodeId).
+		Set("isUp", isUp).
+		Set("countUp", 0).
+		Set("countDown", 0).
+		Update()
+	if err != nil {
+		return err
+	}
+	return this.NotifyDNSUpdate(tx, nodeId)
+}
+
 // 修改节点活跃状态
 func (this *NodeDAO) UpdateNodeActive(tx *dbs.Tx, nodeId int64, isActive bool) error {
 	if nodeId <= 0 {
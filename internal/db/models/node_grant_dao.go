@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"errors"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/iwind/TeaGo/Tea"
@@ -73,7 +74,7 @@ func (this *NodeGrantDAO) FindNodeGrantName(tx *dbs.Tx, id uint32) (string, erro
 }
 
 // 创建认证信息
-func (this *NodeGrantDAO) CreateGrant(tx *dbs.Tx, adminId int64, name string, method string, username string, password string, privateKey string, description string, nodeId int64) (grantId int64, err error) {
+func (this *NodeGrantDAO) CreateGrant(tx *dbs.Tx, adminId int64, name string, method string, username string, password string, privateKey string, passphrase string, sudo bool, sudoPassword string, knownHosts string, jumpHostsJSON []byte, description string, nodeId int64) (grantId int64, err error) {
 	op := NewNodeGrantOperator()
 	op.AdminId = adminId
 	op.Name = name
@@ -85,8 +86,14 @@ func (this *NodeGrantDAO) CreateGrant(tx *dbs.Tx, adminId int64, name string, me
 		op.Password = password
 		op.Su = false // TODO 需要做到前端可以配置
 	case "privateKey":
+		op.Username = username
 		op.PrivateKey = privateKey
+		op.Passphrase = passphrase
 	}
+	op.Sudo = sudo
+	op.SudoPassword = sudoPassword
+	op.KnownHosts = knownHosts
+	op.JumpHosts = JSONBytes(jumpHostsJSON)
 	op.Description = description
 	op.NodeId = nodeId
 	op.State = NodeGrantStateEnabled
@@ -95,7 +102,7 @@ func (this *NodeGrantDAO) CreateGrant(tx *dbs.Tx, adminId int64, name string, me
 }
 
 // 修改认证信息
-func (this *NodeGrantDAO) UpdateGrant(tx *dbs.Tx, grantId int64, name string, method string, username string, password string, privateKey string, description string, nodeId int64) error {
+func (this *NodeGrantDAO) UpdateGrant(tx *dbs.Tx, grantId int64, name string, method string, username string, password string, privateKey string, passphrase string, sudo bool, sudoPassword string, knownHosts string, jumpHostsJSON []byte, description string, nodeId int64) error {
 	if grantId <= 0 {
 		return errors.New("invalid grantId")
 	}
@@ -111,11 +118,68 @@ func (this *NodeGrantDAO) UpdateGrant(tx *dbs.Tx, grantId int64, name string, me
 		op.Password = password
 		op.Su = false // TODO 需要做到前端可以配置
 	case "privateKey":
+		op.Username = username
 		op.PrivateKey = privateKey
+		op.Passphrase = passphrase
+	}
+	op.Sudo = sudo
+	op.SudoPassword = sudoPassword
+	op.KnownHosts = knownHosts
+
+	// 保留已信任的跳板机主机公钥指纹
+	jumpHosts, err := DecodeNodeGrantJumpHosts(jumpHostsJSON)
+	if err != nil {
+		return err
+	}
+	grant, err := this.FindEnabledNodeGrant(tx, grantId)
+	if err != nil {
+		return err
+	}
+	if grant != nil {
+		oldJumpHosts, err := grant.DecodeJumpHosts()
+		if err != nil {
+			return err
+		}
+		keepNodeGrantJumpHostKeys(oldJumpHosts, jumpHosts)
 	}
+	jumpHostsJSON, err = json.Marshal(jumpHosts)
+	if err != nil {
+		return err
+	}
+	op.JumpHosts = jumpHostsJSON
+
 	op.Description = description
 	op.NodeId = nodeId
-	err := this.Save(tx, op)
+	err = this.Save(tx, op)
+	return err
+}
+
+// 信任跳板机的主机公钥指纹
+func (this *NodeGrantDAO) UpdateGrantJumpHostKey(tx *dbs.Tx, grantId int64, host string, port int, fingerprint string) error {
+	grant, err := this.FindEnabledNodeGrant(tx, grantId)
+	if err != nil {
+		return err
+	}
+	if grant == nil {
+		return nil
+	}
+	jumpHosts, err := grant.DecodeJumpHosts()
+	if err != nil {
+		return err
+	}
+	for _, jumpHost := range jumpHosts {
+		if jumpHost.Host == host && jumpHost.Port == port {
+			jumpHost.HostKey = fingerprint
+		}
+	}
+	jumpHostsJSON, err := json.Marshal(jumpHosts)
+	if err != nil {
+		return err
+	}
+	_, err = this.Query(tx).
+		Pk(grantId).
+		Set("jumpHosts", jumpHostsJSON).
+		Update()
 	return err
 }
 
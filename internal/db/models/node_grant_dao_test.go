@@ -2,4 +2,32 @@ package models
 
 import (
 	_ "github.com/go-sql-driver/mysql"
+	"testing"
 )
+
+func TestKeepNodeGrantJumpHostKeys(t *testing.T) {
+	oldJumpHosts := []*NodeGrantJumpHost{
+		{Host: "192.168.1.100", Port: 22, HostKey: "SHA256:a"},
+		{Host: "192.168.1.101", Port: 22, HostKey: "SHA256:b"},
+		{Host: "192.168.1.103", Port: 22, HostKey: "SHA256:d"},
+	}
+	newJumpHosts := []*NodeGrantJumpHost{
+		{Host: "192.168.1.100", Port: 22},
+		{Host: "192.168.1.101", Port: 2222, HostKey: "SHA256:b"},
+		{Host: "192.168.1.102", Port: 22, HostKey: "SHA256:c"},
+		{Host: "192.168.1.103", Port: 22, HostKey: "SHA256:e"},
+	}
+	keepNodeGrantJumpHostKeys(oldJumpHosts, newJumpHosts)
+	if newJumpHosts[0].HostKey != "SHA256:a" {
+		t.Fatal("host key of unchanged jump host should be kept, got '" + newJumpHosts[0].HostKey + "'")
+	}
+	if newJumpHosts[1].HostKey != "" {
+		t.Fatal("host key of changed jump host should be cleared")
+	}
+	if newJumpHosts[2].HostKey != "SHA256:c" {
+		t.Fatal("host key of new jump host should be kept, got '" + newJumpHosts[2].HostKey + "'")
+	}
+	if newJumpHosts[3].HostKey != "SHA256:e" {
+		t.Fatal("host key changed by admin should be kept, got '" + newJumpHosts[3].HostKey + "'")
+	}
+}
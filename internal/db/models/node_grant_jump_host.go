@@ -0,0 +1,12 @@
+package models
+
+// 跳板机配置
+type NodeGrantJumpHost struct {
+	Host       string `json:"host"`
+	Port       int    `json:"port"`
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	PrivateKey string `json:"privateKey"`
+	Passphrase string `json:"passphrase"` // 密钥密码
+	HostKey    string `json:"hostKey"`    // 已信任的主机公钥指纹
+}
@@ -2,33 +2,43 @@ package models
 
 // 节点授权
 type NodeGrant struct {
-	Id          uint32 `field:"id"`          // ID
-	AdminId     uint32 `field:"adminId"`     // 管理员ID
-	Name        string `field:"name"`        // 名称
-	Method      string `field:"method"`      // 登录方式
-	Username    string `field:"username"`    // 用户名
-	Password    string `field:"password"`    // 密码
-	Su          uint8  `field:"su"`          // 是否需要su
-	PrivateKey  string `field:"privateKey"`  // 密钥
-	Description string `field:"description"` // 备注
-	NodeId      uint32 `field:"nodeId"`      // 专有节点
-	State       uint8  `field:"state"`       // 状态
-	CreatedAt   uint64 `field:"createdAt"`   // 创建时间
+	Id           uint32 `field:"id"`           // ID
+	AdminId      uint32 `field:"adminId"`      // 管理员ID
+	Name         string `field:"name"`         // 名称
+	Method       string `field:"method"`       // 登录方式
+	Username     string `field:"username"`     // 用户名
+	Password     string `field:"password"`     // 密码
+	Su           uint8  `field:"su"`           // 是否需要su
+	Sudo         uint8  `field:"sudo"`         // 是否使用sudo执行命令
+	SudoPassword string `field:"sudoPassword"` // sudo密码
+	PrivateKey   string `field:"privateKey"`   // 密钥
+	Passphrase   string `field:"passphrase"`   // 密钥密码
+	KnownHosts   string `field:"knownHosts"`   // 预置的主机公钥指纹或known_hosts内容
+	JumpHosts    string `field:"jumpHosts"`    // 跳板机
+	Description  string `field:"description"`  // 备注
+	NodeId       uint32 `field:"nodeId"`       // 专有节点
+	State        uint8  `field:"state"`        // 状态
+	CreatedAt    uint64 `field:"createdAt"`    // 创建时间
 }
 
 type NodeGrantOperator struct {
-	Id          interface{} // ID
-	AdminId     interface{} // 管理员ID
-	Name        interface{} // 名称
-	Method      interface{} // 登录方式
-	Username    interface{} // 用户名
-	Password    interface{} // 密码
-	Su          interface{} // 是否需要su
-	PrivateKey  interface{} // 密钥
-	Description interface{} // 备注
-	NodeId      interface{} // 专有节点
-	State       interface{} // 状态
-	CreatedAt   interface{} // 创建时间
+	Id           interface{} // ID
+	AdminId      interface{} // 管理员ID
+	Name         interface{} // 名称
+	Method       interface{} // 登录方式
+	Username     interface{} // 用户名
+	Password     interface{} // 密码
+	Su           interface{} // 是否需要su
+	Sudo         interface{} // 是否使用sudo执行命令
+	SudoPassword interface{} // sudo密码
+	PrivateKey   interface{} // 密钥
+	Passphrase   interface{} // 密钥密码
+	KnownHosts   interface{} // 预置的主机公钥指纹或known_hosts内容
+	JumpHosts    interface{} // 跳板机
+	Description  interface{} // 备注
+	NodeId       interface{} // 专有节点
+	State        interface{} // 状态
+	CreatedAt    interface{} // 创建时间
 }
 
 func NewNodeGrantOperator() *NodeGrantOperator {
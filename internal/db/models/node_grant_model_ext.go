@@ -1 +1,65 @@
 package models
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// 解析跳板机列表，按照连接顺序排列
+func (this *NodeGrant) DecodeJumpHosts() ([]*NodeGrantJumpHost, error) {
+	return DecodeNodeGrantJumpHosts([]byte(this.JumpHosts))
+}
+
+// 解析并校验跳板机列表
+func DecodeNodeGrantJumpHosts(jumpHostsJSON []byte) ([]*NodeGrantJumpHost, error) {
+	result := []*NodeGrantJumpHost{}
+	if !IsNotNull(string(jumpHostsJSON)) {
+		return result, nil
+	}
+	err := json.Unmarshal(jumpHostsJSON, &result)
+	if err != nil {
+		return nil, err
+	}
+	for index, jumpHost := range result {
+		if jumpHost == nil || len(jumpHost.Host) == 0 {
+			return nil, errors.New("jump host #" + strconv.Itoa(index+1) + ": 'host' should not be empty")
+		}
+		if jumpHost.Port <= 0 {
+			jumpHost.Port = 22
+		}
+		if len(jumpHost.Username) == 0 {
+			return nil, errors.New("jump host #" + strconv.Itoa(index+1) + ": 'username' should not be empty")
+		}
+		if len(jumpHost.Password) == 0 && len(jumpHost.PrivateKey) == 0 {
+			return nil, errors.New("jump host #" + strconv.Itoa(index+1) + ": require 'password' or 'privateKey'")
+		}
+	}
+	return result, nil
+}
+
+// 合并跳板机已信任的主机公钥指纹
+// 管理员明确填写的新指纹会被保留（比如跳板机更换主机公钥后），没有填写时保留地址没有变化的跳板机原有的指纹，
+// 地址变化的跳板机如果仍然使用其他跳板机原有的指纹，则清除指纹以便重新信任
+func keepNodeGrantJumpHostKeys(oldJumpHosts []*NodeGrantJumpHost, newJumpHosts []*NodeGrantJumpHost) {
+	oldHostKeys := map[string]string{} // host:port => hostKey
+	oldHostKeyMap := map[string]bool{} // hostKey => true
+	for _, jumpHost := range oldJumpHosts {
+		oldHostKeys[jumpHost.Host+":"+strconv.Itoa(jumpHost.Port)] = jumpHost.HostKey
+		if len(jumpHost.HostKey) > 0 {
+			oldHostKeyMap[jumpHost.HostKey] = true
+		}
+	}
+	for _, jumpHost := range newJumpHosts {
+		oldHostKey, ok := oldHostKeys[jumpHost.Host+":"+strconv.Itoa(jumpHost.Port)]
+		if ok {
+			if len(jumpHost.HostKey) == 0 {
+				jumpHost.HostKey = oldHostKey
+			}
+			continue
+		}
+		if oldHostKeyMap[jumpHost.HostKey] {
+			jumpHost.HostKey = ""
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"github.com/TeaOSLab/EdgeAPI/internal/utils/numberutils"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/iwind/TeaGo/Tea"
+	"github.com/iwind/TeaGo/dbs"
+	"github.com/iwind/TeaGo/maps"
+	"time"
+)
+
+// 健康检查的检查点类型
+type NodeHealthCheckVantageType = string
+
+const (
+	NodeHealthCheckVantageTypeAPI  NodeHealthCheckVantageType = "api"  // API节点
+	NodeHealthCheckVantageTypeNode NodeHealthCheckVantageType = "node" // 边缘节点
+)
+
+type NodeHealthCheckResultDAO dbs.DAO
+
+func NewNodeHealthCheckResultDAO() *NodeHealthCheckResultDAO {
+	return dbs.NewDAO(&NodeHealthCheckResultDAO{
+		DAOObject: dbs.DAOObject{
+			DB:     Tea.Env,
+			Table:  "edgeNodeHealthCheckResults",
+			Model:  new(NodeHealthCheckResult),
+			PkName: "id",
+		},
+	}).(*NodeHealthCheckResultDAO)
+}
+
+var SharedNodeHealthCheckResultDAO *NodeHealthCheckResultDAO
+
+func init() {
+	dbs.OnReady(func() {
+		SharedNodeHealthCheckResultDAO = NewNodeHealthCheckResultDAO()
+	})
+}
+
+// 保存某个检查点对节点的检查结果
+// 每个检查点对每个节点、每个IP地址只保留最新的一条结果，addrId为0时表示节点整体结果
+func (this *NodeHealthCheckResultDAO) UpdateNodeHealthCheckResult(tx *dbs.Tx, clusterId int64, nodeId int64, addrId int64, vantageType NodeHealthCheckVantageType, vantageId int64, isOk bool, errString string, costMs int64) error {
+	if nodeId <= 0 || vantageId <= 0 {
+		return nil
+	}
+	if len(errString) > 1024 {
+		errString = errString[:1024]
+	}
+
+	uniqueId := numberutils.FormatInt64(nodeId) + "@" + numberutils.FormatInt64(addrId) + "@" + vantageType + "@" + numberutils.FormatInt64(vantageId)
+	updatedAt := time.Now().Unix()
+	_, _, err := this.Query(tx).
+		InsertOrUpdate(maps.Map{
+			"clusterId":   clusterId,
+			"nodeId":      nodeId,
+			"addrId":      addrId,
+			"vantageType": vantageType,
+			"vantageId":   vantageId,
+			"uniqueId":    uniqueId,
+			"isOk":        isOk,
+			"error":       errString,
+			"costMs":      costMs,
+			"updatedAt":   updatedAt,
+		}, maps.Map{
+			"clusterId": clusterId,
+			"isOk":      isOk,
+			"error":     errString,
+			"costMs":    costMs,
+			"updatedAt": updatedAt,
+		})
+	return err
+}
+
+// 查找某个节点的所有整体检查结果，不包括单个IP地址的结果
+func (this *NodeHealthCheckResultDAO) FindAllNodeHealthCheckResults(tx *dbs.Tx, nodeId int64) (result []*NodeHealthCheckResult, err error) {
+	_, err = this.Query(tx).
+		Attr("nodeId", nodeId).
+		Attr("addrId", 0).
+		Asc("vantageType").
+		Asc("vantageId").
+		Slice(&result).
+		FindAll()
+	return
+}
+
+// 查找集群在某个时间之后更新的检查结果
+func (this *NodeHealthCheckResultDAO) FindAllClusterHealthCheckResultsAfter(tx *dbs.Tx, clusterId int64, timestamp int64) (result []*NodeHealthCheckResult, err error) {
+	_, err = this.Query(tx).
+		Attr("clusterId", clusterId).
+		Gte("updatedAt", timestamp).
+		Slice(&result).
+		FindAll()
+	return
+}
+
+// 删除某个节点相关的检查结果，包括此节点作为检查点的结果
+func (this *NodeHealthCheckResultDAO) DeleteNodeHealthCheckResults(tx *dbs.Tx, nodeId int64) error {
+	_, err := this.Query(tx).
+		Attr("nodeId", nodeId).
+		Delete()
+	if err != nil {
+		return err
+	}
+	_, err = this.Query(tx).
+		Attr("vantageType", NodeHealthCheckVantageTypeNode).
+		Attr("vantageId", nodeId).
+		Delete()
+	return err
+}
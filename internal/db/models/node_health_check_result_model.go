@@ -0,0 +1,34 @@
+package models
+
+// 节点健康检查结果
+type NodeHealthCheckResult struct {
+	Id          uint64 `field:"id"`          // ID
+	ClusterId   uint32 `field:"clusterId"`   // 集群ID
+	NodeId      uint32 `field:"nodeId"`      // 被检查的节点ID
+	AddrId      uint32 `field:"addrId"`      // 被检查的IP地址ID，0表示节点整体结果
+	VantageType string `field:"vantageType"` // 检查点类型：api|node
+	VantageId   uint32 `field:"vantageId"`   // 检查点ID
+	UniqueId    string `field:"uniqueId"`    // 唯一ID：nodeId@addrId@vantageType@vantageId
+	IsOk        uint8  `field:"isOk"`        // 是否成功
+	Error       string `field:"error"`       // 错误信息
+	CostMs      uint32 `field:"costMs"`      // 耗时（毫秒）
+	UpdatedAt   uint64 `field:"updatedAt"`   // 修改时间
+}
+
+type NodeHealthCheckResultOperator struct {
+	Id          interface{} // ID
+	ClusterId   interface{} // 集群ID
+	NodeId      interface{} // 被检查的节点ID
+	AddrId      interface{} // 被检查的IP地址ID，0表示节点整体结果
+	VantageType interface{} // 检查点类型：api|node
+	VantageId   interface{} // 检查点ID
+	UniqueId    interface{} // 唯一ID：nodeId@addrId@vantageType@vantageId
+	IsOk        interface{} // 是否成功
+	Error       interface{} // 错误信息
+	CostMs      interface{} // 耗时（毫秒）
+	UpdatedAt   interface{} // 修改时间
+}
+
+func NewNodeHealthCheckResultOperator() *NodeHealthCheckResultOperator {
+	return &NodeHealthCheckResultOperator{}
+}
@@ -14,3 +14,24 @@ type NodeInstallStatus struct {
 func NewNodeInstallStatus() *NodeInstallStatus {
 	return &NodeInstallStatus{}
 }
+
+// 修改步骤进度，步骤不存在时自动添加
+func (this *NodeInstallStatus) UpdateStep(name string, percent int) {
+	if percent < 0 {
+		percent = 0
+	} else if percent > 100 {
+		percent = 100
+	}
+
+	for _, step := range this.Steps {
+		if step.Name == name {
+			step.Percent = percent
+			return
+		}
+	}
+	this.Steps = append(this.Steps, &NodeInstallStatusStep{
+		Name:        name,
+		Description: nodeInstallStepDescriptions[name],
+		Percent:     percent,
+	})
+}
@@ -1,7 +1,35 @@
 package models
 
+// 安装步骤
+const (
+	NodeInstallStepRegister = "register" // 注册节点，只有通过令牌安装时才有此步骤
+	NodeInstallStepPrepare  = "prepare"  // 检查环境
+	NodeInstallStepUpload   = "upload"   // 上传（或下载）安装包
+	NodeInstallStepUnzip    = "unzip"    // 解压安装包
+	NodeInstallStepConfig   = "config"   // 修改配置
+	NodeInstallStepTest     = "test"     // 测试
+	NodeInstallStepStart    = "start"    // 启动
+)
+
+// 所有安装步骤
+var nodeInstallStepDescriptions = map[string]string{
+	NodeInstallStepRegister: "注册节点",
+	NodeInstallStepPrepare:  "检查环境",
+	NodeInstallStepUpload:   "上传安装包",
+	NodeInstallStepUnzip:    "解压安装包",
+	NodeInstallStepConfig:   "修改配置",
+	NodeInstallStepTest:     "测试",
+	NodeInstallStepStart:    "启动",
+}
+
 type NodeInstallStatusStep struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	Percent     int    `json:"percent"`
 }
+
+// 判断是否为合法的安装步骤
+func IsValidNodeInstallStep(name string) bool {
+	_, ok := nodeInstallStepDescriptions[name]
+	return ok
+}
@@ -0,0 +1,128 @@
+package models
+
+import (
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/iwind/TeaGo/Tea"
+	"github.com/iwind/TeaGo/dbs"
+	"github.com/iwind/TeaGo/rands"
+	"github.com/iwind/TeaGo/types"
+	"time"
+)
+
+const (
+	NodeInstallTokenStateEnabled  = 1 // 已启用
+	NodeInstallTokenStateDisabled = 0 // 已禁用
+)
+
+type NodeInstallTokenDAO dbs.DAO
+
+func NewNodeInstallTokenDAO() *NodeInstallTokenDAO {
+	return dbs.NewDAO(&NodeInstallTokenDAO{
+		DAOObject: dbs.DAOObject{
+			DB:     Tea.Env,
+			Table:  "edgeNodeInstallTokens",
+			Model:  new(NodeInstallToken),
+			PkName: "id",
+		},
+	}).(*NodeInstallTokenDAO)
+}
+
+var SharedNodeInstallTokenDAO *NodeInstallTokenDAO
+
+func init() {
+	dbs.OnReady(func() {
+		SharedNodeInstallTokenDAO = NewNodeInstallTokenDAO()
+	})
+}
+
+// 创建令牌
+func (this *NodeInstallTokenDAO) CreateInstallToken(tx *dbs.Tx, adminId int64, clusterId int64, lifeSeconds int64) (tokenId int64, token string, err error) {
+	if lifeSeconds <= 0 {
+		lifeSeconds = 3600
+	}
+	token = rands.HexString(32)
+
+	op := NewNodeInstallTokenOperator()
+	op.AdminId = adminId
+	op.ClusterId = clusterId
+	op.Token = token
+	op.ExpiredAt = time.Now().Unix() + lifeSeconds
+	op.IsUsed = false
+	op.State = NodeInstallTokenStateEnabled
+	op.CreatedAt = time.Now().Unix()
+	err = this.Save(tx, op)
+	if err != nil {
+		return 0, "", err
+	}
+	return types.Int64(op.Id), token, nil
+}
+
+// 禁用令牌
+func (this *NodeInstallTokenDAO) DisableInstallToken(tx *dbs.Tx, tokenId int64) error {
+	_, err := this.Query(tx).
+		Pk(tokenId).
+		Set("state", NodeInstallTokenStateDisabled).
+		Update()
+	return err
+}
+
+// 根据令牌查找
+func (this *NodeInstallTokenDAO) FindEnabledInstallTokenWithToken(tx *dbs.Tx, token string) (*NodeInstallToken, error) {
+	if len(token) == 0 {
+		return nil, nil
+	}
+	one, err := this.Query(tx).
+		Attr("token", token).
+		State(NodeInstallTokenStateEnabled).
+		Find()
+	if err != nil || one == nil {
+		return nil, err
+	}
+	return one.(*NodeInstallToken), nil
+}
+
+// 查找集群的所有令牌
+func (this *NodeInstallTokenDAO) FindAllEnabledInstallTokensWithClusterId(tx *dbs.Tx, clusterId int64) (result []*NodeInstallToken, err error) {
+	_, err = this.Query(tx).
+		Attr("clusterId", clusterId).
+		State(NodeInstallTokenStateEnabled).
+		DescPk().
+		Slice(&result).
+		FindAll()
+	return
+}
+
+// 使用令牌
+// 每个令牌只能使用一次，返回false表示令牌已经被使用或者已过期
+func (this *NodeInstallTokenDAO) UseInstallToken(tx *dbs.Tx, tokenId int64) (bool, error) {
+	now := time.Now().Unix()
+	rows, err := this.Query(tx).
+		Pk(tokenId).
+		State(NodeInstallTokenStateEnabled).
+		Attr("isUsed", 0).
+		Gte("expiredAt", now).
+		Set("isUsed", 1).
+		Set("usedAt", now).
+		Update()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// 设置令牌注册的节点
+func (this *NodeInstallTokenDAO) UpdateInstallTokenNodeId(tx *dbs.Tx, tokenId int64, nodeId int64) error {
+	_, err := this.Query(tx).
+		Pk(tokenId).
+		Set("nodeId", nodeId).
+		Update()
+	return err
+}
+
+// 删除过期的令牌
+func (this *NodeInstallTokenDAO) DeleteExpiredInstallTokens(tx *dbs.Tx, beforeTime int64) error {
+	_, err := this.Query(tx).
+		Lt("expiredAt", beforeTime).
+		Delete()
+	return err
+}
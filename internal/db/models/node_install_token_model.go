@@ -0,0 +1,32 @@
+package models
+
+// 节点安装令牌
+type NodeInstallToken struct {
+	Id        uint64 `field:"id"`        // ID
+	AdminId   uint32 `field:"adminId"`   // 管理员ID
+	ClusterId uint32 `field:"clusterId"` // 集群ID
+	Token     string `field:"token"`     // 令牌
+	ExpiredAt uint64 `field:"expiredAt"` // 过期时间
+	IsUsed    uint8  `field:"isUsed"`    // 是否已使用
+	UsedAt    uint64 `field:"usedAt"`    // 使用时间
+	NodeId    uint32 `field:"nodeId"`    // 注册的节点ID
+	State     uint8  `field:"state"`     // 状态
+	CreatedAt uint64 `field:"createdAt"` // 创建时间
+}
+
+type NodeInstallTokenOperator struct {
+	Id        interface{} // ID
+	AdminId   interface{} // 管理员ID
+	ClusterId interface{} // 集群ID
+	Token     interface{} // 令牌
+	ExpiredAt interface{} // 过期时间
+	IsUsed    interface{} // 是否已使用
+	UsedAt    interface{} // 使用时间
+	NodeId    interface{} // 注册的节点ID
+	State     interface{} // 状态
+	CreatedAt interface{} // 创建时间
+}
+
+func NewNodeInstallTokenOperator() *NodeInstallTokenOperator {
+	return &NodeInstallTokenOperator{}
+}
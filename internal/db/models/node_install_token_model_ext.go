@@ -0,0 +1,8 @@
+package models
+
+import "time"
+
+// 判断是否已过期
+func (this *NodeInstallToken) IsExpired() bool {
+	return int64(this.ExpiredAt) < time.Now().Unix()
+}
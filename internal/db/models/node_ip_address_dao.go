@@ -199,3 +199,103 @@ func (this *NodeIPAddressDAO) FindNodeAccessIPAddresses(tx *dbs.Tx, nodeId int64
 		FindAll()
 	return
 }
+
+// 查找节点所有的可访问并且在线的IP地址
+func (this *NodeIPAddressDAO) FindNodeAccessAndUpIPAddresses(tx *dbs.Tx, nodeId int64) (result []*NodeIPAddress, err error) {
+	_, err = this.Query(tx).
+		Attr("nodeId", nodeId).
+		State(NodeIPAddressStateEnabled).
+		Attr("canAccess", true).
+		Attr("isUp", true).
+		Desc("order").
+		AscPk().
+		Slice(&result).
+		FindAll()
+	return
+}
+
+// 设置IP地址在线状态
+// 和节点一样，需要连续maxUp次在线或者连续maxDown次下线才会改变状态
+func (this *NodeIPAddressDAO) UpdateAddressIsUp(tx *dbs.Tx, addressId int64, isUp bool, maxUp int, maxDown int) (changed bool, err error) {
+	if addressId <= 0 {
+		return false, errors.New("invalid addressId")
+	}
+	one, err := this.Query(tx).
+		Pk(addressId).
+		Result("isUp", "countUp", "countDown").
+		Find()
+	if err != nil {
+		return false, err
+	}
+	if one == nil {
+		return false, nil
+	}
+	address := one.(*NodeIPAddress)
+	oldIsUp := address.IsUp == 1
+
+	countUp := int(address.CountUp)
+	countDown := int(address.CountDown)
+
+	op := NewNodeIPAddressOperator()
+	op.Id = addressId
+
+	if isUp {
+		countUp++
+		countDown = 0
+
+		if !oldIsUp && countUp >= maxUp {
+			changed = true
+			op.IsUp = true
+		}
+	} else {
+		countDown++
+		countUp = 0
+
+		if oldIsUp && countDown >= maxDown {
+			changed = true
+			op.IsUp = false
+		}
+	}
+
+	op.CountUp = countUp
+	op.CountDown = countDown
+	err = this.Save(tx, op)
+	if err != nil {
+		return false, err
+	}
+	return
+}
+
+// 恢复集群中所有已下线的IP地址，并返回IP地址所属的节点ID
+// 在关闭检查所有IP地址或者自动下线功能后使用，防止IP地址一直处于下线状态
+func (this *NodeIPAddressDAO) ResetAllDownAddressesWithClusterId(tx *dbs.Tx, clusterId int64) (nodeIds []int64, err error) {
+	ones, err := this.Query(tx).
+		State(NodeIPAddressStateEnabled).
+		Attr("isUp", false).
+		Where("nodeId IN (SELECT id FROM "+SharedNodeDAO.Table+" WHERE clusterId=:clusterId)").
+		Param("clusterId", clusterId).
+		Result("id", "nodeId").
+		FindAll()
+	if err != nil {
+		return nil, err
+	}
+	nodeIdMap := map[int64]bool{}
+	for _, one := range ones {
+		address := one.(*NodeIPAddress)
+		_, err = this.Query(tx).
+			Pk(address.Id).
+			Set("isUp", true).
+			Set("countUp", 0).
+			Set("countDown", 0).
+			Update()
+		if err != nil {
+			return nil, err
+		}
+		nodeId := int64(address.NodeId)
+		if !nodeIdMap[nodeId] {
+			nodeIdMap[nodeId] = true
+			nodeIds = append(nodeIds, nodeId)
+		}
+	}
+	return
+}
@@ -10,6 +10,9 @@ type NodeIPAddress struct {
 	State       uint8  `field:"state"`       // 状态
 	Order       uint32 `field:"order"`       // 排序
 	CanAccess   uint8  `field:"canAccess"`   // 是否可以访问
+	IsUp        uint8  `field:"isUp"`        // 是否在线
+	CountUp     uint32 `field:"countUp"`     // 连续在线次数
+	CountDown   uint32 `field:"countDown"`   // 连续下线次数
 }
 
 type NodeIPAddressOperator struct {
@@ -21,6 +24,9 @@ type NodeIPAddressOperator struct {
 	State       interface{} // 状态
 	Order       interface{} // 排序
 	CanAccess   interface{} // 是否可以访问
+	IsUp        interface{} // 是否在线
+	CountUp     interface{} // 连续在线次数
+	CountDown   interface{} // 连续下线次数
 }
 
 func NewNodeIPAddressOperator() *NodeIPAddressOperator {
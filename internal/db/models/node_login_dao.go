@@ -1,11 +1,13 @@
 package models
 
 import (
+	"encoding/json"
 	"errors"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/iwind/TeaGo/Tea"
 	"github.com/iwind/TeaGo/dbs"
 	"github.com/iwind/TeaGo/types"
+	"time"
 )
 
 const (
@@ -90,12 +92,29 @@ func (this *NodeLoginDAO) UpdateNodeLogin(tx *dbs.Tx, loginId int64, name string
 	if loginId <= 0 {
 		return errors.New("invalid loginId")
 	}
+
+	// 主机地址或端口变化后需要重新信任主机公钥
+	oldLogin, err := this.FindEnabledNodeLogin(tx, uint32(loginId))
+	if err != nil {
+		return err
+	}
+
 	login := NewNodeLoginOperator()
 	login.Id = loginId
 	login.Name = name
 	login.Type = loginType
 	login.Params = string(paramsJSON)
-	err := this.Save(tx, login)
+	if oldLogin != nil && oldLogin.Type == NodeLoginTypeSSH && loginType == NodeLoginTypeSSH {
+		oldParams, _ := oldLogin.DecodeSSHParams()
+		newParams := &NodeLoginSSHParams{}
+		_ = json.Unmarshal(paramsJSON, newParams)
+		if oldParams == nil || oldParams.Host != newParams.Host || oldParams.Port != newParams.Port {
+			login.HostKey = ""
+			login.PendingHostKey = ""
+			login.HostKeyUpdatedAt = time.Now().Unix()
+		}
+	}
+	err = this.Save(tx, login)
 	return err
 }
 
@@ -122,3 +141,48 @@ func (this *NodeLoginDAO) DisableNodeLogins(tx *dbs.Tx, nodeId int64) error {
 		Update()
 	return err
 }
+
+// 信任主机公钥指纹
+func (this *NodeLoginDAO) UpdateNodeLoginHostKey(tx *dbs.Tx, loginId int64, fingerprint string) error {
+	if loginId <= 0 {
+		return errors.New("invalid loginId")
+	}
+	_, err := this.Query(tx).
+		Pk(loginId).
+		Set("hostKey", fingerprint).
+		Set("pendingHostKey", "").
+		Set("hostKeyUpdatedAt", time.Now().Unix()).
+		Update()
+	return err
+}
+
+// 记录变化后待确认的主机公钥指纹
+func (this *NodeLoginDAO) UpdateNodeLoginPendingHostKey(tx *dbs.Tx, loginId int64, fingerprint string) error {
+	if loginId <= 0 {
+		return errors.New("invalid loginId")
+	}
+	_, err := this.Query(tx).
+		Pk(loginId).
+		Set("pendingHostKey", fingerprint).
+		Update()
+	return err
+}
+
+// 确认接受新的主机公钥指纹
+// 指纹必须和最近一次连接时看到的指纹一致，防止误接受其他的公钥
+func (this *NodeLoginDAO) AcceptNodeLoginHostKey(tx *dbs.Tx, loginId int64, fingerprint string) error {
+	login, err := this.FindEnabledNodeLogin(tx, uint32(loginId))
+	if err != nil {
+		return err
+	}
+	if login == nil {
+		return errors.New("can not find login with id '" + types.String(loginId) + "'")
+	}
+	if len(login.PendingHostKey) == 0 {
+		return errors.New("there is no pending host key to accept")
+	}
+	if login.PendingHostKey != fingerprint {
+		return errors.New("fingerprint '" + fingerprint + "' does not match the pending host key '" + login.PendingHostKey + "'")
+	}
+	return this.UpdateNodeLoginHostKey(tx, loginId, fingerprint)
+}
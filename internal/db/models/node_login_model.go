@@ -2,21 +2,27 @@ package models
 
 //
 type NodeLogin struct {
-	Id     uint32 `field:"id"`     // ID
-	NodeId uint32 `field:"nodeId"` // 节点ID
-	Name   string `field:"name"`   // 名称
-	Type   string `field:"type"`   // 类型：ssh,agent
-	Params string `field:"params"` // 配置参数
-	State  uint8  `field:"state"`  // 状态
+	Id               uint32 `field:"id"`               // ID
+	NodeId           uint32 `field:"nodeId"`           // 节点ID
+	Name             string `field:"name"`             // 名称
+	Type             string `field:"type"`             // 类型：ssh,agent
+	Params           string `field:"params"`           // 配置参数
+	HostKey          string `field:"hostKey"`          // 已信任的主机公钥指纹
+	PendingHostKey   string `field:"pendingHostKey"`   // 变化后待确认的主机公钥指纹
+	HostKeyUpdatedAt uint64 `field:"hostKeyUpdatedAt"` // 主机公钥更新时间
+	State            uint8  `field:"state"`            // 状态
 }
 
 type NodeLoginOperator struct {
-	Id     interface{} // ID
-	NodeId interface{} // 节点ID
-	Name   interface{} // 名称
-	Type   interface{} // 类型：ssh,agent
-	Params interface{} // 配置参数
-	State  interface{} // 状态
+	Id               interface{} // ID
+	NodeId           interface{} // 节点ID
+	Name             interface{} // 名称
+	Type             interface{} // 类型：ssh,agent
+	Params           interface{} // 配置参数
+	HostKey          interface{} // 已信任的主机公钥指纹
+	PendingHostKey   interface{} // 变化后待确认的主机公钥指纹
+	HostKeyUpdatedAt interface{} // 主机公钥更新时间
+	State            interface{} // 状态
 }
 
 func NewNodeLoginOperator() *NodeLoginOperator {
@@ -32,12 +32,24 @@ func NewNodeTaskDAO() *NodeTaskDAO {
 
 var SharedNodeTaskDAO *NodeTaskDAO
 
+// 任务创建后的回调，用来及时通知节点
+// 集群任务回调时nodeId为0
+type NodeTaskCreatedCallback func(clusterId int64, nodeId int64, taskType NodeTaskType)
+
+var nodeTaskCreatedCallbacks = []NodeTaskCreatedCallback{}
+
 func init() {
 	dbs.OnReady(func() {
 		SharedNodeTaskDAO = NewNodeTaskDAO()
 	})
 }
 
+// 添加任务创建后的回调
+// 需要在程序初始化时调用
+func OnNodeTaskCreated(callback NodeTaskCreatedCallback) {
+	nodeTaskCreatedCallbacks = append(nodeTaskCreatedCallbacks, callback)
+}
+
 // 创建单个节点任务
 func (this *NodeTaskDAO) CreateNodeTask(tx *dbs.Tx, clusterId int64, nodeId int64, taskType NodeTaskType) error {
 	if clusterId <= 0 || nodeId <= 0 {
@@ -56,13 +68,20 @@ func (this *NodeTaskDAO) CreateNodeTask(tx *dbs.Tx, clusterId int64, nodeId int6
 			"isOk":      0,
 			"error":     "",
 		}, maps.Map{
-			"clusterId": clusterId,
-			"updatedAt": updatedAt,
-			"isDone":    0,
-			"isOk":      0,
-			"error":     "",
+			"clusterId":     clusterId,
+			"updatedAt":     updatedAt,
+			"isDone":        0,
+			"isOk":          0,
+			"isNotified":    0,
+			"notifiedAt":    0,
+			"notifyLatency": 0,
+			"error":         "",
 		})
-	return err
+	if err != nil {
+		return err
+	}
+	this.notifyTaskCreated(clusterId, nodeId, taskType)
+	return nil
 }
 
 // 创建集群任务
@@ -91,7 +110,11 @@ func (this *NodeTaskDAO) CreateClusterTask(tx *dbs.Tx, clusterId int64, taskType
 			"isNotified": 0,
 			"error":      "",
 		})
-	return err
+	if err != nil {
+		return err
+	}
+	this.notifyTaskCreated(clusterId, 0, taskType)
+	return nil
 }
 
 // 分解集群任务
@@ -129,6 +152,15 @@ func (this *NodeTaskDAO) ExtractClusterTask(tx *dbs.Tx, clusterId int64, taskTyp
 	return nil
 }
 
+// 检查集群任务是否存在（尚未分解）
+func (this *NodeTaskDAO) ExistsClusterTask(tx *dbs.Tx, clusterId int64, taskType NodeTaskType) (bool, error) {
+	return this.Query(tx).
+		Attr("clusterId", clusterId).
+		Attr("nodeId", 0).
+		Attr("type", taskType).
+		Exist()
+}
+
 // 分解所有集群任务
 func (this *NodeTaskDAO) ExtractAllClusterTasks(tx *dbs.Tx) error {
 	ones, err := this.Query(tx).
@@ -276,3 +308,33 @@ func (this *NodeTaskDAO) UpdateTasksNotified(tx *dbs.Tx, taskIds []int64) error
 	}
 	return nil
 }
+
+// 设置任务已通过推送通知，并记录通知延迟
+func (this *NodeTaskDAO) UpdateTasksNotifiedWithLatency(tx *dbs.Tx, taskIds []int64, latencyMs int64) error {
+	if len(taskIds) == 0 {
+		return nil
+	}
+	if latencyMs < 0 {
+		latencyMs = 0
+	}
+	for _, taskId := range taskIds {
+		_, err := this.Query(tx).
+			Pk(taskId).
+			Attr("isDone", 0).
+			Set("isNotified", 1).
+			Set("notifiedAt", time.Now().Unix()).
+			Set("notifyLatency", latencyMs).
+			Update()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 调用任务创建后的回调
+func (this *NodeTaskDAO) notifyTaskCreated(clusterId int64, nodeId int64, taskType NodeTaskType) {
+	for _, callback := range nodeTaskCreatedCallbacks {
+		callback(clusterId, nodeId, taskType)
+	}
+}
@@ -107,7 +107,7 @@ func (this *OriginDAO) CreateOrigin(tx *dbs.Tx, adminId int64, userId int64, nam
 }
 
 // 修改源站
-func (this *OriginDAO) UpdateOrigin(tx *dbs.Tx, originId int64, name string, addrJSON string, description string, weight int32, isOn bool) error {
+func (this *OriginDAO) UpdateOrigin(tx *dbs.Tx, originId int64, name string, addrJSON string, description string, weight int32, isOn bool, author *ServerConfigRevisionAuthor) error {
 	if originId <= 0 {
 		return errors.New("invalid originId")
 	}
@@ -127,7 +127,7 @@ func (this *OriginDAO) UpdateOrigin(tx *dbs.Tx, originId int64, name string, add
 		return err
 	}
 
-	return this.NotifyUpdate(tx, originId)
+	return this.NotifyUpdateWithAuthor(tx, originId, author)
 }
 
 // 将源站信息转换为配置
@@ -264,12 +264,17 @@ func (this *OriginDAO) ComposeOriginConfig(tx *dbs.Tx, originId int64) (*serverc
 
 // 通知更新
 func (this *OriginDAO) NotifyUpdate(tx *dbs.Tx, originId int64) error {
+	return this.NotifyUpdateWithAuthor(tx, originId, nil)
+}
+
+// 通知更新，并记录配置的修改者
+func (this *OriginDAO) NotifyUpdateWithAuthor(tx *dbs.Tx, originId int64, author *ServerConfigRevisionAuthor) error {
 	reverseProxyId, err := SharedReverseProxyDAO.FindReverseProxyContainsOriginId(tx, originId)
 	if err != nil {
 		return err
 	}
 	if reverseProxyId > 0 {
-		return SharedReverseProxyDAO.NotifyUpdate(tx, reverseProxyId)
+		return SharedReverseProxyDAO.NotifyUpdateWithAuthor(tx, reverseProxyId, author)
 	}
 	return nil
 }
@@ -179,7 +179,7 @@ func (this *ReverseProxyDAO) CreateReverseProxy(tx *dbs.Tx, adminId int64, userI
 }
 
 // 修改反向代理调度算法
-func (this *ReverseProxyDAO) UpdateReverseProxyScheduling(tx *dbs.Tx, reverseProxyId int64, schedulingJSON []byte) error {
+func (this *ReverseProxyDAO) UpdateReverseProxyScheduling(tx *dbs.Tx, reverseProxyId int64, schedulingJSON []byte, author *ServerConfigRevisionAuthor) error {
 	if reverseProxyId <= 0 {
 		return errors.New("invalid reverseProxyId")
 	}
@@ -194,11 +194,11 @@ func (this *ReverseProxyDAO) UpdateReverseProxyScheduling(tx *dbs.Tx, reversePro
 	if err != nil {
 		return err
 	}
-	return this.NotifyUpdate(tx, reverseProxyId)
+	return this.NotifyUpdateWithAuthor(tx, reverseProxyId, author)
 }
 
 // 修改主要源站
-func (this *ReverseProxyDAO) UpdateReverseProxyPrimaryOrigins(tx *dbs.Tx, reverseProxyId int64, origins []byte) error {
+func (this *ReverseProxyDAO) UpdateReverseProxyPrimaryOrigins(tx *dbs.Tx, reverseProxyId int64, origins []byte, author *ServerConfigRevisionAuthor) error {
 	if reverseProxyId <= 0 {
 		return errors.New("invalid reverseProxyId")
 	}
@@ -213,11 +213,11 @@ func (this *ReverseProxyDAO) UpdateReverseProxyPrimaryOrigins(tx *dbs.Tx, revers
 	if err != nil {
 		return err
 	}
-	return this.NotifyUpdate(tx, reverseProxyId)
+	return this.NotifyUpdateWithAuthor(tx, reverseProxyId, author)
 }
 
 // 修改备用源站
-func (this *ReverseProxyDAO) UpdateReverseProxyBackupOrigins(tx *dbs.Tx, reverseProxyId int64, origins []byte) error {
+func (this *ReverseProxyDAO) UpdateReverseProxyBackupOrigins(tx *dbs.Tx, reverseProxyId int64, origins []byte, author *ServerConfigRevisionAuthor) error {
 	if reverseProxyId <= 0 {
 		return errors.New("invalid reverseProxyId")
 	}
@@ -232,11 +232,11 @@ func (this *ReverseProxyDAO) UpdateReverseProxyBackupOrigins(tx *dbs.Tx, reverse
 	if err != nil {
 		return err
 	}
-	return this.NotifyUpdate(tx, reverseProxyId)
+	return this.NotifyUpdateWithAuthor(tx, reverseProxyId, author)
 }
 
 // 修改是否启用
-func (this *ReverseProxyDAO) UpdateReverseProxy(tx *dbs.Tx, reverseProxyId int64, requestHostType int8, requestHost string, requestURI string, stripPrefix string, autoFlush bool, addHeaders []string) error {
+func (this *ReverseProxyDAO) UpdateReverseProxy(tx *dbs.Tx, reverseProxyId int64, requestHostType int8, requestHost string, requestURI string, stripPrefix string, autoFlush bool, addHeaders []string, author *ServerConfigRevisionAuthor) error {
 	if reverseProxyId <= 0 {
 		return errors.New("invalid reverseProxyId")
 	}
@@ -267,7 +267,7 @@ func (this *ReverseProxyDAO) UpdateReverseProxy(tx *dbs.Tx, reverseProxyId int64
 	if err != nil {
 		return err
 	}
-	return this.NotifyUpdate(tx, reverseProxyId)
+	return this.NotifyUpdateWithAuthor(tx, reverseProxyId, author)
 }
 
 // 查找包含某个源站的反向代理ID
@@ -283,12 +283,17 @@ func (this *ReverseProxyDAO) FindReverseProxyContainsOriginId(tx *dbs.Tx, origin
 
 // 通知更新
 func (this *ReverseProxyDAO) NotifyUpdate(tx *dbs.Tx, reverseProxyId int64) error {
+	return this.NotifyUpdateWithAuthor(tx, reverseProxyId, nil)
+}
+
+// 通知更新，并记录配置的修改者
+func (this *ReverseProxyDAO) NotifyUpdateWithAuthor(tx *dbs.Tx, reverseProxyId int64, author *ServerConfigRevisionAuthor) error {
 	serverId, err := SharedServerDAO.FindEnabledServerIdWithReverseProxyId(tx, reverseProxyId)
 	if err != nil {
 		return err
 	}
 	if serverId > 0 {
-		return SharedServerDAO.NotifyUpdate(tx, serverId)
+		return SharedServerDAO.NotifyUpdateWithAuthor(tx, serverId, author)
 	}
 	return nil
 }
@@ -0,0 +1,362 @@
+package models
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"github.com/TeaOSLab/EdgeAPI/internal/utils/jsondiff"
+	"github.com/TeaOSLab/EdgeCommon/pkg/serverconfigs"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/iwind/TeaGo/Tea"
+	"github.com/iwind/TeaGo/dbs"
+	"github.com/iwind/TeaGo/maps"
+	"strconv"
+	"time"
+)
+
+type ServerConfigRevisionAuthorType = string
+
+// 配置修改者类型
+const (
+	ServerConfigRevisionAuthorTypeAdmin  ServerConfigRevisionAuthorType = "admin"  // 管理员
+	ServerConfigRevisionAuthorTypeUser   ServerConfigRevisionAuthorType = "user"   // 用户
+	ServerConfigRevisionAuthorTypeSystem ServerConfigRevisionAuthorType = "system" // 系统，或者通过Web、反向代理等子对象间接修改
+)
+
+// 配置修改者
+type ServerConfigRevisionAuthor struct {
+	Type        ServerConfigRevisionAuthorType
+	Id          int64
+	Description string // 修改说明
+}
+
+// 根据管理员ID和用户ID构造修改者
+func NewServerConfigRevisionAuthor(adminId int64, userId int64) *ServerConfigRevisionAuthor {
+	if userId > 0 {
+		return &ServerConfigRevisionAuthor{
+			Type: ServerConfigRevisionAuthorTypeUser,
+			Id:   userId,
+		}
+	}
+	return &ServerConfigRevisionAuthor{
+		Type: ServerConfigRevisionAuthorTypeAdmin,
+		Id:   adminId,
+	}
+}
+
+// 回滚时需要恢复的Web字段
+var serverConfigRevisionWebFields = []string{"isOn", "root", "charset", "shutdown", "pages", "redirectToHttps", "indexes", "maxRequestBodySize", "requestHeader", "responseHeader", "accessLog", "stat", "gzip", "cache", "firewall", "locations", "websocket", "rewriteRules", "hostRedirects"}
+
+// 回滚时需要恢复的反向代理字段
+var serverConfigRevisionReverseProxyFields = []string{"isOn", "scheduling", "primaryOrigins", "backupOrigins", "stripPrefix", "requestHostType", "requestHost", "requestURI", "autoFlush", "addHeaders"}
+
+// 回滚时需要恢复的源站字段
+var serverConfigRevisionOriginFields = []string{"isOn", "name", "addr", "description", "code", "weight", "connTimeout", "readTimeout", "idleTimeout", "maxFails", "maxConns", "maxIdleConns", "httpRequestURI", "httpRequestHeader", "httpResponseHeader", "host", "healthCheck", "cert", "ftp", "state"}
+
+// 回滚时最多返回的没有恢复的配置路径数量
+const serverConfigRevisionMaxUnrestoredPaths = 20
+
+type ServerConfigRevisionDAO dbs.DAO
+
+func NewServerConfigRevisionDAO() *ServerConfigRevisionDAO {
+	return dbs.NewDAO(&ServerConfigRevisionDAO{
+		DAOObject: dbs.DAOObject{
+			DB:     Tea.Env,
+			Table:  "edgeServerConfigRevisions",
+			Model:  new(ServerConfigRevision),
+			PkName: "id",
+		},
+	}).(*ServerConfigRevisionDAO)
+}
+
+var SharedServerConfigRevisionDAO *ServerConfigRevisionDAO
+
+func init() {
+	dbs.OnReady(func() {
+		SharedServerConfigRevisionDAO = NewServerConfigRevisionDAO()
+	})
+}
+
+// 保存配置版本
+// 和最新版本相同时不会重复保存；版本保存后不会再修改
+func (this *ServerConfigRevisionDAO) CreateRevision(tx *dbs.Tx, serverId int64, configJSON []byte, author *ServerConfigRevisionAuthor) error {
+	configMd5 := fmt.Sprintf("%x", md5.Sum(configJSON))
+	lastMd5, err := this.Query(tx).
+		Attr("serverId", serverId).
+		Result("configMd5").
+		DescPk().
+		FindStringCol("")
+	if err != nil {
+		return err
+	}
+	if lastMd5 == configMd5 {
+		return nil
+	}
+
+	source, err := this.composeSource(tx, serverId)
+	if err != nil {
+		return err
+	}
+	sourceJSON, err := json.Marshal(source)
+	if err != nil {
+		return err
+	}
+
+	if author == nil {
+		author = &ServerConfigRevisionAuthor{Type: ServerConfigRevisionAuthorTypeSystem}
+	}
+
+	op := NewServerConfigRevisionOperator()
+	op.ServerId = serverId
+	op.Config = JSONBytes(configJSON)
+	op.ConfigMd5 = configMd5
+	op.Source = sourceJSON
+	op.AuthorType = author.Type
+	op.AuthorId = author.Id
+	op.Description = author.Description
+	op.CreatedAt = time.Now().Unix()
+	return this.Save(tx, op)
+}
+
+// 查找单个版本
+func (this *ServerConfigRevisionDAO) FindRevision(tx *dbs.Tx, revisionId int64) (*ServerConfigRevision, error) {
+	one, err := this.Query(tx).
+		Pk(revisionId).
+		Find()
+	if one == nil {
+		return nil, err
+	}
+	return one.(*ServerConfigRevision), err
+}
+
+// 计算服务的版本数量
+func (this *ServerConfigRevisionDAO) CountRevisionsWithServerId(tx *dbs.Tx, serverId int64) (int64, error) {
+	return this.Query(tx).
+		Attr("serverId", serverId).
+		Count()
+}
+
+// 列出服务的版本，不包含配置内容
+func (this *ServerConfigRevisionDAO) ListRevisionsWithServerId(tx *dbs.Tx, serverId int64, offset int64, size int64) (result []*ServerConfigRevision, err error) {
+	_, err = this.Query(tx).
+		Attr("serverId", serverId).
+		Result("id", "serverId", "configMd5", "authorType", "authorId", "description", "createdAt").
+		DescPk().
+		Offset(offset).
+		Limit(size).
+		Slice(&result).
+		FindAll()
+	return
+}
+
+// 回滚到某个版本
+// 恢复服务的域名、HTTPS、Web和反向代理设置，回滚本身也会生成一个新的版本
+// 路径规则、证书、WAF等子对象不在快照中，如果它们已经改变，返回恢复后仍然和版本不一致的配置路径
+func (this *ServerConfigRevisionDAO) RollbackRevision(tx *dbs.Tx, revisionId int64, author *ServerConfigRevisionAuthor) (unrestoredPaths []string, err error) {
+	revision, err := this.FindRevision(tx, revisionId)
+	if err != nil {
+		return nil, err
+	}
+	if revision == nil {
+		return nil, ErrNotFound
+	}
+	serverId := int64(revision.ServerId)
+
+	source := &ServerConfigRevisionSource{}
+	err = json.Unmarshal([]byte(revision.Source), source)
+	if err != nil {
+		return nil, errors.New("decode revision source failed: " + err.Error())
+	}
+
+	// 服务
+	serverNamesJSON := source.ServerNames
+	if len(serverNamesJSON) == 0 {
+		serverNamesJSON = "[]"
+	}
+	_, err = SharedServerDAO.Query(tx).
+		Pk(serverId).
+		Set("serverNames", serverNamesJSON).
+		Set("https", string(JSONBytes([]byte(source.HTTPS)))).
+		Set("webId", source.WebId).
+		Set("reverseProxy", string(JSONBytes([]byte(source.ReverseProxy)))).
+		Update()
+	if err != nil {
+		return nil, err
+	}
+
+	// Web
+	if source.WebId > 0 && len(source.Web) > 0 {
+		err = this.restoreFields(SharedHTTPWebDAO.Query(tx).Pk(source.WebId), source.Web, serverConfigRevisionWebFields)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 反向代理
+	if source.ReverseProxyId > 0 && len(source.ReverseProxySettings) > 0 {
+		err = this.restoreFields(SharedReverseProxyDAO.Query(tx).Pk(source.ReverseProxyId), source.ReverseProxySettings, serverConfigRevisionReverseProxyFields)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 源站
+	for _, origin := range source.Origins {
+		originId := origin.GetInt64("id")
+		if originId <= 0 {
+			continue
+		}
+		err = this.restoreFields(SharedOriginDAO.Query(tx).Pk(originId), origin, serverConfigRevisionOriginFields)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 查找没有恢复的配置
+	unrestoredPaths, err = this.findUnrestoredPaths(tx, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	if author == nil {
+		author = &ServerConfigRevisionAuthor{Type: ServerConfigRevisionAuthorTypeSystem}
+	}
+	if len(author.Description) == 0 {
+		author.Description = "rollback to revision #" + strconv.FormatInt(revisionId, 10)
+	}
+	err = SharedServerDAO.NotifyUpdateWithAuthor(tx, serverId, author)
+	if err != nil {
+		return nil, err
+	}
+	return unrestoredPaths, nil
+}
+
+// 查找恢复后仍然和版本不一致的配置路径
+func (this *ServerConfigRevisionDAO) findUnrestoredPaths(tx *dbs.Tx, revision *ServerConfigRevision) ([]string, error) {
+	serverConfig, err := SharedServerDAO.ComposeServerConfig(tx, int64(revision.ServerId))
+	if err != nil {
+		return nil, err
+	}
+	configJSON, err := json.Marshal(serverConfig)
+	if err != nil {
+		return nil, err
+	}
+	if fmt.Sprintf("%x", md5.Sum(configJSON)) == revision.ConfigMd5 {
+		return nil, nil
+	}
+
+	changes, err := jsondiff.Diff([]byte(revision.Config), configJSON)
+	if err != nil {
+		return nil, err
+	}
+	paths := []string{}
+	for _, change := range changes {
+		if len(paths) >= serverConfigRevisionMaxUnrestoredPaths {
+			paths = append(paths, "...")
+			break
+		}
+		paths = append(paths, change.Path)
+	}
+	return paths, nil
+}
+
+// 读取回滚需要的原始设置
+func (this *ServerConfigRevisionDAO) composeSource(tx *dbs.Tx, serverId int64) (*ServerConfigRevisionSource, error) {
+	one, err := SharedServerDAO.Query(tx).
+		Pk(serverId).
+		Result("serverNames", "https", "webId", "reverseProxy").
+		Find()
+	if err != nil {
+		return nil, err
+	}
+	if one == nil {
+		return nil, ErrNotFound
+	}
+	server := one.(*Server)
+
+	source := &ServerConfigRevisionSource{
+		ServerNames:  server.ServerNames,
+		HTTPS:        server.Https,
+		WebId:        int64(server.WebId),
+		ReverseProxy: server.ReverseProxy,
+	}
+
+	// Web
+	if server.WebId > 0 {
+		source.Web, err = this.findFields(SharedHTTPWebDAO.Query(tx).Pk(server.WebId), serverConfigRevisionWebFields)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 反向代理
+	if IsNotNull(server.ReverseProxy) {
+		ref := &serverconfigs.ReverseProxyRef{}
+		err = json.Unmarshal([]byte(server.ReverseProxy), ref)
+		if err != nil {
+			return nil, err
+		}
+		if ref.ReverseProxyId > 0 {
+			source.ReverseProxyId = ref.ReverseProxyId
+			source.ReverseProxySettings, err = this.findFields(SharedReverseProxyDAO.Query(tx).Pk(ref.ReverseProxyId), serverConfigRevisionReverseProxyFields)
+			if err != nil {
+				return nil, err
+			}
+
+			// 源站
+			for _, field := range []string{"primaryOrigins", "backupOrigins"} {
+				originsJSON := source.ReverseProxySettings.GetString(field)
+				if !IsNotNull(originsJSON) {
+					continue
+				}
+				originRefs := []*serverconfigs.OriginRef{}
+				err = json.Unmarshal([]byte(originsJSON), &originRefs)
+				if err != nil {
+					return nil, err
+				}
+				for _, originRef := range originRefs {
+					if originRef.OriginId <= 0 {
+						continue
+					}
+					origin, err := this.findFields(SharedOriginDAO.Query(tx).Pk(originRef.OriginId), append([]string{"id"}, serverConfigRevisionOriginFields...))
+					if err != nil {
+						return nil, err
+					}
+					if len(origin) > 0 {
+						source.Origins = append(source.Origins, origin)
+					}
+				}
+			}
+		}
+	}
+
+	return source, nil
+}
+
+// 读取一组字段
+func (this *ServerConfigRevisionDAO) findFields(query *dbs.Query, fields []string) (maps.Map, error) {
+	ones, _, err := query.
+		Result(fields...).
+		FindOnes()
+	if err != nil {
+		return nil, err
+	}
+	if len(ones) == 0 {
+		return nil, nil
+	}
+	return ones[0], nil
+}
+
+// 恢复一组字段
+func (this *ServerConfigRevisionDAO) restoreFields(query *dbs.Query, values maps.Map, fields []string) error {
+	for _, field := range fields {
+		value, ok := values[field]
+		if !ok {
+			continue
+		}
+		query.Set(field, value)
+	}
+	_, err := query.Update()
+	return err
+}
@@ -0,0 +1,30 @@
+package models
+
+// 服务配置版本
+type ServerConfigRevision struct {
+	Id          uint64 `field:"id"`          // ID
+	ServerId    uint32 `field:"serverId"`    // 服务ID
+	Config      string `field:"config"`      // 组合后的配置
+	ConfigMd5   string `field:"configMd5"`   // 配置MD5
+	Source      string `field:"source"`      // 用于回滚的原始设置
+	AuthorType  string `field:"authorType"`  // 修改者类型
+	AuthorId    uint32 `field:"authorId"`    // 修改者ID
+	Description string `field:"description"` // 描述
+	CreatedAt   uint64 `field:"createdAt"`   // 创建时间
+}
+
+type ServerConfigRevisionOperator struct {
+	Id          interface{} // ID
+	ServerId    interface{} // 服务ID
+	Config      interface{} // 组合后的配置
+	ConfigMd5   interface{} // 配置MD5
+	Source      interface{} // 用于回滚的原始设置
+	AuthorType  interface{} // 修改者类型
+	AuthorId    interface{} // 修改者ID
+	Description interface{} // 描述
+	CreatedAt   interface{} // 创建时间
+}
+
+func NewServerConfigRevisionOperator() *ServerConfigRevisionOperator {
+	return &ServerConfigRevisionOperator{}
+}
@@ -0,0 +1,15 @@
+package models
+
+import "github.com/iwind/TeaGo/maps"
+
+// 配置版本对应的原始设置，用于回滚
+type ServerConfigRevisionSource struct {
+	ServerNames          string     `json:"serverNames"`          // 域名
+	HTTPS                string     `json:"https"`                // HTTPS
+	WebId                int64      `json:"webId"`                // Web ID
+	Web                  maps.Map   `json:"web"`                  // Web设置
+	ReverseProxy         string     `json:"reverseProxy"`         // 反向代理引用
+	ReverseProxyId       int64      `json:"reverseProxyId"`       // 反向代理ID
+	ReverseProxySettings maps.Map   `json:"reverseProxySettings"` // 反向代理设置
+	Origins              []maps.Map `json:"origins"`              // 源站设置
+}
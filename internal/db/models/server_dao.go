@@ -310,6 +310,11 @@ func (this *ServerDAO) UpdateServerIsOn(tx *dbs.Tx, serverId int64, isOn bool) e
 
 // 修改服务配置
 func (this *ServerDAO) UpdateServerConfig(tx *dbs.Tx, serverId int64, configJSON []byte, updateMd5 bool) (isChanged bool, err error) {
+	return this.updateServerConfig(tx, serverId, configJSON, updateMd5, nil)
+}
+
+// 修改服务配置，并记录配置版本
+func (this *ServerDAO) updateServerConfig(tx *dbs.Tx, serverId int64, configJSON []byte, updateMd5 bool, author *ServerConfigRevisionAuthor) (isChanged bool, err error) {
 	if serverId <= 0 {
 		return false, errors.New("serverId should not be smaller than 0")
 	}
@@ -348,7 +353,15 @@ func (this *ServerDAO) UpdateServerConfig(tx *dbs.Tx, serverId int64, configJSON
 		op.ConfigMd5 = newConfigMd5
 	}
 	err = this.Save(tx, op)
-	return true, err
+	if err != nil {
+		return false, err
+	}
+
+	err = SharedServerConfigRevisionDAO.CreateRevision(tx, serverId, configJSON, author)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 // 修改HTTP配置
@@ -371,7 +384,7 @@ func (this *ServerDAO) UpdateServerHTTP(tx *dbs.Tx, serverId int64, config []byt
 }
 
 // 修改HTTPS配置
-func (this *ServerDAO) UpdateServerHTTPS(tx *dbs.Tx, serverId int64, httpsJSON []byte) error {
+func (this *ServerDAO) UpdateServerHTTPS(tx *dbs.Tx, serverId int64, httpsJSON []byte, author *ServerConfigRevisionAuthor) error {
 	if serverId <= 0 {
 		return errors.New("serverId should not be smaller than 0")
 	}
@@ -386,7 +399,7 @@ func (this *ServerDAO) UpdateServerHTTPS(tx *dbs.Tx, serverId int64, httpsJSON [
 		return err
 	}
 
-	return this.NotifyUpdate(tx, serverId)
+	return this.NotifyUpdateWithAuthor(tx, serverId, author)
 }
 
 // 修改TCP配置
@@ -466,7 +479,7 @@ func (this *ServerDAO) UpdateServerUDP(tx *dbs.Tx, serverId int64, config []byte
 }
 
 // 修改Web配置
-func (this *ServerDAO) UpdateServerWeb(tx *dbs.Tx, serverId int64, webId int64) error {
+func (this *ServerDAO) UpdateServerWeb(tx *dbs.Tx, serverId int64, webId int64, author *ServerConfigRevisionAuthor) error {
 	if serverId <= 0 {
 		return errors.New("serverId should not be smaller than 0")
 	}
@@ -477,7 +490,7 @@ func (this *ServerDAO) UpdateServerWeb(tx *dbs.Tx, serverId int64, webId int64)
 	if err != nil {
 		return err
 	}
-	return this.NotifyUpdate(tx, serverId)
+	return this.NotifyUpdateWithAuthor(tx, serverId, author)
 }
 
 // 初始化Web配置
@@ -532,7 +545,7 @@ func (this *ServerDAO) FindServerServerNames(tx *dbs.Tx, serverId int64) (server
 }
 
 // 修改ServerNames配置
-func (this *ServerDAO) UpdateServerNames(tx *dbs.Tx, serverId int64, serverNames []byte) error {
+func (this *ServerDAO) UpdateServerNames(tx *dbs.Tx, serverId int64, serverNames []byte, author *ServerConfigRevisionAuthor) error {
 	if serverId <= 0 {
 		return errors.New("serverId should not be smaller than 0")
 	}
@@ -548,7 +561,7 @@ func (this *ServerDAO) UpdateServerNames(tx *dbs.Tx, serverId int64, serverNames
 	if err != nil {
 		return err
 	}
-	return this.NotifyUpdate(tx, serverId)
+	return this.NotifyUpdateWithAuthor(tx, serverId, author)
 }
 
 // 修改域名审核
@@ -609,7 +622,7 @@ func (this *ServerDAO) UpdateServerAuditing(tx *dbs.Tx, serverId int64, result *
 }
 
 // 修改反向代理配置
-func (this *ServerDAO) UpdateServerReverseProxy(tx *dbs.Tx, serverId int64, config []byte) error {
+func (this *ServerDAO) UpdateServerReverseProxy(tx *dbs.Tx, serverId int64, config []byte, author *ServerConfigRevisionAuthor) error {
 	if serverId <= 0 {
 		return errors.New("serverId should not be smaller than 0")
 	}
@@ -621,7 +634,7 @@ func (this *ServerDAO) UpdateServerReverseProxy(tx *dbs.Tx, serverId int64, conf
 		return err
 	}
 
-	return this.NotifyUpdate(tx, serverId)
+	return this.NotifyUpdateWithAuthor(tx, serverId, author)
 }
 
 // 计算所有可用服务数量
@@ -743,6 +756,20 @@ func (this *ServerDAO) FindAllEnabledServerIdsWithUserId(tx *dbs.Tx, userId int6
 	return
 }
 
+// 获取某个集群的所有的服务ID
+func (this *ServerDAO) FindAllEnabledServerIdsWithClusterId(tx *dbs.Tx, clusterId int64) (serverIds []int64, err error) {
+	ones, err := this.Query(tx).
+		State(ServerStateEnabled).
+		Attr("clusterId", clusterId).
+		AscPk().
+		ResultPk().
+		FindAll()
+	for _, one := range ones {
+		serverIds = append(serverIds, int64(one.(*Server).Id))
+	}
+	return
+}
+
 // 查找服务的搜索条件
 func (this *ServerDAO) FindServerNodeFilters(tx *dbs.Tx, serverId int64) (isOk bool, clusterId int64, err error) {
 	one, err := this.Query(tx).
@@ -923,6 +950,11 @@ func (this *ServerDAO) ComposeServerConfig(tx *dbs.Tx, serverId int64) (*serverc
 
 // 更新服务的Config配置
 func (this *ServerDAO) RenewServerConfig(tx *dbs.Tx, serverId int64, updateMd5 bool) (isChanged bool, err error) {
+	return this.renewServerConfig(tx, serverId, updateMd5, nil)
+}
+
+// 更新服务的Config配置，并记录修改者
+func (this *ServerDAO) renewServerConfig(tx *dbs.Tx, serverId int64, updateMd5 bool, author *ServerConfigRevisionAuthor) (isChanged bool, err error) {
 	serverConfig, err := this.ComposeServerConfig(tx, serverId)
 	if err != nil {
 		return false, err
@@ -931,7 +963,7 @@ func (this *ServerDAO) RenewServerConfig(tx *dbs.Tx, serverId int64, updateMd5 b
 	if err != nil {
 		return false, err
 	}
-	return this.UpdateServerConfig(tx, serverId, data, updateMd5)
+	return this.updateServerConfig(tx, serverId, data, updateMd5, author)
 }
 
 // 根据条件获取反向代理配置
@@ -1337,8 +1369,13 @@ func (this *ServerDAO) GenDNSName(tx *dbs.Tx) (string, error) {
 
 // 同步集群
 func (this *ServerDAO) NotifyUpdate(tx *dbs.Tx, serverId int64) error {
+	return this.NotifyUpdateWithAuthor(tx, serverId, nil)
+}
+
+// 同步集群，并记录配置的修改者
+func (this *ServerDAO) NotifyUpdateWithAuthor(tx *dbs.Tx, serverId int64, author *ServerConfigRevisionAuthor) error {
 	// 更新配置
-	_, err := this.RenewServerConfig(tx, serverId, true)
+	_, err := this.renewServerConfig(tx, serverId, true, author)
 	if err != nil && err != ErrNotFound {
 		return err
 	}
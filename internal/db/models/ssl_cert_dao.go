@@ -314,6 +314,21 @@ func (this *SSLCertDAO) FindAllExpiringCerts(tx *dbs.Tx, days int) (result []*SS
 	return
 }
 
+// 查找所有在某个时间之前到期的ACME证书
+// 这里我们只返回有限的字段以节省内存
+func (this *SSLCertDAO) FindAllACMECertsExpiringBefore(tx *dbs.Tx, timestamp int64) (result []*SSLCert, err error) {
+	_, err = this.Query(tx).
+		State(SSLCertStateEnabled).
+		Attr("isOn", true).
+		Where("acmeTaskId>0").
+		Lte("timeEndAt", timestamp).
+		Result("id", "adminId", "userId", "timeEndAt", "name", "dnsNames", "acmeTaskId").
+		Slice(&result).
+		Asc("timeEndAt").
+		FindAll()
+	return
+}
+
 // 设置当前证书事件通知时间
 func (this *SSLCertDAO) UpdateCertNotifiedAt(tx *dbs.Tx, certId int64) error {
 	_, err := this.Query(tx).
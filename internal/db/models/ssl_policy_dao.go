@@ -180,6 +180,66 @@ func (this *SSLPolicyDAO) FindAllEnabledPolicyIdsWithCertId(tx *dbs.Tx, certId i
 	return policyIds, nil
 }
 
+// 将某个证书添加到所有使用另外一个证书的策略中
+// 用于在策略中同时提供RSA和ECDSA双证书
+func (this *SSLPolicyDAO) AddCertToPoliciesWithCertId(tx *dbs.Tx, certId int64, newCertId int64) error {
+	if certId <= 0 || newCertId <= 0 || certId == newCertId {
+		return nil
+	}
+
+	policyIds, err := this.FindAllEnabledPolicyIdsWithCertId(tx, certId)
+	if err != nil {
+		return err
+	}
+	for _, policyId := range policyIds {
+		certs, err := this.Query(tx).
+			Pk(policyId).
+			Result("certs").
+			FindStringCol("")
+		if err != nil {
+			return err
+		}
+		refs := []*sslconfigs.SSLCertRef{}
+		if IsNotNull(certs) {
+			err = json.Unmarshal([]byte(certs), &refs)
+			if err != nil {
+				return err
+			}
+		}
+
+		found := false
+		for _, ref := range refs {
+			if ref.CertId == newCertId {
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		refs = append(refs, &sslconfigs.SSLCertRef{
+			IsOn:   true,
+			CertId: newCertId,
+		})
+		refsJSON, err := json.Marshal(refs)
+		if err != nil {
+			return err
+		}
+		_, err = this.Query(tx).
+			Pk(policyId).
+			Set("certs", refsJSON).
+			Update()
+		if err != nil {
+			return err
+		}
+		err = this.NotifyUpdate(tx, policyId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // 创建Policy
 func (this *SSLPolicyDAO) CreatePolicy(tx *dbs.Tx, adminId int64, userId int64, http2Enabled bool, minVersion string, certsJSON []byte, hstsJSON []byte, clientAuthType int32, clientCACertsJSON []byte, cipherSuitesIsOn bool, cipherSuites []string) (int64, error) {
 	op := NewSSLPolicyOperator()
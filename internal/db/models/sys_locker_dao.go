@@ -6,6 +6,7 @@ import (
 	"github.com/iwind/TeaGo/dbs"
 	"github.com/iwind/TeaGo/maps"
 	"github.com/iwind/TeaGo/types"
+	"strconv"
 	"time"
 )
 
@@ -114,11 +115,20 @@ func (this *SysLockerDAO) Unlock(tx *dbs.Tx, key string) error {
 
 // 增加版本号
 func (this *SysLockerDAO) Increase(tx *dbs.Tx, key string, defaultValue int64) (int64, error) {
+	return this.IncreaseBy(tx, key, defaultValue, 1)
+}
+
+// 一次增加多个版本号，返回增加后的版本号
+// 调用者可以使用 [返回值-count+1, 返回值] 之间的所有版本号
+func (this *SysLockerDAO) IncreaseBy(tx *dbs.Tx, key string, defaultValue int64, count int64) (int64, error) {
+	if count < 1 {
+		count = 1
+	}
 	if tx == nil {
 		var result int64
 		var err error
 		err = this.Instance.RunTx(func(tx *dbs.Tx) error {
-			result, err = this.Increase(tx, key, defaultValue)
+			result, err = this.IncreaseBy(tx, key, defaultValue, count)
 			if err != nil {
 				return err
 			}
@@ -129,9 +139,9 @@ func (this *SysLockerDAO) Increase(tx *dbs.Tx, key string, defaultValue int64) (
 	err := this.Query(tx).
 		InsertOrUpdateQuickly(maps.Map{
 			"key":     key,
-			"version": defaultValue,
+			"version": defaultValue + count - 1,
 		}, maps.Map{
-			"version": dbs.SQL("version+1"),
+			"version": dbs.SQL("version+" + strconv.FormatInt(count, 10)),
 		})
 	if err != nil {
 		return 0, err
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"github.com/TeaOSLab/EdgeAPI/internal/errors"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/iwind/TeaGo/Tea"
@@ -49,7 +50,10 @@ func (this *UserAccessKeyDAO) DisableUserAccessKey(tx *dbs.Tx, id int64) error {
 		Pk(id).
 		Set("state", UserAccessKeyStateDisabled).
 		Update()
-	return err
+	if err != nil {
+		return err
+	}
+	return SharedAPIAccessTokenDAO.DeleteAccessTokensWithAccessKeyId(tx, id)
 }
 
 // 查找启用中的条目
@@ -65,13 +69,23 @@ func (this *UserAccessKeyDAO) FindEnabledUserAccessKey(tx *dbs.Tx, id int64) (*U
 }
 
 // 创建Key
-func (this *UserAccessKeyDAO) CreateAccessKey(tx *dbs.Tx, userId int64, description string) (int64, error) {
-	if userId <= 0 {
-		return 0, errors.New("invalid userId")
+// adminId和userId只能有一个大于0，scopes为空表示可以访问所有的服务和方法
+func (this *UserAccessKeyDAO) CreateAccessKey(tx *dbs.Tx, adminId int64, userId int64, description string, scopes []string) (int64, error) {
+	if adminId <= 0 && userId <= 0 {
+		return 0, errors.New("invalid adminId or userId")
+	}
+	if userId > 0 {
+		adminId = 0
 	}
 	op := NewUserAccessKeyOperator()
+	op.AdminId = adminId
 	op.UserId = userId
 	op.Description = description
+	scopesJSON, err := this.encodeScopes(scopes)
+	if err != nil {
+		return 0, err
+	}
+	op.Scopes = scopesJSON
 	op.UniqueId = rands.String(16)
 	op.Secret = rands.String(32)
 	op.IsOn = true
@@ -79,9 +93,16 @@ func (this *UserAccessKeyDAO) CreateAccessKey(tx *dbs.Tx, userId int64, descript
 	return this.SaveInt64(tx, op)
 }
 
-// 查找用户所有的Key
-func (this *UserAccessKeyDAO) FindAllEnabledAccessKeys(tx *dbs.Tx, userId int64) (result []*UserAccessKey, err error) {
-	_, err = this.Query(tx).
+// 查找管理员或用户所有的Key
+func (this *UserAccessKeyDAO) FindAllEnabledAccessKeys(tx *dbs.Tx, adminId int64, userId int64) (result []*UserAccessKey, err error) {
+	query := this.Query(tx)
+	if userId > 0 {
+		query.Attr("userId", userId)
+	} else {
+		query.Attr("adminId", adminId)
+		query.Attr("userId", 0)
+	}
+	_, err = query.
 		State(UserAccessKeyStateEnabled).
 		DescPk().
 		Slice(&result).
@@ -107,7 +128,32 @@ func (this *UserAccessKeyDAO) UpdateAccessKeyIsOn(tx *dbs.Tx, accessKeyId int64,
 		Pk(accessKeyId).
 		Set("isOn", isOn).
 		Update()
-	return err
+	if err != nil {
+		return err
+	}
+	if !isOn {
+		return SharedAPIAccessTokenDAO.DeleteAccessTokensWithAccessKeyId(tx, accessKeyId)
+	}
+	return nil
+}
+
+// 修改允许访问的服务和方法
+func (this *UserAccessKeyDAO) UpdateAccessKeyScopes(tx *dbs.Tx, accessKeyId int64, scopes []string) error {
+	if accessKeyId <= 0 {
+		return errors.New("invalid accessKeyId")
+	}
+	scopesJSON, err := this.encodeScopes(scopes)
+	if err != nil {
+		return err
+	}
+	_, err = this.Query(tx).
+		Pk(accessKeyId).
+		Set("scopes", scopesJSON).
+		Update()
+	if err != nil {
+		return err
+	}
+	return SharedAPIAccessTokenDAO.DeleteAccessTokensWithAccessKeyId(tx, accessKeyId)
 }
 
 // 根据UniqueId查找AccessKey
@@ -123,3 +169,11 @@ func (this *UserAccessKeyDAO) FindAccessKeyWithUniqueId(tx *dbs.Tx, uniqueId str
 
 	return one.(*UserAccessKey), nil
 }
+
+// 编码Scopes
+func (this *UserAccessKeyDAO) encodeScopes(scopes []string) ([]byte, error) {
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return json.Marshal(scopes)
+}
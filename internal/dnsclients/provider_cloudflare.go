@@ -0,0 +1,361 @@
+package dnsclients
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	teaconst "github.com/TeaOSLab/EdgeAPI/internal/const"
+	"github.com/iwind/TeaGo/maps"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const cloudflareAPIEndpoint = "https://api.cloudflare.com/client/v4"
+
+var cloudflareHTTPClient = &http.Client{
+	Timeout: 10 * time.Second,
+}
+
+// Cloudflare服务商
+type CloudflareProvider struct {
+	BaseProvider
+
+	apiToken string
+	apiKey   string
+	email    string
+	endpoint string
+
+	zoneMap    map[string]string // domain => zoneId
+	zoneLocker sync.Mutex
+}
+
+// 认证
+// 参数：
+//   - apiToken 或者 apiKey + email
+//   - endpoint 可选，默认为 https://api.cloudflare.com/client/v4
+func (this *CloudflareProvider) Auth(params maps.Map) error {
+	this.apiToken = params.GetString("apiToken")
+	this.apiKey = params.GetString("apiKey")
+	this.email = params.GetString("email")
+	this.endpoint = strings.TrimRight(params.GetString("endpoint"), "/")
+	if len(this.endpoint) == 0 {
+		this.endpoint = cloudflareAPIEndpoint
+	}
+
+	if len(this.apiToken) == 0 {
+		if len(this.apiKey) == 0 {
+			return errors.New("'apiToken' or 'apiKey' should not be empty")
+		}
+		if len(this.email) == 0 {
+			return errors.New("'email' should not be empty when using 'apiKey'")
+		}
+	}
+	return nil
+}
+
+// 获取域名解析记录列表
+func (this *CloudflareProvider) GetRecords(domain string) (records []*Record, err error) {
+	zoneId, err := this.findZoneId(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	page := 1
+	size := 100
+	for {
+		resp := &cloudflareRecordsResponse{}
+		err = this.doAPI(http.MethodGet, "/zones/"+zoneId+"/dns_records", url.Values{
+			"page":     []string{strconv.Itoa(page)},
+			"per_page": []string{strconv.Itoa(size)},
+		}, nil, resp)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range resp.Result {
+			records = append(records, this.convertRecord(domain, record))
+		}
+
+		if resp.ResultInfo.TotalPages <= page || len(resp.Result) == 0 {
+			break
+		}
+		page++
+	}
+	return
+}
+
+// 读取域名支持的线路数据
+// Cloudflare不支持线路，所以只返回一个默认线路
+func (this *CloudflareProvider) GetRoutes(domain string) (routes []*Route, err error) {
+	routes = []*Route{
+		{
+			Name: "默认",
+			Code: this.DefaultRoute(),
+		},
+	}
+	return
+}
+
+// 查询单个记录
+func (this *CloudflareProvider) QueryRecord(domain string, name string, recordType RecordType) (*Record, error) {
+	zoneId, err := this.findZoneId(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &cloudflareRecordsResponse{}
+	err = this.doAPI(http.MethodGet, "/zones/"+zoneId+"/dns_records", url.Values{
+		"type": []string{recordType},
+		"name": []string{this.fullName(domain, name)},
+	}, nil, resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Result) == 0 {
+		return nil, nil
+	}
+	return this.convertRecord(domain, resp.Result[0]), nil
+}
+
+// 设置记录
+func (this *CloudflareProvider) AddRecord(domain string, newRecord *Record) error {
+	if newRecord == nil {
+		return errors.New("invalid new record")
+	}
+	zoneId, err := this.findZoneId(domain)
+	if err != nil {
+		return err
+	}
+
+	resp := &cloudflareRecordResponse{}
+	return this.doAPI(http.MethodPost, "/zones/"+zoneId+"/dns_records", nil, this.buildRecordParams(domain, newRecord), resp)
+}
+
+// 修改记录
+func (this *CloudflareProvider) UpdateRecord(domain string, record *Record, newRecord *Record) error {
+	if record == nil {
+		return errors.New("invalid record")
+	}
+	if newRecord == nil {
+		return errors.New("invalid new record")
+	}
+	zoneId, err := this.findZoneId(domain)
+	if err != nil {
+		return err
+	}
+
+	resp := &cloudflareRecordResponse{}
+	return this.doAPI(http.MethodPut, "/zones/"+zoneId+"/dns_records/"+record.Id, nil, this.buildRecordParams(domain, newRecord), resp)
+}
+
+// 删除记录
+func (this *CloudflareProvider) DeleteRecord(domain string, record *Record) error {
+	if record == nil {
+		return errors.New("invalid record to delete")
+	}
+	zoneId, err := this.findZoneId(domain)
+	if err != nil {
+		return err
+	}
+
+	resp := &cloudflareBaseResponse{}
+	return this.doAPI(http.MethodDelete, "/zones/"+zoneId+"/dns_records/"+record.Id, nil, nil, resp)
+}
+
+// 默认线路
+func (this *CloudflareProvider) DefaultRoute() string {
+	return "default"
+}
+
+// 根据域名查找Zone ID
+func (this *CloudflareProvider) findZoneId(domain string) (string, error) {
+	this.zoneLocker.Lock()
+	zoneId, ok := this.zoneMap[domain]
+	this.zoneLocker.Unlock()
+	if ok {
+		return zoneId, nil
+	}
+
+	resp := &cloudflareZonesResponse{}
+	err := this.doAPI(http.MethodGet, "/zones", url.Values{
+		"name": []string{domain},
+	}, nil, resp)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Result) == 0 {
+		return "", errors.New("can not find zone for domain '" + domain + "'")
+	}
+	zoneId = resp.Result[0].Id
+
+	this.zoneLocker.Lock()
+	if this.zoneMap == nil {
+		this.zoneMap = map[string]string{}
+	}
+	this.zoneMap[domain] = zoneId
+	this.zoneLocker.Unlock()
+
+	return zoneId, nil
+}
+
+// 转换Cloudflare记录
+func (this *CloudflareProvider) convertRecord(domain string, record *cloudflareRecord) *Record {
+	value := record.Content
+	if record.Type == RecordTypeCName && !strings.HasSuffix(value, ".") {
+		value += "."
+	}
+	return &Record{
+		Id:    record.Id,
+		Name:  this.shortName(domain, record.Name),
+		Type:  record.Type,
+		Value: value,
+		Route: this.DefaultRoute(),
+	}
+}
+
+// 构造记录参数
+func (this *CloudflareProvider) buildRecordParams(domain string, record *Record) maps.Map {
+	return maps.Map{
+		"type":    record.Type,
+		"name":    this.fullName(domain, record.Name),
+		"content": strings.TrimSuffix(record.Value, "."),
+		"ttl":     1, // 1 表示自动
+	}
+}
+
+// 子域名转换为完整域名
+func (this *CloudflareProvider) fullName(domain string, name string) string {
+	if len(name) == 0 || name == "@" {
+		return domain
+	}
+	return name + "." + domain
+}
+
+// 完整域名转换为子域名
+func (this *CloudflareProvider) shortName(domain string, name string) string {
+	if name == domain {
+		return "@"
+	}
+	return strings.TrimSuffix(name, "."+domain)
+}
+
+// 执行请求
+func (this *CloudflareProvider) doAPI(method string, path string, query url.Values, params maps.Map, respPtr cloudflareResponseInterface) error {
+	apiURL := this.endpoint + path
+	if len(query) > 0 {
+		apiURL += "?" + query.Encode()
+	}
+
+	var body io.Reader
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, apiURL, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "GoEdge/"+teaconst.Version)
+	if len(this.apiToken) > 0 {
+		req.Header.Set("Authorization", "Bearer "+this.apiToken)
+	} else {
+		req.Header.Set("X-Auth-Key", this.apiKey)
+		req.Header.Set("X-Auth-Email", this.email)
+	}
+
+	resp, err := cloudflareHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(data, respPtr)
+	if err != nil {
+		return errors.New("decode response failed: " + err.Error() + ", status: " + strconv.Itoa(resp.StatusCode))
+	}
+	if !respPtr.IsSuccess() {
+		return errors.New(respPtr.ErrorString())
+	}
+	return nil
+}
+
+type cloudflareResponseInterface interface {
+	IsSuccess() bool
+	ErrorString() string
+}
+
+type cloudflareBaseResponse struct {
+	Success bool `json:"success"`
+	Errors  []struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+	ResultInfo struct {
+		Page       int `json:"page"`
+		PerPage    int `json:"per_page"`
+		TotalPages int `json:"total_pages"`
+		Count      int `json:"count"`
+		TotalCount int `json:"total_count"`
+	} `json:"result_info"`
+}
+
+func (this *cloudflareBaseResponse) IsSuccess() bool {
+	return this.Success
+}
+
+func (this *cloudflareBaseResponse) ErrorString() string {
+	if len(this.Errors) == 0 {
+		return "unknown error"
+	}
+	s := []string{}
+	for _, err := range this.Errors {
+		s = append(s, "code: "+strconv.Itoa(err.Code)+", message: "+err.Message)
+	}
+	return strings.Join(s, "; ")
+}
+
+type cloudflareZone struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type cloudflareZonesResponse struct {
+	cloudflareBaseResponse
+
+	Result []*cloudflareZone `json:"result"`
+}
+
+type cloudflareRecord struct {
+	Id      string `json:"id"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	TTL     int    `json:"ttl"`
+}
+
+type cloudflareRecordsResponse struct {
+	cloudflareBaseResponse
+
+	Result []*cloudflareRecord `json:"result"`
+}
+
+type cloudflareRecordResponse struct {
+	cloudflareBaseResponse
+
+	Result *cloudflareRecord `json:"result"`
+}
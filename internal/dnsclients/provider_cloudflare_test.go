@@ -0,0 +1,279 @@
+package dnsclients
+
+import (
+	"encoding/json"
+	"github.com/iwind/TeaGo/maps"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCloudflareProvider_GetRecords(t *testing.T) {
+	provider, server := testCloudflareProvider(t)
+	defer server.Close()
+
+	for i := 0; i < 150; i++ {
+		err := provider.AddRecord("example.com", &Record{
+			Name:  "node" + strconv.Itoa(i),
+			Type:  RecordTypeA,
+			Value: "192.168.1." + strconv.Itoa(i),
+			Route: provider.DefaultRoute(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records, err := provider.GetRecords("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 150 {
+		t.Fatal("expect 150 records, but got", len(records))
+	}
+	if records[0].Name != "node0" || records[0].Route != provider.DefaultRoute() {
+		t.Fatalf("invalid record: %#v", records[0])
+	}
+}
+
+func TestCloudflareProvider_GetRoutes(t *testing.T) {
+	provider, server := testCloudflareProvider(t)
+	defer server.Close()
+
+	routes, err := provider.GetRoutes("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 1 || routes[0].Code != provider.DefaultRoute() {
+		t.Fatalf("invalid routes: %#v", routes)
+	}
+}
+
+func TestCloudflareProvider_Records(t *testing.T) {
+	provider, server := testCloudflareProvider(t)
+	defer server.Close()
+
+	err := provider.AddRecord("example.com", &Record{
+		Name:  "www",
+		Type:  RecordTypeCName,
+		Value: "cdn.example.net.",
+		Route: provider.DefaultRoute(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record, err := provider.QueryRecord("example.com", "www", RecordTypeCName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record == nil {
+		t.Fatal("record should not be nil")
+	}
+	if record.Value != "cdn.example.net." {
+		t.Fatal("CNAME value should end with dot, but got", record.Value)
+	}
+
+	err = provider.UpdateRecord("example.com", record, &Record{
+		Name:  "www",
+		Type:  RecordTypeCName,
+		Value: "cdn2.example.net",
+		Route: provider.DefaultRoute(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err = provider.QueryRecord("example.com", "www", RecordTypeCName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record == nil || record.Value != "cdn2.example.net." {
+		t.Fatalf("update failed: %#v", record)
+	}
+
+	err = provider.DeleteRecord("example.com", record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err = provider.QueryRecord("example.com", "www", RecordTypeCName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record != nil {
+		t.Fatal("record should be deleted")
+	}
+}
+
+func TestCloudflareProvider_InvalidZone(t *testing.T) {
+	provider, server := testCloudflareProvider(t)
+	defer server.Close()
+
+	_, err := provider.GetRecords("not-exist.com")
+	if err == nil {
+		t.Fatal("should return error for unknown zone")
+	}
+	t.Log(err)
+}
+
+func TestCloudflareProvider_InvalidToken(t *testing.T) {
+	provider, server := testCloudflareProvider(t)
+	defer server.Close()
+
+	err := provider.Auth(maps.Map{
+		"apiToken": "invalid",
+		"endpoint": server.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = provider.GetRecords("example.com")
+	if err == nil {
+		t.Fatal("should return error for invalid token")
+	}
+	t.Log(err)
+}
+
+// 使用httptest模拟Cloudflare v4 API
+func testCloudflareProvider(t *testing.T) (*CloudflareProvider, *httptest.Server) {
+	locker := sync.Mutex{}
+	records := []maps.Map{}
+	lastId := 0
+
+	writeJSON := func(writer http.ResponseWriter, status int, m maps.Map) {
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(status)
+		data, _ := json.Marshal(m)
+		_, _ = writer.Write(data)
+	}
+	writeError := func(writer http.ResponseWriter, status int, message string) {
+		writeJSON(writer, status, maps.Map{
+			"success": false,
+			"errors": []maps.Map{
+				{
+					"code":    status,
+					"message": message,
+				},
+			},
+		})
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		locker.Lock()
+		defer locker.Unlock()
+
+		if req.Header.Get("Authorization") != "Bearer test-token" {
+			writeError(writer, http.StatusForbidden, "Invalid API Token")
+			return
+		}
+
+		path := req.URL.Path
+		query := req.URL.Query()
+		if path == "/zones" {
+			result := []maps.Map{}
+			if query.Get("name") == "example.com" {
+				result = append(result, maps.Map{"id": "zone1", "name": "example.com"})
+			}
+			writeJSON(writer, http.StatusOK, maps.Map{"success": true, "result": result})
+			return
+		}
+		if !strings.HasPrefix(path, "/zones/zone1/dns_records") {
+			writeError(writer, http.StatusNotFound, "not found")
+			return
+		}
+		recordId := strings.TrimPrefix(strings.TrimPrefix(path, "/zones/zone1/dns_records"), "/")
+
+		switch req.Method {
+		case http.MethodGet:
+			filtered := []maps.Map{}
+			for _, record := range records {
+				if len(query.Get("type")) > 0 && record.GetString("type") != query.Get("type") {
+					continue
+				}
+				if len(query.Get("name")) > 0 && record.GetString("name") != query.Get("name") {
+					continue
+				}
+				filtered = append(filtered, record)
+			}
+
+			page, _ := strconv.Atoi(query.Get("page"))
+			if page <= 0 {
+				page = 1
+			}
+			perPage, _ := strconv.Atoi(query.Get("per_page"))
+			if perPage <= 0 {
+				perPage = 20
+			}
+			from := (page - 1) * perPage
+			to := from + perPage
+			if from > len(filtered) {
+				from = len(filtered)
+			}
+			if to > len(filtered) {
+				to = len(filtered)
+			}
+			writeJSON(writer, http.StatusOK, maps.Map{
+				"success": true,
+				"result":  filtered[from:to],
+				"result_info": maps.Map{
+					"page":        page,
+					"per_page":    perPage,
+					"count":       to - from,
+					"total_count": len(filtered),
+					"total_pages": (len(filtered) + perPage - 1) / perPage,
+				},
+			})
+		case http.MethodPost, http.MethodPut:
+			data, _ := ioutil.ReadAll(req.Body)
+			params := maps.Map{}
+			err := json.Unmarshal(data, &params)
+			if err != nil {
+				writeError(writer, http.StatusBadRequest, err.Error())
+				return
+			}
+			if strings.HasSuffix(params.GetString("content"), ".") {
+				writeError(writer, http.StatusBadRequest, "content should not end with dot")
+				return
+			}
+			if req.Method == http.MethodPost {
+				lastId++
+				params["id"] = "record" + strconv.Itoa(lastId)
+				records = append(records, params)
+				writeJSON(writer, http.StatusOK, maps.Map{"success": true, "result": params})
+				return
+			}
+			for index, record := range records {
+				if record.GetString("id") == recordId {
+					params["id"] = recordId
+					records[index] = params
+					writeJSON(writer, http.StatusOK, maps.Map{"success": true, "result": params})
+					return
+				}
+			}
+			writeError(writer, http.StatusNotFound, "record not found")
+		case http.MethodDelete:
+			for index, record := range records {
+				if record.GetString("id") == recordId {
+					records = append(records[:index], records[index+1:]...)
+					writeJSON(writer, http.StatusOK, maps.Map{"success": true, "result": maps.Map{"id": recordId}})
+					return
+				}
+			}
+			writeError(writer, http.StatusNotFound, "record not found")
+		}
+	}))
+
+	provider := &CloudflareProvider{}
+	err := provider.Auth(maps.Map{
+		"apiToken": "test-token",
+		"endpoint": server.URL,
+	})
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	return provider, server
+}
@@ -0,0 +1,354 @@
+package dnsclients
+
+import (
+	"errors"
+	"github.com/iwind/TeaGo/maps"
+	"github.com/miekg/dns"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RFC 2136动态更新服务商
+// 适用于BIND、Knot、PowerDNS等支持动态更新（UPDATE）和区域传送（AXFR）的权威DNS服务器
+type RFC2136Provider struct {
+	BaseProvider
+
+	server    string // host:port
+	keyName   string
+	keySecret string // base64
+	algorithm string
+	ttl       uint32
+	timeout   time.Duration
+}
+
+// 认证
+// 参数：
+//   - server 服务器地址，可以带端口，默认端口为53
+//   - keyName TSIG密钥名称
+//   - keySecret TSIG密钥（Base64编码）
+//   - algorithm TSIG算法，默认为hmac-sha256
+//   - ttl 新记录的TTL，默认为600
+func (this *RFC2136Provider) Auth(params maps.Map) error {
+	this.server = params.GetString("server")
+	if len(this.server) == 0 {
+		return errors.New("'server' should not be empty")
+	}
+	_, _, err := net.SplitHostPort(this.server)
+	if err != nil {
+		this.server = net.JoinHostPort(this.server, "53")
+	}
+
+	this.keyName = params.GetString("keyName")
+	this.keySecret = params.GetString("keySecret")
+	if len(this.keyName) > 0 && len(this.keySecret) == 0 {
+		return errors.New("'keySecret' should not be empty")
+	}
+	if len(this.keyName) > 0 {
+		this.keyName = dns.Fqdn(this.keyName)
+	}
+
+	this.algorithm = params.GetString("algorithm")
+	if len(this.algorithm) == 0 {
+		this.algorithm = dns.HmacSHA256
+	} else {
+		this.algorithm = dns.Fqdn(strings.ToLower(this.algorithm))
+	}
+	switch this.algorithm {
+	case dns.HmacSHA1, dns.HmacSHA256, dns.HmacSHA512:
+	default:
+		return errors.New("unsupported TSIG algorithm '" + this.algorithm + "'")
+	}
+
+	this.ttl = 600
+	ttl, err := strconv.Atoi(params.GetString("ttl"))
+	if err == nil && ttl > 0 {
+		this.ttl = uint32(ttl)
+	}
+
+	this.timeout = 10 * time.Second
+
+	return nil
+}
+
+// 获取域名解析记录列表
+// 使用AXFR区域传送获取
+func (this *RFC2136Provider) GetRecords(domain string) (records []*Record, err error) {
+	zone := dns.Fqdn(domain)
+
+	msg := new(dns.Msg)
+	msg.SetAxfr(zone)
+	this.signMsg(msg)
+
+	transfer := &dns.Transfer{
+		DialTimeout:  this.timeout,
+		ReadTimeout:  this.timeout,
+		WriteTimeout: this.timeout,
+		TsigSecret:   this.tsigSecret(),
+	}
+	envelopes, err := transfer.In(msg, this.server)
+	if err != nil {
+		return nil, err
+	}
+	for envelope := range envelopes {
+		if envelope.Error != nil {
+			return nil, envelope.Error
+		}
+		for _, rr := range envelope.RR {
+			record := this.convertRR(zone, rr)
+			if record != nil {
+				records = append(records, record)
+			}
+		}
+	}
+	return
+}
+
+// 读取域名支持的线路数据
+// RFC 2136不支持线路，所以只返回一个默认线路
+func (this *RFC2136Provider) GetRoutes(domain string) (routes []*Route, err error) {
+	routes = []*Route{
+		{
+			Name: "默认",
+			Code: this.DefaultRoute(),
+		},
+	}
+	return
+}
+
+// 查询单个记录
+// 直接向权威服务器查询，不需要区域传送权限
+func (this *RFC2136Provider) QueryRecord(domain string, name string, recordType RecordType) (*Record, error) {
+	rrType, ok := dns.StringToType[recordType]
+	if !ok {
+		return nil, errors.New("invalid record type '" + recordType + "'")
+	}
+	zone := dns.Fqdn(domain)
+
+	msg := new(dns.Msg)
+	msg.SetQuestion(this.fullName(zone, name), rrType)
+	msg.RecursionDesired = false
+	this.signMsg(msg)
+
+	resp, err := this.exchange(msg)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Rcode == dns.RcodeNameError {
+		return nil, nil
+	}
+	if resp.Rcode != dns.RcodeSuccess {
+		return nil, errors.New("query failed: " + dns.RcodeToString[resp.Rcode])
+	}
+	for _, rr := range resp.Answer {
+		if rr.Header().Rrtype != rrType {
+			continue
+		}
+		record := this.convertRR(zone, rr)
+		if record != nil {
+			return record, nil
+		}
+	}
+	return nil, nil
+}
+
+// 设置记录
+func (this *RFC2136Provider) AddRecord(domain string, newRecord *Record) error {
+	if newRecord == nil {
+		return errors.New("invalid new record")
+	}
+	zone := dns.Fqdn(domain)
+	rr, err := this.buildRR(zone, newRecord)
+	if err != nil {
+		return err
+	}
+
+	msg := new(dns.Msg)
+	msg.SetUpdate(zone)
+	msg.Insert([]dns.RR{rr})
+	return this.update(msg)
+}
+
+// 修改记录
+// 在同一个UPDATE消息中删除旧记录、添加新记录，保证操作的原子性
+func (this *RFC2136Provider) UpdateRecord(domain string, record *Record, newRecord *Record) error {
+	if record == nil {
+		return errors.New("invalid record")
+	}
+	if newRecord == nil {
+		return errors.New("invalid new record")
+	}
+	zone := dns.Fqdn(domain)
+	oldRR, err := this.buildRR(zone, record)
+	if err != nil {
+		return err
+	}
+	newRR, err := this.buildRR(zone, newRecord)
+	if err != nil {
+		return err
+	}
+
+	msg := new(dns.Msg)
+	msg.SetUpdate(zone)
+	msg.Remove([]dns.RR{oldRR})
+	msg.Insert([]dns.RR{newRR})
+	return this.update(msg)
+}
+
+// 删除记录
+func (this *RFC2136Provider) DeleteRecord(domain string, record *Record) error {
+	if record == nil {
+		return errors.New("invalid record to delete")
+	}
+	zone := dns.Fqdn(domain)
+	rr, err := this.buildRR(zone, record)
+	if err != nil {
+		return err
+	}
+
+	msg := new(dns.Msg)
+	msg.SetUpdate(zone)
+	msg.Remove([]dns.RR{rr})
+	return this.update(msg)
+}
+
+// 默认线路
+func (this *RFC2136Provider) DefaultRoute() string {
+	return "default"
+}
+
+// 发送UPDATE消息
+func (this *RFC2136Provider) update(msg *dns.Msg) error {
+	this.signMsg(msg)
+
+	resp, err := this.exchange(msg)
+	if err != nil {
+		return err
+	}
+	if resp.Rcode != dns.RcodeSuccess {
+		return errors.New("update failed: " + dns.RcodeToString[resp.Rcode])
+	}
+	return nil
+}
+
+// 发送消息
+func (this *RFC2136Provider) exchange(msg *dns.Msg) (*dns.Msg, error) {
+	client := &dns.Client{
+		Net:        "tcp",
+		Timeout:    this.timeout,
+		TsigSecret: this.tsigSecret(),
+	}
+	resp, _, err := client.Exchange(msg, this.server)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// 对消息进行TSIG签名
+func (this *RFC2136Provider) signMsg(msg *dns.Msg) {
+	if len(this.keyName) == 0 {
+		return
+	}
+	msg.SetTsig(this.keyName, this.algorithm, 300, time.Now().Unix())
+}
+
+// TSIG密钥
+func (this *RFC2136Provider) tsigSecret() map[string]string {
+	if len(this.keyName) == 0 {
+		return nil
+	}
+	return map[string]string{
+		this.keyName: this.keySecret,
+	}
+}
+
+// 将记录转换为RR
+func (this *RFC2136Provider) buildRR(zone string, record *Record) (dns.RR, error) {
+	header := dns.RR_Header{
+		Name:  this.fullName(zone, record.Name),
+		Class: dns.ClassINET,
+		Ttl:   this.ttl,
+	}
+
+	switch record.Type {
+	case RecordTypeA:
+		ip := net.ParseIP(record.Value).To4()
+		if ip == nil {
+			return nil, errors.New("invalid IPv4 address '" + record.Value + "'")
+		}
+		header.Rrtype = dns.TypeA
+		return &dns.A{Hdr: header, A: ip}, nil
+	case RecordTypeAAAA:
+		ip := net.ParseIP(record.Value)
+		if ip == nil || ip.To4() != nil {
+			return nil, errors.New("invalid IPv6 address '" + record.Value + "'")
+		}
+		header.Rrtype = dns.TypeAAAA
+		return &dns.AAAA{Hdr: header, AAAA: ip}, nil
+	case RecordTypeCName:
+		header.Rrtype = dns.TypeCNAME
+		return &dns.CNAME{Hdr: header, Target: dns.Fqdn(record.Value)}, nil
+	case RecordTypeTXT:
+		header.Rrtype = dns.TypeTXT
+		return &dns.TXT{Hdr: header, Txt: this.splitTXT(record.Value)}, nil
+	}
+	return nil, errors.New("unsupported record type '" + record.Type + "'")
+}
+
+// 将RR转换为记录
+func (this *RFC2136Provider) convertRR(zone string, rr dns.RR) *Record {
+	header := rr.Header()
+	record := &Record{
+		Name:  this.shortName(zone, header.Name),
+		Route: this.DefaultRoute(),
+	}
+	switch v := rr.(type) {
+	case *dns.A:
+		record.Type = RecordTypeA
+		record.Value = v.A.String()
+	case *dns.AAAA:
+		record.Type = RecordTypeAAAA
+		record.Value = v.AAAA.String()
+	case *dns.CNAME:
+		record.Type = RecordTypeCName
+		record.Value = v.Target
+	case *dns.TXT:
+		record.Type = RecordTypeTXT
+		record.Value = strings.Join(v.Txt, "")
+	default:
+		return nil
+	}
+
+	// RFC 2136中没有记录ID，这里使用记录内容作为ID
+	record.Id = record.Name + "@" + record.Type + "@" + record.Value
+	return record
+}
+
+// 子域名转换为完整域名
+func (this *RFC2136Provider) fullName(zone string, name string) string {
+	if len(name) == 0 || name == "@" {
+		return zone
+	}
+	return dns.Fqdn(name + "." + zone)
+}
+
+// 完整域名转换为子域名
+func (this *RFC2136Provider) shortName(zone string, name string) string {
+	if strings.EqualFold(name, zone) {
+		return "@"
+	}
+	return strings.TrimSuffix(name, "."+zone)
+}
+
+// 分割TXT记录，单个字符串不能超过255个字节
+func (this *RFC2136Provider) splitTXT(value string) []string {
+	result := []string{}
+	for len(value) > 255 {
+		result = append(result, value[:255])
+		value = value[255:]
+	}
+	result = append(result, value)
+	return result
+}
@@ -0,0 +1,249 @@
+package dnsclients
+
+import (
+	"github.com/iwind/TeaGo/maps"
+	"github.com/miekg/dns"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testRFC2136KeyName = "edge-key."
+const testRFC2136KeySecret = "c2VjcmV0LWtleS1mb3ItdGVzdGluZy1vbmx5" // secret-key-for-testing-only
+
+func TestRFC2136Provider_Records(t *testing.T) {
+	provider, stop := testRFC2136Provider(t, testRFC2136KeySecret)
+	defer stop()
+
+	err := provider.AddRecord("example.com", &Record{
+		Name:  "node1",
+		Type:  RecordTypeA,
+		Value: "192.168.1.100",
+		Route: provider.DefaultRoute(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = provider.AddRecord("example.com", &Record{
+		Name:  "www",
+		Type:  RecordTypeCName,
+		Value: "cdn.example.net",
+		Route: provider.DefaultRoute(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := provider.GetRecords("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatal("expect 2 records, but got", len(records))
+	}
+	for _, record := range records {
+		t.Log(record.Id, record.Type, record.Name, record.Value, record.Route)
+	}
+
+	record, err := provider.QueryRecord("example.com", "www", RecordTypeCName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record == nil || record.Value != "cdn.example.net." {
+		t.Fatalf("invalid record: %#v", record)
+	}
+
+	err = provider.UpdateRecord("example.com", record, &Record{
+		Name:  "www",
+		Type:  RecordTypeCName,
+		Value: "cdn2.example.net.",
+		Route: provider.DefaultRoute(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err = provider.QueryRecord("example.com", "www", RecordTypeCName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record == nil || record.Value != "cdn2.example.net." {
+		t.Fatalf("update failed: %#v", record)
+	}
+
+	err = provider.DeleteRecord("example.com", record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err = provider.QueryRecord("example.com", "www", RecordTypeCName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record != nil {
+		t.Fatal("record should be deleted")
+	}
+}
+
+func TestRFC2136Provider_TXT(t *testing.T) {
+	provider, stop := testRFC2136Provider(t, testRFC2136KeySecret)
+	defer stop()
+
+	for _, value := range []string{"token1", "token2"} {
+		err := provider.AddRecord("example.com", &Record{
+			Name:  "_acme-challenge",
+			Type:  RecordTypeTXT,
+			Value: value,
+			Route: provider.DefaultRoute(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 只删除其中一个值
+	err := provider.DeleteRecord("example.com", &Record{
+		Name:  "_acme-challenge",
+		Type:  RecordTypeTXT,
+		Value: "token1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record, err := provider.QueryRecord("example.com", "_acme-challenge", RecordTypeTXT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record == nil || record.Value != "token2" {
+		t.Fatalf("invalid record: %#v", record)
+	}
+}
+
+func TestRFC2136Provider_BadKey(t *testing.T) {
+	provider, stop := testRFC2136Provider(t, "YmFkLWtleQ==")
+	defer stop()
+
+	err := provider.AddRecord("example.com", &Record{
+		Name:  "node1",
+		Type:  RecordTypeA,
+		Value: "192.168.1.100",
+	})
+	if err == nil {
+		t.Fatal("update with bad key should fail")
+	}
+	t.Log(err)
+}
+
+// 启动一个进程内的权威DNS服务器，支持UPDATE、AXFR和普通查询
+func testRFC2136Provider(t *testing.T, keySecret string) (provider *RFC2136Provider, stop func()) {
+	zone := "example.com."
+	locker := sync.Mutex{}
+	rrs := []dns.RR{}
+
+	handler := dns.HandlerFunc(func(writer dns.ResponseWriter, req *dns.Msg) {
+		locker.Lock()
+		defer locker.Unlock()
+
+		resp := new(dns.Msg)
+		resp.SetReply(req)
+		resp.Authoritative = true
+
+		defer func() {
+			if req.IsTsig() != nil {
+				resp.SetTsig(testRFC2136KeyName, dns.HmacSHA256, 300, time.Now().Unix())
+			}
+			_ = writer.WriteMsg(resp)
+		}()
+
+		if req.IsTsig() == nil || writer.TsigStatus() != nil {
+			resp.Rcode = dns.RcodeRefused
+			return
+		}
+		if len(req.Question) == 0 {
+			resp.Rcode = dns.RcodeFormatError
+			return
+		}
+		if req.Opcode == dns.OpcodeUpdate && req.Question[0].Name != zone {
+			resp.Rcode = dns.RcodeNotZone
+			return
+		}
+
+		switch {
+		case req.Opcode == dns.OpcodeUpdate:
+			for _, update := range req.Ns {
+				header := update.Header()
+				switch header.Class {
+				case dns.ClassINET:
+					rrs = append(rrs, update)
+				case dns.ClassNONE:
+					result := []dns.RR{}
+					for _, rr := range rrs {
+						c := dns.Copy(update)
+						c.Header().Class = dns.ClassINET
+						c.Header().Ttl = rr.Header().Ttl
+						if rr.String() != c.String() {
+							result = append(result, rr)
+						}
+					}
+					rrs = result
+				case dns.ClassANY:
+					result := []dns.RR{}
+					for _, rr := range rrs {
+						if rr.Header().Name != header.Name || (header.Rrtype != dns.TypeANY && rr.Header().Rrtype != header.Rrtype) {
+							result = append(result, rr)
+						}
+					}
+					rrs = result
+				}
+			}
+		case req.Question[0].Qtype == dns.TypeAXFR:
+			soa, _ := dns.NewRR(zone + " 3600 IN SOA ns1." + zone + " admin." + zone + " 1 3600 600 86400 600")
+			resp.Answer = append([]dns.RR{soa}, rrs...)
+			resp.Answer = append(resp.Answer, soa)
+		default:
+			question := req.Question[0]
+			for _, rr := range rrs {
+				if rr.Header().Name == question.Name && rr.Header().Rrtype == question.Qtype {
+					resp.Answer = append(resp.Answer, rr)
+				}
+			}
+		}
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan bool)
+	server := &dns.Server{
+		Listener: listener,
+		Handler:  handler,
+		TsigSecret: map[string]string{
+			testRFC2136KeyName: testRFC2136KeySecret,
+		},
+		MsgAcceptFunc: func(dh dns.Header) dns.MsgAcceptAction {
+			return dns.MsgAccept // 默认会拒绝UPDATE消息
+		},
+		NotifyStartedFunc: func() {
+			close(started)
+		},
+	}
+	go func() {
+		_ = server.ActivateAndServe()
+	}()
+	<-started
+
+	provider = &RFC2136Provider{}
+	err = provider.Auth(maps.Map{
+		"server":    listener.Addr().String(),
+		"keyName":   testRFC2136KeyName,
+		"keySecret": keySecret,
+	})
+	if err != nil {
+		_ = server.Shutdown()
+		t.Fatal(err)
+	}
+	return provider, func() {
+		_ = server.Shutdown()
+	}
+}
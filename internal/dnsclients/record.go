@@ -4,6 +4,7 @@ type RecordType = string
 
 const (
 	RecordTypeA     RecordType = "A"
+	RecordTypeAAAA  RecordType = "AAAA"
 	RecordTypeCName RecordType = "CNAME"
 	RecordTypeTXT   RecordType = "TXT"
 )
@@ -9,6 +9,8 @@ const (
 	ProviderTypeDNSPod     ProviderType = "dnspod"
 	ProviderTypeAliDNS     ProviderType = "alidns"
 	ProviderTypeDNSCom     ProviderType = "dnscom"
+	ProviderTypeCloudflare ProviderType = "cloudflare"
+	ProviderTypeRFC2136    ProviderType = "rfc2136"
 	ProviderTypeCustomHTTP ProviderType = "customHTTP"
 )
 
@@ -22,6 +24,14 @@ var AllProviderTypes = []maps.Map{
 		"name": "DNSPod",
 		"code": ProviderTypeDNSPod,
 	},
+	{
+		"name": "Cloudflare",
+		"code": ProviderTypeCloudflare,
+	},
+	{
+		"name": "RFC 2136动态更新（BIND/Knot/PowerDNS等）",
+		"code": ProviderTypeRFC2136,
+	},
 	/**{
 		"name": "帝恩思DNS.COM",
 		"code": ProviderTypeDNSCom,
@@ -39,6 +49,10 @@ func FindProvider(providerType ProviderType) ProviderInterface {
 		return &DNSPodProvider{}
 	case ProviderTypeAliDNS:
 		return &AliDNSProvider{}
+	case ProviderTypeCloudflare:
+		return &CloudflareProvider{}
+	case ProviderTypeRFC2136:
+		return &RFC2136Provider{}
 	case ProviderTypeCustomHTTP:
 		return &CustomHTTPProvider{}
 	}
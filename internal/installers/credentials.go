@@ -1,9 +1,19 @@
 package installers
 
+import "golang.org/x/crypto/ssh"
+
 type Credentials struct {
 	Host       string
 	Port       int
 	Username   string
 	Password   string
 	PrivateKey string
+	Passphrase string // 密钥密码
+
+	Sudo         bool   // 是否使用sudo执行命令
+	SudoPassword string // sudo密码，为空时使用登录密码
+
+	JumpHosts []*Credentials // 跳板机，按照连接顺序排列，只使用其中的登录信息
+
+	HostKeyCallback ssh.HostKeyCallback // 校验主机公钥，为空时不校验
 }
@@ -0,0 +1,215 @@
+package installers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"golang.org/x/crypto/ssh"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// 主机公钥错误
+type HostKeyError struct {
+	Host        string
+	Port        int
+	Expected    string // 期望的公钥指纹
+	Fingerprint string // 实际的公钥指纹
+	IsRevoked   bool   // 是否已被吊销
+}
+
+func (this *HostKeyError) Error() string {
+	addr := net.JoinHostPort(this.Host, strconv.Itoa(this.Port))
+	if this.IsRevoked {
+		return "host key '" + this.Fingerprint + "' of '" + addr + "' has been revoked"
+	}
+	if len(this.Expected) > 0 {
+		return "host key of '" + addr + "' has changed from '" + this.Expected + "' to '" + this.Fingerprint + "', it should be accepted by administrator before connecting"
+	}
+	return "host key '" + this.Fingerprint + "' of '" + addr + "' does not match the known hosts"
+}
+
+// 计算公钥指纹
+func HostKeyFingerprint(key ssh.PublicKey) string {
+	return ssh.FingerprintSHA256(key)
+}
+
+// known_hosts中的单条记录
+type knownHostEntry struct {
+	marker   string
+	patterns []string
+	key      ssh.PublicKey
+}
+
+// 预置的主机公钥
+// 支持直接填写公钥指纹（SHA256:xxx、MD5:xx:xx），也支持known_hosts文件格式
+type KnownHosts struct {
+	fingerprints []string
+	entries      []*knownHostEntry
+}
+
+// 解析预置的主机公钥
+func ParseKnownHosts(content string) (*KnownHosts, error) {
+	result := &KnownHosts{}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		// 公钥指纹
+		if strings.HasPrefix(line, "SHA256:") || strings.HasPrefix(line, "MD5:") {
+			result.fingerprints = append(result.fingerprints, line)
+			continue
+		}
+
+		marker, hosts, key, _, _, err := ssh.ParseKnownHosts([]byte(line))
+		if err != nil {
+			if err == io.EOF {
+				continue
+			}
+			return nil, errors.New("parse known hosts line '" + line + "' failed: " + err.Error())
+		}
+
+		// 不支持CA
+		if marker == "cert-authority" {
+			continue
+		}
+		result.entries = append(result.entries, &knownHostEntry{
+			marker:   marker,
+			patterns: hosts,
+			key:      key,
+		})
+	}
+	return result, nil
+}
+
+// 判断是否有针对某个主机的预置公钥
+func (this *KnownHosts) HasHost(host string, port int) bool {
+	if len(this.fingerprints) > 0 {
+		return true
+	}
+	for _, entry := range this.entries {
+		if entry.marker != "revoked" && this.matchHost(entry.patterns, host, port) {
+			return true
+		}
+	}
+	return false
+}
+
+// 检查主机公钥
+func (this *KnownHosts) Check(host string, port int, key ssh.PublicKey) error {
+	fingerprint := HostKeyFingerprint(key)
+	keyBytes := key.Marshal()
+
+	// 吊销的公钥
+	for _, entry := range this.entries {
+		if entry.marker == "revoked" && bytes.Equal(entry.key.Marshal(), keyBytes) {
+			return &HostKeyError{
+				Host:        host,
+				Port:        port,
+				Fingerprint: fingerprint,
+				IsRevoked:   true,
+			}
+		}
+	}
+
+	if !this.HasHost(host, port) {
+		return nil
+	}
+
+	md5Fingerprint := ssh.FingerprintLegacyMD5(key)
+	for _, expected := range this.fingerprints {
+		if expected == fingerprint || strings.TrimPrefix(expected, "MD5:") == md5Fingerprint {
+			return nil
+		}
+	}
+	for _, entry := range this.entries {
+		if entry.marker == "revoked" || !this.matchHost(entry.patterns, host, port) {
+			continue
+		}
+		if bytes.Equal(entry.key.Marshal(), keyBytes) {
+			return nil
+		}
+	}
+
+	return &HostKeyError{
+		Host:        host,
+		Port:        port,
+		Fingerprint: fingerprint,
+	}
+}
+
+// 判断主机是否匹配known_hosts中的主机列表
+func (this *KnownHosts) matchHost(patterns []string, host string, port int) bool {
+	addr := host
+	if port != 22 {
+		addr = "[" + host + "]:" + strconv.Itoa(port)
+	}
+
+	isMatched := false
+	for _, pattern := range patterns {
+		isNegated := strings.HasPrefix(pattern, "!")
+		if isNegated {
+			pattern = pattern[1:]
+		}
+
+		var ok bool
+		if strings.HasPrefix(pattern, "|1|") {
+			ok = this.matchHashedHost(pattern, addr)
+		} else {
+			ok = matchWildcard(pattern, addr)
+		}
+		if !ok {
+			continue
+		}
+		if isNegated {
+			return false
+		}
+		isMatched = true
+	}
+	return isMatched
+}
+
+// 匹配经过Hash的主机：|1|salt|hash
+func (this *KnownHosts) matchHashedHost(pattern string, addr string) bool {
+	pieces := strings.Split(pattern[3:], "|")
+	if len(pieces) != 2 {
+		return false
+	}
+	salt, err := base64.StdEncoding.DecodeString(pieces[0])
+	if err != nil {
+		return false
+	}
+	hash, err := base64.StdEncoding.DecodeString(pieces[1])
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha1.New, salt)
+	_, _ = mac.Write([]byte(addr))
+	return hmac.Equal(mac.Sum(nil), hash)
+}
+
+// 通配符匹配，支持*和?
+func matchWildcard(pattern string, s string) bool {
+	if len(pattern) == 0 {
+		return len(s) == 0
+	}
+	switch pattern[0] {
+	case '*':
+		for i := 0; i <= len(s); i++ {
+			if matchWildcard(pattern[1:], s[i:]) {
+				return true
+			}
+		}
+		return false
+	case '?':
+		return len(s) > 0 && matchWildcard(pattern[1:], s[1:])
+	default:
+		return len(s) > 0 && pattern[0] == s[0] && matchWildcard(pattern[1:], s[1:])
+	}
+}
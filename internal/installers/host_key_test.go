@@ -0,0 +1,145 @@
+package installers
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"golang.org/x/crypto/ssh"
+	"strings"
+	"testing"
+)
+
+func testHostKey(t *testing.T) ssh.PublicKey {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func testKnownHostsLine(hosts string, key ssh.PublicKey) string {
+	return hosts + " " + strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key)))
+}
+
+func TestKnownHosts_Fingerprint(t *testing.T) {
+	key := testHostKey(t)
+	otherKey := testHostKey(t)
+
+	knownHosts, err := ParseKnownHosts("# comment\n" + HostKeyFingerprint(key) + "\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !knownHosts.HasHost("192.168.1.100", 22) {
+		t.Fatal("fingerprint should match any host")
+	}
+	if err := knownHosts.Check("192.168.1.100", 22, key); err != nil {
+		t.Fatal(err)
+	}
+	if err := knownHosts.Check("192.168.1.100", 22, otherKey); err == nil {
+		t.Fatal("other key should not be accepted")
+	} else {
+		t.Log(err)
+	}
+
+	// MD5
+	knownHosts, err = ParseKnownHosts("MD5:" + ssh.FingerprintLegacyMD5(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := knownHosts.Check("192.168.1.100", 22, key); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKnownHosts_Lines(t *testing.T) {
+	key := testHostKey(t)
+	otherKey := testHostKey(t)
+
+	content := testKnownHostsLine("192.168.1.100,example.com", key) + "\n" +
+		testKnownHostsLine("[192.168.1.101]:2222", key) + "\n" +
+		testKnownHostsLine("*.example.org,!bad.example.org", key)
+	knownHosts, err := ParseKnownHosts(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, item := range []struct {
+		host      string
+		port      int
+		hasHost   bool
+		keyIsOk   bool
+		otherIsOk bool
+	}{
+		{"192.168.1.100", 22, true, true, false},
+		{"example.com", 22, true, true, false},
+		{"192.168.1.101", 2222, true, true, false},
+		{"192.168.1.101", 22, false, true, true},
+		{"a.example.org", 22, true, true, false},
+		{"bad.example.org", 22, false, true, true},
+		{"192.168.1.102", 22, false, true, true},
+	} {
+		if knownHosts.HasHost(item.host, item.port) != item.hasHost {
+			t.Fatal(item.host, item.port, "hasHost should be", item.hasHost)
+		}
+		if (knownHosts.Check(item.host, item.port, key) == nil) != item.keyIsOk {
+			t.Fatal(item.host, item.port, "key check should be", item.keyIsOk)
+		}
+		if (knownHosts.Check(item.host, item.port, otherKey) == nil) != item.otherIsOk {
+			t.Fatal(item.host, item.port, "other key check should be", item.otherIsOk)
+		}
+	}
+}
+
+func TestKnownHosts_Hashed(t *testing.T) {
+	key := testHostKey(t)
+
+	salt := []byte("0123456789abcdefghij")
+	mac := hmac.New(sha1.New, salt)
+	_, _ = mac.Write([]byte("192.168.1.100"))
+	hashedHost := "|1|" + base64.StdEncoding.EncodeToString(salt) + "|" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	knownHosts, err := ParseKnownHosts(testKnownHostsLine(hashedHost, key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !knownHosts.HasHost("192.168.1.100", 22) {
+		t.Fatal("hashed host should match")
+	}
+	if knownHosts.HasHost("192.168.1.101", 22) {
+		t.Fatal("hashed host should not match")
+	}
+	if err := knownHosts.Check("192.168.1.100", 22, key); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKnownHosts_Revoked(t *testing.T) {
+	key := testHostKey(t)
+
+	knownHosts, err := ParseKnownHosts("@revoked " + testKnownHostsLine("*", key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if knownHosts.HasHost("192.168.1.100", 22) {
+		t.Fatal("revoked key should not be a known host")
+	}
+	err = knownHosts.Check("192.168.1.100", 22, key)
+	if err == nil {
+		t.Fatal("revoked key should be refused")
+	}
+	t.Log(err)
+}
+
+func TestKnownHosts_Invalid(t *testing.T) {
+	_, err := ParseKnownHosts("192.168.1.100 ssh-ed25519 invalid")
+	if err == nil {
+		t.Fatal("should be failed")
+	}
+	t.Log(err)
+}
@@ -0,0 +1,118 @@
+package installers
+
+import (
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"golang.org/x/crypto/ssh"
+	"net"
+)
+
+// 节点主机公钥校验
+// 首次连接时信任并记录主机公钥（如果认证信息中预置了公钥，则必须和预置的一致），
+// 以后公钥发生变化时拒绝连接，直到管理员确认接受新的公钥
+type nodeHostKeyVerifier struct {
+	login *models.NodeLogin
+	grant *models.NodeGrant
+	host  string
+	port  int
+
+	err error // 校验失败时的错误
+}
+
+func newNodeHostKeyVerifier(login *models.NodeLogin, grant *models.NodeGrant, host string, port int) *nodeHostKeyVerifier {
+	return &nodeHostKeyVerifier{
+		login: login,
+		grant: grant,
+		host:  host,
+		port:  port,
+	}
+}
+
+// 校验主机公钥
+func (this *nodeHostKeyVerifier) Callback(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	this.err = this.verify(key)
+	return this.err
+}
+
+// 校验跳板机的主机公钥
+func (this *nodeHostKeyVerifier) JumpHostCallback(jumpHost *models.NodeGrantJumpHost) ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		this.err = this.verifyJumpHost(jumpHost, key)
+		return this.err
+	}
+}
+
+// 实际校验过程
+func (this *nodeHostKeyVerifier) verify(key ssh.PublicKey) error {
+	loginId := int64(this.login.Id)
+	fingerprint := HostKeyFingerprint(key)
+
+	// 已经信任的公钥
+	if len(this.login.HostKey) > 0 {
+		if this.login.HostKey == fingerprint {
+			return nil
+		}
+		err := models.SharedNodeLoginDAO.UpdateNodeLoginPendingHostKey(nil, loginId, fingerprint)
+		if err != nil {
+			return err
+		}
+		return &HostKeyError{
+			Host:        this.host,
+			Port:        this.port,
+			Expected:    this.login.HostKey,
+			Fingerprint: fingerprint,
+		}
+	}
+
+	// 预置的公钥
+	if this.grant != nil && len(this.grant.KnownHosts) > 0 {
+		knownHosts, err := ParseKnownHosts(this.grant.KnownHosts)
+		if err != nil {
+			return err
+		}
+		err = knownHosts.Check(this.host, this.port, key)
+		if err != nil {
+			err1 := models.SharedNodeLoginDAO.UpdateNodeLoginPendingHostKey(nil, loginId, fingerprint)
+			if err1 != nil {
+				return err1
+			}
+			return err
+		}
+	}
+
+	// 首次连接时信任
+	return models.SharedNodeLoginDAO.UpdateNodeLoginHostKey(nil, loginId, fingerprint)
+}
+
+// 校验跳板机的主机公钥
+// 跳板机的公钥指纹记录在认证信息中，变化后需要管理员在认证信息中填写新的指纹
+func (this *nodeHostKeyVerifier) verifyJumpHost(jumpHost *models.NodeGrantJumpHost, key ssh.PublicKey) error {
+	fingerprint := HostKeyFingerprint(key)
+
+	// 已经信任的公钥
+	if len(jumpHost.HostKey) > 0 {
+		if jumpHost.HostKey == fingerprint {
+			return nil
+		}
+		return &HostKeyError{
+			Host:        jumpHost.Host,
+			Port:        jumpHost.Port,
+			Expected:    jumpHost.HostKey,
+			Fingerprint: fingerprint,
+		}
+	}
+
+	// 预置的公钥
+	if len(this.grant.KnownHosts) > 0 {
+		knownHosts, err := ParseKnownHosts(this.grant.KnownHosts)
+		if err != nil {
+			return err
+		}
+		err = knownHosts.Check(jumpHost.Host, jumpHost.Port, key)
+		if err != nil {
+			return err
+		}
+	}
+
+	// 首次连接时信任
+	return models.SharedNodeGrantDAO.UpdateGrantJumpHostKey(nil, int64(this.grant.Id), jumpHost.Host, jumpHost.Port, fingerprint)
+}
@@ -19,20 +19,65 @@ type BaseInstaller struct {
 
 // 登录SSH服务
 func (this *BaseInstaller) Login(credentials *Credentials) error {
-	var hostKeyCallback ssh.HostKeyCallback = nil
+	// 依次连接跳板机
+	jumpClients := []*ssh.Client{}
+	closeJumpClients := func() {
+		for i := len(jumpClients) - 1; i >= 0; i-- {
+			_ = jumpClients[i].Close()
+		}
+	}
+	var viaClient *ssh.Client
+	for _, jumpHost := range credentials.JumpHosts {
+		jumpClient, err := this.dial(viaClient, jumpHost)
+		if err != nil {
+			closeJumpClients()
+			return errors.New("connect to jump host '" + jumpHost.Host + ":" + strconv.Itoa(jumpHost.Port) + "' failed: " + err.Error())
+		}
+		jumpClients = append(jumpClients, jumpClient)
+		viaClient = jumpClient
+	}
+
+	sshClient, err := this.dial(viaClient, credentials)
+	if err != nil {
+		closeJumpClients()
+		return err
+	}
+	client, err := NewSSHClient(sshClient)
+	if err != nil {
+		closeJumpClients()
+		return err
+	}
+	client.jumpClients = jumpClients
+
+	// sudo
+	if credentials.Sudo {
+		sudoPassword := credentials.SudoPassword
+		if len(sudoPassword) == 0 {
+			sudoPassword = credentials.Password
+		}
+		client.Sudo(sudoPassword)
+	}
+
+	this.client = client
+	return nil
+}
+
+// 连接SSH服务，如果viaClient不为空，则通过viaClient转发连接
+func (this *BaseInstaller) dial(viaClient *ssh.Client, credentials *Credentials) (*ssh.Client, error) {
+	var hostKeyCallback = credentials.HostKeyCallback
 
 	// 检查参数
 	if len(credentials.Host) == 0 {
-		return errors.New("'host' should not be empty")
+		return nil, errors.New("'host' should not be empty")
 	}
 	if credentials.Port <= 0 {
-		return errors.New("'port' should be greater than 0")
+		return nil, errors.New("'port' should be greater than 0")
 	}
 	if len(credentials.Password) == 0 && len(credentials.PrivateKey) == 0 {
-		return errors.New("require user 'password' or 'privateKey'")
+		return nil, errors.New("require user 'password' or 'privateKey'")
 	}
 
-	// 不使用known_hosts
+	// 没有设置时不校验主机公钥
 	if hostKeyCallback == nil {
 		hostKeyCallback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
 			return nil
@@ -57,9 +102,15 @@ func (this *BaseInstaller) Login(credentials *Credentials) error {
 			methods = append(methods, authMethod)
 		}
 	} else {
-		signer, err := ssh.ParsePrivateKey([]byte(credentials.PrivateKey))
+		var signer ssh.Signer
+		var err error
+		if len(credentials.Passphrase) > 0 {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(credentials.PrivateKey), []byte(credentials.Passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey([]byte(credentials.PrivateKey))
+		}
 		if err != nil {
-			return errors.New("parse private key: " + err.Error())
+			return nil, errors.New("parse private key: " + err.Error())
 		}
 		authMethod := ssh.PublicKeys(signer)
 		methods = append(methods, authMethod)
@@ -73,16 +124,21 @@ func (this *BaseInstaller) Login(credentials *Credentials) error {
 		Timeout:         5 * time.Second, // TODO 后期可以设置这个超时时间
 	}
 
-	sshClient, err := ssh.Dial("tcp", credentials.Host+":"+strconv.Itoa(credentials.Port), config)
+	addr := net.JoinHostPort(credentials.Host, strconv.Itoa(credentials.Port))
+	if viaClient == nil {
+		return ssh.Dial("tcp", addr, config)
+	}
+
+	conn, err := viaClient.Dial("tcp", addr)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	client, err := NewSSHClient(sshClient)
+	clientConn, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
 	if err != nil {
-		return err
+		_ = conn.Close()
+		return nil, err
 	}
-	this.client = client
-	return nil
+	return ssh.NewClient(clientConn, chans, reqs), nil
 }
 
 // 关闭SSH服务
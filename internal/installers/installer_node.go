@@ -26,6 +26,7 @@ func (this *NodeInstaller) Install(dir string, params interface{}, installStatus
 	}
 
 	// 检查目标目录是否存在
+	installStatus.UpdateStep(models.NodeInstallStepPrepare, 0)
 	_, err = this.client.Stat(dir)
 	if err != nil {
 		err = this.client.MkdirAll(dir)
@@ -43,6 +44,7 @@ func (this *NodeInstaller) Install(dir string, params interface{}, installStatus
 	}
 
 	// 上传安装文件
+	installStatus.UpdateStep(models.NodeInstallStepUpload, 0)
 	filePrefix := "edge-node-" + env.OS + "-" + env.Arch
 	zipFile, err := this.LookupLatestInstaller(filePrefix)
 	if err != nil {
@@ -56,6 +58,7 @@ func (this *NodeInstaller) Install(dir string, params interface{}, installStatus
 	if err != nil {
 		return err
 	}
+	installStatus.UpdateStep(models.NodeInstallStepUpload, 100)
 
 	// 测试运行环境
 	// 升级的节点暂时不列入测试
@@ -68,6 +71,7 @@ func (this *NodeInstaller) Install(dir string, params interface{}, installStatus
 			return errors.New("test failed: " + stderr)
 		}
 	}
+	installStatus.UpdateStep(models.NodeInstallStepPrepare, 100)
 
 	// 如果是升级则优雅停止先前的进程
 	exePath := dir + "/edge-node/bin/edge-node"
@@ -85,6 +89,7 @@ func (this *NodeInstaller) Install(dir string, params interface{}, installStatus
 	}
 
 	// 解压
+	installStatus.UpdateStep(models.NodeInstallStepUnzip, 0)
 	_, stderr, err := this.client.Exec(dir + "/" + env.HelperName + " -cmd=unzip -zip=\"" + targetZip + "\" -target=\"" + dir + "\"")
 	if err != nil {
 		return err
@@ -92,8 +97,10 @@ func (this *NodeInstaller) Install(dir string, params interface{}, installStatus
 	if len(stderr) > 0 {
 		return errors.New("unzip installer failed: " + stderr)
 	}
+	installStatus.UpdateStep(models.NodeInstallStepUnzip, 100)
 
 	// 修改配置文件
+	installStatus.UpdateStep(models.NodeInstallStepConfig, 0)
 	{
 		templateFile := dir + "/edge-node/configs/api.template.yaml"
 		configFile := dir + "/edge-node/configs/api.yaml"
@@ -111,8 +118,10 @@ func (this *NodeInstaller) Install(dir string, params interface{}, installStatus
 			return errors.New("write 'configs/api.yaml': " + err.Error())
 		}
 	}
+	installStatus.UpdateStep(models.NodeInstallStepConfig, 100)
 
 	// 测试
+	installStatus.UpdateStep(models.NodeInstallStepTest, 0)
 	_, stderr, err = this.client.Exec(dir + "/edge-node/bin/edge-node test")
 	if err != nil {
 		installStatus.ErrorCode = "TEST_FAILED"
@@ -125,8 +134,10 @@ func (this *NodeInstaller) Install(dir string, params interface{}, installStatus
 
 		return errors.New("test edge node failed: " + stderr)
 	}
+	installStatus.UpdateStep(models.NodeInstallStepTest, 100)
 
 	// 启动
+	installStatus.UpdateStep(models.NodeInstallStepStart, 0)
 	_, stderr, err = this.client.Exec(dir + "/edge-node/bin/edge-node start")
 	if err != nil {
 		return errors.New("start edge node failed: " + err.Error())
@@ -135,6 +146,7 @@ func (this *NodeInstaller) Install(dir string, params interface{}, installStatus
 	if len(stderr) > 0 {
 		return errors.New("start edge node failed: " + stderr)
 	}
+	installStatus.UpdateStep(models.NodeInstallStepStart, 100)
 
 	return nil
 }
@@ -177,15 +177,19 @@ func (this *Queue) InstallNode(nodeId int64, installStatus *models.NodeInstallSt
 		IsUpgrading: isUpgrading,
 	}
 
+	hostKeyVerifier := newNodeHostKeyVerifier(login, grant, loginParams.Host, loginParams.Port)
 	installer := &NodeInstaller{}
-	err = installer.Login(&Credentials{
-		Host:       loginParams.Host,
-		Port:       loginParams.Port,
-		Username:   grant.Username,
-		Password:   grant.Password,
-		PrivateKey: grant.PrivateKey,
-	})
+	credentials, err := this.buildCredentials(grant, loginParams, hostKeyVerifier)
 	if err != nil {
+		installStatus.ErrorCode = "INVALID_GRANT"
+		return err
+	}
+	err = installer.Login(credentials)
+	if err != nil {
+		if hostKeyVerifier.err != nil {
+			installStatus.ErrorCode = "SSH_HOST_KEY_MISMATCH"
+			return hostKeyVerifier.err
+		}
 		installStatus.ErrorCode = "SSH_LOGIN_FAILED"
 		return err
 	}
@@ -265,17 +269,19 @@ func (this *Queue) StartNode(nodeId int64) error {
 		}
 	}
 
+	hostKeyVerifier := newNodeHostKeyVerifier(login, grant, loginParams.Host, loginParams.Port)
 	installer := &NodeInstaller{}
-	err = installer.Login(&Credentials{
-		Host:       loginParams.Host,
-		Port:       loginParams.Port,
-		Username:   grant.Username,
-		Password:   grant.Password,
-		PrivateKey: grant.PrivateKey,
-	})
+	credentials, err := this.buildCredentials(grant, loginParams, hostKeyVerifier)
 	if err != nil {
 		return err
 	}
+	err = installer.Login(credentials)
+	if err != nil {
+		if hostKeyVerifier.err != nil {
+			return hostKeyVerifier.err
+		}
+		return err
+	}
 	defer func() {
 		_ = installer.Close()
 	}()
@@ -366,15 +372,17 @@ func (this *Queue) StopNode(nodeId int64) error {
 		}
 	}
 
+	hostKeyVerifier := newNodeHostKeyVerifier(login, grant, loginParams.Host, loginParams.Port)
 	installer := &NodeInstaller{}
-	err = installer.Login(&Credentials{
-		Host:       loginParams.Host,
-		Port:       loginParams.Port,
-		Username:   grant.Username,
-		Password:   grant.Password,
-		PrivateKey: grant.PrivateKey,
-	})
+	credentials, err := this.buildCredentials(grant, loginParams, hostKeyVerifier)
+	if err != nil {
+		return err
+	}
+	err = installer.Login(credentials)
 	if err != nil {
+		if hostKeyVerifier.err != nil {
+			return hostKeyVerifier.err
+		}
 		return err
 	}
 	defer func() {
@@ -398,3 +406,37 @@ func (this *Queue) StopNode(nodeId int64) error {
 
 	return nil
 }
+
+// 构造登录信息
+func (this *Queue) buildCredentials(grant *models.NodeGrant, loginParams *models.NodeLoginSSHParams, hostKeyVerifier *nodeHostKeyVerifier) (*Credentials, error) {
+	credentials := &Credentials{
+		Host:            loginParams.Host,
+		Port:            loginParams.Port,
+		Username:        grant.Username,
+		Password:        grant.Password,
+		PrivateKey:      grant.PrivateKey,
+		Passphrase:      grant.Passphrase,
+		Sudo:            grant.Sudo == 1,
+		SudoPassword:    grant.SudoPassword,
+		HostKeyCallback: hostKeyVerifier.Callback,
+	}
+
+	// 跳板机
+	jumpHosts, err := grant.DecodeJumpHosts()
+	if err != nil {
+		return nil, errors.New("decode jump hosts failed: " + err.Error())
+	}
+	for _, jumpHost := range jumpHosts {
+		credentials.JumpHosts = append(credentials.JumpHosts, &Credentials{
+			Host:            jumpHost.Host,
+			Port:            jumpHost.Port,
+			Username:        jumpHost.Username,
+			Password:        jumpHost.Password,
+			PrivateKey:      jumpHost.PrivateKey,
+			Passphrase:      jumpHost.Passphrase,
+			HostKeyCallback: hostKeyVerifier.JumpHostCallback(jumpHost),
+		})
+	}
+
+	return credentials, nil
+}
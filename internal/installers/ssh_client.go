@@ -2,17 +2,26 @@ package installers
 
 import (
 	"bytes"
+	"errors"
 	"github.com/pkg/sftp"
 	"golang.org/x/crypto/ssh"
 	"io"
 	"net"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type SSHClient struct {
 	raw  *ssh.Client
 	sftp *sftp.Client
+
+	jumpClients []*ssh.Client // 跳板机连接
+
+	sudo         bool
+	sudoPassword string
 }
 
 func NewSSHClient(raw *ssh.Client) (*SSHClient, error) {
@@ -30,25 +39,52 @@ func NewSSHClient(raw *ssh.Client) (*SSHClient, error) {
 	return c, nil
 }
 
+// 使用sudo执行命令和修改文件
+func (this *SSHClient) Sudo(password string) {
+	this.sudo = true
+	this.sudoPassword = password
+}
+
 // 执行shell命令
 func (this *SSHClient) Exec(cmd string) (stdout string, stderr string, err error) {
+	stdoutBytes, stderrBytes, err := this.run(cmd)
+	if err != nil {
+		return string(stdoutBytes), string(stderrBytes), err
+	}
+	return strings.TrimRight(string(stdoutBytes), "\n"), string(stderrBytes), nil
+}
+
+// 执行shell命令并返回原始输出
+func (this *SSHClient) run(cmd string) (stdout []byte, stderr []byte, err error) {
 	session, err := this.raw.NewSession()
 	if err != nil {
-		return "", "", err
+		return nil, nil, err
 	}
 	defer func() {
 		_ = session.Close()
 	}()
 
+	if this.sudo {
+		var stdin string
+		cmd, stdin = sudoCommand(cmd, this.sudoPassword)
+		if len(stdin) > 0 {
+			session.Stdin = strings.NewReader(stdin)
+		}
+	}
+
 	stdoutBuf := bytes.NewBuffer([]byte{})
 	stderrBuf := bytes.NewBuffer([]byte{})
 	session.Stdout = stdoutBuf
 	session.Stderr = stderrBuf
 	err = session.Run(cmd)
-	if err != nil {
-		return stdoutBuf.String(), stderrBuf.String(), err
-	}
-	return strings.TrimRight(stdoutBuf.String(), "\n"), stderrBuf.String(), nil
+	return stdoutBuf.Bytes(), stderrBuf.Bytes(), err
+}
+
+// 执行sudo命令
+// 根据命令的退出状态判断是否成功，sudo可能会在正常执行时输出警告信息，所以不根据错误输出判断
+func (this *SSHClient) execSudo(cmd string) error {
+	_, stderr, err := this.Exec(cmd)
+	return sudoError(err, stderr)
 }
 
 func (this *SSHClient) Listen(network string, addr string) (net.Listener, error) {
@@ -63,7 +99,13 @@ func (this *SSHClient) Close() error {
 	if this.sftp != nil {
 		_ = this.sftp.Close()
 	}
-	return this.raw.Close()
+	err := this.raw.Close()
+
+	// 从后往前关闭跳板机连接
+	for i := len(this.jumpClients) - 1; i >= 0; i-- {
+		_ = this.jumpClients[i].Close()
+	}
+	return err
 }
 
 func (this *SSHClient) OpenFile(path string, flags int) (*sftp.File, error) {
@@ -71,23 +113,66 @@ func (this *SSHClient) OpenFile(path string, flags int) (*sftp.File, error) {
 }
 
 func (this *SSHClient) Stat(path string) (os.FileInfo, error) {
-	return this.sftp.Stat(path)
+	stat, err := this.sftp.Stat(path)
+	if err != nil && this.sudo {
+		// 登录用户可能没有权限访问，使用sudo检查
+		_, _, err1 := this.run("test -e " + ShellQuote(path))
+		if err1 != nil {
+			return nil, err
+		}
+		return &sudoFileInfo{name: filepath.Base(path)}, nil
+	}
+	return stat, err
 }
 
 func (this *SSHClient) Mkdir(path string) error {
+	if this.sudo {
+		return this.execSudo("mkdir " + ShellQuote(path))
+	}
 	return this.sftp.Mkdir(path)
 }
 
 func (this *SSHClient) MkdirAll(path string) error {
+	if this.sudo {
+		return this.execSudo("mkdir -p " + ShellQuote(path))
+	}
 	return this.sftp.MkdirAll(path)
 }
 
 func (this *SSHClient) Chmod(path string, mode os.FileMode) error {
+	if this.sudo {
+		return this.execSudo("chmod " + strconv.FormatUint(uint64(mode.Perm()), 8) + " " + ShellQuote(path))
+	}
 	return this.sftp.Chmod(path, mode)
 }
 
 // 拷贝文件
 func (this *SSHClient) Copy(localPath string, remotePath string, mode os.FileMode) error {
+	// 使用sudo时先上传到临时目录再移动到目标位置
+	if this.sudo {
+		tmpPath := this.tmpPath(remotePath)
+		err := this.copy(localPath, tmpPath)
+		if err != nil {
+			_ = this.sftp.Remove(tmpPath)
+			return err
+		}
+		err = this.execSudo("mv -f " + ShellQuote(tmpPath) + " " + ShellQuote(remotePath))
+		if err != nil {
+			_ = this.sftp.Remove(tmpPath)
+			return err
+		}
+		return this.Chmod(remotePath, mode)
+	}
+
+	err := this.copy(localPath, remotePath)
+	if err != nil {
+		return err
+	}
+	return this.Chmod(remotePath, mode)
+}
+
+// 使用sftp上传文件
+func (this *SSHClient) copy(localPath string, remotePath string) error {
 	localFp, err := os.Open(localPath)
 	if err != nil {
 		return err
@@ -103,11 +188,7 @@ func (this *SSHClient) Copy(localPath string, remotePath string, mode os.FileMod
 		_ = remoteFp.Close()
 	}()
 	_, err = io.Copy(remoteFp, localFp)
-	if err != nil {
-		return err
-	}
-
-	return this.Chmod(remotePath, mode)
+	return err
 }
 
 // 获取新Session
@@ -119,6 +200,14 @@ func (this *SSHClient) NewSession() (*ssh.Session, error) {
 func (this *SSHClient) ReadFile(path string) ([]byte, error) {
 	fp, err := this.sftp.OpenFile(path, 0444)
 	if err != nil {
+		// 登录用户可能没有权限访问，使用sudo读取
+		if this.sudo {
+			stdout, stderr, err1 := this.run("cat " + ShellQuote(path))
+			if err1 != nil {
+				return nil, errors.New(err1.Error() + ": " + strings.TrimSpace(string(stderr)))
+			}
+			return stdout, nil
+		}
 		return nil, err
 	}
 	defer func() {
@@ -136,6 +225,27 @@ func (this *SSHClient) ReadFile(path string) ([]byte, error) {
 
 // 写入文件内容
 func (this *SSHClient) WriteFile(path string, data []byte) (n int, err error) {
+	// 使用sudo时先写入临时文件再移动到目标位置
+	if this.sudo {
+		tmpPath := this.tmpPath(path)
+		n, err = this.writeFile(tmpPath, data)
+		if err != nil {
+			_ = this.sftp.Remove(tmpPath)
+			return 0, err
+		}
+		err = this.execSudo("mv -f " + ShellQuote(tmpPath) + " " + ShellQuote(path))
+		if err != nil {
+			_ = this.sftp.Remove(tmpPath)
+			return 0, err
+		}
+		return n, nil
+	}
+
+	return this.writeFile(path, data)
+}
+
+// 使用sftp写入文件内容
+func (this *SSHClient) writeFile(path string, data []byte) (n int, err error) {
 	fp, err := this.sftp.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY)
 	if err != nil {
 		return 0, err
@@ -150,5 +260,52 @@ func (this *SSHClient) WriteFile(path string, data []byte) (n int, err error) {
 
 // 删除文件
 func (this *SSHClient) Remove(path string) error {
+	if this.sudo {
+		return this.execSudo("rm -f " + ShellQuote(path))
+	}
 	return this.sftp.Remove(path)
 }
+
+// 生成临时文件路径
+func (this *SSHClient) tmpPath(path string) string {
+	return "/tmp/.edge-" + strconv.FormatInt(time.Now().UnixNano(), 10) + "-" + filepath.Base(path)
+}
+
+// 使用sudo时文件的信息，只用来判断文件是否存在
+type sudoFileInfo struct {
+	name string
+}
+
+func (this *sudoFileInfo) Name() string       { return this.name }
+func (this *sudoFileInfo) Size() int64        { return 0 }
+func (this *sudoFileInfo) Mode() os.FileMode  { return 0 }
+func (this *sudoFileInfo) ModTime() time.Time { return time.Time{} }
+func (this *sudoFileInfo) IsDir() bool        { return false }
+func (this *sudoFileInfo) Sys() interface{}   { return nil }
+
+// 构造使用sudo执行的命令，返回命令和需要写入到标准输入的内容
+// 只有使用 sudo -S 时才会通过标准输入发送密码，-k 让sudo忽略已缓存的凭据，保证密码总是被sudo读取；
+// 命令本身的标准输入重定向到/dev/null，防止读取到密码
+func sudoCommand(cmd string, password string) (sudoCmd string, stdin string) {
+	if len(password) > 0 {
+		return "sudo -S -k -p '' sh -c " + ShellQuote("exec </dev/null; "+cmd), password + "\n"
+	}
+	return "sudo -n sh -c " + ShellQuote(cmd), ""
+}
+
+// 根据sudo命令的执行结果生成错误信息
+func sudoError(err error, stderr string) error {
+	if err == nil {
+		return nil
+	}
+	stderr = strings.TrimSpace(stderr)
+	if len(stderr) > 0 {
+		return errors.New(err.Error() + ": " + stderr)
+	}
+	return err
+}
+
+// 转义shell参数
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
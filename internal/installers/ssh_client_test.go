@@ -0,0 +1,73 @@
+package installers
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestShellQuote(t *testing.T) {
+	for _, item := range [][2]string{
+		{"", "''"},
+		{"/opt/edge node", "'/opt/edge node'"},
+		{"echo 'hello'", `'echo '"'"'hello'"'"''`},
+	} {
+		if ShellQuote(item[0]) != item[1] {
+			t.Fatal(item[0], "=>", ShellQuote(item[0]), ", expected:", item[1])
+		}
+	}
+}
+
+func TestSudoCommand_NoPassword(t *testing.T) {
+	cmd, stdin := sudoCommand("mkdir -p '/opt/edge'", "")
+	if cmd != `sudo -n sh -c 'mkdir -p '"'"'/opt/edge'"'"''` {
+		t.Fatal("unexpected command:", cmd)
+	}
+	if len(stdin) > 0 {
+		t.Fatal("should not send anything to stdin without password")
+	}
+}
+
+func TestSudoCommand_Password(t *testing.T) {
+	cmd, stdin := sudoCommand("cat", "123456")
+	if !strings.HasPrefix(cmd, "sudo -S -k -p '' sh -c ") {
+		t.Fatal("unexpected command:", cmd)
+	}
+	if stdin != "123456\n" {
+		t.Fatal("unexpected stdin:", stdin)
+	}
+
+	// 命令本身不能读取到密码
+	shPath, err := exec.LookPath("sh")
+	if err != nil {
+		t.Log("skip: " + err.Error())
+		return
+	}
+	c := exec.Command(shPath, "-c", strings.TrimPrefix(cmd, "sudo -S -k -p '' "))
+	c.Stdin = strings.NewReader(stdin)
+	output, err := c.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output) > 0 {
+		t.Fatal("command should not read the password, but got:", string(output))
+	}
+}
+
+func TestSudoError(t *testing.T) {
+	// 成功执行时忽略警告信息
+	if sudoError(nil, "sudo: unable to resolve host edge-node\n") != nil {
+		t.Fatal("stderr without error should be ignored")
+	}
+
+	err := sudoError(errors.New("Process exited with status 1"), "mkdir: cannot create directory '/opt/edge': Permission denied\n")
+	if err == nil || err.Error() != "Process exited with status 1: mkdir: cannot create directory '/opt/edge': Permission denied" {
+		t.Fatal("unexpected error:", err)
+	}
+
+	err = sudoError(errors.New("Process exited with status 1"), "")
+	if err == nil || err.Error() != "Process exited with status 1" {
+		t.Fatal("unexpected error:", err)
+	}
+}
@@ -0,0 +1,42 @@
+package iplists
+
+import (
+	"github.com/TeaOSLab/EdgeAPI/internal/utils"
+	"strings"
+)
+
+// IP条目
+type Entry struct {
+	IPFrom    string `json:"ipFrom"`
+	IPTo      string `json:"ipTo"` // 单个IP时为空
+	ExpiredAt int64  `json:"expiredAt"`
+	Reason    string `json:"reason"`
+}
+
+// 解析并规范化IP条目
+// ipFrom可以是单个IP、CIDR或者 开始IP-结束IP 格式的范围
+func NewEntry(ipFrom string, ipTo string) (*Entry, error) {
+	if len(ipTo) == 0 {
+		// IPv6地址中不会包含 -
+		if index := strings.Index(ipFrom, "-"); index > 0 {
+			ipTo = ipFrom[index+1:]
+			ipFrom = ipFrom[:index]
+		}
+	}
+	from, to, err := utils.ParseIPRange(ipFrom, ipTo)
+	if err != nil {
+		return nil, err
+	}
+	entry := &Entry{
+		IPFrom: from.String(),
+	}
+	if !from.Equal(to) {
+		entry.IPTo = to.String()
+	}
+	return entry, nil
+}
+
+// 用来判断是否为同一个IP范围的Key
+func (this *Entry) Key() string {
+	return this.IPFrom + "-" + this.IPTo
+}
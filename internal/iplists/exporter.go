@@ -0,0 +1,56 @@
+package iplists
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// 导出IP列表
+func Export(format Format, entries []*Entry) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	switch format {
+	case FormatText, FormatAuto:
+		for _, entry := range entries {
+			buf.WriteString(entry.IPFrom)
+			if len(entry.IPTo) > 0 {
+				buf.WriteString("-" + entry.IPTo)
+			}
+			if len(entry.Reason) > 0 {
+				buf.WriteString(" # " + entry.Reason)
+			}
+			buf.WriteByte('\n')
+		}
+	case FormatCSV:
+		writer := csv.NewWriter(buf)
+		err := writer.Write([]string{"ipFrom", "ipTo", "expiredAt", "reason"})
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			err = writer.Write([]string{entry.IPFrom, entry.IPTo, strconv.FormatInt(entry.ExpiredAt, 10), entry.Reason})
+			if err != nil {
+				return nil, err
+			}
+		}
+		writer.Flush()
+		err = writer.Error()
+		if err != nil {
+			return nil, err
+		}
+	case FormatJSON:
+		if entries == nil {
+			entries = []*Entry{}
+		}
+		data, err := json.Marshal(entries)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+	default:
+		return nil, errors.New("invalid format '" + format + "'")
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,45 @@
+package iplists
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// 订阅源的最大尺寸
+const maxFeedSize = 64 * 1024 * 1024
+
+var sharedHTTPClient = &http.Client{
+	Timeout: 60 * time.Second,
+}
+
+// 下载并解析订阅源
+func Fetch(url string, format Format) (*ParseResult, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "GoEdge-API")
+	resp, err := sharedHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("invalid response status code '" + strconv.Itoa(resp.StatusCode) + "'")
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxFeedSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxFeedSize {
+		return nil, errors.New("feed is too large")
+	}
+	return Parse(format, data)
+}
@@ -0,0 +1,31 @@
+package iplists
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/drop.txt" {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = writer.Write([]byte("; Spamhaus DROP List\n1.10.16.0/20 ; SBL256894\n1.19.0.0/16 ; SBL434604\n"))
+	}))
+	defer server.Close()
+
+	result, err := Fetch(server.URL+"/drop.txt", FormatText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Entries) != 2 {
+		t.Fatal("should have 2 entries")
+	}
+
+	_, err = Fetch(server.URL+"/404.txt", FormatText)
+	if err == nil {
+		t.Fatal("should fail")
+	}
+}
@@ -0,0 +1,271 @@
+package iplists
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type Format = string
+
+const (
+	FormatAuto Format = ""     // 自动识别
+	FormatText Format = "text" // 每行一个IP、CIDR或者IP范围，支持 # 和 ; 注释，兼容Spamhaus DROP和FireHOL格式
+	FormatCSV  Format = "csv"  // ipFrom,ipTo,expiredAt,reason，可以有标题行
+	FormatJSON Format = "json" // JSON数组或者每行一个JSON对象
+)
+
+// 解析结果
+type ParseResult struct {
+	Entries []*Entry
+	Errors  []string // 无法解析的行
+}
+
+// 解析IP列表
+// 重复的条目只保留第一个
+func Parse(format Format, data []byte) (*ParseResult, error) {
+	if format == FormatAuto {
+		format = detectFormat(data)
+	}
+
+	var result *ParseResult
+	var err error
+	switch format {
+	case FormatText:
+		result, err = parseText(data)
+	case FormatCSV:
+		result, err = parseCSV(data)
+	case FormatJSON:
+		result, err = parseJSON(data)
+	default:
+		return nil, errors.New("invalid format '" + format + "'")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 去重
+	keyMap := map[string]bool{}
+	entries := []*Entry{}
+	for _, entry := range result.Entries {
+		key := entry.Key()
+		if keyMap[key] {
+			continue
+		}
+		keyMap[key] = true
+		entries = append(entries, entry)
+	}
+	result.Entries = entries
+	return result, nil
+}
+
+// 识别格式
+func detectFormat(data []byte) Format {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && (data[0] == '[' || data[0] == '{') {
+		return FormatJSON
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.Contains(line, ",") {
+			return FormatCSV
+		}
+		break
+	}
+	return FormatText
+}
+
+// 解析文本格式
+func parseText(data []byte) (*ParseResult, error) {
+	result := &ParseResult{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		// 注释，比如：1.2.3.0/24 ; SBL123456
+		reason := ""
+		if index := strings.IndexAny(line, "#;"); index >= 0 {
+			reason = strings.TrimSpace(line[index+1:])
+			line = strings.TrimSpace(line[:index])
+		}
+		if len(line) == 0 {
+			continue
+		}
+
+		// 只取第一列
+		fields := strings.Fields(line)
+		entry, err := NewEntry(fields[0], "")
+		if err != nil {
+			result.Errors = append(result.Errors, "line "+strconv.Itoa(lineNumber)+": "+err.Error())
+			continue
+		}
+		entry.Reason = reason
+		result.Entries = append(result.Entries, entry)
+	}
+	err := scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// 解析CSV格式
+func parseCSV(data []byte) (*ParseResult, error) {
+	result := &ParseResult{}
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	// 列的位置
+	columnMap := map[string]int{
+		"ipFrom":    0,
+		"ipTo":      1,
+		"expiredAt": 2,
+		"reason":    3,
+	}
+	column := func(record []string, name string) string {
+		index, ok := columnMap[name]
+		if !ok || index >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[index])
+	}
+
+	lineNumber := 0
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		lineNumber++
+		if err != nil {
+			result.Errors = append(result.Errors, "line "+strconv.Itoa(lineNumber)+": "+err.Error())
+			continue
+		}
+		if len(record) == 0 || len(strings.TrimSpace(record[0])) == 0 {
+			continue
+		}
+
+		// 标题行
+		if lineNumber == 1 && !strings.ContainsAny(record[0], ".:") {
+			columnMap = map[string]int{}
+			for index, name := range record {
+				columnMap[strings.TrimSpace(name)] = index
+			}
+			if _, ok := columnMap["ipFrom"]; !ok {
+				return nil, errors.New("can not find 'ipFrom' column in csv header")
+			}
+			continue
+		}
+
+		entry, err := NewEntry(column(record, "ipFrom"), column(record, "ipTo"))
+		if err != nil {
+			result.Errors = append(result.Errors, "line "+strconv.Itoa(lineNumber)+": "+err.Error())
+			continue
+		}
+		expiredAt := column(record, "expiredAt")
+		if len(expiredAt) > 0 {
+			entry.ExpiredAt, err = strconv.ParseInt(expiredAt, 10, 64)
+			if err != nil {
+				result.Errors = append(result.Errors, "line "+strconv.Itoa(lineNumber)+": invalid expiredAt '"+expiredAt+"'")
+				continue
+			}
+		}
+		entry.Reason = column(record, "reason")
+		result.Entries = append(result.Entries, entry)
+	}
+	return result, nil
+}
+
+// JSON格式中的单个条目
+// 除了 ipFrom 外，也支持Spamhaus JSON格式中的 cidr 字段
+type jsonEntry struct {
+	IPFrom    string `json:"ipFrom"`
+	IPTo      string `json:"ipTo"`
+	CIDR      string `json:"cidr"`
+	ExpiredAt int64  `json:"expiredAt"`
+	Reason    string `json:"reason"`
+}
+
+// 解析JSON格式
+// 支持字符串数组、对象数组和每行一个对象
+func parseJSON(data []byte) (*ParseResult, error) {
+	result := &ParseResult{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	index := 0
+	addEntry := func(raw json.RawMessage) {
+		index++
+		var ipFrom, ipTo, reason string
+		var expiredAt int64
+		raw = bytes.TrimSpace(raw)
+		if len(raw) > 0 && raw[0] == '"' {
+			err := json.Unmarshal(raw, &ipFrom)
+			if err != nil {
+				result.Errors = append(result.Errors, "item "+strconv.Itoa(index)+": "+err.Error())
+				return
+			}
+		} else {
+			item := &jsonEntry{}
+			err := json.Unmarshal(raw, item)
+			if err != nil {
+				result.Errors = append(result.Errors, "item "+strconv.Itoa(index)+": "+err.Error())
+				return
+			}
+			if len(item.IPFrom) == 0 && len(item.CIDR) == 0 {
+				// 忽略没有IP的对象，比如Spamhaus文件最后的元数据
+				return
+			}
+			ipFrom, ipTo, expiredAt, reason = item.IPFrom, item.IPTo, item.ExpiredAt, item.Reason
+			if len(ipFrom) == 0 {
+				ipFrom = item.CIDR
+			}
+		}
+		entry, err := NewEntry(ipFrom, ipTo)
+		if err != nil {
+			result.Errors = append(result.Errors, "item "+strconv.Itoa(index)+": "+err.Error())
+			return
+		}
+		entry.ExpiredAt = expiredAt
+		entry.Reason = reason
+		result.Entries = append(result.Entries, entry)
+	}
+
+	for {
+		var raw json.RawMessage
+		err := decoder.Decode(&raw)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		raw = bytes.TrimSpace(raw)
+		if len(raw) > 0 && raw[0] == '[' {
+			items := []json.RawMessage{}
+			err = json.Unmarshal(raw, &items)
+			if err != nil {
+				return nil, err
+			}
+			for _, item := range items {
+				addEntry(item)
+			}
+			continue
+		}
+		addEntry(raw)
+	}
+	return result, nil
+}
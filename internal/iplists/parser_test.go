@@ -0,0 +1,113 @@
+package iplists
+
+import (
+	"testing"
+)
+
+func TestParse_Text(t *testing.T) {
+	result, err := Parse(FormatAuto, []byte(`; Spamhaus DROP List
+; Last-Modified: Mon, 01 Mar 2021 00:00:00 GMT
+1.10.16.0/20 ; SBL256894
+1.19.0.0/16 ; SBL434604
+# FireHOL
+192.168.1.1
+192.168.1.10-192.168.1.20
+2001:db8::/32
+2001:db8::/32
+abc
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range result.Entries {
+		t.Log(entry.IPFrom, entry.IPTo, entry.Reason)
+	}
+	if len(result.Entries) != 5 {
+		t.Fatal("should have 5 entries, but got", len(result.Entries))
+	}
+	if result.Entries[0].IPFrom != "1.10.16.0" || result.Entries[0].IPTo != "1.10.31.255" || result.Entries[0].Reason != "SBL256894" {
+		t.Fatal("unexpected first entry", result.Entries[0])
+	}
+	if result.Entries[3].IPTo != "192.168.1.20" {
+		t.Fatal("unexpected range entry", result.Entries[3])
+	}
+	if len(result.Errors) != 1 {
+		t.Fatal("should have 1 error", result.Errors)
+	}
+}
+
+func TestParse_CSV(t *testing.T) {
+	result, err := Parse(FormatAuto, []byte(`ipFrom,ipTo,reason,expiredAt
+192.168.1.1,,test,1614556800
+192.168.2.1,192.168.2.100,range,
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Entries) != 2 || len(result.Errors) != 0 {
+		t.Fatal("unexpected result", result.Entries, result.Errors)
+	}
+	if result.Entries[0].ExpiredAt != 1614556800 || result.Entries[0].Reason != "test" {
+		t.Fatal("unexpected first entry", result.Entries[0])
+	}
+	if result.Entries[1].IPTo != "192.168.2.100" {
+		t.Fatal("unexpected second entry", result.Entries[1])
+	}
+
+	// 没有标题行
+	result, err = Parse(FormatCSV, []byte("10.0.0.1,10.0.0.2,0,no header\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Entries) != 1 || result.Entries[0].Reason != "no header" {
+		t.Fatal("unexpected result", result.Entries)
+	}
+}
+
+func TestParse_JSON(t *testing.T) {
+	result, err := Parse(FormatAuto, []byte(`["192.168.1.1", {"ipFrom":"10.0.0.0/8","reason":"private"}, {"ipFrom":"bad"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Entries) != 2 || len(result.Errors) != 1 {
+		t.Fatal("unexpected result", result.Entries, result.Errors)
+	}
+	if result.Entries[1].IPTo != "10.255.255.255" || result.Entries[1].Reason != "private" {
+		t.Fatal("unexpected second entry", result.Entries[1])
+	}
+
+	// Spamhaus JSON
+	result, err = Parse(FormatAuto, []byte(`{"cidr":"1.10.16.0/20","sblid":"SBL256894","rir":"apnic"}
+{"cidr":"2001:db8::/32","sblid":"SBL1","rir":"ripencc"}
+{"type":"metadata","timestamp":1614556800,"size":2}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Entries) != 2 || len(result.Errors) != 0 {
+		t.Fatal("unexpected result", result.Entries, result.Errors)
+	}
+}
+
+func TestExport(t *testing.T) {
+	entries := []*Entry{
+		{IPFrom: "192.168.1.1", Reason: "test"},
+		{IPFrom: "192.168.2.1", IPTo: "192.168.2.100", ExpiredAt: 1614556800},
+	}
+	for _, format := range []Format{FormatText, FormatCSV, FormatJSON} {
+		data, err := Export(format, entries)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Log(format + ":\n" + string(data))
+
+		// 导出的内容可以重新导入
+		result, err := Parse(format, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Entries) != 2 || result.Entries[1].IPTo != "192.168.2.100" || result.Entries[0].Reason != "test" {
+			t.Fatal(format + ": unexpected result")
+		}
+	}
+}
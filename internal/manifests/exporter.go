@@ -0,0 +1,148 @@
+package manifests
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/iwind/TeaGo/dbs"
+	"github.com/iwind/TeaGo/types"
+	"strconv"
+	"time"
+)
+
+// 对象引用
+type objectRef struct {
+	Type ObjectType
+	Id   int64
+}
+
+func (this *objectRef) key() string {
+	return this.Type + "_" + strconv.FormatInt(this.Id, 10)
+}
+
+// 导出服务及其引用的所有对象
+// 共享对象（证书、缓存策略、IP名单和公用WAF策略）也会导出，IP名单中的IP不会导出
+// 敏感对象（比如包含私钥的证书）只有在includeSecrets为true时才会导出，否则只保留ID引用
+// userId大于0时，不属于此用户的敏感对象始终只保留ID引用
+func Export(tx *dbs.Tx, serverIds []int64, userId int64, includeSecrets bool) (*Manifest, error) {
+	manifest := &Manifest{
+		Version:    Version,
+		ExportedAt: time.Now().Unix(),
+		Objects:    []*Object{},
+	}
+
+	queue := []*objectRef{}
+	visited := map[string]bool{}
+	push := func(objectType ObjectType, id int64) int64 {
+		ref := &objectRef{Type: objectType, Id: id}
+		if !visited[ref.key()] {
+			visited[ref.key()] = true
+			queue = append(queue, ref)
+		}
+		return id
+	}
+	rootKeys := map[string]bool{}
+	for _, serverId := range serverIds {
+		push(ObjectTypeServer, serverId)
+		rootKeys[(&objectRef{Type: ObjectTypeServer, Id: serverId}).key()] = true
+	}
+
+	for len(queue) > 0 {
+		ref := queue[0]
+		queue = queue[1:]
+
+		spec, ok := objectSpecs[ref.Type]
+		if !ok {
+			continue
+		}
+		object, err := exportObject(tx, spec, ref, userId, includeSecrets)
+		if err != nil {
+			return nil, err
+		}
+		if object == nil {
+			if rootKeys[ref.key()] {
+				return nil, errors.New("can not find server '" + strconv.FormatInt(ref.Id, 10) + "'")
+			}
+			continue
+		}
+		manifest.Objects = append(manifest.Objects, object)
+
+		// 继续导出引用的对象
+		for field, objectType := range spec.refFields {
+			// 所属对象不需要导出
+			if field == spec.ownerField {
+				continue
+			}
+			id, _ := toInt64(object.Fields[field])
+			if id > 0 {
+				push(objectType, id)
+			}
+		}
+		for field, keys := range spec.jsonFields {
+			WalkRefs(object.Fields[field], keys, push)
+		}
+	}
+
+	return manifest, nil
+}
+
+// 导出单个对象
+func exportObject(tx *dbs.Tx, spec *objectSpec, ref *objectRef, userId int64, includeSecrets bool) (*Object, error) {
+	columns := []string{"userId"}
+	columns = append(columns, spec.fields...)
+	for field := range spec.jsonFields {
+		columns = append(columns, field)
+	}
+	for field := range spec.refFields {
+		columns = append(columns, field)
+	}
+
+	ones, _, err := spec.dao().Query(tx).
+		Pk(ref.Id).
+		Attr("state", 1).
+		Result(columns...).
+		FindOnes()
+	if err != nil {
+		return nil, err
+	}
+	if len(ones) == 0 {
+		return nil, nil
+	}
+	one := ones[0]
+
+	// 敏感对象需要明确指定才导出，并且只有所属用户才能导出
+	if spec.isSecret && (!includeSecrets || (userId > 0 && one.GetInt64("userId") != userId)) {
+		return nil, nil
+	}
+
+	fields := map[string]interface{}{}
+	for _, field := range spec.fields {
+		value := one.Get(field)
+		if data, ok := value.([]byte); ok {
+			value = string(data)
+		}
+		fields[field] = value
+	}
+	for field := range spec.jsonFields {
+		data := one.GetString(field)
+		if !models.IsNotNull(data) {
+			fields[field] = nil
+			continue
+		}
+		var value interface{}
+		err = json.Unmarshal([]byte(data), &value)
+		if err != nil {
+			return nil, errors.New("decode '" + ref.Type + "." + field + "' failed: " + err.Error())
+		}
+		fields[field] = value
+	}
+	for field := range spec.refFields {
+		fields[field] = types.Int64(one.Get(field))
+	}
+
+	return &Object{
+		Type:   ref.Type,
+		Id:     ref.Id,
+		Fields: fields,
+	}, nil
+}
@@ -0,0 +1,358 @@
+package manifests
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/iwind/TeaGo/dbs"
+	"sort"
+	"time"
+)
+
+type ImportAction = string
+
+// 导入动作
+const (
+	ImportActionCreate ImportAction = "create" // 创建新对象
+	ImportActionReuse  ImportAction = "reuse"  // 引用已有的共享对象
+)
+
+// 导入选项
+type ImportOptions struct {
+	AdminId     int64
+	UserId      int64
+	ClusterId   int64
+	DryRun      bool // 只检查清单并返回将要执行的动作，不写入数据
+	ReuseShared bool // 复用已有的共享对象，证书使用名称和证书内容查找，其他共享对象使用名称和类型查找
+}
+
+// 导入步骤
+type ImportStep struct {
+	Type   ObjectType
+	OldId  int64
+	NewId  int64
+	Action ImportAction
+}
+
+// 导入结果
+type ImportResult struct {
+	ServerIds []int64
+	Steps     []*ImportStep
+	Warnings  []string
+}
+
+// 导入清单
+// 所有对象都会使用新的ID创建，清单内部的引用会替换为新的ID，清单之外的引用会保持不变
+// 清单之外的引用和复用的共享对象必须属于options.UserId，或者是管理员创建的公用对象
+func Import(tx *dbs.Tx, manifest *Manifest, options *ImportOptions) (*ImportResult, error) {
+	if manifest == nil {
+		return nil, errors.New("'manifest' should not be nil")
+	}
+	if options == nil {
+		options = &ImportOptions{}
+	}
+
+	result := &ImportResult{}
+
+	// 检查对象
+	objectMap := map[string]*Object{}
+	for _, object := range manifest.Objects {
+		_, ok := objectSpecs[object.Type]
+		if !ok {
+			return nil, errors.New("unsupported object type '" + object.Type + "'")
+		}
+		ref := &objectRef{Type: object.Type, Id: object.Id}
+		_, ok = objectMap[ref.key()]
+		if ok {
+			return nil, errors.New("duplicate object '" + ref.key() + "'")
+		}
+		objectMap[ref.key()] = object
+	}
+
+	// 从服务开始查找需要创建的对象，复用的共享对象引用的子对象不需要再创建
+	idMap := map[string]int64{}
+	creatingObjects := []*Object{}
+	externalRefs := map[string]*objectRef{}
+	visited := map[string]bool{}
+	queue := []*Object{}
+	for _, object := range manifest.Objects {
+		if object.Type == ObjectTypeServer {
+			visited[(&objectRef{Type: object.Type, Id: object.Id}).key()] = true
+			queue = append(queue, object)
+		}
+	}
+	var walkErr error
+	for len(queue) > 0 {
+		object := queue[0]
+		queue = queue[1:]
+		creatingObjects = append(creatingObjects, object)
+
+		walkObjectRefs(object, func(objectType ObjectType, id int64) int64 {
+			ref := &objectRef{Type: objectType, Id: id}
+			if walkErr != nil || visited[ref.key()] {
+				return id
+			}
+			visited[ref.key()] = true
+			refObject, ok := objectMap[ref.key()]
+			if !ok {
+				externalRefs[ref.key()] = ref
+				return id
+			}
+
+			// 复用已有的共享对象
+			spec := objectSpecs[refObject.Type]
+			if options.ReuseShared && spec.isSharedObject(refObject.Fields) {
+				existId, err := findReusableObject(tx, spec, refObject, options.UserId)
+				if err != nil {
+					walkErr = err
+					return id
+				}
+				if existId > 0 {
+					idMap[ref.key()] = existId
+					result.Steps = append(result.Steps, &ImportStep{
+						Type:   refObject.Type,
+						OldId:  refObject.Id,
+						NewId:  existId,
+						Action: ImportActionReuse,
+					})
+					return id
+				}
+			}
+
+			queue = append(queue, refObject)
+			return id
+		})
+		if walkErr != nil {
+			return nil, walkErr
+		}
+	}
+	for _, object := range manifest.Objects {
+		if !visited[(&objectRef{Type: object.Type, Id: object.Id}).key()] {
+			result.Warnings = append(result.Warnings, "skip unreferenced object '"+(&objectRef{Type: object.Type, Id: object.Id}).key()+"'")
+		}
+	}
+
+	// 检查清单之外的引用
+	externalRefList := []*objectRef{}
+	for _, ref := range externalRefs {
+		externalRefList = append(externalRefList, ref)
+	}
+	sort.Slice(externalRefList, func(i, j int) bool {
+		if externalRefList[i].Type == externalRefList[j].Type {
+			return externalRefList[i].Id < externalRefList[j].Id
+		}
+		return externalRefList[i].Type < externalRefList[j].Type
+	})
+	for _, ref := range externalRefList {
+		err := checkExternalRef(tx, ref, options.UserId)
+		if err != nil {
+			if !options.DryRun {
+				return nil, err
+			}
+			result.Warnings = append(result.Warnings, err.Error())
+			continue
+		}
+		result.Steps = append(result.Steps, &ImportStep{
+			Type:   ref.Type,
+			OldId:  ref.Id,
+			NewId:  ref.Id,
+			Action: ImportActionReuse,
+		})
+	}
+
+	if options.DryRun {
+		for _, object := range creatingObjects {
+			result.Steps = append(result.Steps, &ImportStep{
+				Type:   object.Type,
+				OldId:  object.Id,
+				Action: ImportActionCreate,
+			})
+		}
+		return result, nil
+	}
+
+	// 先创建空对象以获得新的ID，这样对象之间可以相互引用而不需要考虑创建顺序
+	now := time.Now().Unix()
+	for _, object := range creatingObjects {
+		newId, err := objectSpecs[object.Type].dao().Query(tx).
+			Set("adminId", options.AdminId).
+			Set("userId", options.UserId).
+			Set("state", 0).
+			Set("createdAt", now).
+			Insert()
+		if err != nil {
+			return nil, err
+		}
+		idMap[(&objectRef{Type: object.Type, Id: object.Id}).key()] = newId
+		result.Steps = append(result.Steps, &ImportStep{
+			Type:   object.Type,
+			OldId:  object.Id,
+			NewId:  newId,
+			Action: ImportActionCreate,
+		})
+	}
+
+	// 写入对象内容
+	remap := func(objectType ObjectType, id int64) int64 {
+		newId, ok := idMap[(&objectRef{Type: objectType, Id: id}).key()]
+		if ok {
+			return newId
+		}
+		return id
+	}
+	for _, object := range creatingObjects {
+		spec := objectSpecs[object.Type]
+		newId := idMap[(&objectRef{Type: object.Type, Id: object.Id}).key()]
+		walkObjectRefs(object, remap)
+
+		query := spec.dao().Query(tx).Pk(newId)
+		for _, field := range spec.fields {
+			value, ok := object.Fields[field]
+			if !ok || value == nil {
+				continue
+			}
+			if b, isBool := value.(bool); isBool {
+				if b {
+					value = 1
+				} else {
+					value = 0
+				}
+			}
+			query.Set(field, value)
+		}
+		for field := range spec.refFields {
+			id, _ := toInt64(object.Fields[field])
+			query.Set(field, id)
+		}
+		for field := range spec.jsonFields {
+			value, ok := object.Fields[field]
+			if !ok || value == nil {
+				continue
+			}
+			data, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			query.Set(field, string(data))
+		}
+
+		if object.Type == ObjectTypeServer {
+			dnsName, err := models.SharedServerDAO.GenDNSName(tx)
+			if err != nil {
+				return nil, err
+			}
+			query.Set("dnsName", dnsName)
+			query.Set("clusterId", options.ClusterId)
+			query.Set("groupIds", "[]")
+			query.Set("version", 1)
+			result.ServerIds = append(result.ServerIds, newId)
+		}
+
+		query.Set("state", 1)
+		_, err := query.Update()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 通知更新
+	author := models.NewServerConfigRevisionAuthor(options.AdminId, options.UserId)
+	author.Description = "import from manifest"
+	for _, serverId := range result.ServerIds {
+		err := models.SharedServerDAO.NotifyUpdateWithAuthor(tx, serverId, author)
+		if err != nil {
+			return nil, err
+		}
+		err = models.SharedServerDAO.NotifyDNSUpdate(tx, serverId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// 遍历对象中所有的引用
+func walkObjectRefs(object *Object, fn func(objectType ObjectType, id int64) int64) {
+	spec := objectSpecs[object.Type]
+	for field, objectType := range spec.refFields {
+		id, _ := toInt64(object.Fields[field])
+		if id > 0 {
+			object.Fields[field] = fn(objectType, id)
+		}
+	}
+	for field, keys := range spec.jsonFields {
+		value, ok := object.Fields[field]
+		if ok {
+			object.Fields[field] = WalkRefs(value, keys, fn)
+		}
+	}
+}
+
+// 查找可以复用的共享对象，优先使用用户自己的对象
+func findReusableObject(tx *dbs.Tx, spec *objectSpec, object *Object, userId int64) (int64, error) {
+	if len(spec.matchFields) == 0 {
+		return 0, nil
+	}
+	query := spec.dao().Query(tx).
+		ResultPk().
+		Attr("state", 1)
+	for _, field := range spec.matchFields {
+		value, ok := object.Fields[field]
+		if !ok || value == nil {
+			return 0, nil
+		}
+		query.Attr(field, value)
+	}
+	if len(spec.ownerField) > 0 {
+		query.Attr(spec.ownerField, 0)
+	}
+	if userId > 0 {
+		query.Where("(userId=:userId OR userId=0)").
+			Param("userId", userId).
+			Desc("userId")
+	} else {
+		query.Attr("userId", 0)
+	}
+	ones, _, err := query.
+		AscPk().
+		Limit(1).
+		FindOnes()
+	if err != nil {
+		return 0, err
+	}
+	if len(ones) == 0 {
+		return 0, nil
+	}
+	return ones[0].GetInt64("id"), nil
+}
+
+// 检查清单之外的引用
+// 引用的对象必须存在，并且属于用户或者是管理员创建的公用对象
+func checkExternalRef(tx *dbs.Tx, ref *objectRef, userId int64) error {
+	spec := objectSpecs[ref.Type]
+	columns := []string{"userId"}
+	if len(spec.ownerField) > 0 {
+		columns = append(columns, spec.ownerField)
+	}
+	ones, _, err := spec.dao().Query(tx).
+		Pk(ref.Id).
+		Attr("state", 1).
+		Result(columns...).
+		FindOnes()
+	if err != nil {
+		return err
+	}
+	if len(ones) == 0 {
+		return errors.New("can not find referenced object '" + ref.key() + "'")
+	}
+	one := ones[0]
+	ownerUserId := one.GetInt64("userId")
+	if ownerUserId > 0 && ownerUserId != userId {
+		return errors.New("referenced object '" + ref.key() + "' does not belong to the user")
+	}
+	if len(spec.ownerField) > 0 && one.GetInt64(spec.ownerField) > 0 {
+		return errors.New("referenced object '" + ref.key() + "' is not shared")
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package manifests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/go-yaml/yaml"
+	"strconv"
+)
+
+type Format = string
+
+// 清单格式
+const (
+	FormatYAML Format = "yaml"
+	FormatJSON Format = "json"
+)
+
+// 当前清单版本
+const Version = 1
+
+// 服务配置清单
+// 包含一组服务及其引用的所有对象，对象之间通过原有ID相互引用，导入时会重新分配ID
+type Manifest struct {
+	Version    int       `yaml:"version" json:"version"`
+	ExportedAt int64     `yaml:"exportedAt" json:"exportedAt"`
+	Objects    []*Object `yaml:"objects" json:"objects"`
+}
+
+// 清单中的单个对象
+type Object struct {
+	Type   ObjectType             `yaml:"type" json:"type"`
+	Id     int64                  `yaml:"id" json:"id"`
+	Fields map[string]interface{} `yaml:"fields" json:"fields"`
+}
+
+// 编码清单
+func Encode(format Format, manifest *Manifest) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return json.MarshalIndent(manifest, "", "  ")
+	case FormatYAML, "":
+		return yaml.Marshal(manifest)
+	}
+	return nil, errors.New("unsupported format '" + format + "'")
+}
+
+// 解码清单，自动识别YAML和JSON
+func Decode(data []byte) (*Manifest, error) {
+	manifest := &Manifest{}
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		err := json.Unmarshal(data, manifest)
+		if err != nil {
+			return nil, errors.New("decode json failed: " + err.Error())
+		}
+	} else {
+		err := yaml.Unmarshal(data, manifest)
+		if err != nil {
+			return nil, errors.New("decode yaml failed: " + err.Error())
+		}
+	}
+
+	if manifest.Version <= 0 || manifest.Version > Version {
+		return nil, errors.New("unsupported manifest version '" + strconv.Itoa(manifest.Version) + "'")
+	}
+
+	// YAML中的对象会被解析为map[interface{}]interface{}，这里统一转换
+	for _, object := range manifest.Objects {
+		if object == nil {
+			return nil, errors.New("invalid empty object")
+		}
+		if object.Id <= 0 {
+			return nil, errors.New("invalid id for object '" + object.Type + "'")
+		}
+		if object.Fields == nil {
+			object.Fields = map[string]interface{}{}
+		}
+		for k, v := range object.Fields {
+			object.Fields[k] = normalizeValue(v)
+		}
+	}
+	return manifest, nil
+}
+
+// 将YAML解析的值转换为JSON兼容的值
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		result := map[string]interface{}{}
+		for k, item := range v {
+			switch key := k.(type) {
+			case string:
+				result[key] = normalizeValue(item)
+			default:
+				result[toString(key)] = normalizeValue(item)
+			}
+		}
+		return result
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = normalizeValue(item)
+		}
+		return v
+	case []interface{}:
+		for index, item := range v {
+			v[index] = normalizeValue(item)
+		}
+		return v
+	}
+	return value
+}
+
+// 转换为字符串
+func toString(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	s, err := strconv.Unquote(string(data))
+	if err != nil {
+		return string(data)
+	}
+	return s
+}
@@ -0,0 +1,101 @@
+package manifests
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	manifest := &Manifest{
+		Version:    Version,
+		ExportedAt: 1614556800,
+		Objects: []*Object{
+			{
+				Type: ObjectTypeServer,
+				Id:   1,
+				Fields: map[string]interface{}{
+					"name":         "example",
+					"reverseProxy": map[string]interface{}{"isOn": true, "reverseProxyId": 2},
+				},
+			},
+		},
+	}
+	for _, format := range []Format{FormatYAML, FormatJSON} {
+		data, err := Encode(format, manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Log(string(data))
+
+		result, err := Decode(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Objects) != 1 || result.Objects[0].Type != ObjectTypeServer || result.Objects[0].Id != 1 {
+			t.Fatal("unexpected objects", result.Objects)
+		}
+
+		// 解码后的值应该可以直接转换为JSON
+		reverseProxyJSON, err := json.Marshal(result.Objects[0].Fields["reverseProxy"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(reverseProxyJSON) != `{"isOn":true,"reverseProxyId":2}` {
+			t.Fatal("unexpected reverseProxy", string(reverseProxyJSON))
+		}
+	}
+}
+
+func TestDecode_InvalidVersion(t *testing.T) {
+	_, err := Decode([]byte(`{"version":100,"objects":[]}`))
+	if err == nil {
+		t.Fatal("should fail with unsupported version")
+	}
+	t.Log(err)
+}
+
+func TestWalkRefs(t *testing.T) {
+	var value interface{}
+	err := json.Unmarshal([]byte(`[{"isOn":true,"locationId":1,"children":[{"isOn":true,"locationId":2}]},{"locationId":3,"name":"locationId"}]`), &value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := []int64{}
+	value = WalkRefs(value, RefKeys{"locationId": ObjectTypeLocation}, func(objectType ObjectType, id int64) int64 {
+		if objectType != ObjectTypeLocation {
+			t.Fatal("unexpected type", objectType)
+		}
+		ids = append(ids, id)
+		return id + 100
+	})
+	if len(ids) != 3 {
+		t.Fatal("should have 3 refs, but got", ids)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `[{"children":[{"isOn":true,"locationId":102}],"isOn":true,"locationId":101},{"locationId":103,"name":"locationId"}]` {
+		t.Fatal("unexpected result", string(data))
+	}
+}
+
+func TestObjectSpec_IsSharedObject(t *testing.T) {
+	if !objectSpecs[ObjectTypeSSLCert].isSharedObject(map[string]interface{}{}) {
+		t.Fatal("cert should be shared")
+	}
+	if objectSpecs[ObjectTypeWeb].isSharedObject(map[string]interface{}{}) {
+		t.Fatal("web should not be shared")
+	}
+
+	// 公用WAF策略
+	firewallSpec := objectSpecs[ObjectTypeFirewallPolicy]
+	if !firewallSpec.isSharedObject(map[string]interface{}{"serverId": 0}) {
+		t.Fatal("public firewall policy should be shared")
+	}
+	if firewallSpec.isSharedObject(map[string]interface{}{"serverId": float64(1)}) {
+		t.Fatal("server firewall policy should not be shared")
+	}
+}
@@ -0,0 +1,75 @@
+package manifests
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+)
+
+// 引用字段名和对象类型的对应关系
+type RefKeys = map[string]ObjectType
+
+// 遍历JSON中的对象引用
+// 如果字段名在keys中并且值为正整数，则调用fn，并将值替换为fn的返回值
+func WalkRefs(value interface{}, keys RefKeys, fn func(objectType ObjectType, id int64) int64) interface{} {
+	if len(keys) == 0 {
+		return value
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			objectType, ok := keys[k]
+			if ok {
+				id, isId := toInt64(item)
+				if isId {
+					if id > 0 {
+						v[k] = fn(objectType, id)
+					}
+					continue
+				}
+			}
+			v[k] = WalkRefs(item, keys, fn)
+		}
+	case []interface{}:
+		for index, item := range v {
+			v[index] = WalkRefs(item, keys, fn)
+		}
+	}
+	return value
+}
+
+// 转换为整数
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		if v > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(v), true
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, false
+		}
+		return int64(v), true
+	case json.Number:
+		i, err := v.Int64()
+		return i, err == nil
+	case string:
+		i, err := strconv.ParseInt(v, 10, 64)
+		return i, err == nil
+	case []byte:
+		i, err := strconv.ParseInt(string(v), 10, 64)
+		return i, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,268 @@
+package manifests
+
+import (
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/iwind/TeaGo/dbs"
+)
+
+type ObjectType = string
+
+// 对象类型
+const (
+	ObjectTypeServer            ObjectType = "server"
+	ObjectTypeWeb               ObjectType = "web"
+	ObjectTypeReverseProxy      ObjectType = "reverseProxy"
+	ObjectTypeOrigin            ObjectType = "origin"
+	ObjectTypeLocation          ObjectType = "location"
+	ObjectTypeSSLPolicy         ObjectType = "sslPolicy"
+	ObjectTypeHeaderPolicy      ObjectType = "headerPolicy"
+	ObjectTypeHeader            ObjectType = "header"
+	ObjectTypeGzip              ObjectType = "gzip"
+	ObjectTypeWebsocket         ObjectType = "websocket"
+	ObjectTypePage              ObjectType = "page"
+	ObjectTypeRewriteRule       ObjectType = "rewriteRule"
+	ObjectTypeFirewallPolicy    ObjectType = "firewallPolicy"
+	ObjectTypeFirewallRuleGroup ObjectType = "firewallRuleGroup"
+	ObjectTypeFirewallRuleSet   ObjectType = "firewallRuleSet"
+	ObjectTypeFirewallRule      ObjectType = "firewallRule"
+
+	// 以下为共享对象，会和服务一起导出，导入时可以选择复用已有的同名对象
+	ObjectTypeSSLCert     ObjectType = "sslCert"
+	ObjectTypeCachePolicy ObjectType = "cachePolicy"
+	ObjectTypeIPList      ObjectType = "ipList"
+)
+
+// 对象查询接口
+type querier interface {
+	Query(tx *dbs.Tx) *dbs.Query
+}
+
+// 对象定义
+type objectSpec struct {
+	dao         func() querier        // 对应的DAO，因为DAO在数据库准备好之后才会初始化，所以这里使用函数
+	isShared    bool                  // 是否为共享对象
+	isSecret    bool                  // 是否包含私钥等敏感信息，需要明确指定才会导出，用户只能导出自己的此类对象
+	ownerField  string                // 如果此字段值为0，表示对象为共享对象，比如公用的WAF策略
+	fields      []string              // 普通字段
+	jsonFields  map[string]RefKeys    // JSON字段及其中的引用
+	refFields   map[string]ObjectType // 引用其他对象ID的字段
+	matchFields []string              // 导入时用来查找可复用的已有共享对象的字段，比如证书使用名称和证书内容
+}
+
+// 判断对象是否为共享对象
+func (this *objectSpec) isSharedObject(fields map[string]interface{}) bool {
+	if this.isShared {
+		return true
+	}
+	if len(this.ownerField) > 0 {
+		ownerId, _ := toInt64(fields[this.ownerField])
+		return ownerId <= 0
+	}
+	return false
+}
+
+// 所有对象定义
+var objectSpecs = map[ObjectType]*objectSpec{
+	ObjectTypeServer: {
+		dao:    func() querier { return models.SharedServerDAO },
+		fields: []string{"isOn", "type", "name", "description"},
+		jsonFields: map[string]RefKeys{
+			"serverNames":  nil,
+			"http":         nil,
+			"https":        {"sslPolicyId": ObjectTypeSSLPolicy},
+			"tcp":          nil,
+			"tls":          {"sslPolicyId": ObjectTypeSSLPolicy},
+			"unix":         nil,
+			"udp":          nil,
+			"reverseProxy": {"reverseProxyId": ObjectTypeReverseProxy},
+			"includeNodes": nil,
+			"excludeNodes": nil,
+		},
+		refFields: map[string]ObjectType{"webId": ObjectTypeWeb},
+	},
+	ObjectTypeWeb: {
+		dao:    func() querier { return models.SharedHTTPWebDAO },
+		fields: []string{"isOn"},
+		jsonFields: map[string]RefKeys{
+			"root":               nil,
+			"charset":            nil,
+			"shutdown":           nil,
+			"pages":              {"id": ObjectTypePage},
+			"redirectToHttps":    nil,
+			"indexes":            nil,
+			"maxRequestBodySize": nil,
+			"requestHeader":      {"headerPolicyId": ObjectTypeHeaderPolicy},
+			"responseHeader":     {"headerPolicyId": ObjectTypeHeaderPolicy},
+			"accessLog":          nil,
+			"stat":               nil,
+			"gzip":               {"gzipId": ObjectTypeGzip},
+			"cache":              {"cachePolicyId": ObjectTypeCachePolicy},
+			"firewall":           {"firewallPolicyId": ObjectTypeFirewallPolicy},
+			"locations":          {"locationId": ObjectTypeLocation},
+			"websocket":          {"websocketId": ObjectTypeWebsocket},
+			"rewriteRules":       {"rewriteRuleId": ObjectTypeRewriteRule},
+			"hostRedirects":      nil,
+		},
+	},
+	ObjectTypeReverseProxy: {
+		dao:    func() querier { return models.SharedReverseProxyDAO },
+		fields: []string{"isOn", "stripPrefix", "requestHostType", "requestHost", "requestURI", "autoFlush"},
+		jsonFields: map[string]RefKeys{
+			"scheduling":     nil,
+			"primaryOrigins": {"originId": ObjectTypeOrigin},
+			"backupOrigins":  {"originId": ObjectTypeOrigin},
+			"addHeaders":     nil,
+		},
+	},
+	ObjectTypeOrigin: {
+		dao:    func() querier { return models.SharedOriginDAO },
+		fields: []string{"isOn", "name", "description", "code", "weight", "maxFails", "maxConns", "maxIdleConns", "httpRequestURI", "host"},
+		jsonFields: map[string]RefKeys{
+			"addr":               nil,
+			"connTimeout":        nil,
+			"readTimeout":        nil,
+			"idleTimeout":        nil,
+			"httpRequestHeader":  {"headerPolicyId": ObjectTypeHeaderPolicy},
+			"httpResponseHeader": {"headerPolicyId": ObjectTypeHeaderPolicy},
+			"healthCheck":        nil,
+			"cert":               {"certId": ObjectTypeSSLCert},
+			"ftp":                nil,
+		},
+	},
+	ObjectTypeLocation: {
+		dao:    func() querier { return models.SharedHTTPLocationDAO },
+		fields: []string{"isOn", "pattern", "name", "description", "urlPrefix", "isBreak"},
+		jsonFields: map[string]RefKeys{
+			"reverseProxy": {"reverseProxyId": ObjectTypeReverseProxy},
+			"conds":        nil,
+		},
+		refFields: map[string]ObjectType{"webId": ObjectTypeWeb, "parentId": ObjectTypeLocation},
+	},
+	ObjectTypeSSLPolicy: {
+		dao:    func() querier { return models.SharedSSLPolicyDAO },
+		fields: []string{"isOn", "clientAuthType", "minVersion", "cipherSuitesIsOn", "http2Enabled"},
+		jsonFields: map[string]RefKeys{
+			"certs":         {"certId": ObjectTypeSSLCert},
+			"clientCACerts": {"certId": ObjectTypeSSLCert},
+			"cipherSuites":  nil,
+			"hsts":          nil,
+		},
+	},
+	ObjectTypeHeaderPolicy: {
+		dao:    func() querier { return models.SharedHTTPHeaderPolicyDAO },
+		fields: []string{"isOn"},
+		jsonFields: map[string]RefKeys{
+			"addHeaders":     {"headerId": ObjectTypeHeader},
+			"addTrailers":    {"headerId": ObjectTypeHeader},
+			"setHeaders":     {"headerId": ObjectTypeHeader},
+			"replaceHeaders": {"headerId": ObjectTypeHeader},
+			"expires":        nil,
+			"deleteHeaders":  nil,
+		},
+	},
+	ObjectTypeHeader: {
+		dao:    func() querier { return models.SharedHTTPHeaderDAO },
+		fields: []string{"isOn", "name", "value", "order"},
+		jsonFields: map[string]RefKeys{
+			"status": nil,
+		},
+	},
+	ObjectTypeGzip: {
+		dao:    func() querier { return models.SharedHTTPGzipDAO },
+		fields: []string{"isOn", "level"},
+		jsonFields: map[string]RefKeys{
+			"minLength": nil,
+			"maxLength": nil,
+			"conds":     nil,
+		},
+	},
+	ObjectTypeWebsocket: {
+		dao:    func() querier { return models.SharedHTTPWebsocketDAO },
+		fields: []string{"isOn", "allowAllOrigins", "requestSameOrigin", "requestOrigin"},
+		jsonFields: map[string]RefKeys{
+			"handshakeTimeout": nil,
+			"allowedOrigins":   nil,
+		},
+	},
+	ObjectTypePage: {
+		dao:    func() querier { return models.SharedHTTPPageDAO },
+		fields: []string{"isOn", "url", "newStatus"},
+		jsonFields: map[string]RefKeys{
+			"statusList": nil,
+		},
+	},
+	ObjectTypeRewriteRule: {
+		dao:    func() querier { return models.SharedHTTPRewriteRuleDAO },
+		fields: []string{"isOn", "pattern", "replace", "mode", "redirectStatus", "proxyHost", "isBreak", "withQuery"},
+		jsonFields: map[string]RefKeys{
+			"conds": nil,
+		},
+	},
+	ObjectTypeFirewallPolicy: {
+		dao:        func() querier { return models.SharedHTTPFirewallPolicyDAO },
+		ownerField: "serverId",
+		fields:     []string{"isOn", "name", "description"},
+		jsonFields: map[string]RefKeys{
+			"inbound":      {"groupId": ObjectTypeFirewallRuleGroup, "listId": ObjectTypeIPList},
+			"outbound":     {"groupId": ObjectTypeFirewallRuleGroup, "listId": ObjectTypeIPList},
+			"blockOptions": nil,
+		},
+		refFields:   map[string]ObjectType{"serverId": ObjectTypeServer},
+		matchFields: []string{"name"},
+	},
+	ObjectTypeFirewallRuleGroup: {
+		dao:    func() querier { return models.SharedHTTPFirewallRuleGroupDAO },
+		fields: []string{"isOn", "name", "description", "code"},
+		jsonFields: map[string]RefKeys{
+			"sets": {"setId": ObjectTypeFirewallRuleSet},
+		},
+	},
+	ObjectTypeFirewallRuleSet: {
+		dao:    func() querier { return models.SharedHTTPFirewallRuleSetDAO },
+		fields: []string{"isOn", "code", "name", "description", "connector", "action"},
+		jsonFields: map[string]RefKeys{
+			"rules":         {"ruleId": ObjectTypeFirewallRule},
+			"actionOptions": nil,
+		},
+	},
+	ObjectTypeFirewallRule: {
+		dao:    func() querier { return models.SharedHTTPFirewallRuleDAO },
+		fields: []string{"isOn", "description", "param", "operator", "value", "isCaseInsensitive"},
+		jsonFields: map[string]RefKeys{
+			"paramFilters":      nil,
+			"checkpointOptions": nil,
+		},
+	},
+	ObjectTypeSSLCert: {
+		dao:      func() querier { return models.SharedSSLCertDAO },
+		isShared: true,
+		isSecret: true,
+		fields:   []string{"isOn", "name", "description", "certData", "keyData", "serverName", "isCA", "timeBeginAt", "timeEndAt"},
+		jsonFields: map[string]RefKeys{
+			"dnsNames":    nil,
+			"commonNames": nil,
+		},
+		matchFields: []string{"name", "certData"},
+	},
+	ObjectTypeCachePolicy: {
+		dao:      func() querier { return models.SharedHTTPCachePolicyDAO },
+		isShared: true,
+		fields:   []string{"isOn", "name", "description", "maxKeys", "type"},
+		jsonFields: map[string]RefKeys{
+			"capacity": nil,
+			"maxSize":  nil,
+			"options":  nil,
+		},
+		matchFields: []string{"name", "type"},
+	},
+	ObjectTypeIPList: {
+		dao:      func() querier { return models.SharedIPListDAO },
+		isShared: true,
+		fields:   []string{"isOn", "type", "name", "code"},
+		jsonFields: map[string]RefKeys{
+			"timeout": nil,
+			"actions": nil,
+		},
+		matchFields: []string{"name", "type"},
+	},
+}
@@ -173,6 +173,8 @@ func (this *APINode) listenRPC(listener net.Listener, tlsConfig *tls.Config) err
 	pb.RegisterAdminServiceServer(rpcServer, &services.AdminService{})
 	pb.RegisterNodeGrantServiceServer(rpcServer, &services.NodeGrantService{})
 	pb.RegisterServerServiceServer(rpcServer, &services.ServerService{})
+	pb.RegisterServerConfigRevisionServiceServer(rpcServer, &services.ServerConfigRevisionService{})
+	pb.RegisterServerManifestServiceServer(rpcServer, &services.ServerManifestService{})
 	pb.RegisterNodeServiceServer(rpcServer, &services.NodeService{})
 	pb.RegisterNodeClusterServiceServer(rpcServer, &services.NodeClusterService{})
 	pb.RegisterNodeIPAddressServiceServer(rpcServer, &services.NodeIPAddressService{})
@@ -199,6 +201,9 @@ func (this *APINode) listenRPC(listener net.Listener, tlsConfig *tls.Config) err
 	pb.RegisterNodeLogServiceServer(rpcServer, &services.NodeLogService{})
 	pb.RegisterHTTPAccessLogServiceServer(rpcServer, &services.HTTPAccessLogService{})
 	pb.RegisterMessageServiceServer(rpcServer, &services.MessageService{})
+	pb.RegisterMessageChannelServiceServer(rpcServer, &services.MessageChannelService{})
+	pb.RegisterNodeInstallTokenServiceServer(rpcServer, &services.NodeInstallTokenService{})
+	pb.RegisterNodeClusterUpgradeServiceServer(rpcServer, &services.NodeClusterUpgradeService{})
 	pb.RegisterNodeGroupServiceServer(rpcServer, &services.NodeGroupService{})
 	pb.RegisterNodeRegionServiceServer(rpcServer, &services.NodeRegionService{})
 	pb.RegisterNodePriceItemServiceServer(rpcServer, &services.NodePriceItemService{})
@@ -210,6 +215,7 @@ func (this *APINode) listenRPC(listener net.Listener, tlsConfig *tls.Config) err
 	pb.RegisterRegionProvinceServiceServer(rpcServer, &services.RegionProvinceService{})
 	pb.RegisterIPListServiceServer(rpcServer, &services.IPListService{})
 	pb.RegisterIPItemServiceServer(rpcServer, &services.IPItemService{})
+	pb.RegisterIPListFeedServiceServer(rpcServer, &services.IPListFeedService{})
 	pb.RegisterLogServiceServer(rpcServer, &services.LogService{})
 	pb.RegisterDNSProviderServiceServer(rpcServer, &services.DNSProviderService{})
 	pb.RegisterDNSDomainServiceServer(rpcServer, &services.DNSDomainService{})
@@ -0,0 +1,326 @@
+package nodes
+
+import (
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/TeaOSLab/EdgeAPI/internal/installers"
+	"github.com/TeaOSLab/EdgeAPI/internal/rpc/services"
+	rpcutils "github.com/TeaOSLab/EdgeAPI/internal/rpc/utils"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/iwind/TeaGo/maps"
+	"github.com/iwind/TeaGo/types"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 通过令牌安装节点
+// 适用于API节点无法通过SSH登录的节点，安装过程：
+//   1. 管理员生成和集群绑定的一次性安装令牌
+//   2. 在节点上执行：curl -fsSL "REST地址/install/bootstrap.sh?token=安装令牌" | sh
+//   3. 脚本注册节点、下载安装包、修改配置并启动，同时报告每一步的安装进度
+
+var installOSArchReg = regexp.MustCompile(`^[a-z0-9]+$`)
+
+// 令牌注册节点后可以报告进度的时间
+const installTokenReportSeconds = 86400
+
+// 令牌注册节点后，节点安装成功之前可以重新执行安装脚本的时间
+const installTokenRetrySeconds = 3600
+
+// 处理安装相关请求
+func (this *RestServer) handleInstall(writer http.ResponseWriter, req *http.Request) {
+	req.Body = http.MaxBytesReader(writer, req.Body, 64*1024)
+
+	switch req.URL.Path {
+	case "/install/bootstrap.sh":
+		this.handleInstallBootstrap(writer, req)
+	case "/install/package":
+		this.handleInstallPackage(writer, req)
+	case "/install/register":
+		this.handleInstallRegister(writer, req)
+	case "/install/status":
+		this.handleInstallStatus(writer, req)
+	default:
+		this.writeError(writer, http.StatusNotFound, "invalid path '"+req.URL.Path+"'", false)
+	}
+}
+
+// 安装脚本
+func (this *RestServer) handleInstallBootstrap(writer http.ResponseWriter, req *http.Request) {
+	token, ok := this.findInstallToken(writer, req)
+	if !ok {
+		return
+	}
+
+	cluster, err := models.SharedNodeClusterDAO.FindEnabledNodeCluster(nil, int64(token.ClusterId))
+	if err != nil {
+		this.writeError(writer, http.StatusInternalServerError, "server error: "+err.Error(), false)
+		return
+	}
+	if cluster == nil {
+		this.writeError(writer, http.StatusNotFound, "can not find cluster", false)
+		return
+	}
+
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+	script := strings.NewReplacer(
+		"{{API}}", installers.ShellQuote(scheme+"://"+req.Host),
+		"{{TOKEN}}", installers.ShellQuote(token.Token),
+		"{{INSTALL_DIR}}", installers.ShellQuote(cluster.InstallDir),
+	).Replace(installBootstrapScript)
+
+	writer.Header().Set("Content-Type", "text/x-shellscript; charset=utf-8")
+	_, _ = writer.Write([]byte(script))
+}
+
+// 下载安装包
+func (this *RestServer) handleInstallPackage(writer http.ResponseWriter, req *http.Request) {
+	_, ok := this.findInstallToken(writer, req)
+	if !ok {
+		return
+	}
+
+	osName := req.FormValue("os")
+	archName := req.FormValue("arch")
+	if !installOSArchReg.MatchString(osName) || !installOSArchReg.MatchString(archName) {
+		this.writeError(writer, http.StatusBadRequest, "invalid 'os' or 'arch'", false)
+		return
+	}
+
+	zipFile, err := (&installers.BaseInstaller{}).LookupLatestInstaller("edge-node-" + osName + "-" + archName)
+	if err != nil {
+		this.writeError(writer, http.StatusInternalServerError, "server error: "+err.Error(), false)
+		return
+	}
+	if len(zipFile) == 0 {
+		this.writeError(writer, http.StatusNotFound, "can not find installer file for "+osName+"/"+archName, false)
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/zip")
+	http.ServeFile(writer, req, zipFile)
+}
+
+// 注册节点
+func (this *RestServer) handleInstallRegister(writer http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		this.writeError(writer, http.StatusMethodNotAllowed, "method not allowed", false)
+		return
+	}
+
+	token, ok := this.findInstallToken(writer, req)
+	if !ok {
+		return
+	}
+
+	name := strings.TrimSpace(req.FormValue("name"))
+	if len(name) == 0 {
+		name = "node-" + types.String(time.Now().Unix())
+	}
+
+	// 已经注册过的节点在安装完成之前可以重新执行安装脚本，比如下载或者解压失败后重试
+	if token.IsUsed == 1 {
+		this.handleInstallRegisterRetry(writer, req, token)
+		return
+	}
+
+	// 令牌只能使用一次
+	isUsed, err := models.SharedNodeInstallTokenDAO.UseInstallToken(nil, int64(token.Id))
+	if err != nil {
+		this.writeError(writer, http.StatusInternalServerError, "server error: "+err.Error(), false)
+		return
+	}
+	if !isUsed {
+		this.writeError(writer, http.StatusForbidden, "install token has been used or expired", false)
+		return
+	}
+
+	ctx := rpcutils.NewPlainContext(rpcutils.UserTypeCluster, int64(token.ClusterId))
+	resp, err := (&services.NodeService{}).RegisterClusterNode(ctx, &pb.RegisterClusterNodeRequest{Name: name})
+	if err != nil {
+		this.writeError(writer, restErrorStatusCode(err), err.Error(), false)
+		return
+	}
+
+	nodeId, err := models.SharedNodeDAO.FindEnabledNodeIdWithUniqueId(nil, resp.UniqueId)
+	if err != nil {
+		this.writeError(writer, http.StatusInternalServerError, "server error: "+err.Error(), false)
+		return
+	}
+	err = this.updateInstallRegistered(int64(token.Id), nodeId)
+	if err != nil {
+		this.writeError(writer, http.StatusInternalServerError, "server error: "+err.Error(), false)
+		return
+	}
+
+	this.writeInstallRegistered(writer, req, resp.UniqueId, resp.Secret, resp.Endpoints)
+}
+
+// 重新注册节点
+// 在重试时间内并且节点尚未安装成功时，返回已经注册的节点信息，而不再创建新节点
+func (this *RestServer) handleInstallRegisterRetry(writer http.ResponseWriter, req *http.Request, token *models.NodeInstallToken) {
+	if token.NodeId == 0 || time.Now().Unix()-int64(token.UsedAt) > installTokenRetrySeconds {
+		this.writeError(writer, http.StatusForbidden, "install token has been used or expired", false)
+		return
+	}
+
+	node, err := models.SharedNodeDAO.FindEnabledNode(nil, int64(token.NodeId))
+	if err != nil {
+		this.writeError(writer, http.StatusInternalServerError, "server error: "+err.Error(), false)
+		return
+	}
+	if node == nil || node.IsInstalled == 1 {
+		this.writeError(writer, http.StatusForbidden, "install token has been used or expired", false)
+		return
+	}
+
+	apiAddrs, err := models.SharedNodeClusterDAO.FindAllAPINodeAddrsWithCluster(nil, int64(token.ClusterId))
+	if err != nil {
+		this.writeError(writer, http.StatusInternalServerError, "server error: "+err.Error(), false)
+		return
+	}
+
+	err = this.updateInstallRegistered(int64(token.Id), int64(node.Id))
+	if err != nil {
+		this.writeError(writer, http.StatusInternalServerError, "server error: "+err.Error(), false)
+		return
+	}
+
+	this.writeInstallRegistered(writer, req, node.UniqueId, node.Secret, apiAddrs)
+}
+
+// 输出注册的节点信息
+func (this *RestServer) writeInstallRegistered(writer http.ResponseWriter, req *http.Request, uniqueId string, secret string, endpoints []string) {
+	// 供安装脚本直接使用
+	if req.FormValue("format") == "shell" {
+		writer.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = writer.Write([]byte("NODE_ID=" + installers.ShellQuote(uniqueId) + "\n" +
+			"NODE_SECRET=" + installers.ShellQuote(secret) + "\n" +
+			"NODE_ENDPOINTS=" + installers.ShellQuote((&installers.NodeParams{Endpoints: endpoints}).QuoteEndpoints()) + "\n"))
+		return
+	}
+
+	this.writeJSON(writer, http.StatusOK, maps.Map{
+		"code":    200,
+		"message": "ok",
+		"data": maps.Map{
+			"uniqueId":  uniqueId,
+			"secret":    secret,
+			"endpoints": endpoints,
+		},
+	}, false)
+}
+
+// 报告安装进度
+func (this *RestServer) handleInstallStatus(writer http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		this.writeError(writer, http.StatusMethodNotAllowed, "method not allowed", false)
+		return
+	}
+
+	token, ok := this.findInstallToken(writer, req)
+	if !ok {
+		return
+	}
+	if token.IsUsed == 0 || token.NodeId == 0 || time.Now().Unix()-int64(token.UsedAt) > installTokenReportSeconds {
+		this.writeError(writer, http.StatusForbidden, "node has not been registered with the install token", false)
+		return
+	}
+
+	stepName := req.FormValue("step")
+	if !models.IsValidNodeInstallStep(stepName) {
+		this.writeError(writer, http.StatusBadRequest, "invalid step '"+stepName+"'", false)
+		return
+	}
+
+	nodeId := int64(token.NodeId)
+	installStatus, err := models.SharedNodeDAO.FindNodeInstallStatus(nil, nodeId)
+	if err != nil {
+		this.writeError(writer, http.StatusInternalServerError, "server error: "+err.Error(), false)
+		return
+	}
+	if installStatus == nil {
+		installStatus = models.NewNodeInstallStatus()
+	}
+	installStatus.UpdateStep(stepName, types.Int(req.FormValue("percent")))
+	installStatus.IsRunning = true
+	installStatus.UpdatedAt = time.Now().Unix()
+
+	isFinished := installBoolValue(req.FormValue("isFinished"))
+	if isFinished {
+		installStatus.IsRunning = false
+		installStatus.IsFinished = true
+		installStatus.IsOk = installBoolValue(req.FormValue("isOk"))
+		installStatus.Error = req.FormValue("error")
+		installStatus.ErrorCode = req.FormValue("errorCode")
+	}
+
+	err = models.SharedNodeDAO.UpdateNodeInstallStatus(nil, nodeId, installStatus)
+	if err != nil {
+		this.writeError(writer, http.StatusInternalServerError, "server error: "+err.Error(), false)
+		return
+	}
+
+	if isFinished && installStatus.IsOk {
+		err = models.SharedNodeDAO.UpdateNodeIsInstalled(nil, nodeId, true)
+		if err != nil {
+			this.writeError(writer, http.StatusInternalServerError, "server error: "+err.Error(), false)
+			return
+		}
+	}
+
+	this.writeJSON(writer, http.StatusOK, maps.Map{
+		"code":    200,
+		"message": "ok",
+		"data":    maps.Map{},
+	}, false)
+}
+
+// 查找请求中的令牌
+func (this *RestServer) findInstallToken(writer http.ResponseWriter, req *http.Request) (token *models.NodeInstallToken, ok bool) {
+	token, err := models.SharedNodeInstallTokenDAO.FindEnabledInstallTokenWithToken(nil, req.FormValue("token"))
+	if err != nil {
+		this.writeError(writer, http.StatusInternalServerError, "server error: "+err.Error(), false)
+		return nil, false
+	}
+	if token == nil {
+		this.writeError(writer, http.StatusForbidden, "invalid install token", false)
+		return nil, false
+	}
+
+	// 注册节点之后仍然可以下载安装包和报告进度
+	if token.IsExpired() && (token.IsUsed == 0 || time.Now().Unix()-int64(token.UsedAt) > installTokenReportSeconds) {
+		this.writeError(writer, http.StatusForbidden, "install token has expired", false)
+		return nil, false
+	}
+	return token, true
+}
+
+// 注册节点后修改令牌和节点安装状态
+func (this *RestServer) updateInstallRegistered(tokenId int64, nodeId int64) error {
+	err := models.SharedNodeInstallTokenDAO.UpdateInstallTokenNodeId(nil, tokenId, nodeId)
+	if err != nil {
+		return err
+	}
+
+	// 安装完成后才设置为已安装
+	err = models.SharedNodeDAO.UpdateNodeIsInstalled(nil, nodeId, false)
+	if err != nil {
+		return err
+	}
+
+	installStatus := models.NewNodeInstallStatus()
+	installStatus.IsRunning = true
+	installStatus.UpdatedAt = time.Now().Unix()
+	installStatus.UpdateStep(models.NodeInstallStepRegister, 100)
+	return models.SharedNodeDAO.UpdateNodeInstallStatus(nil, nodeId, installStatus)
+}
+
+// 解析布尔值参数
+func installBoolValue(s string) bool {
+	return s == "1" || s == "true"
+}
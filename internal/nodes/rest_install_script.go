@@ -0,0 +1,128 @@
+package nodes
+
+// 通过令牌安装节点的脚本
+// 其中的 {{API}}、{{TOKEN}}、{{INSTALL_DIR}} 在输出时会被替换
+const installBootstrapScript = `#!/bin/sh
+# 边缘节点安装脚本
+# 用法：curl -fsSL "API地址/install/bootstrap.sh?token=安装令牌" | sh
+
+API={{API}}
+TOKEN={{TOKEN}}
+INSTALL_DIR=${INSTALL_DIR:-{{INSTALL_DIR}}}
+if [ -z "$INSTALL_DIR" ]; then
+	INSTALL_DIR="$HOME/edge-node"
+fi
+CURRENT_STEP=""
+
+# 报告安装进度：step percent [isFinished isOk error errorCode]
+report() {
+	curl -fsS -X POST "$API/install/status" \
+		--data-urlencode "token=$TOKEN" \
+		--data-urlencode "step=$1" \
+		--data-urlencode "percent=$2" \
+		--data-urlencode "isFinished=${3:-0}" \
+		--data-urlencode "isOk=${4:-0}" \
+		--data-urlencode "error=${5:-}" \
+		--data-urlencode "errorCode=${6:-}" >/dev/null 2>&1 || true
+}
+
+step() {
+	CURRENT_STEP=$1
+	echo "[STEP]$1"
+	report "$1" 0
+}
+
+done_step() {
+	report "$CURRENT_STEP" 100
+}
+
+fail() {
+	echo "[ERROR]$2" >&2
+	report "$CURRENT_STEP" 0 1 0 "$2" "$1"
+	exit 1
+}
+
+for cmd in curl unzip uname hostname sed; do
+	if ! command -v "$cmd" >/dev/null 2>&1; then
+		echo "[ERROR]'$cmd' command not found" >&2
+		exit 1
+	fi
+done
+
+# 注册节点
+CURRENT_STEP="register"
+echo "[STEP]register"
+REGISTER_RESULT=$(curl -fsS -X POST "$API/install/register" \
+	--data-urlencode "token=$TOKEN" \
+	--data-urlencode "name=$(hostname)" \
+	--data-urlencode "format=shell") || {
+	echo "[ERROR]register node failed, the install token may have been used or expired" >&2
+	exit 1
+}
+eval "$REGISTER_RESULT"
+if [ -z "$NODE_ID" ] || [ -z "$NODE_SECRET" ]; then
+	echo "[ERROR]register node failed: invalid response" >&2
+	exit 1
+fi
+
+# 检查环境
+step "prepare"
+OS=$(uname -s | tr 'A-Z' 'a-z')
+case "$OS" in
+	linux|darwin) ;;
+	*) fail "INSTALL_HELPER_FAILED" "installer not supported os '$OS'" ;;
+esac
+case "$(uname -m)" in
+	x86_64|amd64) ARCH="amd64" ;;
+	aarch64|arm64|armv8*) ARCH="arm64" ;;
+	aarch64_be) ARCH="arm64be" ;;
+	mips64el) ARCH="mips64le" ;;
+	mips64) ARCH="mips64" ;;
+	i386|i486|i586|i686|x86) ARCH="386" ;;
+	*) fail "INSTALL_HELPER_FAILED" "installer not supported arch '$(uname -m)'" ;;
+esac
+mkdir -p "$INSTALL_DIR" || fail "CREATE_ROOT_DIRECTORY_FAILED" "create directory '$INSTALL_DIR' failed"
+done_step
+
+# 下载安装包
+step "upload"
+ZIP_FILE="$INSTALL_DIR/edge-node-$OS-$ARCH.zip"
+curl -fsSL -o "$ZIP_FILE" "$API/install/package?token=$TOKEN&os=$OS&arch=$ARCH" || fail "DOWNLOAD_FAILED" "download installer file for $OS/$ARCH failed"
+done_step
+
+# 解压
+step "unzip"
+if [ -f "$INSTALL_DIR/edge-node/bin/edge-node" ]; then
+	"$INSTALL_DIR/edge-node/bin/edge-node" quit >/dev/null 2>&1 || true
+	rm -f "$INSTALL_DIR/edge-node/bin/edge-node"
+fi
+unzip -o -q "$ZIP_FILE" -d "$INSTALL_DIR" || fail "UNZIP_FAILED" "unzip installer failed"
+rm -f "$ZIP_FILE"
+done_step
+
+# 修改配置文件
+step "config"
+CONFIG_DIR="$INSTALL_DIR/edge-node/configs"
+sed -e "s|\${endpoints}|$NODE_ENDPOINTS|g" \
+	-e "s|\${nodeId}|$NODE_ID|g" \
+	-e "s|\${nodeSecret}|$NODE_SECRET|g" \
+	"$CONFIG_DIR/api.template.yaml" > "$CONFIG_DIR/api.yaml" || fail "CONFIG_FAILED" "write 'configs/api.yaml' failed"
+done_step
+
+# 测试
+step "test"
+TEST_RESULT=$("$INSTALL_DIR/edge-node/bin/edge-node" test 2>&1) || {
+	if echo "$TEST_RESULT" | grep -qi "rpc"; then
+		fail "RPC_TEST_FAILED" "test edge node failed: $TEST_RESULT"
+	fi
+	fail "TEST_FAILED" "test edge node failed: $TEST_RESULT"
+}
+done_step
+
+# 启动
+step "start"
+START_RESULT=$("$INSTALL_DIR/edge-node/bin/edge-node" start 2>&1) || fail "START_FAILED" "start edge node failed: $START_RESULT"
+report "start" 100 1 1
+
+echo "[OK]edge node has been installed to '$INSTALL_DIR'"
+`
@@ -0,0 +1,20 @@
+package nodes
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInstallBootstrapScript(t *testing.T) {
+	for _, placeholder := range []string{"{{API}}", "{{TOKEN}}", "{{INSTALL_DIR}}"} {
+		if !strings.Contains(installBootstrapScript, placeholder) {
+			t.Fatal("placeholder '" + placeholder + "' not found")
+		}
+	}
+	if !strings.HasPrefix(installBootstrapScript, "#!/bin/sh\n") {
+		t.Fatal("script should start with shebang")
+	}
+	if !strings.Contains(installBootstrapScript, "installer not supported arch") {
+		t.Fatal("unknown arch should not fall back to 386")
+	}
+}
@@ -0,0 +1,192 @@
+package nodes
+
+import (
+	teaconst "github.com/TeaOSLab/EdgeAPI/internal/const"
+	"github.com/iwind/TeaGo/maps"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"reflect"
+	"sort"
+)
+
+// 根据服务列表生成OpenAPI 3文档
+func buildOpenAPI(servicesMap map[string]*restService) maps.Map {
+	schemas := maps.Map{}
+	paths := maps.Map{}
+
+	serviceNames := []string{}
+	for serviceName := range servicesMap {
+		serviceNames = append(serviceNames, serviceName)
+	}
+	sort.Strings(serviceNames)
+
+	for _, serviceName := range serviceNames {
+		serviceType := servicesMap[serviceName].Value
+		for i := 0; i < serviceType.NumMethod(); i++ {
+			methodName := serviceType.Type().Method(i).Name
+			method, ok := findRestMethod(serviceType, methodName)
+			if !ok {
+				continue
+			}
+
+			reqRef := openAPIMessageRef(reflect.New(method.Type().In(1).Elem()).Interface().(proto.Message).ProtoReflect().Descriptor(), schemas)
+			respRef := openAPIMessageRef(reflect.New(method.Type().Out(0).Elem()).Interface().(proto.Message).ProtoReflect().Descriptor(), schemas)
+
+			operation := maps.Map{
+				"operationId": serviceName + "_" + methodName,
+				"tags":        []string{serviceName},
+				"requestBody": maps.Map{
+					"required": true,
+					"content": maps.Map{
+						"application/json": maps.Map{
+							"schema": maps.Map{"$ref": reqRef},
+						},
+					},
+				},
+				"responses": maps.Map{
+					"200": maps.Map{
+						"description": "OK",
+						"content": maps.Map{
+							"application/json": maps.Map{
+								"schema": maps.Map{
+									"type": "object",
+									"properties": maps.Map{
+										"code":    maps.Map{"type": "integer"},
+										"message": maps.Map{"type": "string"},
+										"data":    maps.Map{"$ref": respRef},
+									},
+								},
+							},
+						},
+					},
+					"default": maps.Map{
+						"$ref": "#/components/responses/Error",
+					},
+				},
+			}
+			if isPublicRestMethod(serviceName, methodName) {
+				operation["security"] = []maps.Map{}
+			}
+
+			paths["/"+serviceName+"/"+methodName] = maps.Map{
+				"post": operation,
+			}
+		}
+	}
+
+	return maps.Map{
+		"openapi": "3.0.3",
+		"info": maps.Map{
+			"title":   teaconst.ProductName,
+			"version": teaconst.Version,
+		},
+		"paths": paths,
+		"components": maps.Map{
+			"schemas": schemas,
+			"responses": maps.Map{
+				"Error": maps.Map{
+					"description": "Error",
+					"content": maps.Map{
+						"application/json": maps.Map{
+							"schema": maps.Map{
+								"type": "object",
+								"properties": maps.Map{
+									"code":    maps.Map{"type": "integer"},
+									"message": maps.Map{"type": "string"},
+									"data":    maps.Map{"type": "object"},
+								},
+							},
+						},
+					},
+				},
+			},
+			"securitySchemes": maps.Map{
+				"accessToken": maps.Map{
+					"type": "apiKey",
+					"in":   "header",
+					"name": "Edge-Access-Token",
+				},
+			},
+		},
+		"security": []maps.Map{
+			{
+				"accessToken": []string{},
+			},
+		},
+	}
+}
+
+// 生成消息的Schema，并返回引用地址
+func openAPIMessageRef(desc protoreflect.MessageDescriptor, schemas maps.Map) string {
+	name := string(desc.FullName())
+	ref := "#/components/schemas/" + name
+	if schemas.Has(name) {
+		return ref
+	}
+
+	// 先占位，防止消息之间互相引用时无限循环
+	schema := maps.Map{
+		"type": "object",
+	}
+	schemas[name] = schema
+
+	properties := maps.Map{}
+	fields := desc.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		properties[field.JSONName()] = openAPIFieldSchema(field, schemas)
+	}
+	schema["properties"] = properties
+	return ref
+}
+
+// 生成字段的Schema
+func openAPIFieldSchema(field protoreflect.FieldDescriptor, schemas maps.Map) maps.Map {
+	if field.IsMap() {
+		return maps.Map{
+			"type":                 "object",
+			"additionalProperties": openAPIValueSchema(field.MapValue(), schemas),
+		}
+	}
+	if field.IsList() {
+		return maps.Map{
+			"type":  "array",
+			"items": openAPIValueSchema(field, schemas),
+		}
+	}
+	return openAPIValueSchema(field, schemas)
+}
+
+// 生成单个值的Schema，和protojson的编码方式保持一致
+func openAPIValueSchema(field protoreflect.FieldDescriptor, schemas maps.Map) maps.Map {
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		return maps.Map{"type": "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return maps.Map{"type": "integer", "format": "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return maps.Map{"type": "integer", "format": "uint32"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return maps.Map{"type": "string", "format": "int64"}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return maps.Map{"type": "string", "format": "uint64"}
+	case protoreflect.FloatKind:
+		return maps.Map{"type": "number", "format": "float"}
+	case protoreflect.DoubleKind:
+		return maps.Map{"type": "number", "format": "double"}
+	case protoreflect.StringKind:
+		return maps.Map{"type": "string"}
+	case protoreflect.BytesKind:
+		return maps.Map{"type": "string", "format": "byte"}
+	case protoreflect.EnumKind:
+		enumValues := []string{}
+		values := field.Enum().Values()
+		for i := 0; i < values.Len(); i++ {
+			enumValues = append(enumValues, string(values.Get(i).Name()))
+		}
+		return maps.Map{"type": "string", "enum": enumValues}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return maps.Map{"$ref": openAPIMessageRef(field.Message(), schemas)}
+	}
+	return maps.Map{}
+}
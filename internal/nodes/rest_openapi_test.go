@@ -0,0 +1,50 @@
+package nodes
+
+import (
+	"errors"
+	"github.com/TeaOSLab/EdgeAPI/internal/tests/helloworld"
+	"github.com/iwind/TeaGo/maps"
+	"reflect"
+	"testing"
+)
+
+func TestBuildOpenAPI(t *testing.T) {
+	doc := buildOpenAPI(map[string]*restService{
+		"Greeter": {Value: reflect.ValueOf(new(helloworld.UnimplementedGreeterServer))},
+	})
+
+	paths := doc.GetMap("paths")
+	if !paths.Has("/Greeter/SayHello") {
+		t.Fatal("'/Greeter/SayHello' should be in paths")
+	}
+
+	schemas := doc.GetMap("components").GetMap("schemas")
+	request := schemas.GetMap("HelloRequest")
+	if request == nil {
+		t.Fatal("'HelloRequest' should be in schemas")
+	}
+	properties := request.GetMap("properties")
+	if !properties.Has("pageNumber") {
+		t.Fatal("field name should be in json format")
+	}
+	if properties.GetMap("ages").GetString("type") != "array" {
+		t.Fatal("'ages' should be array")
+	}
+	if !schemas.Has("HelloReply") {
+		t.Fatal("'HelloReply' should be in schemas")
+	}
+	t.Log(string(doc.AsPrettyJSON()))
+}
+
+func TestRestErrorStatusCode(t *testing.T) {
+	for _, s := range []maps.Map{
+		{"error": "Permission Denied", "code": 403},
+		{"error": "access key not found", "code": 404},
+		{"error": "invalid 'serverId'", "code": 400},
+	} {
+		code := restErrorStatusCode(errors.New(s.GetString("error")))
+		if code != s.GetInt("code") {
+			t.Fatal(s.GetString("error"), "expect", s.GetInt("code"), "but got", code)
+		}
+	}
+}
@@ -8,22 +8,126 @@ import (
 	"github.com/TeaOSLab/EdgeAPI/internal/rpc/services"
 	rpcutils "github.com/TeaOSLab/EdgeAPI/internal/rpc/utils"
 	"github.com/iwind/TeaGo/maps"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"reflect"
 	"regexp"
+	"strings"
+	"sync"
 	"time"
 )
 
 var servicePathReg = regexp.MustCompile(`^/([a-zA-Z0-9]+)/([a-zA-Z0-9]+)$`)
-var servicesMap = map[string]reflect.Value{
-	"APIAccessTokenService": reflect.ValueOf(new(services.APIAccessTokenService)),
-	"HTTPAccessLogService":  reflect.ValueOf(new(services.HTTPAccessLogService)),
-	"IPItemService":         reflect.ValueOf(new(services.IPItemService)),
+
+// 可以通过HTTP访问的服务
+// 新增服务时需要同时加入到这里
+var servicesMap = map[string]*restService{
+	"APIAccessTokenService":                  {Value: reflect.ValueOf(new(services.APIAccessTokenService)), AllowUser: false},
+	"AdminService":                           {Value: reflect.ValueOf(new(services.AdminService)), AllowUser: false},
+	"NodeGrantService":                       {Value: reflect.ValueOf(new(services.NodeGrantService)), AllowUser: false},
+	"ServerService":                          {Value: reflect.ValueOf(new(services.ServerService)), AllowUser: true},
+	"ServerConfigRevisionService":            {Value: reflect.ValueOf(new(services.ServerConfigRevisionService)), AllowUser: true},
+	"ServerManifestService":                  {Value: reflect.ValueOf(new(services.ServerManifestService)), AllowUser: true},
+	"NodeService":                            {Value: reflect.ValueOf(new(services.NodeService)), AllowUser: true},
+	"NodeClusterService":                     {Value: reflect.ValueOf(new(services.NodeClusterService)), AllowUser: true},
+	"NodeIPAddressService":                   {Value: reflect.ValueOf(new(services.NodeIPAddressService)), AllowUser: false},
+	"APINodeService":                         {Value: reflect.ValueOf(new(services.APINodeService)), AllowUser: true},
+	"OriginService":                          {Value: reflect.ValueOf(new(services.OriginService)), AllowUser: true},
+	"HTTPWebService":                         {Value: reflect.ValueOf(new(services.HTTPWebService)), AllowUser: true},
+	"ReverseProxyService":                    {Value: reflect.ValueOf(new(services.ReverseProxyService)), AllowUser: true},
+	"HTTPGzipService":                        {Value: reflect.ValueOf(new(services.HTTPGzipService)), AllowUser: false},
+	"HTTPHeaderPolicyService":                {Value: reflect.ValueOf(new(services.HTTPHeaderPolicyService)), AllowUser: true},
+	"HTTPHeaderService":                      {Value: reflect.ValueOf(new(services.HTTPHeaderService)), AllowUser: true},
+	"HTTPPageService":                        {Value: reflect.ValueOf(new(services.HTTPPageService)), AllowUser: false},
+	"HTTPAccessLogPolicyService":             {Value: reflect.ValueOf(new(services.HTTPAccessLogPolicyService)), AllowUser: false},
+	"HTTPCachePolicyService":                 {Value: reflect.ValueOf(new(services.HTTPCachePolicyService)), AllowUser: true},
+	"HTTPFirewallPolicyService":              {Value: reflect.ValueOf(new(services.HTTPFirewallPolicyService)), AllowUser: true},
+	"HTTPLocationService":                    {Value: reflect.ValueOf(new(services.HTTPLocationService)), AllowUser: true},
+	"HTTPWebsocketService":                   {Value: reflect.ValueOf(new(services.HTTPWebsocketService)), AllowUser: true},
+	"HTTPRewriteRuleService":                 {Value: reflect.ValueOf(new(services.HTTPRewriteRuleService)), AllowUser: false},
+	"SSLCertService":                         {Value: reflect.ValueOf(new(services.SSLCertService)), AllowUser: true},
+	"SSLPolicyService":                       {Value: reflect.ValueOf(new(services.SSLPolicyService)), AllowUser: true},
+	"SysSettingService":                      {Value: reflect.ValueOf(new(services.SysSettingService)), AllowUser: false},
+	"HTTPFirewallRuleGroupService":           {Value: reflect.ValueOf(new(services.HTTPFirewallRuleGroupService)), AllowUser: true},
+	"HTTPFirewallRuleSetService":             {Value: reflect.ValueOf(new(services.HTTPFirewallRuleSetService)), AllowUser: true},
+	"DBNodeService":                          {Value: reflect.ValueOf(new(services.DBNodeService)), AllowUser: false},
+	"NodeLogService":                         {Value: reflect.ValueOf(new(services.NodeLogService)), AllowUser: false},
+	"HTTPAccessLogService":                   {Value: reflect.ValueOf(new(services.HTTPAccessLogService)), AllowUser: true},
+	"MessageService":                         {Value: reflect.ValueOf(new(services.MessageService)), AllowUser: true},
+	"MessageChannelService":                  {Value: reflect.ValueOf(new(services.MessageChannelService)), AllowUser: false},
+	"NodeGroupService":                       {Value: reflect.ValueOf(new(services.NodeGroupService)), AllowUser: false},
+	"NodeRegionService":                      {Value: reflect.ValueOf(new(services.NodeRegionService)), AllowUser: false},
+	"NodePriceItemService":                   {Value: reflect.ValueOf(new(services.NodePriceItemService)), AllowUser: false},
+	"ServerGroupService":                     {Value: reflect.ValueOf(new(services.ServerGroupService)), AllowUser: false},
+	"IPLibraryService":                       {Value: reflect.ValueOf(new(services.IPLibraryService)), AllowUser: true},
+	"FileChunkService":                       {Value: reflect.ValueOf(new(services.FileChunkService)), AllowUser: false},
+	"FileService":                            {Value: reflect.ValueOf(new(services.FileService)), AllowUser: false},
+	"RegionCountryService":                   {Value: reflect.ValueOf(new(services.RegionCountryService)), AllowUser: false},
+	"RegionProvinceService":                  {Value: reflect.ValueOf(new(services.RegionProvinceService)), AllowUser: false},
+	"IPListService":                          {Value: reflect.ValueOf(new(services.IPListService)), AllowUser: true},
+	"IPItemService":                          {Value: reflect.ValueOf(new(services.IPItemService)), AllowUser: true},
+	"IPListFeedService":                      {Value: reflect.ValueOf(new(services.IPListFeedService)), AllowUser: false},
+	"LogService":                             {Value: reflect.ValueOf(new(services.LogService)), AllowUser: true},
+	"DNSProviderService":                     {Value: reflect.ValueOf(new(services.DNSProviderService)), AllowUser: true},
+	"DNSDomainService":                       {Value: reflect.ValueOf(new(services.DNSDomainService)), AllowUser: true},
+	"DNSService":                             {Value: reflect.ValueOf(new(services.DNSService)), AllowUser: false},
+	"ACMEUserService":                        {Value: reflect.ValueOf(new(services.ACMEUserService)), AllowUser: true},
+	"ACMETaskService":                        {Value: reflect.ValueOf(new(services.ACMETaskService)), AllowUser: true},
+	"ACMEAuthenticationService":              {Value: reflect.ValueOf(new(services.ACMEAuthenticationService)), AllowUser: false},
+	"UserService":                            {Value: reflect.ValueOf(new(services.UserService)), AllowUser: false},
+	"ServerDailyStatService":                 {Value: reflect.ValueOf(new(services.ServerDailyStatService)), AllowUser: false},
+	"UserBillService":                        {Value: reflect.ValueOf(new(services.UserBillService)), AllowUser: true},
+	"UserNodeService":                        {Value: reflect.ValueOf(new(services.UserNodeService)), AllowUser: true},
+	"LoginService":                           {Value: reflect.ValueOf(new(services.LoginService)), AllowUser: false},
+	"UserAccessKeyService":                   {Value: reflect.ValueOf(new(services.UserAccessKeyService)), AllowUser: true},
+	"SysLockerService":                       {Value: reflect.ValueOf(new(services.SysLockerService)), AllowUser: false},
+	"NodeTaskService":                        {Value: reflect.ValueOf(new(services.NodeTaskService)), AllowUser: false},
+	"DBService":                              {Value: reflect.ValueOf(new(services.DBService)), AllowUser: false},
+	"ServerRegionCityMonthlyStatService":     {Value: reflect.ValueOf(new(services.ServerRegionCityMonthlyStatService)), AllowUser: true},
+	"ServerRegionCountryMonthlyStatService":  {Value: reflect.ValueOf(new(services.ServerRegionCountryMonthlyStatService)), AllowUser: true},
+	"ServerRegionProvinceMonthlyStatService": {Value: reflect.ValueOf(new(services.ServerRegionProvinceMonthlyStatService)), AllowUser: true},
+	"ServerRegionProviderMonthlyStatService": {Value: reflect.ValueOf(new(services.ServerRegionProviderMonthlyStatService)), AllowUser: true},
+	"ServerClientSystemMonthlyStatService":   {Value: reflect.ValueOf(new(services.ServerClientSystemMonthlyStatService)), AllowUser: true},
+	"ServerClientBrowserMonthlyStatService":  {Value: reflect.ValueOf(new(services.ServerClientBrowserMonthlyStatService)), AllowUser: true},
+	"ServerHTTPFirewallDailyStatService":     {Value: reflect.ValueOf(new(services.ServerHTTPFirewallDailyStatService)), AllowUser: true},
+	"DNSTaskService":                         {Value: reflect.ValueOf(new(services.DNSTaskService)), AllowUser: false},
+	"NodeClusterFirewallActionService":       {Value: reflect.ValueOf(new(services.NodeClusterFirewallActionService)), AllowUser: false},
+	"NodeInstallTokenService":                {Value: reflect.ValueOf(new(services.NodeInstallTokenService)), AllowUser: false},
+	"NodeClusterUpgradeService":              {Value: reflect.ValueOf(new(services.NodeClusterUpgradeService)), AllowUser: false},
+}
+
+// 用户AccessToken不能调用的方法
+// 这些方法虽然在允许用户调用的服务中，但是并不校验调用者角色
+var userDeniedMethods = map[string]bool{
+	"APINodeService.FindCurrentAPINodeVersion": true,
+}
+
+// 限制了Scope的AccessToken不能调用的服务
+// 防止通过管理AccessKey和AccessToken扩大自己的权限
+var scopedDeniedServices = map[string]bool{
+	"APIAccessTokenService": true,
+	"UserAccessKeyService":  true,
+}
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+var protoMessageType = reflect.TypeOf((*proto.Message)(nil)).Elem()
+
+// 可以通过HTTP访问的服务定义
+type restService struct {
+	Value     reflect.Value
+	AllowUser bool // 是否允许用户AccessToken调用
 }
 
-type RestServer struct{}
+type RestServer struct {
+	openAPIOnce sync.Once
+	openAPIJSON []byte
+}
 
 func (this *RestServer) Listen(listener net.Listener) error {
 	mux := http.NewServeMux()
@@ -50,7 +154,7 @@ func (this *RestServer) handle(writer http.ResponseWriter, req *http.Request) {
 
 	// 欢迎页
 	if path == "/" {
-		this.writeJSON(writer, maps.Map{
+		this.writeJSON(writer, http.StatusOK, maps.Map{
 			"code":    200,
 			"message": "Welcome to API",
 			"data":    maps.Map{},
@@ -58,142 +162,144 @@ func (this *RestServer) handle(writer http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	// OpenAPI文档
+	if path == "/openapi.json" {
+		this.openAPIOnce.Do(func() {
+			this.openAPIJSON = buildOpenAPI(servicesMap).AsPrettyJSON()
+		})
+		writer.Header().Set("Content-Type", "application/json")
+		_, _ = writer.Write(this.openAPIJSON)
+		return
+	}
+
+	// 通过令牌安装节点
+	if strings.HasPrefix(path, "/install/") {
+		this.handleInstall(writer, req)
+		return
+	}
+
 	matches := servicePathReg.FindStringSubmatch(path)
 	if len(matches) != 3 {
-		writer.WriteHeader(http.StatusNotFound)
+		this.writeError(writer, http.StatusNotFound, "invalid path '"+path+"'", shouldPretty)
 		return
 	}
 
 	serviceName := matches[1]
 	methodName := matches[2]
 
-	serviceType, ok := servicesMap[serviceName]
+	service, ok := servicesMap[serviceName]
 	if !ok {
-		writer.WriteHeader(http.StatusNotFound)
+		this.writeError(writer, http.StatusNotFound, "service '"+serviceName+"' not found", shouldPretty)
 		return
 	}
 
-	method := serviceType.MethodByName(methodName)
-	if !method.IsValid() {
-		writer.WriteHeader(http.StatusNotFound)
-		return
-	}
-	if method.Type().NumIn() != 2 || method.Type().NumOut() != 2 {
-		writer.WriteHeader(http.StatusNotFound)
-		return
-	}
-	if method.Type().In(0).Name() != "Context" {
-		writer.WriteHeader(http.StatusNotFound)
+	method, ok := findRestMethod(service.Value, methodName)
+	if !ok {
+		this.writeError(writer, http.StatusNotFound, "method '"+serviceName+"."+methodName+"' not found", shouldPretty)
 		return
 	}
 
 	// 上下文
 	ctx := context.Background()
 
-	if serviceName != "APIAccessTokenService" || methodName != "GetAPIAccessToken" {
+	if !isPublicRestMethod(serviceName, methodName) {
 		// 校验TOKEN
 		token := req.Header.Get("Edge-Access-Token")
 		if len(token) == 0 {
-			this.writeJSON(writer, maps.Map{
-				"code":    400,
-				"data":    maps.Map{},
-				"message": "require 'Edge-Access-Token' header",
-			}, shouldPretty)
+			this.writeError(writer, http.StatusUnauthorized, "require 'Edge-Access-Token' header", shouldPretty)
 			return
 		}
 
 		accessToken, err := models.SharedAPIAccessTokenDAO.FindAccessToken(nil, token)
 		if err != nil {
-			this.writeJSON(writer, maps.Map{
-				"code":    400,
-				"data":    maps.Map{},
-				"message": "server error: " + err.Error(),
-			}, shouldPretty)
+			this.writeError(writer, http.StatusInternalServerError, "server error: "+err.Error(), shouldPretty)
 			return
 		}
 
 		if accessToken == nil || int64(accessToken.ExpiredAt) < time.Now().Unix() {
-			this.writeJSON(writer, maps.Map{
-				"code":    400,
-				"data":    maps.Map{},
-				"message": "invalid access token",
-			}, shouldPretty)
+			this.writeError(writer, http.StatusUnauthorized, "invalid access token", shouldPretty)
+			return
+		}
+
+		if !accessToken.AllowMethod(serviceName, methodName) || (scopedDeniedServices[serviceName] && accessToken.HasScopes()) {
+			this.writeError(writer, http.StatusForbidden, "access token is not allowed to call '"+serviceName+"."+methodName+"'", shouldPretty)
 			return
 		}
 
 		if accessToken.UserId > 0 {
-			ctx = rpcutils.NewPlainContext("user", int64(accessToken.UserId))
+			if !isUserRestMethod(serviceName, methodName) {
+				this.writeError(writer, http.StatusForbidden, "user access token is not allowed to call '"+serviceName+"."+methodName+"'", shouldPretty)
+				return
+			}
+			ctx = rpcutils.NewPlainContext(rpcutils.UserTypeUser, int64(accessToken.UserId))
+		} else if accessToken.AdminId > 0 {
+			exists, err := models.SharedAdminDAO.ExistEnabledAdmin(nil, int64(accessToken.AdminId))
+			if err != nil {
+				this.writeError(writer, http.StatusInternalServerError, "server error: "+err.Error(), shouldPretty)
+				return
+			}
+			if !exists {
+				this.writeError(writer, http.StatusUnauthorized, "invalid access token", shouldPretty)
+				return
+			}
+			ctx = rpcutils.NewPlainContext(rpcutils.UserTypeAdmin, int64(accessToken.AdminId))
 		} else {
 			// TODO 支持更多类型的角色
-			this.writeJSON(writer, maps.Map{
-				"code":    400,
-				"data":    maps.Map{},
-				"message": "not supported role",
-			}, shouldPretty)
+			this.writeError(writer, http.StatusForbidden, "not supported role", shouldPretty)
 			return
 		}
+		ctx.(*rpcutils.PlainContext).AccessTokenId = int64(accessToken.Id)
 	}
 
 	// TODO 需要防止BODY过大攻击
 	body, err := ioutil.ReadAll(req.Body)
 	if err != nil {
-		writer.WriteHeader(http.StatusBadRequest)
-		_, _ = writer.Write([]byte(err.Error()))
+		this.writeError(writer, http.StatusBadRequest, err.Error(), shouldPretty)
 		return
 	}
 
 	// 请求数据
-	reqValue := reflect.New(method.Type().In(1).Elem()).Interface()
-	err = json.Unmarshal(body, reqValue)
-	if err != nil {
-		writer.WriteHeader(http.StatusBadRequest)
-		_, _ = writer.Write([]byte(err.Error()))
-		return
+	reqValue := reflect.New(method.Type().In(1).Elem())
+	if len(strings.TrimSpace(string(body))) > 0 {
+		err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(body, reqValue.Interface().(proto.Message))
+		if err != nil {
+			this.writeError(writer, http.StatusBadRequest, "invalid request body: "+err.Error(), shouldPretty)
+			return
+		}
 	}
 
-	result := method.Call([]reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(reqValue)})
+	result := method.Call([]reflect.Value{reflect.ValueOf(ctx), reqValue})
 	resultErr := result[1].Interface()
 	if resultErr != nil {
 		e, ok := resultErr.(error)
 		if ok {
-			this.writeJSON(writer, maps.Map{
-				"code":    400,
-				"message": e.Error(),
-				"data":    maps.Map{},
-			}, shouldPretty)
-		} else {
-			this.writeJSON(writer, maps.Map{
-				"code":    500,
-				"message": "server error: server should return a error object, but return a " + result[1].Type().String(),
-				"data":    maps.Map{},
-			}, shouldPretty)
-		}
-	} else { // 没有返回错误
-		data := maps.Map{
-			"code":    200,
-			"message": "ok",
-			"data":    result[0].Interface(),
-		}
-		var dataJSON []byte
-		if shouldPretty {
-			dataJSON = data.AsPrettyJSON()
+			this.writeError(writer, restErrorStatusCode(e), e.Error(), shouldPretty)
 		} else {
-			dataJSON = data.AsJSON()
+			this.writeError(writer, http.StatusInternalServerError, "server error: server should return a error object, but return a "+result[1].Type().String(), shouldPretty)
 		}
+		return
+	}
+
+	// 没有返回错误
+	var dataJSON = []byte("{}")
+	resp, ok := result[0].Interface().(proto.Message)
+	if ok && !result[0].IsNil() {
+		dataJSON, err = protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(resp)
 		if err != nil {
-			this.writeJSON(writer, maps.Map{
-				"code":    500,
-				"message": "server error: marshal json failed: " + err.Error(),
-				"data":    maps.Map{},
-			}, shouldPretty)
-		} else {
-			_, _ = writer.Write(dataJSON)
+			this.writeError(writer, http.StatusInternalServerError, "server error: marshal json failed: "+err.Error(), shouldPretty)
+			return
 		}
 	}
+	this.writeJSON(writer, http.StatusOK, maps.Map{
+		"code":    200,
+		"message": "ok",
+		"data":    json.RawMessage(dataJSON),
+	}, shouldPretty)
 }
 
-func (this *RestServer) writeJSON(writer http.ResponseWriter, v maps.Map, pretty bool) {
+func (this *RestServer) writeJSON(writer http.ResponseWriter, statusCode int, v maps.Map, pretty bool) {
 	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(statusCode)
 
 	if pretty {
 		_, _ = writer.Write(v.AsPrettyJSON())
@@ -201,3 +307,90 @@ func (this *RestServer) writeJSON(writer http.ResponseWriter, v maps.Map, pretty
 		_, _ = writer.Write(v.AsJSON())
 	}
 }
+
+func (this *RestServer) writeError(writer http.ResponseWriter, statusCode int, message string, pretty bool) {
+	this.writeJSON(writer, statusCode, maps.Map{
+		"code":    statusCode,
+		"message": message,
+		"data":    maps.Map{},
+	}, pretty)
+}
+
+// 查找可以通过HTTP调用的方法
+// 方法必须是 func(context.Context, *pb.XXXRequest) (*pb.XXXResponse, error) 的形式
+func findRestMethod(serviceType reflect.Value, methodName string) (method reflect.Value, ok bool) {
+	method = serviceType.MethodByName(methodName)
+	if !method.IsValid() {
+		return
+	}
+	methodType := method.Type()
+	if methodType.NumIn() != 2 || methodType.NumOut() != 2 {
+		return
+	}
+	if methodType.In(0) != contextType || methodType.Out(1) != errorType {
+		return
+	}
+	if methodType.In(1).Kind() != reflect.Ptr || !methodType.In(1).Implements(protoMessageType) {
+		return
+	}
+	if !methodType.Out(0).Implements(protoMessageType) {
+		return
+	}
+	return method, true
+}
+
+// 判断是否为不需要AccessToken的方法
+func isPublicRestMethod(serviceName string, methodName string) bool {
+	return serviceName == "APIAccessTokenService" && methodName == "GetAPIAccessToken"
+}
+
+// 判断是否为用户AccessToken可以调用的方法
+func isUserRestMethod(serviceName string, methodName string) bool {
+	service, ok := servicesMap[serviceName]
+	if !ok || !service.AllowUser {
+		return false
+	}
+	return !userDeniedMethods[serviceName+"."+methodName]
+}
+
+// 根据错误获取HTTP状态码
+func restErrorStatusCode(err error) int {
+	s, ok := status.FromError(err)
+	if ok && s.Code() != codes.Unknown {
+		switch s.Code() {
+		case codes.OK:
+			return http.StatusOK
+		case codes.Canceled:
+			return 499
+		case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+			return http.StatusBadRequest
+		case codes.DeadlineExceeded:
+			return http.StatusGatewayTimeout
+		case codes.NotFound:
+			return http.StatusNotFound
+		case codes.AlreadyExists, codes.Aborted:
+			return http.StatusConflict
+		case codes.PermissionDenied:
+			return http.StatusForbidden
+		case codes.Unauthenticated:
+			return http.StatusUnauthorized
+		case codes.ResourceExhausted:
+			return http.StatusTooManyRequests
+		case codes.Unimplemented:
+			return http.StatusNotImplemented
+		case codes.Unavailable:
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusInternalServerError
+	}
+
+	// 服务中大部分错误都是普通的错误对象，这里根据错误信息判断
+	message := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(message, "permission denied"):
+		return http.StatusForbidden
+	case strings.Contains(message, "not found"):
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,82 @@
+package notifications
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/iwind/TeaGo/maps"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 钉钉自定义机器人
+type DingTalkChannel struct {
+	webhookURL string
+	secret     string
+}
+
+// 初始化
+// 参数：
+//   - webhookURL 机器人Webhook地址
+//   - secret 加签密钥，可以为空
+func (this *DingTalkChannel) Init(params maps.Map) error {
+	this.webhookURL = params.GetString("webhookURL")
+	if len(this.webhookURL) == 0 {
+		return errors.New("'webhookURL' should not be empty")
+	}
+	this.secret = params.GetString("secret")
+	return nil
+}
+
+// 发送消息
+func (this *DingTalkChannel) Send(msg *Message) error {
+	apiURL := this.webhookURL
+	if len(this.secret) > 0 {
+		timestamp := strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)
+		sign := this.sign(timestamp)
+		if strings.Contains(apiURL, "?") {
+			apiURL += "&"
+		} else {
+			apiURL += "?"
+		}
+		apiURL += "timestamp=" + timestamp + "&sign=" + url.QueryEscape(sign)
+	}
+
+	respData, err := postJSON(apiURL, maps.Map{
+		"msgtype": "text",
+		"text": maps.Map{
+			"content": msg.Text(),
+		},
+	}, nil)
+	if err != nil {
+		return err
+	}
+	return checkRobotResponse(respData)
+}
+
+// 加签
+func (this *DingTalkChannel) sign(timestamp string) string {
+	h := hmac.New(sha256.New, []byte(this.secret))
+	h.Write([]byte(timestamp + "\n" + this.secret))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// 检查钉钉和企业微信机器人的响应
+func checkRobotResponse(respData []byte) error {
+	resp := &struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}{}
+	err := json.Unmarshal(respData, resp)
+	if err != nil {
+		return errors.New("decode response failed: " + err.Error())
+	}
+	if resp.ErrCode != 0 {
+		return errors.New("send failed: [" + strconv.Itoa(resp.ErrCode) + "]" + resp.ErrMsg)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package notifications
+
+import (
+	"encoding/json"
+	"github.com/iwind/TeaGo/maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDingTalkChannel_Send(t *testing.T) {
+	channel := &DingTalkChannel{}
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		if query.Get("access_token") != "abc" || query.Get("sign") != channel.sign(query.Get("timestamp")) {
+			_, _ = writer.Write([]byte(`{"errcode":310000,"errmsg":"sign not match"}`))
+			return
+		}
+		body := maps.Map{}
+		_ = json.NewDecoder(req.Body).Decode(&body)
+		if body.GetString("msgtype") != "text" {
+			_, _ = writer.Write([]byte(`{"errcode":40035,"errmsg":"invalid msgtype"}`))
+			return
+		}
+		_, _ = writer.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+	defer server.Close()
+
+	err := channel.Init(maps.Map{
+		"webhookURL": server.URL + "/robot/send?access_token=abc",
+		"secret":     "SEC123",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = channel.Send(testMessage())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 错误的token
+	_ = channel.Init(maps.Map{
+		"webhookURL": server.URL + "/robot/send?access_token=def",
+		"secret":     "SEC123",
+	})
+	err = channel.Send(testMessage())
+	if err == nil {
+		t.Fatal("send with wrong token should fail")
+	}
+	t.Log(err)
+}
@@ -0,0 +1,197 @@
+package notifications
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"github.com/iwind/TeaGo/maps"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type EmailTLSMode = string
+
+const (
+	EmailTLSModeNone     EmailTLSMode = "none"     // 不加密
+	EmailTLSModeSSL      EmailTLSMode = "ssl"      // 直接使用TLS连接，通常为465端口
+	EmailTLSModeStartTLS EmailTLSMode = "starttls" // 连接后使用STARTTLS升级，通常为587端口
+)
+
+// SMTP邮件
+type EmailChannel struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+	to       []string
+	tlsMode  EmailTLSMode
+	timeout  time.Duration
+}
+
+// 初始化
+// 参数：
+//   - host SMTP服务器地址
+//   - port SMTP服务器端口，默认根据tlsMode决定
+//   - username 用户名，为空表示不需要认证
+//   - password 密码
+//   - from 发件人，默认为用户名
+//   - to 收件人，多个收件人用逗号隔开
+//   - tlsMode 加密方式：none|ssl|starttls，默认为starttls
+func (this *EmailChannel) Init(params maps.Map) error {
+	this.host = params.GetString("host")
+	if len(this.host) == 0 {
+		return errors.New("'host' should not be empty")
+	}
+
+	this.tlsMode = params.GetString("tlsMode")
+	switch this.tlsMode {
+	case "":
+		this.tlsMode = EmailTLSModeStartTLS
+	case EmailTLSModeNone, EmailTLSModeSSL, EmailTLSModeStartTLS:
+	default:
+		return errors.New("invalid tlsMode '" + this.tlsMode + "'")
+	}
+
+	this.port = params.GetInt("port")
+	if this.port <= 0 {
+		switch this.tlsMode {
+		case EmailTLSModeSSL:
+			this.port = 465
+		case EmailTLSModeStartTLS:
+			this.port = 587
+		default:
+			this.port = 25
+		}
+	}
+
+	this.username = params.GetString("username")
+	this.password = params.GetString("password")
+	this.from = params.GetString("from")
+	if len(this.from) == 0 {
+		this.from = this.username
+	}
+	if len(this.from) == 0 {
+		return errors.New("'from' should not be empty")
+	}
+
+	this.to = []string{}
+	for _, to := range strings.Split(params.GetString("to"), ",") {
+		to = strings.TrimSpace(to)
+		if len(to) > 0 {
+			this.to = append(this.to, to)
+		}
+	}
+	if len(this.to) == 0 {
+		return errors.New("'to' should not be empty")
+	}
+
+	this.timeout = 10 * time.Second
+	return nil
+}
+
+// 发送消息
+func (this *EmailChannel) Send(msg *Message) error {
+	client, err := this.dial()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+
+	if this.tlsMode == EmailTLSModeStartTLS {
+		ok, _ := client.Extension("STARTTLS")
+		if !ok {
+			return errors.New("the server does not support STARTTLS")
+		}
+		err = client.StartTLS(&tls.Config{ServerName: this.host})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(this.username) > 0 {
+		err = client.Auth(smtp.PlainAuth("", this.username, this.password, this.host))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = client.Mail(this.from)
+	if err != nil {
+		return err
+	}
+	for _, to := range this.to {
+		err = client.Rcpt(to)
+		if err != nil {
+			return err
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(this.compose(msg))
+	if err != nil {
+		return err
+	}
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// 连接服务器
+func (this *EmailChannel) dial() (*smtp.Client, error) {
+	addr := net.JoinHostPort(this.host, strconv.Itoa(this.port))
+	dialer := &net.Dialer{Timeout: this.timeout}
+
+	var conn net.Conn
+	var err error
+	if this.tlsMode == EmailTLSModeSSL {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: this.host})
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Now().Add(this.timeout))
+
+	client, err := smtp.NewClient(conn, this.host)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// 组合邮件内容
+func (this *EmailChannel) compose(msg *Message) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString("From: " + this.from + "\r\n")
+	buf.WriteString("To: " + strings.Join(this.to, ", ") + "\r\n")
+	buf.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", msg.Subject()) + "\r\n")
+	buf.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n")
+	buf.WriteString("\r\n")
+
+	// 每行不超过76个字符
+	body := base64.StdEncoding.EncodeToString([]byte(msg.Text()))
+	for len(body) > 76 {
+		buf.WriteString(body[:76] + "\r\n")
+		body = body[76:]
+	}
+	buf.WriteString(body + "\r\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,123 @@
+package notifications
+
+import (
+	"bufio"
+	"github.com/iwind/TeaGo/maps"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestEmailChannel_Send(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = listener.Close()
+	}()
+
+	commands := []string{}
+	data := ""
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() {
+			_ = conn.Close()
+		}()
+		reader := bufio.NewReader(conn)
+		write := func(s string) {
+			_, _ = conn.Write([]byte(s + "\r\n"))
+		}
+		write("220 localhost ESMTP")
+		isData := false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			if isData {
+				if line == "." {
+					isData = false
+					write("250 OK")
+					continue
+				}
+				data += line + "\n"
+				continue
+			}
+			commands = append(commands, line)
+			switch {
+			case strings.HasPrefix(line, "EHLO"), strings.HasPrefix(line, "HELO"):
+				write("250 localhost")
+			case line == "DATA":
+				isData = true
+				write("354 End data with <CR><LF>.<CR><LF>")
+			case line == "QUIT":
+				write("221 Bye")
+				return
+			default:
+				write("250 OK")
+			}
+		}
+	}()
+
+	channel := &EmailChannel{}
+	err = channel.Init(maps.Map{
+		"host":    "127.0.0.1",
+		"port":    listener.Addr().(*net.TCPAddr).Port,
+		"from":    "edge@example.com",
+		"to":      "ops1@example.com, ops2@example.com",
+		"tlsMode": EmailTLSModeNone,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = channel.Send(testMessage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-done
+
+	t.Log(commands)
+	countRcpt := 0
+	for _, command := range commands {
+		if strings.HasPrefix(command, "RCPT TO:") {
+			countRcpt++
+		}
+	}
+	if countRcpt != 2 {
+		t.Fatal("expect 2 recipients, but got", countRcpt)
+	}
+	if !strings.Contains(data, "Subject: =?UTF-8?b?") {
+		t.Fatal("subject should be encoded:", data)
+	}
+}
+
+func TestEmailChannel_Init(t *testing.T) {
+	channel := &EmailChannel{}
+	err := channel.Init(maps.Map{
+		"host":     "smtp.example.com",
+		"username": "edge@example.com",
+		"to":       "ops@example.com",
+		"tlsMode":  EmailTLSModeSSL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if channel.port != 465 || channel.from != "edge@example.com" {
+		t.Fatal("invalid default values:", channel.port, channel.from)
+	}
+
+	err = channel.Init(maps.Map{
+		"host": "smtp.example.com",
+		"from": "edge@example.com",
+	})
+	if err == nil {
+		t.Fatal("'to' should be required")
+	}
+}
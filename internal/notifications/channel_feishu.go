@@ -0,0 +1,71 @@
+package notifications
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/iwind/TeaGo/maps"
+	"strconv"
+	"time"
+)
+
+// 飞书自定义机器人
+type FeishuChannel struct {
+	webhookURL string
+	secret     string
+}
+
+// 初始化
+// 参数：
+//   - webhookURL 机器人Webhook地址
+//   - secret 签名校验密钥，可以为空
+func (this *FeishuChannel) Init(params maps.Map) error {
+	this.webhookURL = params.GetString("webhookURL")
+	if len(this.webhookURL) == 0 {
+		return errors.New("'webhookURL' should not be empty")
+	}
+	this.secret = params.GetString("secret")
+	return nil
+}
+
+// 发送消息
+func (this *FeishuChannel) Send(msg *Message) error {
+	body := maps.Map{
+		"msg_type": "text",
+		"content": maps.Map{
+			"text": msg.Text(),
+		},
+	}
+	if len(this.secret) > 0 {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		body["timestamp"] = timestamp
+		body["sign"] = this.sign(timestamp)
+	}
+
+	respData, err := postJSON(this.webhookURL, body, nil)
+	if err != nil {
+		return err
+	}
+
+	resp := &struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}{}
+	err = json.Unmarshal(respData, resp)
+	if err != nil {
+		return errors.New("decode response failed: " + err.Error())
+	}
+	if resp.Code != 0 {
+		return errors.New("send failed: [" + strconv.Itoa(resp.Code) + "]" + resp.Msg)
+	}
+	return nil
+}
+
+// 签名
+// 飞书使用 timestamp + "\n" + secret 作为密钥对空字符串进行签名
+func (this *FeishuChannel) sign(timestamp string) string {
+	h := hmac.New(sha256.New, []byte(timestamp+"\n"+this.secret))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,35 @@
+package notifications
+
+import (
+	"encoding/json"
+	"github.com/iwind/TeaGo/maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFeishuChannel_Send(t *testing.T) {
+	channel := &FeishuChannel{}
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		body := maps.Map{}
+		_ = json.NewDecoder(req.Body).Decode(&body)
+		if body.GetString("sign") != channel.sign(body.GetString("timestamp")) {
+			_, _ = writer.Write([]byte(`{"code":19021,"msg":"sign match fail or timestamp is not within one hour from current time"}`))
+			return
+		}
+		_, _ = writer.Write([]byte(`{"code":0,"msg":"success"}`))
+	}))
+	defer server.Close()
+
+	err := channel.Init(maps.Map{
+		"webhookURL": server.URL,
+		"secret":     "SEC123",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = channel.Send(testMessage())
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,12 @@
+package notifications
+
+import "github.com/iwind/TeaGo/maps"
+
+// 通知渠道接口
+type ChannelInterface interface {
+	// 初始化
+	Init(params maps.Map) error
+
+	// 发送消息
+	Send(msg *Message) error
+}
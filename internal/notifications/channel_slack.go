@@ -0,0 +1,30 @@
+package notifications
+
+import (
+	"errors"
+	"github.com/iwind/TeaGo/maps"
+)
+
+// Slack Incoming Webhook
+type SlackChannel struct {
+	webhookURL string
+}
+
+// 初始化
+// 参数：
+//   - webhookURL Incoming Webhook地址
+func (this *SlackChannel) Init(params maps.Map) error {
+	this.webhookURL = params.GetString("webhookURL")
+	if len(this.webhookURL) == 0 {
+		return errors.New("'webhookURL' should not be empty")
+	}
+	return nil
+}
+
+// 发送消息
+func (this *SlackChannel) Send(msg *Message) error {
+	_, err := postJSON(this.webhookURL, maps.Map{
+		"text": msg.Text(),
+	}, nil)
+	return err
+}
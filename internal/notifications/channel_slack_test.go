@@ -0,0 +1,37 @@
+package notifications
+
+import (
+	"encoding/json"
+	"github.com/iwind/TeaGo/maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSlackChannel_Send(t *testing.T) {
+	text := ""
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		body := maps.Map{}
+		_ = json.NewDecoder(req.Body).Decode(&body)
+		text = body.GetString("text")
+		_, _ = writer.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	channel := &SlackChannel{}
+	err := channel.Init(maps.Map{
+		"webhookURL": server.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = channel.Send(testMessage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "node1") {
+		t.Fatal("invalid text:", text)
+	}
+	t.Log(text)
+}
@@ -0,0 +1,61 @@
+package notifications
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/iwind/TeaGo/maps"
+	"strconv"
+	"time"
+)
+
+// 通用Webhook
+// 以JSON格式POST消息内容，如果设置了密钥，则在Header中附带签名：
+//
+//	X-Edge-Timestamp: 当前时间戳
+//	X-Edge-Signature: sha256=HEX(HMAC-SHA256(secret, timestamp + "." + body))
+type WebhookChannel struct {
+	url    string
+	secret string
+}
+
+// 初始化
+// 参数：
+//   - url 接收消息的URL
+//   - secret 签名密钥，可以为空
+func (this *WebhookChannel) Init(params maps.Map) error {
+	this.url = params.GetString("url")
+	if len(this.url) == 0 {
+		return errors.New("'url' should not be empty")
+	}
+	this.secret = params.GetString("secret")
+	return nil
+}
+
+// 发送消息
+func (this *WebhookChannel) Send(msg *Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	headers := map[string]string{}
+	if len(this.secret) > 0 {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		headers["X-Edge-Timestamp"] = timestamp
+		headers["X-Edge-Signature"] = "sha256=" + WebhookSignature(this.secret, timestamp, data)
+	}
+	_, err = postData(this.url, data, headers)
+	return err
+}
+
+// 计算Webhook签名，接收方可以使用同样的方法校验
+func WebhookSignature(secret string, timestamp string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp))
+	h.Write([]byte("."))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
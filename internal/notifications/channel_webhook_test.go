@@ -0,0 +1,64 @@
+package notifications
+
+import (
+	"encoding/json"
+	"github.com/iwind/TeaGo/maps"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhookChannel_Send(t *testing.T) {
+	var received *Message
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		signature := req.Header.Get("X-Edge-Signature")
+		if signature != "sha256="+WebhookSignature("123456", req.Header.Get("X-Edge-Timestamp"), body) {
+			writer.WriteHeader(http.StatusForbidden)
+			return
+		}
+		received = &Message{}
+		_ = json.Unmarshal(body, received)
+	}))
+	defer server.Close()
+
+	channel := &WebhookChannel{}
+	err := channel.Init(maps.Map{
+		"url":    server.URL,
+		"secret": "123456",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = channel.Send(testMessage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received == nil || received.Id != 1 || received.Type != "HealthCheckNodeDown" {
+		t.Fatalf("invalid received message: %#v", received)
+	}
+
+	// 错误的密钥
+	_ = channel.Init(maps.Map{
+		"url":    server.URL,
+		"secret": "654321",
+	})
+	err = channel.Send(testMessage())
+	if err == nil {
+		t.Fatal("send with wrong secret should fail")
+	}
+	t.Log(err)
+}
+
+func testMessage() *Message {
+	return &Message{
+		Id:        1,
+		Type:      "HealthCheckNodeDown",
+		Level:     "error",
+		Body:      "健康检查失败，节点\"node1\"已自动下线",
+		ClusterId: 1,
+		NodeId:    2,
+		CreatedAt: 1600000000,
+	}
+}
@@ -0,0 +1,36 @@
+package notifications
+
+import (
+	"errors"
+	"github.com/iwind/TeaGo/maps"
+)
+
+// 企业微信群机器人
+type WeComChannel struct {
+	webhookURL string
+}
+
+// 初始化
+// 参数：
+//   - webhookURL 机器人Webhook地址
+func (this *WeComChannel) Init(params maps.Map) error {
+	this.webhookURL = params.GetString("webhookURL")
+	if len(this.webhookURL) == 0 {
+		return errors.New("'webhookURL' should not be empty")
+	}
+	return nil
+}
+
+// 发送消息
+func (this *WeComChannel) Send(msg *Message) error {
+	respData, err := postJSON(this.webhookURL, maps.Map{
+		"msgtype": "text",
+		"text": maps.Map{
+			"content": msg.Text(),
+		},
+	}, nil)
+	if err != nil {
+		return err
+	}
+	return checkRobotResponse(respData)
+}
@@ -0,0 +1,40 @@
+package notifications
+
+import (
+	"github.com/iwind/TeaGo/maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWeComChannel_Send(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("key") != "abc" {
+			_, _ = writer.Write([]byte(`{"errcode":93000,"errmsg":"invalid webhook url"}`))
+			return
+		}
+		_, _ = writer.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+	defer server.Close()
+
+	channel := &WeComChannel{}
+	err := channel.Init(maps.Map{
+		"webhookURL": server.URL + "/cgi-bin/webhook/send?key=abc",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = channel.Send(testMessage())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = channel.Init(maps.Map{
+		"webhookURL": server.URL + "/cgi-bin/webhook/send?key=def",
+	})
+	err = channel.Send(testMessage())
+	if err == nil {
+		t.Fatal("send with wrong key should fail")
+	}
+	t.Log(err)
+}
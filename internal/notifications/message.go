@@ -0,0 +1,47 @@
+package notifications
+
+import (
+	"encoding/json"
+	timeutil "github.com/iwind/TeaGo/utils/time"
+)
+
+// 待发送的消息
+type Message struct {
+	Id        int64           `json:"id"`
+	Type      string          `json:"type"`
+	Level     string          `json:"level"`
+	Body      string          `json:"body"`
+	ClusterId int64           `json:"clusterId"`
+	NodeId    int64           `json:"nodeId"`
+	Params    json.RawMessage `json:"params,omitempty"`
+	CreatedAt int64           `json:"createdAt"`
+}
+
+// 标题
+func (this *Message) Subject() string {
+	return "[" + this.LevelName() + "]" + this.Type
+}
+
+// 纯文本内容
+func (this *Message) Text() string {
+	text := this.Subject() + "\n" + this.Body
+	if this.CreatedAt > 0 {
+		text += "\n时间：" + timeutil.FormatTime("Y-m-d H:i:s", this.CreatedAt)
+	}
+	return text
+}
+
+// 级别名称
+func (this *Message) LevelName() string {
+	switch this.Level {
+	case "info":
+		return "信息"
+	case "warning":
+		return "警告"
+	case "error":
+		return "错误"
+	case "success":
+		return "成功"
+	}
+	return this.Level
+}
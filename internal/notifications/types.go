@@ -0,0 +1,72 @@
+package notifications
+
+import "github.com/iwind/TeaGo/maps"
+
+type ChannelType = string
+
+// 渠道代号
+const (
+	ChannelTypeEmail    ChannelType = "email"
+	ChannelTypeWebhook  ChannelType = "webhook"
+	ChannelTypeSlack    ChannelType = "slack"
+	ChannelTypeDingTalk ChannelType = "dingTalk"
+	ChannelTypeWeCom    ChannelType = "weCom"
+	ChannelTypeFeishu   ChannelType = "feishu"
+)
+
+// 所有的渠道类型
+var AllChannelTypes = []maps.Map{
+	{
+		"name": "邮件（SMTP）",
+		"code": ChannelTypeEmail,
+	},
+	{
+		"name": "Webhook",
+		"code": ChannelTypeWebhook,
+	},
+	{
+		"name": "Slack",
+		"code": ChannelTypeSlack,
+	},
+	{
+		"name": "钉钉机器人",
+		"code": ChannelTypeDingTalk,
+	},
+	{
+		"name": "企业微信机器人",
+		"code": ChannelTypeWeCom,
+	},
+	{
+		"name": "飞书机器人",
+		"code": ChannelTypeFeishu,
+	},
+}
+
+// 查找渠道实例
+func FindChannel(channelType ChannelType) ChannelInterface {
+	switch channelType {
+	case ChannelTypeEmail:
+		return &EmailChannel{}
+	case ChannelTypeWebhook:
+		return &WebhookChannel{}
+	case ChannelTypeSlack:
+		return &SlackChannel{}
+	case ChannelTypeDingTalk:
+		return &DingTalkChannel{}
+	case ChannelTypeWeCom:
+		return &WeComChannel{}
+	case ChannelTypeFeishu:
+		return &FeishuChannel{}
+	}
+	return nil
+}
+
+// 查找渠道名称
+func FindChannelTypeName(channelType ChannelType) string {
+	for _, t := range AllChannelTypes {
+		if t.GetString("code") == channelType {
+			return t.GetString("name")
+		}
+	}
+	return ""
+}
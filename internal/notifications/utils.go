@@ -0,0 +1,52 @@
+package notifications
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var sharedHTTPClient = &http.Client{
+	Timeout: 10 * time.Second,
+}
+
+// 以JSON格式发送POST请求，并返回响应内容
+func postJSON(url string, body interface{}, headers map[string]string) ([]byte, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return postData(url, data, headers)
+}
+
+// 发送POST请求
+func postData(url string, data []byte, headers map[string]string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("User-Agent", "GoEdge-API")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := sharedHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	respData, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return respData, errors.New("invalid response status code '" + strconv.Itoa(resp.StatusCode) + "': " + string(respData))
+	}
+	return respData, nil
+}
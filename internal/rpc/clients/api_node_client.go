@@ -0,0 +1,197 @@
+package clients
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"github.com/TeaOSLab/EdgeAPI/internal/configs"
+	teaconst "github.com/TeaOSLab/EdgeAPI/internal/const"
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/TeaOSLab/EdgeAPI/internal/encrypt"
+	"github.com/TeaOSLab/EdgeAPI/internal/utils/numberutils"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/iwind/TeaGo/maps"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"strings"
+	"sync"
+	"time"
+)
+
+var sharedAPINodeClientMap = map[int64]*APINodeClient{} // apiNodeId => client
+var sharedAPINodeClientLocker = &sync.Mutex{}
+
+// 连接其他API节点的客户端，用于在API节点之间转发请求
+type APINodeClient struct {
+	apiNodeId int64
+	conn      *grpc.ClientConn
+}
+
+// 获取某个API节点的客户端，客户端会被复用
+func FindAPINodeClient(apiNodeId int64) (*APINodeClient, error) {
+	sharedAPINodeClientLocker.Lock()
+	defer sharedAPINodeClientLocker.Unlock()
+
+	client, ok := sharedAPINodeClientMap[apiNodeId]
+	if ok {
+		state := client.conn.GetState()
+		if state != connectivity.Shutdown && state != connectivity.TransientFailure {
+			return client, nil
+		}
+		_ = client.conn.Close()
+		delete(sharedAPINodeClientMap, apiNodeId)
+	}
+
+	apiNode, err := models.SharedAPINodeDAO.FindEnabledAPINode(nil, apiNodeId)
+	if err != nil {
+		return nil, err
+	}
+	if apiNode == nil || apiNode.IsOn == 0 {
+		return nil, errors.New("api node '" + numberutils.FormatInt64(apiNodeId) + "' not found")
+	}
+	addrs, err := apiNode.DecodeAccessAddrStrings()
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, errors.New("api node '" + numberutils.FormatInt64(apiNodeId) + "' has no access addresses")
+	}
+
+	// 使用第一个能连接上的地址
+	var lastErr error
+	for _, addr := range addrs {
+		conn, err := dialAPINode(apiNode, addr)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		client = &APINodeClient{
+			apiNodeId: apiNodeId,
+			conn:      conn,
+		}
+		sharedAPINodeClientMap[apiNodeId] = client
+		return client, nil
+	}
+	return nil, lastErr
+}
+
+// 连接API节点
+func dialAPINode(apiNode *models.APINode, addr string) (*grpc.ClientConn, error) {
+	host, isTLS, err := ParseAPINodeAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	var option grpc.DialOption
+	if isTLS {
+		tlsConfig, err := apiNodeTLSConfig(apiNode)
+		if err != nil {
+			return nil, err
+		}
+		option = grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))
+	} else {
+		option = grpc.WithInsecure()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return grpc.DialContext(ctx, host, option, grpc.WithBlock())
+}
+
+// 构造连接API节点的TLS配置
+// API节点的证书通常是自签名的，而且访问地址多为IP，所以只校验对方出示的证书链是否能被该API节点HTTPS配置中的证书验证，不校验主机名
+func apiNodeTLSConfig(apiNode *models.APINode) (*tls.Config, error) {
+	httpsConfig, err := apiNode.DecodeHTTPS(nil)
+	if err != nil {
+		return nil, err
+	}
+	if httpsConfig == nil || httpsConfig.SSLPolicy == nil || len(httpsConfig.SSLPolicy.Certs) == 0 {
+		return nil, errors.New("api node '" + numberutils.FormatInt64(int64(apiNode.Id)) + "' has no https certificates")
+	}
+
+	roots := x509.NewCertPool()
+	for _, cert := range httpsConfig.SSLPolicy.Certs {
+		if cert == nil {
+			continue
+		}
+		roots.AppendCertsFromPEM(cert.CertData)
+	}
+
+	return &tls.Config{
+		InsecureSkipVerify: true, // 使用VerifyPeerCertificate代替默认的校验
+		VerifyPeerCertificate: func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return errors.New("api node does not provide any certificate")
+			}
+			certs := []*x509.Certificate{}
+			for _, rawCert := range rawCerts {
+				cert, err := x509.ParseCertificate(rawCert)
+				if err != nil {
+					return err
+				}
+				certs = append(certs, cert)
+			}
+			intermediates := x509.NewCertPool()
+			for _, cert := range certs[1:] {
+				intermediates.AddCert(cert)
+			}
+			_, err := certs[0].Verify(x509.VerifyOptions{
+				Roots:         roots,
+				Intermediates: intermediates,
+			})
+			return err
+		},
+	}, nil
+}
+
+// 分析API节点地址，返回主机地址和是否使用TLS
+func ParseAPINodeAddr(addr string) (host string, isTLS bool, err error) {
+	switch {
+	case strings.HasPrefix(addr, "https://"):
+		host = strings.TrimPrefix(addr, "https://")
+		isTLS = true
+	case strings.HasPrefix(addr, "http://"):
+		host = strings.TrimPrefix(addr, "http://")
+	default:
+		host = addr
+	}
+	host = strings.TrimSuffix(host, "/")
+	if len(host) == 0 {
+		err = errors.New("invalid api node address '" + addr + "'")
+	}
+	return
+}
+
+// 节点服务
+func (this *APINodeClient) NodeRPC() pb.NodeServiceClient {
+	return pb.NewNodeServiceClient(this.conn)
+}
+
+// 构造认证上下文
+// 使用当前API节点的ID和密钥进行认证
+func (this *APINodeClient) Context(parent context.Context) (context.Context, error) {
+	apiConfig, err := configs.SharedAPIConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	data := maps.Map{
+		"timestamp": time.Now().Unix(),
+		"type":      "api",
+		"userId":    apiConfig.NumberId(),
+	}.AsJSON()
+	method, err := encrypt.NewMethodInstance(teaconst.EncryptMethod, apiConfig.Secret, apiConfig.NodeId)
+	if err != nil {
+		return nil, err
+	}
+	data, err = method.Encrypt(data)
+	if err != nil {
+		return nil, err
+	}
+	token := base64.StdEncoding.EncodeToString(data)
+	return metadata.AppendToOutgoingContext(parent, "nodeId", apiConfig.NodeId, "token", token), nil
+}
@@ -0,0 +1,23 @@
+package clients
+
+import "testing"
+
+func TestParseAPINodeAddr(t *testing.T) {
+	for _, addr := range []string{"http://127.0.0.1:8001", "https://192.168.1.100:8003/", "127.0.0.1:8001", "https://"} {
+		host, isTLS, err := ParseAPINodeAddr(addr)
+		t.Log(addr, "=>", host, isTLS, err)
+	}
+
+	host, isTLS, err := ParseAPINodeAddr("https://192.168.1.100:8003")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host != "192.168.1.100:8003" || !isTLS {
+		t.Fatal("invalid result:", host, isTLS)
+	}
+
+	_, _, err = ParseAPINodeAddr("http://")
+	if err == nil {
+		t.Fatal("empty host should fail")
+	}
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"encoding/json"
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/TeaOSLab/EdgeCommon/pkg/messageconfigs"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/iwind/TeaGo/dbs"
+	"github.com/iwind/TeaGo/logs"
+	"github.com/iwind/TeaGo/maps"
+	"github.com/iwind/TeaGo/types"
+	"sync"
+	"time"
+)
+
+var SharedNodeTaskNotifier = NewNodeTaskNotifier()
+
+func init() {
+	models.OnNodeTaskCreated(SharedNodeTaskNotifier.Add)
+
+	dbs.OnReadyDone(func() {
+		go SharedNodeTaskNotifier.Start()
+	})
+}
+
+// 任务在创建后多长时间内不可见时继续重试
+// 任务所在的事务可能尚未提交，这时查询不到任务，需要等事务提交后再通知
+const nodeTaskNotifierMaxWait = 10 * time.Second
+
+// 集群任务
+type nodeTaskNotifierClusterKey struct {
+	clusterId int64
+	taskType  models.NodeTaskType
+}
+
+// 节点任务通知器
+// 任务创建后通过NodeStream及时通知节点，节点定时拉取任务只作为补充
+type NodeTaskNotifier struct {
+	locker     sync.Mutex
+	clusterMap map[nodeTaskNotifierClusterKey]time.Time // 集群任务 => 创建时间
+	nodeMap    map[int64]time.Time                      // node id => 最早的任务创建时间
+	sem        chan bool                                // 限制同时推送的数量
+}
+
+// 获取新对象
+func NewNodeTaskNotifier() *NodeTaskNotifier {
+	return &NodeTaskNotifier{
+		clusterMap: map[nodeTaskNotifierClusterKey]time.Time{},
+		nodeMap:    map[int64]time.Time{},
+		sem:        make(chan bool, 32),
+	}
+}
+
+// 添加待通知的任务
+// 此时任务所在的事务可能尚未提交，所以不立即推送，而是稍后统一推送，查询不到任务时会重试
+func (this *NodeTaskNotifier) Add(clusterId int64, nodeId int64, taskType models.NodeTaskType) {
+	this.add(clusterId, nodeId, taskType, time.Now())
+}
+
+// 添加待通知的任务，并指定任务创建时间
+func (this *NodeTaskNotifier) add(clusterId int64, nodeId int64, taskType models.NodeTaskType, createdAt time.Time) {
+	this.locker.Lock()
+	if nodeId > 0 {
+		oldCreatedAt, ok := this.nodeMap[nodeId]
+		if !ok || createdAt.Before(oldCreatedAt) {
+			this.nodeMap[nodeId] = createdAt
+		}
+	} else if clusterId > 0 {
+		key := nodeTaskNotifierClusterKey{clusterId: clusterId, taskType: taskType}
+		oldCreatedAt, ok := this.clusterMap[key]
+		if !ok || createdAt.Before(oldCreatedAt) {
+			this.clusterMap[key] = createdAt
+		}
+	}
+	this.locker.Unlock()
+}
+
+// 启动
+func (this *NodeTaskNotifier) Start() {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	for range ticker.C {
+		err := this.Loop()
+		if err != nil {
+			logs.Println("[NODE_TASK_NOTIFIER]" + err.Error())
+		}
+	}
+}
+
+// 单次运行
+func (this *NodeTaskNotifier) Loop() error {
+	this.locker.Lock()
+	clusterMap := this.clusterMap
+	if len(clusterMap) > 0 {
+		this.clusterMap = map[nodeTaskNotifierClusterKey]time.Time{}
+	}
+	this.locker.Unlock()
+
+	// 分解集群任务，分解时会重新调用Add()添加节点
+	for key, createdAt := range clusterMap {
+		// 可能已经被NodeTaskExtractor分解，也可能所在的事务尚未提交
+		exists, err := models.SharedNodeTaskDAO.ExistsClusterTask(nil, key.clusterId, key.taskType)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if time.Since(createdAt) < nodeTaskNotifierMaxWait {
+				this.add(key.clusterId, 0, key.taskType, createdAt)
+			}
+			continue
+		}
+		err = models.SharedNodeTaskDAO.ExtractClusterTask(nil, key.clusterId, key.taskType)
+		if err != nil {
+			return err
+		}
+	}
+
+	this.locker.Lock()
+	nodeMap := this.nodeMap
+	if len(nodeMap) > 0 {
+		this.nodeMap = map[int64]time.Time{}
+	}
+	this.locker.Unlock()
+
+	for nodeId, createdAt := range nodeMap {
+		this.sem <- true
+		go func(nodeId int64, createdAt time.Time) {
+			defer func() {
+				<-this.sem
+			}()
+
+			err := this.notifyNode(nodeId, createdAt)
+			if err != nil {
+				logs.Println("[NODE_TASK_NOTIFIER]notify node '" + types.String(nodeId) + "' failed: " + err.Error())
+			}
+		}(nodeId, createdAt)
+	}
+
+	return nil
+}
+
+// 通知单个节点
+// 节点未连接或者未响应时不返回错误，由节点定时拉取任务
+func (this *NodeTaskNotifier) notifyNode(nodeId int64, createdAt time.Time) error {
+	tasks, err := models.SharedNodeTaskDAO.FindDoingNodeTasks(nil, nodeId)
+	if err != nil {
+		return err
+	}
+	taskIds := []int64{}
+	taskTypes := []string{}
+	for _, task := range tasks {
+		if task.IsDone == 1 || task.IsNotified == 1 {
+			continue
+		}
+		taskIds = append(taskIds, int64(task.Id))
+		taskTypes = append(taskTypes, task.Type)
+	}
+	if len(taskIds) == 0 {
+		// 任务所在的事务可能尚未提交，稍后重试
+		if time.Since(createdAt) < nodeTaskNotifierMaxWait {
+			this.add(0, nodeId, "", createdAt)
+		}
+		return nil
+	}
+
+	dataJSON, err := json.Marshal(maps.Map{
+		"taskIds": taskIds,
+		"types":   taskTypes,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := (&NodeService{}).sendCommandToNode(&pb.NodeStreamMessage{
+		NodeId:         nodeId,
+		Code:           messageconfigs.MessageCodeNewNodeTask,
+		DataJSON:       dataJSON,
+		TimeoutSeconds: 5,
+	})
+	if err != nil {
+		return err
+	}
+	if !resp.IsOk {
+		return nil
+	}
+
+	// 节点确认收到后记录通知延迟
+	return models.SharedNodeTaskDAO.UpdateTasksNotifiedWithLatency(nil, taskIds, time.Since(createdAt).Milliseconds())
+}
@@ -7,6 +7,7 @@ import (
 	acmemodels "github.com/TeaOSLab/EdgeAPI/internal/db/models/acme"
 	"github.com/TeaOSLab/EdgeAPI/internal/db/models/dns"
 	"github.com/TeaOSLab/EdgeAPI/internal/dnsclients"
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
 	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
 )
 
@@ -92,10 +93,12 @@ func (this *ACMETaskService) ListEnabledACMETasks(ctx context.Context, req *pb.L
 			continue
 		}
 		pbACMEUser := &pb.ACMEUser{
-			Id:          int64(acmeUser.Id),
-			Email:       acmeUser.Email,
-			Description: acmeUser.Description,
-			CreatedAt:   int64(acmeUser.CreatedAt),
+			Id:           int64(acmeUser.Id),
+			Email:        acmeUser.Email,
+			Description:  acmeUser.Description,
+			CreatedAt:    int64(acmeUser.CreatedAt),
+			DirectoryURL: acmeUser.DirectoryURL,
+			EabKid:       acmeUser.EabKid,
 		}
 
 		var pbProvider *pb.DNSProvider
@@ -162,6 +165,9 @@ func (this *ACMETaskService) ListEnabledACMETasks(ctx context.Context, req *pb.L
 			SslCert:           pbCert,
 			LatestACMETaskLog: pbTaskLog,
 			AuthType:          task.AuthType,
+			KeyType:           task.KeyType,
+			DualCerts:         task.DualCerts == 1,
+			CaDirectoryURL:    task.CaDirectoryURL,
 		})
 	}
 
@@ -179,8 +185,12 @@ func (this *ACMETaskService) CreateACMETask(ctx context.Context, req *pb.CreateA
 		req.AuthType = acme.AuthTypeDNS
 	}
 
+	if len(req.KeyType) > 0 && !acme.IsValidKeyType(req.KeyType) {
+		return nil, errors.New("invalid key type '" + req.KeyType + "'")
+	}
+
 	tx := this.NullTx()
-	taskId, err := acmemodels.SharedACMETaskDAO.CreateACMETask(tx, adminId, userId, req.AuthType, req.AcmeUserId, req.DnsProviderId, req.DnsDomain, req.Domains, req.AutoRenew)
+	taskId, err := acmemodels.SharedACMETaskDAO.CreateACMETask(tx, adminId, userId, req.AuthType, req.AcmeUserId, req.DnsProviderId, req.DnsDomain, req.Domains, req.AutoRenew, req.KeyType, req.DualCerts)
 	if err != nil {
 		return nil, err
 	}
@@ -204,7 +214,11 @@ func (this *ACMETaskService) UpdateACMETask(ctx context.Context, req *pb.UpdateA
 		return nil, this.PermissionError()
 	}
 
-	err = acmemodels.SharedACMETaskDAO.UpdateACMETask(tx, req.AcmeTaskId, req.AcmeUserId, req.DnsProviderId, req.DnsDomain, req.Domains, req.AutoRenew)
+	if len(req.KeyType) > 0 && !acme.IsValidKeyType(req.KeyType) {
+		return nil, errors.New("invalid key type '" + req.KeyType + "'")
+	}
+
+	err = acmemodels.SharedACMETaskDAO.UpdateACMETask(tx, req.AcmeTaskId, req.AcmeUserId, req.DnsProviderId, req.DnsDomain, req.Domains, req.AutoRenew, req.KeyType, req.DualCerts)
 	if err != nil {
 		return nil, err
 	}
@@ -295,10 +309,12 @@ func (this *ACMETaskService) FindEnabledACMETask(ctx context.Context, req *pb.Fi
 		}
 		if acmeUser != nil {
 			pbACMEUser = &pb.ACMEUser{
-				Id:          int64(acmeUser.Id),
-				Email:       acmeUser.Email,
-				Description: acmeUser.Description,
-				CreatedAt:   int64(acmeUser.CreatedAt),
+				Id:           int64(acmeUser.Id),
+				Email:        acmeUser.Email,
+				Description:  acmeUser.Description,
+				CreatedAt:    int64(acmeUser.CreatedAt),
+				DirectoryURL: acmeUser.DirectoryURL,
+				EabKid:       acmeUser.EabKid,
 			}
 		}
 	}
@@ -319,14 +335,17 @@ func (this *ACMETaskService) FindEnabledACMETask(ctx context.Context, req *pb.Fi
 	}
 
 	return &pb.FindEnabledACMETaskResponse{AcmeTask: &pb.ACMETask{
-		Id:          int64(task.Id),
-		IsOn:        task.IsOn == 1,
-		DnsDomain:   task.DnsDomain,
-		Domains:     task.DecodeDomains(),
-		CreatedAt:   int64(task.CreatedAt),
-		AutoRenew:   task.AutoRenew == 1,
-		DnsProvider: pbProvider,
-		AcmeUser:    pbACMEUser,
-		AuthType:    task.AuthType,
+		Id:             int64(task.Id),
+		IsOn:           task.IsOn == 1,
+		DnsDomain:      task.DnsDomain,
+		Domains:        task.DecodeDomains(),
+		CreatedAt:      int64(task.CreatedAt),
+		AutoRenew:      task.AutoRenew == 1,
+		DnsProvider:    pbProvider,
+		AcmeUser:       pbACMEUser,
+		AuthType:       task.AuthType,
+		KeyType:        task.KeyType,
+		DualCerts:      task.DualCerts == 1,
+		CaDirectoryURL: task.CaDirectoryURL,
 	}}, nil
 }
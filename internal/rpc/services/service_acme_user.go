@@ -21,7 +21,7 @@ func (this *ACMEUserService) CreateACMEUser(ctx context.Context, req *pb.CreateA
 
 	tx := this.NullTx()
 
-	acmeUserId, err := acmemodels.SharedACMEUserDAO.CreateACMEUser(tx, adminId, userId, req.Email, req.Description)
+	acmeUserId, err := acmemodels.SharedACMEUserDAO.CreateACMEUser(tx, adminId, userId, req.Email, req.Description, req.DirectoryURL, req.EabKid, req.EabHmacKey)
 	if err != nil {
 		return nil, err
 	}
@@ -47,7 +47,7 @@ func (this *ACMEUserService) UpdateACMEUser(ctx context.Context, req *pb.UpdateA
 		return nil, this.PermissionError()
 	}
 
-	err = acmemodels.SharedACMEUserDAO.UpdateACMEUser(tx, req.AcmeUserId, req.Description)
+	err = acmemodels.SharedACMEUserDAO.UpdateACMEUser(tx, req.AcmeUserId, req.Description, req.DirectoryURL, req.EabKid, req.EabHmacKey)
 	if err != nil {
 		return nil, err
 	}
@@ -114,10 +114,12 @@ func (this *ACMEUserService) ListACMEUsers(ctx context.Context, req *pb.ListACME
 	result := []*pb.ACMEUser{}
 	for _, user := range acmeUsers {
 		result = append(result, &pb.ACMEUser{
-			Id:          int64(user.Id),
-			Email:       user.Email,
-			Description: user.Description,
-			CreatedAt:   int64(user.CreatedAt),
+			Id:           int64(user.Id),
+			Email:        user.Email,
+			Description:  user.Description,
+			CreatedAt:    int64(user.CreatedAt),
+			DirectoryURL: user.DirectoryURL,
+			EabKid:       user.EabKid,
 		})
 	}
 	return &pb.ListACMEUsersResponse{AcmeUsers: result}, nil
@@ -150,10 +152,12 @@ func (this *ACMEUserService) FindEnabledACMEUser(ctx context.Context, req *pb.Fi
 		return &pb.FindEnabledACMEUserResponse{AcmeUser: nil}, nil
 	}
 	return &pb.FindEnabledACMEUserResponse{AcmeUser: &pb.ACMEUser{
-		Id:          int64(acmeUser.Id),
-		Email:       acmeUser.Email,
-		Description: acmeUser.Description,
-		CreatedAt:   int64(acmeUser.CreatedAt),
+		Id:           int64(acmeUser.Id),
+		Email:        acmeUser.Email,
+		Description:  acmeUser.Description,
+		CreatedAt:    int64(acmeUser.CreatedAt),
+		DirectoryURL: acmeUser.DirectoryURL,
+		EabKid:       acmeUser.EabKid,
 	}}, nil
 }
 
@@ -174,10 +178,12 @@ func (this *ACMEUserService) FindAllACMEUsers(ctx context.Context, req *pb.FindA
 	result := []*pb.ACMEUser{}
 	for _, user := range acmeUsers {
 		result = append(result, &pb.ACMEUser{
-			Id:          int64(user.Id),
-			Email:       user.Email,
-			Description: user.Description,
-			CreatedAt:   int64(user.CreatedAt),
+			Id:           int64(user.Id),
+			Email:        user.Email,
+			Description:  user.Description,
+			CreatedAt:    int64(user.CreatedAt),
+			DirectoryURL: user.DirectoryURL,
+			EabKid:       user.EabKid,
 		})
 	}
 	return &pb.FindAllACMEUsersResponse{AcmeUsers: result}, nil
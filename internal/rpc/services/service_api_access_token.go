@@ -14,7 +14,7 @@ type APIAccessTokenService struct {
 
 // 获取AccessToken
 func (this *APIAccessTokenService) GetAPIAccessToken(ctx context.Context, req *pb.GetAPIAccessTokenRequest) (*pb.GetAPIAccessTokenResponse, error) {
-	if req.Type == "user" { // 用户
+	if req.Type == "user" || req.Type == "admin" { // 用户或管理员
 		tx := this.NullTx()
 
 		accessKey, err := models.SharedUserAccessKeyDAO.FindAccessKeyWithUniqueId(tx, req.AccessKeyId)
@@ -27,9 +27,12 @@ func (this *APIAccessTokenService) GetAPIAccessToken(ctx context.Context, req *p
 		if accessKey.Secret != req.AccessKey {
 			return nil, errors.New("access key not found")
 		}
+		if (req.Type == "user" && accessKey.UserId == 0) || (req.Type == "admin" && accessKey.AdminId == 0) {
+			return nil, errors.New("access key not found")
+		}
 
 		// 创建AccessToken
-		token, expiresAt, err := models.SharedAPIAccessTokenDAO.GenerateAccessToken(tx, int64(accessKey.UserId))
+		token, expiresAt, err := models.SharedAPIAccessTokenDAO.GenerateAccessToken(tx, accessKey)
 		if err != nil {
 			return nil, err
 		}
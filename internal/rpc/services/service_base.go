@@ -82,6 +82,32 @@ func (this *BaseService) ValidateUser(ctx context.Context) (userId int64, err er
 	return
 }
 
+// 校验API节点，用于API节点之间互相调用
+func (this *BaseService) ValidateAPINode(ctx context.Context) (apiNodeId int64, err error) {
+	_, apiNodeId, err = rpcutils.ValidateRequest(ctx, rpcutils.UserTypeAPI)
+	if err != nil {
+		return 0, err
+	}
+
+	// 请求中的类型是调用者声明的，这里需要检查令牌的角色，防止其他节点冒充API节点
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return 0, errors.New("context: need 'nodeId'")
+	}
+	nodeIds := md.Get("nodeid")
+	if len(nodeIds) == 0 || len(nodeIds[0]) == 0 {
+		return 0, errors.New("context: need 'nodeId'")
+	}
+	apiToken, err := models.SharedApiTokenDAO.FindEnabledTokenWithNodeCacheable(nil, nodeIds[0])
+	if err != nil {
+		return 0, err
+	}
+	if apiToken == nil || apiToken.Role != rpcutils.UserTypeAPI {
+		return 0, this.PermissionError()
+	}
+	return
+}
+
 // 获取节点ID
 func (this *BaseService) ValidateNodeId(ctx context.Context, roles ...rpcutils.UserType) (role rpcutils.UserType, nodeIntId int64, err error) {
 	if ctx == nil {
@@ -27,7 +27,7 @@ func (this *DBNodeService) CreateDBNode(ctx context.Context, req *pb.CreateDBNod
 
 	tx := this.NullTx()
 
-	nodeId, err := models.SharedDBNodeDAO.CreateDBNode(tx, req.IsOn, req.Name, req.Description, req.Host, req.Port, req.Database, req.Username, req.Password, req.Charset)
+	nodeId, err := models.SharedDBNodeDAO.CreateDBNode(tx, req.IsOn, req.Name, req.Description, req.Host, req.Port, req.Database, req.Username, req.Password, req.Charset, req.Weight)
 	if err != nil {
 		return nil, err
 	}
@@ -44,7 +44,7 @@ func (this *DBNodeService) UpdateDBNode(ctx context.Context, req *pb.UpdateDBNod
 
 	tx := this.NullTx()
 
-	err = models.SharedDBNodeDAO.UpdateNode(tx, req.DbNodeId, req.IsOn, req.Name, req.Description, req.Host, req.Port, req.Database, req.Username, req.Password, req.Charset)
+	err = models.SharedDBNodeDAO.UpdateNode(tx, req.DbNodeId, req.IsOn, req.Name, req.Description, req.Host, req.Port, req.Database, req.Username, req.Password, req.Charset, req.Weight)
 	if err != nil {
 		return nil, err
 	}
@@ -136,6 +136,7 @@ func (this *DBNodeService) ListEnabledDBNodes(ctx context.Context, req *pb.ListE
 			Username:    node.Username,
 			Password:    node.Password,
 			Charset:     node.Charset,
+			Weight:      types.Int32(node.Weight),
 			Status:      status,
 		})
 	}
@@ -170,6 +171,7 @@ func (this *DBNodeService) FindEnabledDBNode(ctx context.Context, req *pb.FindEn
 		Username:    node.Username,
 		Password:    node.Password,
 		Charset:     node.Charset,
+		Weight:      types.Int32(node.Weight),
 	}}, nil
 }
 
@@ -295,3 +297,36 @@ func (this *DBNodeService) TruncateDBNodeTable(ctx context.Context, req *pb.Trun
 	}
 	return this.Success()
 }
+
+// 查找最近的访问日志分片重新平衡计划
+func (this *DBNodeService) FindLatestHTTPAccessLogRebalancePlans(ctx context.Context, req *pb.FindLatestHTTPAccessLogRebalancePlansRequest) (*pb.FindLatestHTTPAccessLogRebalancePlansResponse, error) {
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var tx = this.NullTx()
+	plans, err := models.SharedHTTPAccessLogRebalancePlanDAO.FindLatestPlans(tx, req.Size)
+	if err != nil {
+		return nil, err
+	}
+	result := []*pb.HTTPAccessLogRebalancePlan{}
+	for _, plan := range plans {
+		pbMoves := []*pb.HTTPAccessLogRebalancePlan_Move{}
+		for _, move := range plan.DecodeMoves() {
+			pbMoves = append(pbMoves, &pb.HTTPAccessLogRebalancePlan_Move{
+				ServerId:     move.ServerId,
+				FromDBNodeId: move.FromNodeId,
+				ToDBNodeId:   move.ToNodeId,
+			})
+		}
+		result = append(result, &pb.HTTPAccessLogRebalancePlan{
+			Id:           int64(plan.Id),
+			OldNodesJSON: []byte(plan.OldNodes),
+			NewNodesJSON: []byte(plan.NewNodes),
+			Moves:        pbMoves,
+			CreatedAt:    int64(plan.CreatedAt),
+		})
+	}
+	return &pb.FindLatestHTTPAccessLogRebalancePlansResponse{HttpAccessLogRebalancePlans: result}, nil
+}
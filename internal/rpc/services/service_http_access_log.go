@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"github.com/TeaOSLab/EdgeAPI/internal/accesslogs"
 	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
 	rpcutils "github.com/TeaOSLab/EdgeAPI/internal/rpc/utils"
 	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
@@ -31,6 +32,9 @@ func (this *HTTPAccessLogService) CreateHTTPAccessLogs(ctx context.Context, req
 		return nil, err
 	}
 
+	// 写入访问日志策略中的其他存储
+	accesslogs.SharedManager.Write(req.AccessLogs)
+
 	return &pb.CreateHTTPAccessLogsResponse{}, nil
 }
 
@@ -80,6 +84,141 @@ func (this *HTTPAccessLogService) ListHTTPAccessLogs(ctx context.Context, req *p
 	}, nil
 }
 
+// 搜索访问日志
+// 可以按照IP/CIDR、域名、路径、状态码、User-Agent、请求方法等条件搜索，并且可以跨越多天
+func (this *HTTPAccessLogService) SearchHTTPAccessLogs(ctx context.Context, req *pb.SearchHTTPAccessLogsRequest) (*pb.SearchHTTPAccessLogsResponse, error) {
+	// 校验请求
+	_, userId, err := this.ValidateAdminAndUser(ctx, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	// 检查服务ID
+	if userId > 0 {
+		if req.UserId > 0 && userId != req.UserId {
+			return nil, this.PermissionError()
+		}
+		req.UserId = userId
+
+		if req.ServerId > 0 {
+			err = models.SharedServerDAO.CheckUserServer(tx, userId, req.ServerId)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	statusRanges := []*models.HTTPAccessLogRange{}
+	for _, statusRange := range req.StatusRanges {
+		statusRanges = append(statusRanges, &models.HTTPAccessLogRange{
+			From: int64(statusRange.From),
+			To:   int64(statusRange.To),
+		})
+	}
+
+	accessLogs, requestId, hasMore, err := models.SharedHTTPAccessLogDAO.SearchAccessLogs(tx, &models.HTTPAccessLogSearchQuery{
+		ServerId:            req.ServerId,
+		UserId:              req.UserId,
+		StartDay:            req.StartDay,
+		EndDay:              req.EndDay,
+		StartTime:           req.StartTime,
+		EndTime:             req.EndTime,
+		IP:                  req.Ip,
+		Host:                req.Host,
+		PathPrefix:          req.PathPrefix,
+		PathRegexp:          req.PathRegexp,
+		StatusRanges:        statusRanges,
+		UserAgent:           req.UserAgent,
+		Method:              req.Method,
+		HasError:            req.HasError,
+		FirewallPolicyId:    req.FirewallPolicyId,
+		FirewallRuleGroupId: req.FirewallRuleGroupId,
+		FirewallRuleSetId:   req.FirewallRuleSetId,
+		HasFirewallPolicy:   req.HasFirewallPolicy,
+		LastRequestId:       req.RequestId,
+		Size:                req.Size,
+		Reverse:             req.Reverse,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*pb.HTTPAccessLog{}
+	for _, accessLog := range accessLogs {
+		a, err := accessLog.ToPB()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, a)
+	}
+
+	return &pb.SearchHTTPAccessLogsResponse{
+		AccessLogs: result,
+		HasMore:    hasMore,
+		RequestId:  requestId,
+	}, nil
+}
+
+// 统计访问日志
+// 可以统计某个服务、用户或者集群在一段时间内的访问日志，每个维度返回排名靠前的统计项
+func (this *HTTPAccessLogService) StatHTTPAccessLogs(ctx context.Context, req *pb.StatHTTPAccessLogsRequest) (*pb.StatHTTPAccessLogsResponse, error) {
+	// 校验请求
+	_, userId, err := this.ValidateAdminAndUser(ctx, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	// 用户只能统计自己的服务
+	if userId > 0 {
+		if req.UserId > 0 && userId != req.UserId {
+			return nil, this.PermissionError()
+		}
+		req.UserId = userId
+		req.NodeClusterId = 0
+
+		if req.ServerId > 0 {
+			err = models.SharedServerDAO.CheckUserServer(tx, userId, req.ServerId)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	query := &models.HTTPAccessLogStatQuery{
+		ServerId:  req.ServerId,
+		UserId:    req.UserId,
+		ClusterId: req.NodeClusterId,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		Size:      req.Size,
+	}
+	result := []*pb.HTTPAccessLogStat{}
+	for _, dimension := range req.Dimensions {
+		items, err := models.SharedHTTPAccessLogDAO.StatAccessLogs(tx, query, dimension)
+		if err != nil {
+			return nil, err
+		}
+		pbItems := []*pb.HTTPAccessLogStatItem{}
+		for _, item := range items {
+			pbItems = append(pbItems, &pb.HTTPAccessLogStatItem{
+				Key:   item.Key,
+				Count: item.Count,
+				Bytes: item.Bytes,
+			})
+		}
+		result = append(result, &pb.HTTPAccessLogStat{
+			Dimension: dimension,
+			Items:     pbItems,
+		})
+	}
+
+	return &pb.StatHTTPAccessLogsResponse{Stats: result}, nil
+}
+
 // 查找单个日志
 func (this *HTTPAccessLogService) FindHTTPAccessLog(ctx context.Context, req *pb.FindHTTPAccessLogRequest) (*pb.FindHTTPAccessLogResponse, error) {
 	// 校验请求
@@ -32,7 +32,7 @@ func (this *HTTPAccessLogPolicyService) FindAllEnabledHTTPAccessLogPolicies(ctx
 			Id:          int64(policy.Id),
 			Name:        policy.Name,
 			IsOn:        policy.IsOn == 1,
-			Type:        policy.Name,
+			Type:        policy.Type,
 			OptionsJSON: []byte(policy.Options),
 			CondsJSON:   []byte(policy.Conds),
 		})
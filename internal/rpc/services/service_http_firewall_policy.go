@@ -8,7 +8,6 @@ import (
 	"github.com/TeaOSLab/EdgeAPI/internal/errors"
 	"github.com/TeaOSLab/EdgeAPI/internal/iplibrary"
 	rpcutils "github.com/TeaOSLab/EdgeAPI/internal/rpc/utils"
-	"github.com/TeaOSLab/EdgeAPI/internal/utils"
 	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
 	"github.com/TeaOSLab/EdgeCommon/pkg/serverconfigs/firewallconfigs"
 	"github.com/iwind/TeaGo/lists"
@@ -648,7 +647,6 @@ func (this *HTTPFirewallPolicyService) CheckHTTPFirewallPolicyIPStatus(ctx conte
 			Error: "请输入正确的IP",
 		}, nil
 	}
-	ipLong := utils.IP2Long(req.Ip)
 
 	tx := this.NullTx()
 	firewallPolicy, err := models.SharedHTTPFirewallPolicyDAO.ComposeFirewallPolicy(tx, req.HttpFirewallPolicyId)
@@ -668,7 +666,7 @@ func (this *HTTPFirewallPolicyService) CheckHTTPFirewallPolicyIPStatus(ctx conte
 		firewallPolicy.Inbound.AllowListRef != nil &&
 		firewallPolicy.Inbound.AllowListRef.IsOn &&
 		firewallPolicy.Inbound.AllowListRef.ListId > 0 {
-		item, err := models.SharedIPItemDAO.FindEnabledItemContainsIP(tx, firewallPolicy.Inbound.AllowListRef.ListId, ipLong)
+		item, err := models.SharedIPItemDAO.FindEnabledItemContainsIP(tx, firewallPolicy.Inbound.AllowListRef.ListId, req.Ip)
 		if err != nil {
 			return nil, err
 		}
@@ -697,10 +695,10 @@ func (this *HTTPFirewallPolicyService) CheckHTTPFirewallPolicyIPStatus(ctx conte
 	// 检查黑名单
 	if firewallPolicy.Inbound != nil &&
 		firewallPolicy.Inbound.IsOn &&
-		firewallPolicy.Inbound.AllowListRef != nil &&
-		firewallPolicy.Inbound.AllowListRef.IsOn &&
-		firewallPolicy.Inbound.AllowListRef.ListId > 0 {
-		item, err := models.SharedIPItemDAO.FindEnabledItemContainsIP(tx, firewallPolicy.Inbound.DenyListRef.ListId, ipLong)
+		firewallPolicy.Inbound.DenyListRef != nil &&
+		firewallPolicy.Inbound.DenyListRef.IsOn &&
+		firewallPolicy.Inbound.DenyListRef.ListId > 0 {
+		item, err := models.SharedIPItemDAO.FindEnabledItemContainsIP(tx, firewallPolicy.Inbound.DenyListRef.ListId, req.Ip)
 		if err != nil {
 			return nil, err
 		}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
 	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"github.com/TeaOSLab/EdgeAPI/internal/iplists"
 	rpcutils "github.com/TeaOSLab/EdgeAPI/internal/rpc/utils"
+	"github.com/TeaOSLab/EdgeAPI/internal/utils"
 	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
-	"net"
+	"github.com/iwind/TeaGo/dbs"
 )
 
 // IP条目相关服务
@@ -15,26 +17,34 @@ type IPItemService struct {
 }
 
 // 创建IP
+// 边缘节点在执行WAF动作时也会调用此接口
 func (this *IPItemService) CreateIPItem(ctx context.Context, req *pb.CreateIPItemRequest) (*pb.CreateIPItemResponse, error) {
 	// 校验请求
-	_, userId, err := this.ValidateAdminAndUser(ctx, 0, 0)
+	role, nodeId, err := rpcutils.ValidateRequest(ctx, rpcutils.UserTypeAdmin, rpcutils.UserTypeUser, rpcutils.UserTypeNode)
 	if err != nil {
 		return nil, err
 	}
+	var adminId, userId int64
+	var actor *models.IPItemActor
+	if role == rpcutils.UserTypeNode {
+		actor = models.NewIPItemActor(models.IPItemActorTypeWAF, nodeId)
+	} else {
+		adminId, userId, err = this.ValidateAdminAndUser(ctx, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		actor = this.findActor(ctx, adminId, userId)
+	}
 
 	if len(req.IpFrom) == 0 {
 		return nil, errors.New("'ipFrom' should not be empty")
 	}
 
-	ipFrom := net.ParseIP(req.IpFrom)
-	if ipFrom == nil {
-		return nil, errors.New("invalid 'ipFrom'")
-	}
-
-	if len(req.IpTo) > 0 {
-		ipTo := net.ParseIP(req.IpTo)
-		if ipTo == nil {
-			return nil, errors.New("invalid 'ipTo'")
+	// ipFrom可以是CIDR
+	if req.Type != models.IPItemTypeAll {
+		_, _, err = utils.ParseIPRange(req.IpFrom, req.IpTo)
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -47,11 +57,24 @@ func (this *IPItemService) CreateIPItem(ctx context.Context, req *pb.CreateIPIte
 		}
 	}
 
+	if role == rpcutils.UserTypeNode {
+		// 节点不能封禁所有IP
+		if req.Type == models.IPItemTypeAll {
+			return nil, errors.New("node can not create ip item with type '" + models.IPItemTypeAll + "'")
+		}
+
+		// 节点只能使用所在集群的WAF策略引用的名单
+		err = models.SharedIPListDAO.CheckNodeIPList(tx, nodeId, req.IpListId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if len(req.Type) == 0 {
 		req.Type = models.IPItemTypeIPv4
 	}
 
-	itemId, err := models.SharedIPItemDAO.CreateIPItem(tx, req.IpListId, req.IpFrom, req.IpTo, req.ExpiredAt, req.Reason, req.Type, req.EventLevel)
+	itemId, err := models.SharedIPItemDAO.CreateIPItem(tx, actor, req.IpListId, req.IpFrom, req.IpTo, req.ExpiredAt, req.Reason, req.Type, req.EventLevel)
 	if err != nil {
 		return nil, err
 	}
@@ -62,11 +85,18 @@ func (this *IPItemService) CreateIPItem(ctx context.Context, req *pb.CreateIPIte
 // 修改IP
 func (this *IPItemService) UpdateIPItem(ctx context.Context, req *pb.UpdateIPItemRequest) (*pb.RPCSuccess, error) {
 	// 校验请求
-	_, userId, err := this.ValidateAdminAndUser(ctx, 0, 0)
+	adminId, userId, err := this.ValidateAdminAndUser(ctx, 0, 0)
 	if err != nil {
 		return nil, err
 	}
 
+	if req.Type != models.IPItemTypeAll {
+		_, _, err = utils.ParseIPRange(req.IpFrom, req.IpTo)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	tx := this.NullTx()
 
 	if userId > 0 {
@@ -85,7 +115,7 @@ func (this *IPItemService) UpdateIPItem(ctx context.Context, req *pb.UpdateIPIte
 		req.Type = models.IPItemTypeIPv4
 	}
 
-	err = models.SharedIPItemDAO.UpdateIPItem(tx, req.IpItemId, req.IpFrom, req.IpTo, req.ExpiredAt, req.Reason, req.Type, req.EventLevel)
+	err = models.SharedIPItemDAO.UpdateIPItem(tx, this.findActor(ctx, adminId, userId), req.IpItemId, req.IpFrom, req.IpTo, req.ExpiredAt, req.Reason, req.Type, req.EventLevel)
 	if err != nil {
 		return nil, err
 	}
@@ -96,7 +126,7 @@ func (this *IPItemService) UpdateIPItem(ctx context.Context, req *pb.UpdateIPIte
 // 删除IP
 func (this *IPItemService) DeleteIPItem(ctx context.Context, req *pb.DeleteIPItemRequest) (*pb.RPCSuccess, error) {
 	// 校验请求
-	_, userId, err := this.ValidateAdminAndUser(ctx, 0, 0)
+	adminId, userId, err := this.ValidateAdminAndUser(ctx, 0, 0)
 	if err != nil {
 		return nil, err
 	}
@@ -115,7 +145,7 @@ func (this *IPItemService) DeleteIPItem(ctx context.Context, req *pb.DeleteIPIte
 		}
 	}
 
-	err = models.SharedIPItemDAO.DisableIPItem(tx, req.IpItemId)
+	err = models.SharedIPItemDAO.DisableIPItem(tx, req.IpItemId, this.findActor(ctx, adminId, userId))
 	if err != nil {
 		return nil, err
 	}
@@ -273,3 +303,149 @@ func (this *IPItemService) ListIPItemsAfterVersion(ctx context.Context, req *pb.
 
 	return &pb.ListIPItemsAfterVersionResponse{IpItems: result}, nil
 }
+
+// 批量导入IP
+func (this *IPItemService) ImportIPItems(ctx context.Context, req *pb.ImportIPItemsRequest) (*pb.ImportIPItemsResponse, error) {
+	// 校验请求
+	adminId, userId, err := this.ValidateAdminAndUser(ctx, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	if userId > 0 {
+		err = models.SharedIPListDAO.CheckUserIPList(tx, userId, req.IpListId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := iplists.Parse(req.Format, req.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	actor := this.findActor(ctx, adminId, userId)
+	var countCreated, countSkipped int64
+	err = this.RunTx(func(tx *dbs.Tx) error {
+		countCreated, countSkipped, err = models.SharedIPItemDAO.ImportIPItems(tx, actor, req.IpListId, result.Entries, req.ExpiredAt, req.Reason)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ImportIPItemsResponse{
+		CountCreated: countCreated,
+		CountSkipped: countSkipped,
+		CountErrors:  int64(len(result.Errors)),
+		Errors:       result.Errors,
+	}, nil
+}
+
+// 导出IP
+func (this *IPItemService) ExportIPItems(ctx context.Context, req *pb.ExportIPItemsRequest) (*pb.ExportIPItemsResponse, error) {
+	// 校验请求
+	_, userId, err := this.ValidateAdminAndUser(ctx, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	if userId > 0 {
+		err = models.SharedIPListDAO.CheckUserIPList(tx, userId, req.IpListId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	items, err := models.SharedIPItemDAO.FindAllEnabledIPItemsWithListId(tx, req.IpListId)
+	if err != nil {
+		return nil, err
+	}
+	entries := []*iplists.Entry{}
+	for _, item := range items {
+		// 所有IP类型无法导出
+		if item.Type == models.IPItemTypeAll {
+			continue
+		}
+		entries = append(entries, &iplists.Entry{
+			IPFrom:    item.IpFrom,
+			IPTo:      item.IpTo,
+			ExpiredAt: int64(item.ExpiredAt),
+			Reason:    item.Reason,
+		})
+	}
+	data, err := iplists.Export(req.Format, entries)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ExportIPItemsResponse{
+		Data:  data,
+		Count: int64(len(entries)),
+	}, nil
+}
+
+// 计算某个IP的变更历史数量，包括所有名单
+func (this *IPItemService) CountIPItemHistoriesWithIP(ctx context.Context, req *pb.CountIPItemHistoriesWithIPRequest) (*pb.RPCCountResponse, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	count, err := models.SharedIPItemHistoryDAO.CountHistoriesWithIP(tx, req.Ip)
+	if err != nil {
+		return nil, err
+	}
+	return this.SuccessCount(count)
+}
+
+// 列出某个IP的变更历史，包括所有名单
+func (this *IPItemService) ListIPItemHistoriesWithIP(ctx context.Context, req *pb.ListIPItemHistoriesWithIPRequest) (*pb.ListIPItemHistoriesWithIPResponse, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	histories, err := models.SharedIPItemHistoryDAO.ListHistoriesWithIP(tx, req.Ip, req.Offset, req.Size)
+	if err != nil {
+		return nil, err
+	}
+	result := []*pb.IPItemHistory{}
+	for _, history := range histories {
+		result = append(result, &pb.IPItemHistory{
+			Id:        int64(history.Id),
+			IpItemId:  int64(history.ItemId),
+			IpListId:  int64(history.ListId),
+			Action:    history.Action,
+			ActorType: history.ActorType,
+			ActorId:   int64(history.ActorId),
+			IpFrom:    history.IpFrom,
+			IpTo:      history.IpTo,
+			Reason:    history.Reason,
+			ExpiredAt: int64(history.ExpiredAt),
+			CreatedAt: int64(history.CreatedAt),
+		})
+	}
+	return &pb.ListIPItemHistoriesWithIPResponse{IpItemHistories: result}, nil
+}
+
+// 当前操作者
+func (this *IPItemService) findActor(ctx context.Context, adminId int64, userId int64) *models.IPItemActor {
+	plainCtx, ok := ctx.(*rpcutils.PlainContext)
+	if ok && plainCtx.AccessTokenId > 0 {
+		return models.NewIPItemActor(models.IPItemActorTypeAPIToken, plainCtx.AccessTokenId)
+	}
+	if userId > 0 {
+		return models.NewIPItemActor(models.IPItemActorTypeUser, userId)
+	}
+	return models.NewIPItemActor(models.IPItemActorTypeAdmin, adminId)
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"github.com/TeaOSLab/EdgeAPI/internal/iplists"
+	"github.com/TeaOSLab/EdgeAPI/internal/tasks"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/iwind/TeaGo/types"
+	"net/url"
+)
+
+// IP名单订阅源相关服务
+// 因为订阅源需要API节点访问外部地址，所以只允许管理员操作
+type IPListFeedService struct {
+	BaseService
+}
+
+// 创建订阅源
+func (this *IPListFeedService) CreateIPListFeed(ctx context.Context, req *pb.CreateIPListFeedRequest) (*pb.CreateIPListFeedResponse, error) {
+	// 校验请求
+	adminId, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	err = this.validateFeed(req.Name, req.Url, req.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	list, err := models.SharedIPListDAO.FindEnabledIPList(tx, req.IpListId)
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		return nil, errors.New("can not find ip list '" + types.String(req.IpListId) + "'")
+	}
+
+	feedId, err := models.SharedIPListFeedDAO.CreateFeed(tx, adminId, req.IpListId, req.Name, req.Url, req.Format, req.SyncInterval, req.Reason, req.IsOn)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CreateIPListFeedResponse{IpListFeedId: feedId}, nil
+}
+
+// 修改订阅源
+func (this *IPListFeedService) UpdateIPListFeed(ctx context.Context, req *pb.UpdateIPListFeedRequest) (*pb.RPCSuccess, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	err = this.validateFeed(req.Name, req.Url, req.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	err = models.SharedIPListFeedDAO.UpdateFeed(tx, req.IpListFeedId, req.Name, req.Url, req.Format, req.SyncInterval, req.Reason, req.IsOn)
+	if err != nil {
+		return nil, err
+	}
+	return this.Success()
+}
+
+// 删除订阅源
+// 订阅源中已经加入的IP会被保留，由管理员自行删除
+func (this *IPListFeedService) DeleteIPListFeed(ctx context.Context, req *pb.DeleteIPListFeedRequest) (*pb.RPCSuccess, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	err = models.SharedIPListFeedDAO.DisableFeed(tx, req.IpListFeedId)
+	if err != nil {
+		return nil, err
+	}
+	return this.Success()
+}
+
+// 查找名单的所有订阅源
+func (this *IPListFeedService) FindAllEnabledIPListFeeds(ctx context.Context, req *pb.FindAllEnabledIPListFeedsRequest) (*pb.FindAllEnabledIPListFeedsResponse, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	feeds, err := models.SharedIPListFeedDAO.FindAllEnabledFeedsWithListId(tx, req.IpListId)
+	if err != nil {
+		return nil, err
+	}
+	result := []*pb.IPListFeed{}
+	for _, feed := range feeds {
+		result = append(result, &pb.IPListFeed{
+			Id:           int64(feed.Id),
+			IpListId:     int64(feed.ListId),
+			Name:         feed.Name,
+			Url:          feed.Url,
+			Format:       feed.Format,
+			SyncInterval: int64(feed.SyncInterval),
+			Reason:       feed.Reason,
+			IsOn:         feed.IsOn == 1,
+			CountItems:   int64(feed.CountItems),
+			LastSyncAt:   int64(feed.LastSyncAt),
+			NextSyncAt:   int64(feed.NextSyncAt),
+			Error:        feed.Error,
+		})
+	}
+	return &pb.FindAllEnabledIPListFeedsResponse{IpListFeeds: result}, nil
+}
+
+// 立即同步订阅源
+func (this *IPListFeedService) SyncIPListFeed(ctx context.Context, req *pb.SyncIPListFeedRequest) (*pb.RPCSuccess, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tasks.NewIPListFeedTask().SyncFeed(req.IpListFeedId)
+	if err != nil {
+		return nil, err
+	}
+	return this.Success()
+}
+
+// 校验订阅源参数
+func (this *IPListFeedService) validateFeed(name string, feedURL string, format string) error {
+	if len(name) == 0 {
+		return errors.New("'name' should not be empty")
+	}
+	u, err := url.Parse(feedURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return errors.New("invalid feed url '" + feedURL + "'")
+	}
+	switch format {
+	case iplists.FormatAuto, iplists.FormatText, iplists.FormatCSV, iplists.FormatJSON:
+	default:
+		return errors.New("invalid format '" + format + "'")
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/TeaOSLab/EdgeAPI/internal/notifications"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/iwind/TeaGo/maps"
+	"time"
+)
+
+// 消息通知渠道相关服务
+type MessageChannelService struct {
+	BaseService
+}
+
+// 创建渠道
+func (this *MessageChannelService) CreateMessageChannel(ctx context.Context, req *pb.CreateMessageChannelRequest) (*pb.CreateMessageChannelResponse, error) {
+	// 校验请求
+	adminId, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if notifications.FindChannel(req.Type) == nil {
+		return nil, errors.New("invalid channel type '" + req.Type + "'")
+	}
+
+	tx := this.NullTx()
+
+	channelId, err := models.SharedMessageChannelDAO.CreateMessageChannel(tx, adminId, req.Name, req.Type, req.ParamsJSON, req.Levels, req.MessageTypes, req.RateLimit, req.DedupSeconds, req.IsOn)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CreateMessageChannelResponse{MessageChannelId: channelId}, nil
+}
+
+// 修改渠道
+func (this *MessageChannelService) UpdateMessageChannel(ctx context.Context, req *pb.UpdateMessageChannelRequest) (*pb.RPCSuccess, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	err = models.SharedMessageChannelDAO.UpdateMessageChannel(tx, req.MessageChannelId, req.Name, req.ParamsJSON, req.Levels, req.MessageTypes, req.RateLimit, req.DedupSeconds, req.IsOn)
+	if err != nil {
+		return nil, err
+	}
+	return this.Success()
+}
+
+// 删除渠道
+func (this *MessageChannelService) DeleteMessageChannel(ctx context.Context, req *pb.DeleteMessageChannelRequest) (*pb.RPCSuccess, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	err = models.SharedMessageChannelDAO.DisableMessageChannel(tx, req.MessageChannelId)
+	if err != nil {
+		return nil, err
+	}
+	return this.Success()
+}
+
+// 查找单个渠道
+func (this *MessageChannelService) FindEnabledMessageChannel(ctx context.Context, req *pb.FindEnabledMessageChannelRequest) (*pb.FindEnabledMessageChannelResponse, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	channel, err := models.SharedMessageChannelDAO.FindEnabledMessageChannel(tx, req.MessageChannelId)
+	if err != nil {
+		return nil, err
+	}
+	if channel == nil {
+		return &pb.FindEnabledMessageChannelResponse{MessageChannel: nil}, nil
+	}
+	return &pb.FindEnabledMessageChannelResponse{MessageChannel: this.convertMessageChannel(channel)}, nil
+}
+
+// 查找所有渠道
+func (this *MessageChannelService) FindAllEnabledMessageChannels(ctx context.Context, req *pb.FindAllEnabledMessageChannelsRequest) (*pb.FindAllEnabledMessageChannelsResponse, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	channels, err := models.SharedMessageChannelDAO.FindAllEnabledMessageChannels(tx)
+	if err != nil {
+		return nil, err
+	}
+	result := []*pb.MessageChannel{}
+	for _, channel := range channels {
+		result = append(result, this.convertMessageChannel(channel))
+	}
+	return &pb.FindAllEnabledMessageChannelsResponse{MessageChannels: result}, nil
+}
+
+// 查找所有渠道类型
+func (this *MessageChannelService) FindAllMessageChannelTypes(ctx context.Context, req *pb.FindAllMessageChannelTypesRequest) (*pb.FindAllMessageChannelTypesResponse, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*pb.MessageChannelType{}
+	for _, t := range notifications.AllChannelTypes {
+		result = append(result, &pb.MessageChannelType{
+			Name: t.GetString("name"),
+			Code: t.GetString("code"),
+		})
+	}
+	return &pb.FindAllMessageChannelTypesResponse{MessageChannelTypes: result}, nil
+}
+
+// 发送测试消息
+func (this *MessageChannelService) TestMessageChannel(ctx context.Context, req *pb.TestMessageChannelRequest) (*pb.TestMessageChannelResponse, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	channel := notifications.FindChannel(req.Type)
+	if channel == nil {
+		return &pb.TestMessageChannelResponse{IsOk: false, Error: "invalid channel type '" + req.Type + "'"}, nil
+	}
+	params := maps.Map{}
+	if len(req.ParamsJSON) > 0 {
+		err = json.Unmarshal(req.ParamsJSON, &params)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = channel.Init(params)
+	if err != nil {
+		return &pb.TestMessageChannelResponse{IsOk: false, Error: err.Error()}, nil
+	}
+
+	body := req.Body
+	if len(body) == 0 {
+		body = "这是一条测试消息"
+	}
+	err = channel.Send(&notifications.Message{
+		Type:      "Test",
+		Level:     models.MessageLevelInfo,
+		Body:      body,
+		CreatedAt: time.Now().Unix(),
+	})
+	if err != nil {
+		return &pb.TestMessageChannelResponse{IsOk: false, Error: err.Error()}, nil
+	}
+	return &pb.TestMessageChannelResponse{IsOk: true}, nil
+}
+
+// 计算发送记录数量
+func (this *MessageChannelService) CountMessageDeliveries(ctx context.Context, req *pb.CountMessageDeliveriesRequest) (*pb.RPCCountResponse, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	count, err := models.SharedMessageDeliveryDAO.CountDeliveries(tx, req.MessageChannelId, req.MessageId)
+	if err != nil {
+		return nil, err
+	}
+	return this.SuccessCount(count)
+}
+
+// 列出单页发送记录
+func (this *MessageChannelService) ListMessageDeliveries(ctx context.Context, req *pb.ListMessageDeliveriesRequest) (*pb.ListMessageDeliveriesResponse, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	deliveries, err := models.SharedMessageDeliveryDAO.ListDeliveries(tx, req.MessageChannelId, req.MessageId, req.Offset, req.Size)
+	if err != nil {
+		return nil, err
+	}
+	result := []*pb.MessageDelivery{}
+	for _, delivery := range deliveries {
+		result = append(result, &pb.MessageDelivery{
+			Id:               int64(delivery.Id),
+			MessageChannelId: int64(delivery.ChannelId),
+			MessageId:        int64(delivery.MessageId),
+			IsOk:             delivery.IsOk == 1,
+			Error:            delivery.Error,
+			CreatedAt:        int64(delivery.CreatedAt),
+		})
+	}
+	return &pb.ListMessageDeliveriesResponse{MessageDeliveries: result}, nil
+}
+
+// 转换渠道
+func (this *MessageChannelService) convertMessageChannel(channel *models.MessageChannel) *pb.MessageChannel {
+	return &pb.MessageChannel{
+		Id:           int64(channel.Id),
+		Name:         channel.Name,
+		Type:         channel.Type,
+		TypeName:     notifications.FindChannelTypeName(channel.Type),
+		ParamsJSON:   []byte(channel.Params),
+		Levels:       channel.DecodeLevels(),
+		MessageTypes: channel.DecodeMessageTypes(),
+		RateLimit:    int32(channel.RateLimit),
+		DedupSeconds: int32(channel.DedupSeconds),
+		IsOn:         channel.IsOn == 1,
+	}
+}
@@ -408,10 +408,12 @@ func (this *NodeService) FindEnabledNode(ctx context.Context, req *pb.FindEnable
 	var respLogin *pb.NodeLogin = nil
 	if login != nil {
 		respLogin = &pb.NodeLogin{
-			Id:     int64(login.Id),
-			Name:   login.Name,
-			Type:   login.Type,
-			Params: []byte(login.Params),
+			Id:             int64(login.Id),
+			Name:           login.Name,
+			Type:           login.Type,
+			Params:         []byte(login.Params),
+			HostKey:        login.HostKey,
+			PendingHostKey: login.PendingHostKey,
 		}
 	}
 
@@ -794,6 +796,7 @@ func (this *NodeService) FindAllNotInstalledNodesWithClusterId(ctx context.Conte
 				State:       int64(address.State),
 				Order:       int64(address.Order),
 				CanAccess:   address.CanAccess == 1,
+				IsUp:        address.IsUp == 1,
 			})
 		}
 
@@ -903,6 +906,7 @@ func (this *NodeService) FindAllUpgradeNodesWithClusterId(ctx context.Context, r
 					State:       int64(address.State),
 					Order:       int64(address.Order),
 					CanAccess:   address.CanAccess == 1,
+					IsUp:        address.IsUp == 1,
 				})
 			}
 
@@ -1009,6 +1013,36 @@ func (this *NodeService) UpdateNodeLogin(ctx context.Context, req *pb.UpdateNode
 	return this.Success()
 }
 
+// 接受节点新的主机公钥
+func (this *NodeService) AcceptNodeLoginHostKey(ctx context.Context, req *pb.AcceptNodeLoginHostKeyRequest) (*pb.RPCSuccess, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.Fingerprint) == 0 {
+		return nil, errors.New("'fingerprint' should not be empty")
+	}
+
+	tx := this.NullTx()
+
+	login, err := models.SharedNodeLoginDAO.FindEnabledNodeLoginWithNodeId(tx, req.NodeId)
+	if err != nil {
+		return nil, err
+	}
+	if login == nil {
+		return nil, errors.New("can not find node login information")
+	}
+
+	err = models.SharedNodeLoginDAO.AcceptNodeLoginHostKey(tx, int64(login.Id), req.Fingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	return this.Success()
+}
+
 // 计算某个节点分组内的节点数量
 func (this *NodeService) CountAllEnabledNodesWithNodeGroupId(ctx context.Context, req *pb.CountAllEnabledNodesWithNodeGroupIdRequest) (*pb.RPCCountResponse, error) {
 	// 校验请求
@@ -1056,7 +1090,7 @@ func (this *NodeService) FindAllEnabledNodesDNSWithClusterId(ctx context.Context
 	}
 	result := []*pb.NodeDNSInfo{}
 	for _, node := range nodes {
-		ipAddresses, err := models.SharedNodeIPAddressDAO.FindNodeAccessIPAddresses(tx, int64(node.Id))
+		ipAddresses, err := models.SharedNodeIPAddressDAO.FindNodeAccessAndUpIPAddresses(tx, int64(node.Id))
 		if err != nil {
 			return nil, err
 		}
@@ -1282,3 +1316,82 @@ func (this *NodeService) CheckNodeLatestVersion(ctx context.Context, req *pb.Che
 	}
 	return &pb.CheckNodeLatestVersionResponse{HasNewVersion: false}, nil
 }
+
+// 上报节点之间互相健康检查的结果
+func (this *NodeService) UploadNodeHealthCheckResults(ctx context.Context, req *pb.UploadNodeHealthCheckResultsRequest) (*pb.RPCSuccess, error) {
+	// 校验节点
+	_, nodeId, err := rpcutils.ValidateRequest(ctx, rpcutils.UserTypeNode)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	clusterId, err := models.SharedNodeDAO.FindNodeClusterId(tx, nodeId)
+	if err != nil {
+		return nil, err
+	}
+	if clusterId <= 0 {
+		return this.Success()
+	}
+
+	for _, result := range req.Results {
+		// 只能检查同一个集群中的其他节点
+		if result.NodeId == nodeId {
+			continue
+		}
+		targetClusterId, err := models.SharedNodeDAO.FindNodeClusterId(tx, result.NodeId)
+		if err != nil {
+			return nil, err
+		}
+		if targetClusterId != clusterId {
+			continue
+		}
+
+		err = models.SharedNodeHealthCheckResultDAO.UpdateNodeHealthCheckResult(tx, clusterId, result.NodeId, 0, models.NodeHealthCheckVantageTypeNode, nodeId, result.IsOk, result.Error, result.CostMs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return this.Success()
+}
+
+// 查找节点在各个检查点的健康检查结果
+func (this *NodeService) FindNodeHealthCheckResults(ctx context.Context, req *pb.FindNodeHealthCheckResultsRequest) (*pb.FindNodeHealthCheckResultsResponse, error) {
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	results, err := models.SharedNodeHealthCheckResultDAO.FindAllNodeHealthCheckResults(tx, req.NodeId)
+	if err != nil {
+		return nil, err
+	}
+	pbResults := []*pb.NodeHealthCheckResult{}
+	for _, result := range results {
+		var vantageName string
+		switch result.VantageType {
+		case models.NodeHealthCheckVantageTypeAPI:
+			vantageName, err = models.SharedAPINodeDAO.FindAPINodeName(tx, int64(result.VantageId))
+		case models.NodeHealthCheckVantageTypeNode:
+			vantageName, err = models.SharedNodeDAO.FindNodeName(tx, int64(result.VantageId))
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		pbResults = append(pbResults, &pb.NodeHealthCheckResult{
+			NodeId:      int64(result.NodeId),
+			VantageType: result.VantageType,
+			VantageId:   int64(result.VantageId),
+			VantageName: vantageName,
+			IsOk:        result.IsOk == 1,
+			Error:       result.Error,
+			CostMs:      int64(result.CostMs),
+			UpdatedAt:   int64(result.UpdatedAt),
+		})
+	}
+	return &pb.FindNodeHealthCheckResultsResponse{Results: pbResults}, nil
+}
@@ -293,6 +293,19 @@ func (this *NodeClusterService) UpdateNodeClusterHealthCheck(ctx context.Context
 		return nil, err
 	}
 
+	// 校验分布式检查的法定数量
+	if len(req.HealthCheckJSON) > 0 {
+		probesConfig := &tasks.HealthCheckProbesConfig{}
+		err = json.Unmarshal(req.HealthCheckJSON, probesConfig)
+		if err != nil {
+			return nil, err
+		}
+		err = probesConfig.ValidateQuorum()
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	tx := this.NullTx()
 
 	err = models.SharedNodeClusterDAO.UpdateClusterHealthCheck(tx, req.NodeClusterId, req.HealthCheckJSON)
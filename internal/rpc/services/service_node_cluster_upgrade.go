@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/TeaOSLab/EdgeAPI/internal/installers"
+	"github.com/TeaOSLab/EdgeAPI/internal/tasks"
+	"github.com/TeaOSLab/EdgeAPI/internal/utils/numberutils"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/iwind/TeaGo/dbs"
+	"github.com/iwind/TeaGo/lists"
+	"time"
+)
+
+// 集群滚动升级相关服务
+type NodeClusterUpgradeService struct {
+	BaseService
+}
+
+// 创建集群升级任务
+// 每批升级 BatchSize 个节点，所有节点以新版本重新连接并通过健康检查后，等待 BatchDelay 秒再开始下一批
+func (this *NodeClusterUpgradeService) CreateNodeClusterUpgrade(ctx context.Context, req *pb.CreateNodeClusterUpgradeRequest) (*pb.CreateNodeClusterUpgradeResponse, error) {
+	// 校验请求
+	adminId, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	cluster, err := models.SharedNodeClusterDAO.FindEnabledNodeCluster(tx, req.NodeClusterId)
+	if err != nil {
+		return nil, err
+	}
+	if cluster == nil {
+		return nil, errors.New("can not find cluster with id '" + numberutils.FormatInt64(req.NodeClusterId) + "'")
+	}
+
+	// 同一个集群同时只能有一个升级任务
+	activeUpgrade, err := models.SharedNodeClusterUpgradeDAO.FindActiveUpgradeWithClusterId(tx, req.NodeClusterId)
+	if err != nil {
+		return nil, err
+	}
+	if activeUpgrade != nil {
+		return nil, errors.New("there is already an upgrade running in the cluster")
+	}
+
+	// 需要升级的节点
+	type upgradeNode struct {
+		node       *models.Node
+		os         string
+		arch       string
+		oldVersion string
+		newVersion string
+	}
+	upgradeNodes := []*upgradeNode{}
+	for _, deployFile := range installers.SharedDeployManager.LoadFiles() {
+		nodes, err := models.SharedNodeDAO.FindAllLowerVersionNodesWithClusterId(tx, req.NodeClusterId, deployFile.OS, deployFile.Arch, deployFile.Version)
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range nodes {
+			if len(req.NodeIds) > 0 && !lists.ContainsInt64(req.NodeIds, int64(node.Id)) {
+				continue
+			}
+			status, err := node.DecodeStatus()
+			if err != nil {
+				return nil, err
+			}
+			if status == nil {
+				continue
+			}
+			upgradeNodes = append(upgradeNodes, &upgradeNode{
+				node:       node,
+				os:         deployFile.OS,
+				arch:       deployFile.Arch,
+				oldVersion: status.BuildVersion,
+				newVersion: deployFile.Version,
+			})
+		}
+	}
+	if len(upgradeNodes) == 0 {
+		return nil, errors.New("there are no nodes to upgrade")
+	}
+
+	var upgradeId int64
+	err = this.RunTx(func(tx *dbs.Tx) error {
+		upgradeId, err = models.SharedNodeClusterUpgradeDAO.CreateUpgrade(tx, adminId, req.NodeClusterId, req.BatchSize, req.BatchDelay, req.MaxFailures, req.NodeTimeout, req.RemoveFromDNS)
+		if err != nil {
+			return err
+		}
+		for _, n := range upgradeNodes {
+			err = models.SharedNodeClusterUpgradeNodeDAO.CreateUpgradeNode(tx, upgradeId, req.NodeClusterId, int64(n.node.Id), n.os, n.arch, n.oldVersion, n.newVersion)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.CreateNodeClusterUpgradeResponse{NodeClusterUpgradeId: upgradeId}, nil
+}
+
+// 暂停升级
+// 正在升级的节点会继续完成，但不会开始新的批次
+func (this *NodeClusterUpgradeService) PauseNodeClusterUpgrade(ctx context.Context, req *pb.PauseNodeClusterUpgradeRequest) (*pb.RPCSuccess, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	upgrade, err := models.SharedNodeClusterUpgradeDAO.FindEnabledUpgrade(tx, req.NodeClusterUpgradeId)
+	if err != nil {
+		return nil, err
+	}
+	if upgrade == nil || upgrade.Status != models.NodeClusterUpgradeStatusRunning {
+		return nil, errors.New("the upgrade is not running")
+	}
+
+	err = models.SharedNodeClusterUpgradeDAO.UpdateUpgradeStatus(tx, req.NodeClusterUpgradeId, models.NodeClusterUpgradeStatusPaused, "")
+	if err != nil {
+		return nil, err
+	}
+	return this.Success()
+}
+
+// 继续升级
+// 可以继续已暂停或者因为失败过多而停止的升级
+func (this *NodeClusterUpgradeService) ResumeNodeClusterUpgrade(ctx context.Context, req *pb.ResumeNodeClusterUpgradeRequest) (*pb.RPCSuccess, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	upgrade, err := models.SharedNodeClusterUpgradeDAO.FindEnabledUpgrade(tx, req.NodeClusterUpgradeId)
+	if err != nil {
+		return nil, err
+	}
+	if upgrade == nil || (upgrade.Status != models.NodeClusterUpgradeStatusPaused && upgrade.Status != models.NodeClusterUpgradeStatusHalted) {
+		return nil, errors.New("the upgrade can not be resumed")
+	}
+
+	if upgrade.Status == models.NodeClusterUpgradeStatusHalted {
+		activeUpgrade, err := models.SharedNodeClusterUpgradeDAO.FindActiveUpgradeWithClusterId(tx, int64(upgrade.ClusterId))
+		if err != nil {
+			return nil, err
+		}
+		if activeUpgrade != nil {
+			return nil, errors.New("there is already an upgrade running in the cluster")
+		}
+
+		// 停止时的批次已经结算过，直接开始下一批次
+		err = models.SharedNodeClusterUpgradeDAO.UpdateUpgradeNextBatchAt(tx, req.NodeClusterUpgradeId, time.Now().Unix())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = models.SharedNodeClusterUpgradeDAO.UpdateUpgradeStatus(tx, req.NodeClusterUpgradeId, models.NodeClusterUpgradeStatusRunning, "")
+	if err != nil {
+		return nil, err
+	}
+	return this.Success()
+}
+
+// 取消升级
+func (this *NodeClusterUpgradeService) CancelNodeClusterUpgrade(ctx context.Context, req *pb.CancelNodeClusterUpgradeRequest) (*pb.RPCSuccess, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tasks.NewNodeClusterUpgradeTask().Cancel(req.NodeClusterUpgradeId)
+	if err != nil {
+		return nil, err
+	}
+	return this.Success()
+}
+
+// 查找单个升级任务，包括所有节点的升级进度
+func (this *NodeClusterUpgradeService) FindEnabledNodeClusterUpgrade(ctx context.Context, req *pb.FindEnabledNodeClusterUpgradeRequest) (*pb.FindEnabledNodeClusterUpgradeResponse, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	upgrade, err := models.SharedNodeClusterUpgradeDAO.FindEnabledUpgrade(tx, req.NodeClusterUpgradeId)
+	if err != nil {
+		return nil, err
+	}
+	if upgrade == nil {
+		return &pb.FindEnabledNodeClusterUpgradeResponse{NodeClusterUpgrade: nil}, nil
+	}
+
+	upgradeNodes, err := models.SharedNodeClusterUpgradeNodeDAO.FindAllUpgradeNodes(tx, req.NodeClusterUpgradeId)
+	if err != nil {
+		return nil, err
+	}
+	pbUpgradeNodes := []*pb.NodeClusterUpgradeNode{}
+	for _, upgradeNode := range upgradeNodes {
+		pbUpgradeNodes = append(pbUpgradeNodes, &pb.NodeClusterUpgradeNode{
+			Id:               int64(upgradeNode.Id),
+			NodeId:           int64(upgradeNode.NodeId),
+			Os:               upgradeNode.Os,
+			Arch:             upgradeNode.Arch,
+			OldVersion:       upgradeNode.OldVersion,
+			NewVersion:       upgradeNode.NewVersion,
+			Batch:            int32(upgradeNode.Batch),
+			Status:           upgradeNode.Status,
+			CountTries:       int32(upgradeNode.CountTries),
+			IsRemovedFromDNS: upgradeNode.IsRemovedFromDNS == 1,
+			Error:            upgradeNode.Error,
+			StartedAt:        int64(upgradeNode.StartedAt),
+			FinishedAt:       int64(upgradeNode.FinishedAt),
+		})
+	}
+
+	pbUpgrade := this.convertNodeClusterUpgrade(upgrade)
+	pbUpgrade.Nodes = pbUpgradeNodes
+	return &pb.FindEnabledNodeClusterUpgradeResponse{NodeClusterUpgrade: pbUpgrade}, nil
+}
+
+// 查找集群最近的升级任务
+func (this *NodeClusterUpgradeService) FindLatestNodeClusterUpgrades(ctx context.Context, req *pb.FindLatestNodeClusterUpgradesRequest) (*pb.FindLatestNodeClusterUpgradesResponse, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	upgrades, err := models.SharedNodeClusterUpgradeDAO.FindLatestUpgradesWithClusterId(tx, req.NodeClusterId, req.Size)
+	if err != nil {
+		return nil, err
+	}
+	result := []*pb.NodeClusterUpgrade{}
+	for _, upgrade := range upgrades {
+		result = append(result, this.convertNodeClusterUpgrade(upgrade))
+	}
+	return &pb.FindLatestNodeClusterUpgradesResponse{NodeClusterUpgrades: result}, nil
+}
+
+// 转换升级任务
+func (this *NodeClusterUpgradeService) convertNodeClusterUpgrade(upgrade *models.NodeClusterUpgrade) *pb.NodeClusterUpgrade {
+	return &pb.NodeClusterUpgrade{
+		Id:            int64(upgrade.Id),
+		NodeClusterId: int64(upgrade.ClusterId),
+		BatchSize:     int32(upgrade.BatchSize),
+		BatchDelay:    int32(upgrade.BatchDelay),
+		MaxFailures:   int32(upgrade.MaxFailures),
+		NodeTimeout:   int32(upgrade.NodeTimeout),
+		RemoveFromDNS: upgrade.RemoveFromDNS == 1,
+		Status:        upgrade.Status,
+		Batch:         int32(upgrade.Batch),
+		NextBatchAt:   int64(upgrade.NextBatchAt),
+		Error:         upgrade.Error,
+		CreatedAt:     int64(upgrade.CreatedAt),
+		UpdatedAt:     int64(upgrade.UpdatedAt),
+		FinishedAt:    int64(upgrade.FinishedAt),
+	}
+}
@@ -18,9 +18,15 @@ func (this *NodeGrantService) CreateNodeGrant(ctx context.Context, req *pb.Creat
 		return nil, err
 	}
 
+	// 校验跳板机
+	_, err = models.DecodeNodeGrantJumpHosts(req.JumpHostsJSON)
+	if err != nil {
+		return nil, errors.New("invalid jump hosts: " + err.Error())
+	}
+
 	tx := this.NullTx()
 
-	grantId, err := models.SharedNodeGrantDAO.CreateGrant(tx, adminId, req.Name, req.Method, req.Username, req.Password, req.PrivateKey, req.Description, req.NodeId)
+	grantId, err := models.SharedNodeGrantDAO.CreateGrant(tx, adminId, req.Name, req.Method, req.Username, req.Password, req.PrivateKey, req.Passphrase, req.Sudo, req.SudoPassword, req.KnownHosts, req.JumpHostsJSON, req.Description, req.NodeId)
 	if err != nil {
 		return nil, err
 	}
@@ -39,9 +45,18 @@ func (this *NodeGrantService) UpdateNodeGrant(ctx context.Context, req *pb.Updat
 		return nil, errors.New("wrong grantId")
 	}
 
+	// 校验跳板机
+	_, err = models.DecodeNodeGrantJumpHosts(req.JumpHostsJSON)
+	if err != nil {
+		return nil, errors.New("invalid jump hosts: " + err.Error())
+	}
+
 	tx := this.NullTx()
 
-	err = models.SharedNodeGrantDAO.UpdateGrant(tx, req.GrantId, req.Name, req.Method, req.Username, req.Password, req.PrivateKey, req.Description, req.NodeId)
+	err = models.SharedNodeGrantDAO.UpdateGrant(tx, req.GrantId, req.Name, req.Method, req.Username, req.Password, req.PrivateKey, req.Passphrase, req.Sudo, req.SudoPassword, req.KnownHosts, req.JumpHostsJSON, req.Description, req.NodeId)
+	if err != nil {
+		return nil, err
+	}
 	return this.Success()
 }
 
@@ -142,14 +157,19 @@ func (this *NodeGrantService) FindEnabledGrant(ctx context.Context, req *pb.Find
 		return &pb.FindEnabledGrantResponse{}, nil
 	}
 	return &pb.FindEnabledGrantResponse{Grant: &pb.NodeGrant{
-		Id:          int64(grant.Id),
-		Name:        grant.Name,
-		Method:      grant.Method,
-		Username:    grant.Username,
-		Password:    grant.Password,
-		Su:          grant.Su == 1,
-		PrivateKey:  grant.PrivateKey,
-		Description: grant.Description,
-		NodeId:      int64(grant.NodeId),
+		Id:            int64(grant.Id),
+		Name:          grant.Name,
+		Method:        grant.Method,
+		Username:      grant.Username,
+		Password:      grant.Password,
+		Su:            grant.Su == 1,
+		PrivateKey:    grant.PrivateKey,
+		Passphrase:    grant.Passphrase,
+		Sudo:          grant.Sudo == 1,
+		SudoPassword:  grant.SudoPassword,
+		KnownHosts:    grant.KnownHosts,
+		JumpHostsJSON: []byte(grant.JumpHosts),
+		Description:   grant.Description,
+		NodeId:        int64(grant.NodeId),
 	}}, nil
 }
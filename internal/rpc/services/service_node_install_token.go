@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/TeaOSLab/EdgeAPI/internal/utils/numberutils"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"time"
+)
+
+// 节点安装令牌相关服务
+type NodeInstallTokenService struct {
+	BaseService
+}
+
+// 创建安装令牌
+func (this *NodeInstallTokenService) CreateNodeInstallToken(ctx context.Context, req *pb.CreateNodeInstallTokenRequest) (*pb.CreateNodeInstallTokenResponse, error) {
+	// 校验请求
+	adminId, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	cluster, err := models.SharedNodeClusterDAO.FindEnabledNodeCluster(tx, req.NodeClusterId)
+	if err != nil {
+		return nil, err
+	}
+	if cluster == nil {
+		return nil, errors.New("can not find cluster with id '" + numberutils.FormatInt64(req.NodeClusterId) + "'")
+	}
+
+	// 顺便清除过期很久的令牌
+	err = models.SharedNodeInstallTokenDAO.DeleteExpiredInstallTokens(tx, time.Now().AddDate(0, 0, -7).Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	tokenId, token, err := models.SharedNodeInstallTokenDAO.CreateInstallToken(tx, adminId, req.NodeClusterId, req.LifeSeconds)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CreateNodeInstallTokenResponse{
+		NodeInstallTokenId: tokenId,
+		Token:              token,
+	}, nil
+}
+
+// 删除安装令牌
+func (this *NodeInstallTokenService) DeleteNodeInstallToken(ctx context.Context, req *pb.DeleteNodeInstallTokenRequest) (*pb.RPCSuccess, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	err = models.SharedNodeInstallTokenDAO.DisableInstallToken(tx, req.NodeInstallTokenId)
+	if err != nil {
+		return nil, err
+	}
+	return this.Success()
+}
+
+// 查找集群的所有安装令牌
+func (this *NodeInstallTokenService) FindAllEnabledNodeInstallTokens(ctx context.Context, req *pb.FindAllEnabledNodeInstallTokensRequest) (*pb.FindAllEnabledNodeInstallTokensResponse, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	tokens, err := models.SharedNodeInstallTokenDAO.FindAllEnabledInstallTokensWithClusterId(tx, req.NodeClusterId)
+	if err != nil {
+		return nil, err
+	}
+	result := []*pb.NodeInstallToken{}
+	for _, token := range tokens {
+		result = append(result, &pb.NodeInstallToken{
+			Id:            int64(token.Id),
+			NodeClusterId: int64(token.ClusterId),
+			Token:         token.Token,
+			ExpiredAt:     int64(token.ExpiredAt),
+			IsUsed:        token.IsUsed == 1,
+			UsedAt:        int64(token.UsedAt),
+			NodeId:        int64(token.NodeId),
+			CreatedAt:     int64(token.CreatedAt),
+		})
+	}
+	return &pb.FindAllEnabledNodeInstallTokensResponse{NodeInstallTokens: result}, nil
+}
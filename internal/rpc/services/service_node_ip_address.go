@@ -127,6 +127,7 @@ func (this *NodeIPAddressService) FindEnabledNodeIPAddress(ctx context.Context,
 			State:       int64(address.State),
 			Order:       int64(address.Order),
 			CanAccess:   address.CanAccess == 1,
+			IsUp:        address.IsUp == 1,
 		}
 	}
 
@@ -159,6 +160,7 @@ func (this *NodeIPAddressService) FindAllEnabledIPAddressesWithNodeId(ctx contex
 			State:       int64(address.State),
 			Order:       int64(address.Order),
 			CanAccess:   address.CanAccess == 1,
+			IsUp:        address.IsUp == 1,
 		})
 	}
 
@@ -7,12 +7,14 @@ import (
 	"github.com/TeaOSLab/EdgeAPI/internal/configs"
 	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
 	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"github.com/TeaOSLab/EdgeAPI/internal/rpc/clients"
 	rpcutils "github.com/TeaOSLab/EdgeAPI/internal/rpc/utils"
 	"github.com/TeaOSLab/EdgeAPI/internal/utils/numberutils"
 	"github.com/TeaOSLab/EdgeCommon/pkg/messageconfigs"
 	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
 	"github.com/iwind/TeaGo/logs"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -43,6 +45,9 @@ var commandRequestId = int64(0)
 
 var nodeLocker = &sync.Mutex{}
 var requestChanMap = map[int64]chan *CommandRequest{} // node id => chan
+var nodeStreamCountMap = map[int64]int{}              // node id => 当前API节点上的连接数
+
+const nodeNotConnectedMessage = "not connected yet"
 
 func NextCommandRequestId() int64 {
 	return atomic.AddInt64(&commandRequestId, 1)
@@ -67,19 +72,20 @@ func init() {
 
 // 节点stream
 func (this *NodeService) NodeStream(server pb.NodeService_NodeStreamServer) error {
-	// TODO 使用此stream快速通知边缘节点更新
 	// 校验节点
 	_, nodeId, err := rpcutils.ValidateRequest(server.Context(), rpcutils.UserTypeNode)
 	if err != nil {
 		return err
 	}
 
+	apiConfig, err := configs.SharedAPIConfig()
+	if err != nil {
+		return err
+	}
+	apiNodeId := apiConfig.NumberId()
+
 	// 返回连接成功
 	{
-		apiConfig, err := configs.SharedAPIConfig()
-		if err != nil {
-			return err
-		}
 		connectedMessage := &messageconfigs.ConnectedAPINodeMessage{APINodeId: apiConfig.NumberId()}
 		connectedMessageJSON, err := json.Marshal(connectedMessage)
 		if err != nil {
@@ -126,8 +132,15 @@ func (this *NodeService) NodeStream(server pb.NodeService_NodeStreamServer) erro
 		requestChan = make(chan *CommandRequest, 1024)
 		requestChanMap[nodeId] = requestChan
 	}
+	nodeStreamCountMap[nodeId]++
 	nodeLocker.Unlock()
 
+	// 记录节点连接的API节点，以便其他API节点转发命令
+	err = models.SharedNodeDAO.AddNodeConnectedAPINode(tx, nodeId, apiNodeId)
+	if err != nil {
+		logs.Println("[RPC]add connected api node failed: " + err.Error())
+	}
+
 	// 发送请求
 	go func() {
 		for {
@@ -167,6 +180,20 @@ func (this *NodeService) NodeStream(server pb.NodeService_NodeStreamServer) erro
 				logs.Println(err1.Error())
 			}
 
+			nodeLocker.Lock()
+			nodeStreamCountMap[nodeId]--
+			isDisconnected := nodeStreamCountMap[nodeId] <= 0
+			if isDisconnected {
+				delete(nodeStreamCountMap, nodeId)
+			}
+			nodeLocker.Unlock()
+			if isDisconnected {
+				err1 = models.SharedNodeDAO.RemoveNodeConnectedAPINode(tx, nodeId, apiNodeId)
+				if err1 != nil {
+					logs.Println(err1.Error())
+				}
+			}
+
 			return err
 		}
 
@@ -191,27 +218,176 @@ func (this *NodeService) NodeStream(server pb.NodeService_NodeStreamServer) erro
 }
 
 // 向节点发送命令
+// 如果节点没有连接到当前API节点，则转发到节点连接的API节点上
 func (this *NodeService) SendCommandToNode(ctx context.Context, req *pb.NodeStreamMessage) (*pb.NodeStreamMessage, error) {
 	// 校验请求
 	_, _, err := this.ValidateAdminAndUser(ctx, 0, 0)
 	if err != nil {
-		return nil, err
+		// 其他API节点转发过来的请求只在当前API节点上发送，防止循环转发
+		_, apiErr := this.ValidateAPINode(ctx)
+		if apiErr != nil {
+			return nil, err
+		}
+		if req.NodeId <= 0 {
+			return nil, errors.New("node id should not be less than 0")
+		}
+		return this.sendCommandToLocalNode(req)
 	}
 
-	nodeId := req.NodeId
-	if nodeId <= 0 {
+	if req.NodeId <= 0 {
 		return nil, errors.New("node id should not be less than 0")
 	}
+	return this.sendCommandToNode(req)
+}
+
+// 向集群中的所有节点发送命令，并收集每个节点的结果
+func (this *NodeService) SendCommandToNodeCluster(ctx context.Context, req *pb.SendCommandToNodeClusterRequest) (*pb.SendCommandToNodeClusterResponse, error) {
+	// 校验请求
+	_, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.NodeClusterId <= 0 {
+		return nil, errors.New("invalid 'nodeClusterId'")
+	}
+
+	tx := this.NullTx()
+
+	nodes, err := models.SharedNodeDAO.FindAllEnabledNodesWithClusterId(tx, req.NodeClusterId)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*pb.NodeStreamMessage, len(nodes))
+	wg := &sync.WaitGroup{}
+	wg.Add(len(nodes))
+	concurrent := make(chan bool, 32) // 同时发送的最大数量
+	for index, node := range nodes {
+		concurrent <- true
+		go func(index int, nodeId int64) {
+			defer func() {
+				<-concurrent
+				wg.Done()
+			}()
+
+			resp, err := this.sendCommandToNode(&pb.NodeStreamMessage{
+				NodeId:         nodeId,
+				TimeoutSeconds: req.TimeoutSeconds,
+				Code:           req.Code,
+				DataJSON:       req.DataJSON,
+			})
+			if err != nil {
+				resp = &pb.NodeStreamMessage{
+					Code:    req.Code,
+					IsOk:    false,
+					Message: err.Error(),
+				}
+			}
+			resp.NodeId = nodeId
+			results[index] = resp
+		}(index, int64(node.Id))
+	}
+	wg.Wait()
+
+	countOk := int64(0)
+	for _, result := range results {
+		if result.IsOk {
+			countOk++
+		}
+	}
+	return &pb.SendCommandToNodeClusterResponse{
+		Results: results,
+		CountOk: countOk,
+	}, nil
+}
+
+// 发送命令到节点
+func (this *NodeService) sendCommandToNode(req *pb.NodeStreamMessage) (*pb.NodeStreamMessage, error) {
+	nodeLocker.Lock()
+	isLocal := nodeStreamCountMap[req.NodeId] > 0
+	nodeLocker.Unlock()
+
+	if isLocal {
+		return this.sendCommandToLocalNode(req)
+	}
+	return this.forwardCommandToNode(req)
+}
+
+// 转发命令到节点连接的其他API节点
+func (this *NodeService) forwardCommandToNode(req *pb.NodeStreamMessage) (*pb.NodeStreamMessage, error) {
+	apiConfig, err := configs.SharedAPIConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	apiNodeIds, err := models.SharedNodeDAO.FindNodeConnectedAPINodeIds(this.NullTx(), req.NodeId)
+	if err != nil {
+		return nil, err
+	}
+
+	timeoutSeconds := req.TimeoutSeconds
+	if timeoutSeconds <= 0 {
+		timeoutSeconds = 10
+	}
+
+	message := "node '" + strconv.FormatInt(req.NodeId, 10) + "' " + nodeNotConnectedMessage
+	for _, apiNodeId := range apiNodeIds {
+		if apiNodeId == apiConfig.NumberId() {
+			continue
+		}
+
+		client, err := clients.FindAPINodeClient(apiNodeId)
+		if err != nil {
+			message = "forward to api node '" + strconv.FormatInt(apiNodeId, 10) + "' failed: " + err.Error()
+			continue
+		}
+
+		resp, err := func() (*pb.NodeStreamMessage, error) {
+			forwardCtx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutSeconds+5)*time.Second)
+			defer cancel()
+			forwardCtx, err := client.Context(forwardCtx)
+			if err != nil {
+				return nil, err
+			}
+			return client.NodeRPC().SendCommandToNode(forwardCtx, req)
+		}()
+		if err != nil {
+			message = "forward to api node '" + strconv.FormatInt(apiNodeId, 10) + "' failed: " + err.Error()
+			continue
+		}
+
+		// 节点可能已经从此API节点断开，继续尝试下一个
+		if !resp.IsOk && strings.HasSuffix(resp.Message, nodeNotConnectedMessage) {
+			continue
+		}
+		return resp, nil
+	}
+
+	return &pb.NodeStreamMessage{
+		RequestId: req.RequestId,
+		Code:      req.Code,
+		IsOk:      false,
+		Message:   message,
+	}, nil
+}
+
+// 向连接到当前API节点的节点发送命令
+func (this *NodeService) sendCommandToLocalNode(req *pb.NodeStreamMessage) (*pb.NodeStreamMessage, error) {
+	nodeId := req.NodeId
 
 	nodeLocker.Lock()
 	requestChan, ok := requestChanMap[nodeId]
+	if nodeStreamCountMap[nodeId] <= 0 {
+		ok = false
+	}
 	nodeLocker.Unlock()
 
 	if !ok {
 		return &pb.NodeStreamMessage{
 			RequestId: req.RequestId,
 			IsOk:      false,
-			Message:   "node '" + strconv.FormatInt(nodeId, 10) + "' not connected yet",
+			Message:   "node '" + strconv.FormatInt(nodeId, 10) + "' " + nodeNotConnectedMessage,
 		}, nil
 	}
 
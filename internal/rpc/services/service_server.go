@@ -172,7 +172,7 @@ func (this *ServerService) UpdateServerHTTP(ctx context.Context, req *pb.UpdateS
 // 修改HTTPS服务
 func (this *ServerService) UpdateServerHTTPS(ctx context.Context, req *pb.UpdateServerHTTPSRequest) (*pb.RPCSuccess, error) {
 	// 校验请求
-	_, userId, err := this.ValidateAdminAndUser(ctx, 0, 0)
+	adminId, userId, err := this.ValidateAdminAndUser(ctx, 0, 0)
 	if err != nil {
 		return nil, err
 	}
@@ -187,7 +187,7 @@ func (this *ServerService) UpdateServerHTTPS(ctx context.Context, req *pb.Update
 	}
 
 	// 修改配置
-	err = models.SharedServerDAO.UpdateServerHTTPS(tx, req.ServerId, req.HttpsJSON)
+	err = models.SharedServerDAO.UpdateServerHTTPS(tx, req.ServerId, req.HttpsJSON, models.NewServerConfigRevisionAuthor(adminId, userId))
 	if err != nil {
 		return nil, err
 	}
@@ -296,7 +296,7 @@ func (this *ServerService) UpdateServerUDP(ctx context.Context, req *pb.UpdateSe
 // 修改Web服务
 func (this *ServerService) UpdateServerWeb(ctx context.Context, req *pb.UpdateServerWebRequest) (*pb.RPCSuccess, error) {
 	// 校验请求
-	_, userId, err := this.ValidateAdminAndUser(ctx, 0, 0)
+	adminId, userId, err := this.ValidateAdminAndUser(ctx, 0, 0)
 	if err != nil {
 		return nil, err
 	}
@@ -311,7 +311,7 @@ func (this *ServerService) UpdateServerWeb(ctx context.Context, req *pb.UpdateSe
 	}
 
 	// 修改配置
-	err = models.SharedServerDAO.UpdateServerWeb(tx, req.ServerId, req.WebId)
+	err = models.SharedServerDAO.UpdateServerWeb(tx, req.ServerId, req.WebId, models.NewServerConfigRevisionAuthor(adminId, userId))
 	if err != nil {
 		return nil, err
 	}
@@ -322,7 +322,7 @@ func (this *ServerService) UpdateServerWeb(ctx context.Context, req *pb.UpdateSe
 // 修改反向代理服务
 func (this *ServerService) UpdateServerReverseProxy(ctx context.Context, req *pb.UpdateServerReverseProxyRequest) (*pb.RPCSuccess, error) {
 	// 校验请求
-	_, userId, err := this.ValidateAdminAndUser(ctx, 0, 0)
+	adminId, userId, err := this.ValidateAdminAndUser(ctx, 0, 0)
 	if err != nil {
 		return nil, err
 	}
@@ -337,7 +337,7 @@ func (this *ServerService) UpdateServerReverseProxy(ctx context.Context, req *pb
 	}
 
 	// 修改配置
-	err = models.SharedServerDAO.UpdateServerReverseProxy(tx, req.ServerId, req.ReverseProxyJSON)
+	err = models.SharedServerDAO.UpdateServerReverseProxy(tx, req.ServerId, req.ReverseProxyJSON, models.NewServerConfigRevisionAuthor(adminId, userId))
 	if err != nil {
 		return nil, err
 	}
@@ -388,7 +388,7 @@ func (this *ServerService) FindServerNames(ctx context.Context, req *pb.FindServ
 // 修改域名服务
 func (this *ServerService) UpdateServerNames(ctx context.Context, req *pb.UpdateServerNamesRequest) (*pb.RPCSuccess, error) {
 	// 校验请求
-	_, userId, err := this.ValidateAdminAndUser(ctx, 0, 0)
+	adminId, userId, err := this.ValidateAdminAndUser(ctx, 0, 0)
 	if err != nil {
 		return nil, err
 	}
@@ -415,7 +415,7 @@ func (this *ServerService) UpdateServerNames(ctx context.Context, req *pb.Update
 	}
 
 	// 修改配置
-	err = models.SharedServerDAO.UpdateServerNames(tx, req.ServerId, req.ServerNamesJSON)
+	err = models.SharedServerDAO.UpdateServerNames(tx, req.ServerId, req.ServerNamesJSON, models.NewServerConfigRevisionAuthor(adminId, userId))
 	if err != nil {
 		return nil, err
 	}
@@ -800,7 +800,7 @@ func (this *ServerService) FindAndInitServerReverseProxyConfig(ctx context.Conte
 		if err != nil {
 			return nil, err
 		}
-		err = models.SharedServerDAO.UpdateServerReverseProxy(tx, req.ServerId, refJSON)
+		err = models.SharedServerDAO.UpdateServerReverseProxy(tx, req.ServerId, refJSON, models.NewServerConfigRevisionAuthor(adminId, userId))
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"github.com/TeaOSLab/EdgeAPI/internal/utils/jsondiff"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/iwind/TeaGo/dbs"
+)
+
+// 服务配置版本相关服务
+type ServerConfigRevisionService struct {
+	BaseService
+}
+
+// 计算服务的配置版本数量
+func (this *ServerConfigRevisionService) CountServerConfigRevisions(ctx context.Context, req *pb.CountServerConfigRevisionsRequest) (*pb.RPCCountResponse, error) {
+	// 校验请求
+	_, userId, err := this.ValidateAdminAndUser(ctx, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	if userId > 0 {
+		err = models.SharedServerDAO.CheckUserServer(tx, userId, req.ServerId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	count, err := models.SharedServerConfigRevisionDAO.CountRevisionsWithServerId(tx, req.ServerId)
+	if err != nil {
+		return nil, err
+	}
+	return this.SuccessCount(count)
+}
+
+// 列出单页配置版本
+func (this *ServerConfigRevisionService) ListServerConfigRevisions(ctx context.Context, req *pb.ListServerConfigRevisionsRequest) (*pb.ListServerConfigRevisionsResponse, error) {
+	// 校验请求
+	_, userId, err := this.ValidateAdminAndUser(ctx, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	if userId > 0 {
+		err = models.SharedServerDAO.CheckUserServer(tx, userId, req.ServerId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	revisions, err := models.SharedServerConfigRevisionDAO.ListRevisionsWithServerId(tx, req.ServerId, req.Offset, req.Size)
+	if err != nil {
+		return nil, err
+	}
+	result := []*pb.ServerConfigRevision{}
+	for _, revision := range revisions {
+		result = append(result, &pb.ServerConfigRevision{
+			Id:          int64(revision.Id),
+			ServerId:    int64(revision.ServerId),
+			ConfigMd5:   revision.ConfigMd5,
+			AuthorType:  revision.AuthorType,
+			AuthorId:    int64(revision.AuthorId),
+			Description: revision.Description,
+			CreatedAt:   int64(revision.CreatedAt),
+		})
+	}
+	return &pb.ListServerConfigRevisionsResponse{ServerConfigRevisions: result}, nil
+}
+
+// 查找单个配置版本
+func (this *ServerConfigRevisionService) FindServerConfigRevision(ctx context.Context, req *pb.FindServerConfigRevisionRequest) (*pb.FindServerConfigRevisionResponse, error) {
+	// 校验请求
+	_, userId, err := this.ValidateAdminAndUser(ctx, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	revision, err := this.findRevision(tx, userId, req.ServerConfigRevisionId)
+	if err != nil {
+		return nil, err
+	}
+	if revision == nil {
+		return &pb.FindServerConfigRevisionResponse{ServerConfigRevision: nil}, nil
+	}
+	return &pb.FindServerConfigRevisionResponse{ServerConfigRevision: &pb.ServerConfigRevision{
+		Id:          int64(revision.Id),
+		ServerId:    int64(revision.ServerId),
+		ConfigJSON:  []byte(revision.Config),
+		ConfigMd5:   revision.ConfigMd5,
+		AuthorType:  revision.AuthorType,
+		AuthorId:    int64(revision.AuthorId),
+		Description: revision.Description,
+		CreatedAt:   int64(revision.CreatedAt),
+	}}, nil
+}
+
+// 比较两个配置版本
+func (this *ServerConfigRevisionService) DiffServerConfigRevisions(ctx context.Context, req *pb.DiffServerConfigRevisionsRequest) (*pb.DiffServerConfigRevisionsResponse, error) {
+	// 校验请求
+	_, userId, err := this.ValidateAdminAndUser(ctx, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	fromRevision, err := this.findRevision(tx, userId, req.FromServerConfigRevisionId)
+	if err != nil {
+		return nil, err
+	}
+	toRevision, err := this.findRevision(tx, userId, req.ToServerConfigRevisionId)
+	if err != nil {
+		return nil, err
+	}
+	if fromRevision == nil || toRevision == nil {
+		return nil, errors.New("can not find revision")
+	}
+	if fromRevision.ServerId != toRevision.ServerId {
+		return nil, errors.New("revisions should belong to the same server")
+	}
+
+	changes, err := jsondiff.Diff([]byte(fromRevision.Config), []byte(toRevision.Config))
+	if err != nil {
+		return nil, err
+	}
+	result := []*pb.ServerConfigChange{}
+	for _, change := range changes {
+		result = append(result, &pb.ServerConfigChange{
+			Path:         change.Path,
+			Op:           change.Op,
+			OldValueJSON: change.OldValue,
+			NewValueJSON: change.NewValue,
+		})
+	}
+	return &pb.DiffServerConfigRevisionsResponse{ServerConfigChanges: result}, nil
+}
+
+// 回滚到某个配置版本
+// 返回不在版本快照中、因此没有恢复的配置路径
+func (this *ServerConfigRevisionService) RollbackServerConfigRevision(ctx context.Context, req *pb.RollbackServerConfigRevisionRequest) (*pb.RollbackServerConfigRevisionResponse, error) {
+	// 校验请求
+	adminId, userId, err := this.ValidateAdminAndUser(ctx, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	revision, err := this.findRevision(this.NullTx(), userId, req.ServerConfigRevisionId)
+	if err != nil {
+		return nil, err
+	}
+	if revision == nil {
+		return nil, errors.New("can not find revision")
+	}
+
+	var unrestoredPaths []string
+	err = this.RunTx(func(tx *dbs.Tx) error {
+		unrestoredPaths, err = models.SharedServerConfigRevisionDAO.RollbackRevision(tx, int64(revision.Id), models.NewServerConfigRevisionAuthor(adminId, userId))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.RollbackServerConfigRevisionResponse{UnrestoredPaths: unrestoredPaths}, nil
+}
+
+// 查找版本并检查用户权限
+func (this *ServerConfigRevisionService) findRevision(tx *dbs.Tx, userId int64, revisionId int64) (*models.ServerConfigRevision, error) {
+	revision, err := models.SharedServerConfigRevisionDAO.FindRevision(tx, revisionId)
+	if err != nil {
+		return nil, err
+	}
+	if revision == nil {
+		return nil, nil
+	}
+	if userId > 0 {
+		err = models.SharedServerDAO.CheckUserServer(tx, userId, int64(revision.ServerId))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return revision, nil
+}
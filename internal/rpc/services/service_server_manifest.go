@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"github.com/TeaOSLab/EdgeAPI/internal/manifests"
+	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
+	"github.com/iwind/TeaGo/dbs"
+	"github.com/iwind/TeaGo/types"
+)
+
+// 服务配置清单相关服务
+type ServerManifestService struct {
+	BaseService
+}
+
+// 导出服务配置清单
+// 可以导出指定的服务，也可以导出某个集群下的所有服务
+// 证书私钥等敏感信息只有在includeSecrets为true时才会导出
+func (this *ServerManifestService) ExportServerManifest(ctx context.Context, req *pb.ExportServerManifestRequest) (*pb.ExportServerManifestResponse, error) {
+	// 校验请求
+	_, userId, err := this.ValidateAdminAndUser(ctx, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	serverIds := req.ServerIds
+	if req.NodeClusterId > 0 {
+		if userId > 0 {
+			return nil, this.PermissionError()
+		}
+		clusterServerIds, err := models.SharedServerDAO.FindAllEnabledServerIdsWithClusterId(tx, req.NodeClusterId)
+		if err != nil {
+			return nil, err
+		}
+		serverIds = append(serverIds, clusterServerIds...)
+	}
+	if len(serverIds) == 0 {
+		return nil, errors.New("no servers to export")
+	}
+
+	if userId > 0 {
+		for _, serverId := range serverIds {
+			err = models.SharedServerDAO.CheckUserServer(tx, userId, serverId)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	switch req.Format {
+	case manifests.FormatYAML, manifests.FormatJSON, "":
+	default:
+		return nil, errors.New("invalid format '" + req.Format + "'")
+	}
+
+	manifest, err := manifests.Export(tx, serverIds, userId, req.IncludeSecrets)
+	if err != nil {
+		return nil, err
+	}
+	data, err := manifests.Encode(req.Format, manifest)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ExportServerManifestResponse{ManifestData: data}, nil
+}
+
+// 导入服务配置清单
+// 所有对象都会重新创建，dryRun为true时只返回将要执行的动作，reuseShared为true时复用已有的同名共享对象
+func (this *ServerManifestService) ImportServerManifest(ctx context.Context, req *pb.ImportServerManifestRequest) (*pb.ImportServerManifestResponse, error) {
+	// 校验请求
+	adminId, err := this.ValidateAdmin(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := manifests.Decode(req.ManifestData)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	cluster, err := models.SharedNodeClusterDAO.FindEnabledNodeCluster(tx, req.NodeClusterId)
+	if err != nil {
+		return nil, err
+	}
+	if cluster == nil {
+		return nil, errors.New("can not find cluster '" + types.String(req.NodeClusterId) + "'")
+	}
+
+	if req.UserId > 0 {
+		user, err := models.SharedUserDAO.FindEnabledUser(tx, req.UserId)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, errors.New("can not find user '" + types.String(req.UserId) + "'")
+		}
+	}
+
+	options := &manifests.ImportOptions{
+		AdminId:     adminId,
+		UserId:      req.UserId,
+		ClusterId:   req.NodeClusterId,
+		DryRun:      req.DryRun,
+		ReuseShared: req.ReuseShared,
+	}
+	var result *manifests.ImportResult
+	if req.DryRun {
+		result, err = manifests.Import(tx, manifest, options)
+	} else {
+		err = this.RunTx(func(tx *dbs.Tx) error {
+			result, err = manifests.Import(tx, manifest, options)
+			return err
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pbSteps := []*pb.ServerManifestStep{}
+	for _, step := range result.Steps {
+		pbSteps = append(pbSteps, &pb.ServerManifestStep{
+			ObjectType: step.Type,
+			OldId:      step.OldId,
+			NewId:      step.NewId,
+			Action:     step.Action,
+		})
+	}
+	return &pb.ImportServerManifestResponse{
+		ServerIds:           result.ServerIds,
+		ServerManifestSteps: pbSteps,
+		Warnings:            result.Warnings,
+	}, nil
+}
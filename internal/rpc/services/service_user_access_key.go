@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
 	"github.com/TeaOSLab/EdgeCommon/pkg/rpc/pb"
 )
@@ -12,15 +13,19 @@ type UserAccessKeyService struct {
 }
 
 // 创建AccessKey
+// 管理员在不指定用户时创建的是管理员自己的AccessKey
 func (this *UserAccessKeyService) CreateUserAccessKey(ctx context.Context, req *pb.CreateUserAccessKeyRequest) (*pb.CreateUserAccessKeyResponse, error) {
-	_, _, err := this.ValidateAdminAndUser(ctx, 0, req.UserId)
+	adminId, userId, err := this.ValidateAdminAndUser(ctx, 0, req.UserId)
 	if err != nil {
 		return nil, err
 	}
+	if req.UserId > 0 {
+		userId = req.UserId
+	}
 
 	tx := this.NullTx()
 
-	userAccessKeyId, err := models.SharedUserAccessKeyDAO.CreateAccessKey(tx, req.UserId, req.Description)
+	userAccessKeyId, err := models.SharedUserAccessKeyDAO.CreateAccessKey(tx, adminId, userId, req.Description, req.Scopes)
 	if err != nil {
 		return nil, err
 	}
@@ -29,28 +34,41 @@ func (this *UserAccessKeyService) CreateUserAccessKey(ctx context.Context, req *
 
 // 查找所有的AccessKey
 func (this *UserAccessKeyService) FindAllEnabledUserAccessKeys(ctx context.Context, req *pb.FindAllEnabledUserAccessKeysRequest) (*pb.FindAllEnabledUserAccessKeysResponse, error) {
-	_, _, err := this.ValidateAdminAndUser(ctx, 0, req.UserId)
+	adminId, userId, err := this.ValidateAdminAndUser(ctx, 0, req.UserId)
 	if err != nil {
 		return nil, err
 	}
+	if req.UserId > 0 {
+		userId = req.UserId
+	}
 
 	tx := this.NullTx()
 
-	accessKeys, err := models.SharedUserAccessKeyDAO.FindAllEnabledAccessKeys(tx, req.UserId)
+	accessKeys, err := models.SharedUserAccessKeyDAO.FindAllEnabledAccessKeys(tx, adminId, userId)
 	if err != nil {
 		return nil, err
 	}
 
 	result := []*pb.UserAccessKey{}
 	for _, accessKey := range accessKeys {
+		scopes := []string{}
+		if models.IsNotNull(accessKey.Scopes) {
+			err = json.Unmarshal([]byte(accessKey.Scopes), &scopes)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		result = append(result, &pb.UserAccessKey{
 			Id:          int64(accessKey.Id),
+			AdminId:     int64(accessKey.AdminId),
 			UserId:      int64(accessKey.UserId),
 			SubUserId:   int64(accessKey.SubUserId),
 			IsOn:        accessKey.IsOn == 1,
 			UniqueId:    accessKey.UniqueId,
 			Secret:      accessKey.Secret,
 			Description: accessKey.Description,
+			Scopes:      scopes,
 		})
 	}
 
@@ -108,3 +126,29 @@ func (this *UserAccessKeyService) UpdateUserAccessKeyIsOn(ctx context.Context, r
 	}
 	return this.Success()
 }
+
+// 设置AccessKey允许访问的服务和方法
+func (this *UserAccessKeyService) UpdateUserAccessKeyScopes(ctx context.Context, req *pb.UpdateUserAccessKeyScopesRequest) (*pb.RPCSuccess, error) {
+	_, userId, err := this.ValidateAdminAndUser(ctx, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := this.NullTx()
+
+	if userId > 0 {
+		ok, err := models.SharedUserAccessKeyDAO.CheckUserAccessKey(tx, userId, req.UserAccessKeyId)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, this.PermissionError()
+		}
+	}
+
+	err = models.SharedUserAccessKeyDAO.UpdateAccessKeyScopes(tx, req.UserAccessKeyId, req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	return this.Success()
+}
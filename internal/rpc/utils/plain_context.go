@@ -6,8 +6,9 @@ import (
 )
 
 type PlainContext struct {
-	UserType string
-	UserId   int64
+	UserType      string
+	UserId        int64
+	AccessTokenId int64 // 通过API访问令牌调用时的令牌ID
 
 	ctx context.Context
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/TeaOSLab/EdgeAPI/internal/utils"
 	"github.com/iwind/TeaGo/dbs"
 	"github.com/iwind/TeaGo/lists"
+	"github.com/iwind/TeaGo/logs"
 	"github.com/iwind/TeaGo/rands"
 	"github.com/iwind/TeaGo/types"
 	stringutil "github.com/iwind/TeaGo/utils/string"
@@ -33,6 +34,9 @@ var upgradeFuncs = []*upgradeVersion{
 	{
 		"0.0.10", upgradeV0_0_10,
 	},
+	{
+		"0.0.11", upgradeV0_0_11,
+	},
 }
 
 // 升级SQL数据
@@ -203,3 +207,40 @@ func upgradeV0_0_10(db *dbs.DB) error {
 
 	return nil
 }
+
+// v0.0.11
+func upgradeV0_0_11(db *dbs.DB) error {
+	// IP Item转换为二进制范围，以支持IPv6范围
+	var lastId int64 = 0
+	for {
+		ones, _, err := db.FindOnes("SELECT id, type, ipFrom, ipTo FROM edgeIPItems WHERE id>? AND ipFromBin IS NULL ORDER BY id ASC LIMIT 1000", lastId)
+		if err != nil {
+			return err
+		}
+		if len(ones) == 0 {
+			break
+		}
+		for _, one := range ones {
+			lastId = one.GetInt64("id")
+			if one.GetString("type") == models.IPItemTypeAll {
+				continue
+			}
+			from, to, err := utils.ParseIPRange(one.GetString("ipFrom"), one.GetString("ipTo"))
+			if err != nil {
+				// 无法解析的IP保持原状，记录到升级日志中由管理员处理
+				logs.Println("[SETUP]can not convert ip item '" + types.String(lastId) + "', please check it manually: " + err.Error())
+				continue
+			}
+			itemType := models.IPItemTypeIPv4
+			if from.To4() == nil {
+				itemType = models.IPItemTypeIPv6
+			}
+			_, err = db.Exec("UPDATE edgeIPItems SET type=?, ipFromBin=?, ipToBin=? WHERE id=?", itemType, utils.IP2Bytes(from), utils.IP2Bytes(to), lastId)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package tasks
+
+import (
+	"encoding/json"
+	"github.com/TeaOSLab/EdgeAPI/internal/acme"
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	acmemodels "github.com/TeaOSLab/EdgeAPI/internal/db/models/acme"
+	"github.com/iwind/TeaGo/dbs"
+	"github.com/iwind/TeaGo/logs"
+	"github.com/iwind/TeaGo/maps"
+	"strconv"
+	"time"
+)
+
+func init() {
+	dbs.OnReady(func() {
+		go NewACMERenewTask().Start()
+	})
+}
+
+// ACME证书自动续期任务
+type ACMERenewTask struct {
+	duration time.Duration
+}
+
+func NewACMERenewTask() *ACMERenewTask {
+	return &ACMERenewTask{
+		duration: 10 * time.Minute,
+	}
+}
+
+func (this *ACMERenewTask) Start() {
+	ticker := time.NewTicker(this.duration)
+	for range ticker.C {
+		err := this.loop()
+		if err != nil {
+			logs.Println("[TASK][ACME_RENEW]" + err.Error())
+		}
+	}
+}
+
+// 单次执行
+func (this *ACMERenewTask) loop() error {
+	// 防止多个API节点同时执行
+	lockerKey := "acmeRenewTask"
+	isOk, err := models.SharedSysLockerDAO.Lock(nil, lockerKey, 3600)
+	if err != nil {
+		return err
+	}
+	if !isOk {
+		return nil
+	}
+	defer func() {
+		err = models.SharedSysLockerDAO.Unlock(nil, lockerKey)
+		if err != nil {
+			logs.Println("[TASK][ACME_RENEW]" + err.Error())
+		}
+	}()
+
+	config, err := this.readConfig()
+	if err != nil {
+		return err
+	}
+
+	certs, err := models.SharedSSLCertDAO.FindAllACMECertsExpiringBefore(nil, time.Now().Unix()+int64(config.BeforeDays)*86400)
+	if err != nil {
+		return err
+	}
+
+	countTasks := 0
+	for _, cert := range certs {
+		if config.MaxTasksEveryLoop > 0 && countTasks >= config.MaxTasksEveryLoop {
+			break
+		}
+
+		// 单个证书出错时不影响其他证书续期
+		task, err := acmemodels.SharedACMETaskDAO.FindEnabledACMETask(nil, int64(cert.AcmeTaskId))
+		if err != nil {
+			logs.Println("[TASK][ACME_RENEW]cert '" + strconv.FormatInt(int64(cert.Id), 10) + "': " + err.Error())
+			continue
+		}
+		if task == nil || task.IsOn != 1 || task.AutoRenew != 1 {
+			continue
+		}
+
+		// 任务关联的证书已经不是当前证书
+		if int64(task.CertId) != int64(cert.Id) {
+			continue
+		}
+
+		// 失败后需要等待一段时间再重试
+		if int64(task.NextRenewAt) > time.Now().Unix() {
+			continue
+		}
+
+		countTasks++
+		err = this.renew(config, cert, task)
+		if err != nil {
+			logs.Println("[TASK][ACME_RENEW]cert '" + strconv.FormatInt(int64(cert.Id), 10) + "': " + err.Error())
+			continue
+		}
+	}
+
+	return nil
+}
+
+// 续期单个证书
+func (this *ACMERenewTask) renew(config *acme.RenewConfig, cert *models.SSLCert, task *acmemodels.ACMETask) error {
+	// 执行任务时会在原有的证书记录上更新证书内容，并通知使用此证书的节点
+	isOk, errMsg, _ := acmemodels.SharedACMETaskDAO.RunTask(nil, int64(task.Id))
+	params := maps.Map{
+		"certId":     cert.Id,
+		"acmeTaskId": task.Id,
+	}.AsJSON()
+	if isOk {
+		err := acmemodels.SharedACMETaskDAO.ResetACMETaskRenewFailures(nil, int64(task.Id))
+		if err != nil {
+			return err
+		}
+
+		msg := "系统已成功为你自动更新了证书\"" + cert.Name + "\"（" + cert.DnsNames + "）。"
+		err = models.SharedMessageDAO.CreateMessage(nil, int64(cert.AdminId), int64(cert.UserId), models.MessageTypeSSLCertACMETaskSuccess, models.MessageLevelSuccess, msg, params)
+		if err != nil {
+			return err
+		}
+	} else {
+		failures := int(task.RenewFailures) + 1
+		backoffSeconds := config.BackoffSeconds(failures)
+		err := acmemodels.SharedACMETaskDAO.UpdateACMETaskRenewFailed(nil, int64(task.Id), failures, time.Now().Unix()+backoffSeconds)
+		if err != nil {
+			return err
+		}
+
+		msg := "系统在尝试自动更新证书\"" + cert.Name + "\"（" + cert.DnsNames + "）时发生错误：" + errMsg + "。已连续失败" + strconv.Itoa(failures) + "次，将在" + this.formatSeconds(backoffSeconds) + "后重试，请检查系统设置并修复错误。"
+		err = models.SharedMessageDAO.CreateMessage(nil, int64(cert.AdminId), int64(cert.UserId), models.MessageTypeSSLCertACMETaskFailed, models.MessageLevelError, msg, params)
+		if err != nil {
+			return err
+		}
+	}
+
+	return models.SharedSSLCertDAO.UpdateCertNotifiedAt(nil, int64(cert.Id))
+}
+
+// 读取配置
+func (this *ACMERenewTask) readConfig() (*acme.RenewConfig, error) {
+	config := acme.DefaultRenewConfig()
+	configJSON, err := models.SharedSysSettingDAO.ReadSetting(nil, acme.SettingCodeACMERenewConfig)
+	if err != nil {
+		return nil, err
+	}
+	if len(configJSON) > 0 {
+		err = json.Unmarshal(configJSON, config)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if config.BeforeDays <= 0 {
+		config.BeforeDays = acme.DefaultRenewConfig().BeforeDays
+	}
+	return config, nil
+}
+
+// 格式化时间间隔
+func (this *ACMERenewTask) formatSeconds(seconds int64) string {
+	if seconds >= 3600 {
+		return strconv.FormatInt(seconds/3600, 10) + "小时"
+	}
+	if seconds >= 60 {
+		return strconv.FormatInt(seconds/60, 10) + "分钟"
+	}
+	return strconv.FormatInt(seconds, 10) + "秒"
+}
@@ -0,0 +1,34 @@
+package tasks
+
+import (
+	"github.com/iwind/TeaGo/dbs"
+	"testing"
+)
+
+func TestACMERenewTask_loop(t *testing.T) {
+	dbs.NotifyReady()
+
+	task := NewACMERenewTask()
+	err := task.loop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("ok")
+}
+
+func TestACMERenewTask_formatSeconds(t *testing.T) {
+	task := NewACMERenewTask()
+	for seconds, expected := range map[int64]string{
+		30:    "30秒",
+		60:    "1分钟",
+		600:   "10分钟",
+		3599:  "59分钟",
+		3600:  "1小时",
+		86400: "24小时",
+	} {
+		result := task.formatSeconds(seconds)
+		if result != expected {
+			t.Fatal("formatSeconds(", seconds, ") should be", expected, ", but got", result)
+		}
+	}
+}
@@ -304,6 +304,11 @@ func (this *DNSTaskExecutor) doCluster(taskId int64, clusterId int64) error {
 			continue
 		}
 		for _, ipAddress := range ipAddresses {
+			// 健康检查中下线的IP地址
+			if ipAddress.IsUp == 0 {
+				continue
+			}
+
 			ip := ipAddress.Ip
 			if len(ip) == 0 {
 				continue
@@ -51,10 +51,12 @@ func (this *HealthCheckClusterTask) Reset(config *serverconfigs.HealthCheckConfi
 func (this *HealthCheckClusterTask) Run() {
 	this.Stop()
 
-	if this.config == nil {
-		return
-	}
-	if !this.config.IsOn {
+	// 关闭健康检查后恢复之前已下线的IP地址
+	if this.config == nil || !this.config.IsOn {
+		err := NewHealthCheckExecutor(this.clusterId).RestoreAddresses()
+		if err != nil {
+			logs.Println("[TASK][HEALTH_CHECK]" + err.Error())
+		}
 		return
 	}
 	if this.config.Interval == nil {
@@ -86,7 +88,14 @@ func (this *HealthCheckClusterTask) Stop() {
 }
 
 // 单个循环任务
+// 分布式检查时，每个API节点都会执行检查，但只有一个API节点负责修改节点状态和发送通知
 func (this *HealthCheckClusterTask) loop(seconds int64) error {
+	executor := NewHealthCheckExecutor(this.clusterId)
+	isDistributed, err := executor.IsDistributed()
+	if err != nil {
+		return err
+	}
+
 	// 检查上次运行时间，防止重复运行
 	settingKey := systemconfigs.SettingCodeClusterHealthCheck + "Loop" + numberutils.FormatInt64(this.clusterId)
 	timestamp := time.Now().Unix()
@@ -94,22 +103,34 @@ func (this *HealthCheckClusterTask) loop(seconds int64) error {
 	if err != nil {
 		return err
 	}
-	if c > 0 {
+	isPrimary := c <= 0
+	if !isPrimary && !isDistributed {
 		return nil
 	}
 
 	// 记录时间
-	err = models.SharedSysSettingDAO.UpdateSetting(nil, settingKey, []byte(numberutils.FormatInt64(timestamp)))
-	if err != nil {
-		return err
+	if isPrimary {
+		err = models.SharedSysSettingDAO.UpdateSetting(nil, settingKey, []byte(numberutils.FormatInt64(timestamp)))
+		if err != nil {
+			return err
+		}
 	}
 
 	// 开始运行
-	executor := NewHealthCheckExecutor(this.clusterId)
 	results, err := executor.Run()
 	if err != nil {
 		return err
 	}
+	if !isPrimary {
+		return nil
+	}
+
+	if isDistributed {
+		err = executor.ApplyQuorum(results)
+		if err != nil {
+			return err
+		}
+	}
 
 	failedResults := []maps.Map{}
 	for _, result := range results {
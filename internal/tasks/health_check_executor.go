@@ -3,6 +3,7 @@ package tasks
 import (
 	"crypto/tls"
 	"encoding/json"
+	"github.com/TeaOSLab/EdgeAPI/internal/configs"
 	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
 	"github.com/TeaOSLab/EdgeAPI/internal/db/models/dns"
 	"github.com/TeaOSLab/EdgeAPI/internal/errors"
@@ -26,21 +27,17 @@ func NewHealthCheckExecutor(clusterId int64) *HealthCheckExecutor {
 }
 
 func (this *HealthCheckExecutor) Run() ([]*HealthCheckResult, error) {
-	cluster, err := models.NewNodeClusterDAO().FindEnabledNodeCluster(nil, this.clusterId)
+	healthCheckConfig, probesConfig, err := this.loadConfig()
 	if err != nil {
 		return nil, err
 	}
-	if cluster == nil {
-		return nil, errors.New("can not find cluster with id '" + strconv.FormatInt(this.clusterId, 10) + "'")
-	}
-	if len(cluster.HealthCheck) == 0 || cluster.HealthCheck == "null" {
-		return nil, errors.New("health check config is not found")
-	}
 
-	healthCheckConfig := &serverconfigs.HealthCheckConfig{}
-	err = json.Unmarshal([]byte(cluster.HealthCheck), healthCheckConfig)
-	if err != nil {
-		return nil, err
+	// 不再修改IP地址状态时，恢复之前已下线的IP地址
+	if !probesConfig.CheckAllAddrs || !healthCheckConfig.AutoDown {
+		err = this.RestoreAddresses()
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	results := []*HealthCheckResult{}
@@ -52,6 +49,30 @@ func (this *HealthCheckExecutor) Run() ([]*HealthCheckResult, error) {
 		if node.IsOn != 1 {
 			continue
 		}
+
+		// 检查所有IP地址
+		if probesConfig.CheckAllAddrs {
+			addresses, err := models.NewNodeIPAddressDAO().FindNodeAccessIPAddresses(nil, int64(node.Id))
+			if err != nil {
+				return nil, err
+			}
+			if len(addresses) == 0 {
+				results = append(results, &HealthCheckResult{
+					Node:  node,
+					Error: "no ip address can be used",
+				})
+				continue
+			}
+			for _, address := range addresses {
+				results = append(results, &HealthCheckResult{
+					Node:       node,
+					NodeAddr:   address.Ip,
+					NodeAddrId: int64(address.Id),
+				})
+			}
+			continue
+		}
+
 		result := &HealthCheckResult{
 			Node: node,
 		}
@@ -114,7 +135,8 @@ func (this *HealthCheckExecutor) Run() ([]*HealthCheckResult, error) {
 				case result := <-queue:
 					for i := 1; i <= countTries; i++ {
 						before := time.Now()
-						err := this.checkNode(healthCheckConfig, result)
+						result.Error = ""
+						err := this.checkNode(healthCheckConfig, probesConfig, result)
 						result.CostMs = time.Since(before).Seconds() * 1000
 						if err != nil {
 							result.Error = err.Error()
@@ -127,27 +149,6 @@ func (this *HealthCheckExecutor) Run() ([]*HealthCheckResult, error) {
 						}
 					}
 
-					// 修改节点状态
-					if healthCheckConfig.AutoDown {
-						isChanged, err := models.SharedNodeDAO.UpdateNodeUp(nil, int64(result.Node.Id), result.IsOk, healthCheckConfig.CountUp, healthCheckConfig.CountDown)
-						if err != nil {
-							logs.Println("[HEALTH_CHECK]" + err.Error())
-						} else if isChanged {
-							// 通知DNS更新
-							err = dns.SharedDNSTaskDAO.CreateNodeTask(nil, int64(result.Node.Id), dns.DNSTaskTypeNodeChange)
-							if err != nil {
-								logs.Println("[HEALTH_CHECK]" + err.Error())
-							}
-
-							// 通知恢复或下线
-							if result.IsOk {
-								err = models.NewMessageDAO().CreateNodeMessage(nil, this.clusterId, int64(result.Node.Id), models.MessageTypeHealthCheckNodeUp, models.MessageLevelSuccess, "健康检查成功，节点\""+result.Node.Name+"\"已恢复上线", nil)
-							} else {
-								err = models.NewMessageDAO().CreateNodeMessage(nil, this.clusterId, int64(result.Node.Id), models.MessageTypeHealthCheckNodeDown, models.MessageLevelError, "健康检查失败，节点\""+result.Node.Name+"\"已自动下线", nil)
-							}
-						}
-					}
-
 					wg.Done()
 				default:
 					return
@@ -157,12 +158,411 @@ func (this *HealthCheckExecutor) Run() ([]*HealthCheckResult, error) {
 	}
 	wg.Wait()
 
+	// 分布式检查时只记录当前API节点的检查结果，IP地址和节点状态由 ApplyQuorum() 统一修改
+	if probesConfig.Quorum > 1 {
+		this.saveVantageResults(preparedResults)
+		return results, nil
+	}
+
+	// 修改IP地址和节点状态
+	if healthCheckConfig.AutoDown {
+		this.updateAddressStatus(healthCheckConfig, preparedResults)
+		for _, nodeResult := range this.groupResults(preparedResults) {
+			this.updateNodeUp(healthCheckConfig, nodeResult.Node, nodeResult.IsOk)
+		}
+	}
+
 	return results, nil
 }
 
+// 恢复集群中所有已下线的IP地址
+func (this *HealthCheckExecutor) RestoreAddresses() error {
+	nodeIds, err := models.SharedNodeIPAddressDAO.ResetAllDownAddressesWithClusterId(nil, this.clusterId)
+	if err != nil {
+		return err
+	}
+	for _, nodeId := range nodeIds {
+		err = dns.SharedDNSTaskDAO.CreateNodeTask(nil, nodeId, dns.DNSTaskTypeNodeChange)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 是否为分布式检查
+func (this *HealthCheckExecutor) IsDistributed() (bool, error) {
+	_, probesConfig, err := this.loadConfig()
+	if err != nil {
+		return false, err
+	}
+	return probesConfig.Quorum > 1, nil
+}
+
+// 根据所有检查点的检查结果修改IP地址和节点状态
+// 只有达到法定数量的检查点结果一致时才会修改，防止因为单个API节点网络异常导致节点或IP地址被误下线
+// localResults 为当前API节点的检查结果，用来确定需要修改状态的IP地址
+func (this *HealthCheckExecutor) ApplyQuorum(localResults []*HealthCheckResult) error {
+	healthCheckConfig, probesConfig, err := this.loadConfig()
+	if err != nil {
+		return err
+	}
+	if !healthCheckConfig.AutoDown || probesConfig.Quorum <= 1 {
+		return nil
+	}
+	err = probesConfig.ValidateQuorum()
+	if err != nil {
+		return err
+	}
+
+	// 只使用最近两个周期内的结果
+	interval := 1 * time.Minute
+	if healthCheckConfig.Interval != nil && healthCheckConfig.Interval.Duration() > 0 {
+		interval = healthCheckConfig.Interval.Duration()
+	}
+	vantageResults, err := models.SharedNodeHealthCheckResultDAO.FindAllClusterHealthCheckResultsAfter(nil, this.clusterId, time.Now().Add(-2*interval).Unix())
+	if err != nil {
+		return err
+	}
+	countOkMap := map[int64]int{}         // nodeId => count
+	countFailedMap := map[int64]int{}     // nodeId => count
+	countAddrOkMap := map[int64]int{}     // addrId => count
+	countAddrFailedMap := map[int64]int{} // addrId => count
+	for _, vantageResult := range vantageResults {
+		if vantageResult.VantageType == models.NodeHealthCheckVantageTypeNode {
+			if !probesConfig.NodeVantages || vantageResult.VantageId == vantageResult.NodeId {
+				continue
+			}
+		}
+		if vantageResult.AddrId > 0 {
+			if vantageResult.IsOk == 1 {
+				countAddrOkMap[int64(vantageResult.AddrId)]++
+			} else {
+				countAddrFailedMap[int64(vantageResult.AddrId)]++
+			}
+			continue
+		}
+		if vantageResult.IsOk == 1 {
+			countOkMap[int64(vantageResult.NodeId)]++
+		} else {
+			countFailedMap[int64(vantageResult.NodeId)]++
+		}
+	}
+
+	// IP地址状态
+	addrResults := []*HealthCheckResult{}
+	for _, result := range localResults {
+		if result.NodeAddrId <= 0 {
+			continue
+		}
+		isOk, ok := this.matchQuorum(probesConfig.Quorum, countAddrOkMap[result.NodeAddrId], countAddrFailedMap[result.NodeAddrId])
+		if !ok {
+			continue
+		}
+		addrResults = append(addrResults, &HealthCheckResult{
+			Node:       result.Node,
+			NodeAddr:   result.NodeAddr,
+			NodeAddrId: result.NodeAddrId,
+			IsOk:       isOk,
+		})
+	}
+	this.updateAddressStatus(healthCheckConfig, addrResults)
+
+	// 节点状态
+	nodes, err := models.SharedNodeDAO.FindAllEnabledNodesWithClusterId(nil, this.clusterId)
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		if node.IsOn != 1 {
+			continue
+		}
+		isOk, ok := this.matchQuorum(probesConfig.Quorum, countOkMap[int64(node.Id)], countFailedMap[int64(node.Id)])
+		if !ok {
+			continue
+		}
+		this.updateNodeUp(healthCheckConfig, node, isOk)
+	}
+	return nil
+}
+
+// 检查单个节点，不修改节点状态
+// 集群没有开启健康检查时返回nil；检查所有IP地址时任一IP地址成功即认为成功
+func (this *HealthCheckExecutor) CheckNode(node *models.Node) (*HealthCheckResult, error) {
+	cluster, err := models.SharedNodeClusterDAO.FindEnabledNodeCluster(nil, this.clusterId)
+	if err != nil {
+		return nil, err
+	}
+	if cluster == nil || len(cluster.HealthCheck) == 0 || cluster.HealthCheck == "null" {
+		return nil, nil
+	}
+	healthCheckConfig, probesConfig, err := this.loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if !healthCheckConfig.IsOn {
+		return nil, nil
+	}
+
+	result := &HealthCheckResult{
+		Node: node,
+	}
+	addresses, err := models.SharedNodeIPAddressDAO.FindNodeAccessIPAddresses(nil, int64(node.Id))
+	if err != nil {
+		return nil, err
+	}
+	if len(addresses) == 0 {
+		result.Error = "no ip address can be used"
+		return result, nil
+	}
+	if !probesConfig.CheckAllAddrs {
+		addresses = addresses[:1]
+	}
+	for _, address := range addresses {
+		result.NodeAddr = address.Ip
+		result.IsOk = false
+		result.Error = ""
+		before := time.Now()
+		err = this.checkNode(healthCheckConfig, probesConfig, result)
+		result.CostMs = time.Since(before).Seconds() * 1000
+		if err != nil {
+			result.Error = err.Error()
+		}
+		if result.IsOk {
+			break
+		}
+	}
+	return result, nil
+}
+
+// 健康检查是否会自动恢复节点的上线状态
+func (this *HealthCheckExecutor) IsAutoUp() (bool, error) {
+	cluster, err := models.SharedNodeClusterDAO.FindEnabledNodeCluster(nil, this.clusterId)
+	if err != nil {
+		return false, err
+	}
+	if cluster == nil || len(cluster.HealthCheck) == 0 || cluster.HealthCheck == "null" {
+		return false, nil
+	}
+	healthCheckConfig, _, err := this.loadConfig()
+	if err != nil {
+		return false, err
+	}
+	return healthCheckConfig.IsOn && healthCheckConfig.AutoDown, nil
+}
+
+// 读取集群健康检查配置
+func (this *HealthCheckExecutor) loadConfig() (*serverconfigs.HealthCheckConfig, *HealthCheckProbesConfig, error) {
+	cluster, err := models.NewNodeClusterDAO().FindEnabledNodeCluster(nil, this.clusterId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cluster == nil {
+		return nil, nil, errors.New("can not find cluster with id '" + strconv.FormatInt(this.clusterId, 10) + "'")
+	}
+	if len(cluster.HealthCheck) == 0 || cluster.HealthCheck == "null" {
+		return nil, nil, errors.New("health check config is not found")
+	}
+
+	healthCheckConfig := &serverconfigs.HealthCheckConfig{}
+	err = json.Unmarshal([]byte(cluster.HealthCheck), healthCheckConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 探测配置
+	probesConfig := &HealthCheckProbesConfig{}
+	err = json.Unmarshal([]byte(cluster.HealthCheck), probesConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	return healthCheckConfig, probesConfig, nil
+}
+
+// 按节点合并检查结果
+// 节点的任一IP地址检查成功即认为节点在线
+func (this *HealthCheckExecutor) groupResults(results []*HealthCheckResult) []*HealthCheckResult {
+	nodeResults := []*HealthCheckResult{}
+	nodeResultMap := map[int64]*HealthCheckResult{} // nodeId => result
+	for _, result := range results {
+		nodeId := int64(result.Node.Id)
+		nodeResult, ok := nodeResultMap[nodeId]
+		if !ok {
+			nodeResult = &HealthCheckResult{
+				Node:     result.Node,
+				NodeAddr: result.NodeAddr,
+				IsOk:     result.IsOk,
+				Error:    result.Error,
+				CostMs:   result.CostMs,
+			}
+			nodeResultMap[nodeId] = nodeResult
+			nodeResults = append(nodeResults, nodeResult)
+			continue
+		}
+		if result.IsOk && !nodeResult.IsOk {
+			nodeResult.NodeAddr = result.NodeAddr
+			nodeResult.IsOk = true
+			nodeResult.Error = ""
+			nodeResult.CostMs = result.CostMs
+		}
+	}
+	return nodeResults
+}
+
+// 根据成功和失败的检查点数量判断是否达到法定数量
+// 两种结果都达到或者都没有达到法定数量时返回 ok=false，此时应保持原有状态
+func (this *HealthCheckExecutor) matchQuorum(quorum int, countOk int, countFailed int) (isOk bool, ok bool) {
+	isOkQuorum := countOk >= quorum
+	isFailedQuorum := countFailed >= quorum
+	if isOkQuorum == isFailedQuorum {
+		return false, false
+	}
+	return isOkQuorum, true
+}
+
+// 保存当前API节点作为检查点的检查结果
+func (this *HealthCheckExecutor) saveVantageResults(results []*HealthCheckResult) {
+	apiConfig, err := configs.SharedAPIConfig()
+	if err != nil {
+		logs.Println("[HEALTH_CHECK]" + err.Error())
+		return
+	}
+	apiNodeId := apiConfig.NumberId()
+	if apiNodeId <= 0 {
+		return
+	}
+
+	// 单个IP地址的结果
+	for _, result := range results {
+		if result.NodeAddrId <= 0 {
+			continue
+		}
+		err = models.SharedNodeHealthCheckResultDAO.UpdateNodeHealthCheckResult(nil, this.clusterId, int64(result.Node.Id), result.NodeAddrId, models.NodeHealthCheckVantageTypeAPI, apiNodeId, result.IsOk, result.Error, int64(result.CostMs))
+		if err != nil {
+			logs.Println("[HEALTH_CHECK]" + err.Error())
+		}
+	}
+
+	// 节点整体结果
+	for _, nodeResult := range this.groupResults(results) {
+		err = models.SharedNodeHealthCheckResultDAO.UpdateNodeHealthCheckResult(nil, this.clusterId, int64(nodeResult.Node.Id), 0, models.NodeHealthCheckVantageTypeAPI, apiNodeId, nodeResult.IsOk, nodeResult.Error, int64(nodeResult.CostMs))
+		if err != nil {
+			logs.Println("[HEALTH_CHECK]" + err.Error())
+		}
+	}
+}
+
+// 根据检查结果修改IP地址状态
+func (this *HealthCheckExecutor) updateAddressStatus(healthCheckConfig *serverconfigs.HealthCheckConfig, results []*HealthCheckResult) {
+	for _, result := range results {
+		nodeId := int64(result.Node.Id)
+		if result.NodeAddrId <= 0 {
+			continue
+		}
+		isChanged, err := models.SharedNodeIPAddressDAO.UpdateAddressIsUp(nil, result.NodeAddrId, result.IsOk, healthCheckConfig.CountUp, healthCheckConfig.CountDown)
+		if err != nil {
+			logs.Println("[HEALTH_CHECK]" + err.Error())
+			continue
+		}
+		if isChanged {
+			// 通知DNS更新
+			err = dns.SharedDNSTaskDAO.CreateNodeTask(nil, nodeId, dns.DNSTaskTypeNodeChange)
+			if err != nil {
+				logs.Println("[HEALTH_CHECK]" + err.Error())
+			}
+
+			// 通知恢复或下线
+			if result.IsOk {
+				err = models.NewMessageDAO().CreateNodeMessage(nil, this.clusterId, nodeId, models.MessageTypeHealthCheckNodeUp, models.MessageLevelSuccess, "健康检查成功，节点\""+result.Node.Name+"\"的IP地址\""+result.NodeAddr+"\"已恢复上线", nil)
+			} else {
+				err = models.NewMessageDAO().CreateNodeMessage(nil, this.clusterId, nodeId, models.MessageTypeHealthCheckNodeDown, models.MessageLevelError, "健康检查失败，节点\""+result.Node.Name+"\"的IP地址\""+result.NodeAddr+"\"已自动下线", nil)
+			}
+			if err != nil {
+				logs.Println("[HEALTH_CHECK]" + err.Error())
+			}
+		}
+	}
+}
+
+// 修改节点状态
+func (this *HealthCheckExecutor) updateNodeUp(healthCheckConfig *serverconfigs.HealthCheckConfig, node *models.Node, isOk bool) {
+	nodeId := int64(node.Id)
+
+	// 升级过程中被移出DNS的节点由升级任务负责恢复
+	isUpgrading, err := models.SharedNodeClusterUpgradeNodeDAO.ExistsNodeRemovedFromDNS(nil, nodeId)
+	if err != nil {
+		logs.Println("[HEALTH_CHECK]" + err.Error())
+		return
+	}
+	if isUpgrading {
+		return
+	}
+
+	isChanged, err := models.SharedNodeDAO.UpdateNodeUp(nil, nodeId, isOk, healthCheckConfig.CountUp, healthCheckConfig.CountDown)
+	if err != nil {
+		logs.Println("[HEALTH_CHECK]" + err.Error())
+	} else if isChanged {
+		// 通知DNS更新
+		err = dns.SharedDNSTaskDAO.CreateNodeTask(nil, nodeId, dns.DNSTaskTypeNodeChange)
+		if err != nil {
+			logs.Println("[HEALTH_CHECK]" + err.Error())
+		}
+
+		// 通知恢复或下线
+		if isOk {
+			err = models.NewMessageDAO().CreateNodeMessage(nil, this.clusterId, nodeId, models.MessageTypeHealthCheckNodeUp, models.MessageLevelSuccess, "健康检查成功，节点\""+node.Name+"\"已恢复上线", nil)
+		} else {
+			err = models.NewMessageDAO().CreateNodeMessage(nil, this.clusterId, nodeId, models.MessageTypeHealthCheckNodeDown, models.MessageLevelError, "健康检查失败，节点\""+node.Name+"\"已自动下线", nil)
+		}
+		if err != nil {
+			logs.Println("[HEALTH_CHECK]" + err.Error())
+		}
+	}
+}
+
 // 检查单个节点
-func (this *HealthCheckExecutor) checkNode(healthCheckConfig *serverconfigs.HealthCheckConfig, result *HealthCheckResult) error {
-	url := strings.ReplaceAll(healthCheckConfig.URL, "${host}", result.NodeAddr)
+// 没有设置探测时只检查URL，否则需要所有的探测都成功
+func (this *HealthCheckExecutor) checkNode(healthCheckConfig *serverconfigs.HealthCheckConfig, probesConfig *HealthCheckProbesConfig, result *HealthCheckResult) error {
+	if len(probesConfig.Probes) == 0 {
+		return this.checkURL(healthCheckConfig, result)
+	}
+
+	timeout := 5 * time.Second
+	if healthCheckConfig.Timeout != nil {
+		timeout = healthCheckConfig.Timeout.Duration()
+	}
+
+	for _, probe := range probesConfig.Probes {
+		if probe.Type == HealthCheckProbeTypeHTTP {
+			err := this.checkURL(healthCheckConfig, result)
+			if err != nil {
+				return errors.New("[" + probe.Type + "]" + err.Error())
+			}
+			if !result.IsOk {
+				result.Error = "[" + probe.Type + "]" + result.Error
+				return nil
+			}
+			result.IsOk = false
+			continue
+		}
+
+		err := probe.Probe(result.NodeAddr, timeout)
+		if err != nil {
+			return errors.New("[" + probe.Type + "]" + err.Error())
+		}
+	}
+
+	result.IsOk = true
+	return nil
+}
+
+// 检查URL
+func (this *HealthCheckExecutor) checkURL(healthCheckConfig *serverconfigs.HealthCheckConfig, result *HealthCheckResult) error {
+	host := result.NodeAddr
+	if strings.Contains(host, ":") { // IPv6
+		host = "[" + host + "]"
+	}
+	url := strings.ReplaceAll(healthCheckConfig.URL, "${host}", host)
 	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
 		return err
@@ -1,6 +1,7 @@
 package tasks
 
 import (
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
 	"github.com/iwind/TeaGo/dbs"
 	"testing"
 )
@@ -17,3 +18,44 @@ func TestHealthCheckExecutor_Run(t *testing.T) {
 		t.Log(result.Node.Name, "addr:", result.NodeAddr, "isOk:", result.IsOk, "error:", result.Error)
 	}
 }
+
+func TestHealthCheckExecutor_GroupResults(t *testing.T) {
+	node1 := &models.Node{Id: 1, Name: "node1"}
+	node2 := &models.Node{Id: 2, Name: "node2"}
+
+	executor := NewHealthCheckExecutor(10)
+	nodeResults := executor.groupResults([]*HealthCheckResult{
+		{Node: node1, NodeAddr: "192.168.1.100", NodeAddrId: 1, IsOk: false, Error: "timeout"},
+		{Node: node1, NodeAddr: "192.168.1.101", NodeAddrId: 2, IsOk: true},
+		{Node: node2, NodeAddr: "192.168.1.200", NodeAddrId: 3, IsOk: false, Error: "connection refused"},
+	})
+	if len(nodeResults) != 2 {
+		t.Fatal("expect 2 node results, but got", len(nodeResults))
+	}
+	if !nodeResults[0].IsOk || nodeResults[0].NodeAddr != "192.168.1.101" {
+		t.Fatal("node1 should be ok with any address ok")
+	}
+	if nodeResults[1].IsOk || nodeResults[1].Error != "connection refused" {
+		t.Fatal("node2 should be failed")
+	}
+}
+
+func TestHealthCheckExecutor_MatchQuorum(t *testing.T) {
+	executor := NewHealthCheckExecutor(10)
+	for _, testCase := range []struct {
+		countOk     int
+		countFailed int
+		isOk        bool
+		ok          bool
+	}{
+		{countOk: 2, countFailed: 0, isOk: true, ok: true},
+		{countOk: 0, countFailed: 2, isOk: false, ok: true},
+		{countOk: 1, countFailed: 1, isOk: false, ok: false},
+		{countOk: 2, countFailed: 2, isOk: false, ok: false},
+	} {
+		isOk, ok := executor.matchQuorum(2, testCase.countOk, testCase.countFailed)
+		if isOk != testCase.isOk || ok != testCase.ok {
+			t.Fatal("countOk:", testCase.countOk, "countFailed:", testCase.countFailed, "expect", testCase.isOk, testCase.ok, "but got", isOk, ok)
+		}
+	}
+}
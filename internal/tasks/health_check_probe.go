@@ -0,0 +1,198 @@
+package tasks
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/miekg/dns"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type HealthCheckProbeType = string
+
+const (
+	HealthCheckProbeTypeHTTP HealthCheckProbeType = "http" // 使用健康检查配置中的URL
+	HealthCheckProbeTypeTCP  HealthCheckProbeType = "tcp"
+	HealthCheckProbeTypeTLS  HealthCheckProbeType = "tls"
+	HealthCheckProbeTypeDNS  HealthCheckProbeType = "dns"
+	HealthCheckProbeTypeUDP  HealthCheckProbeType = "udp"
+)
+
+// 健康检查探测配置
+// 和 serverconfigs.HealthCheckConfig 保存在集群的同一个健康检查JSON中
+// 设置了Quorum之后，每个API节点都会执行健康检查，并把结果保存到数据库中，然后根据所有检查点的结果修改节点状态
+type HealthCheckProbesConfig struct {
+	Probes        []*HealthCheckProbe `json:"probes"`        // 探测列表，为空时只检查URL
+	CheckAllAddrs bool                `json:"checkAllAddrs"` // 是否检查节点的所有IP地址
+	Quorum        int                 `json:"quorum"`        // 分布式检查时需要结果一致的检查点数量，小于2时只使用当前API节点的检查结果
+	NodeVantages  bool                `json:"nodeVantages"`  // 分布式检查时是否使用边缘节点上报的互相检查结果
+}
+
+// 检查法定数量是否可以达到
+// 分布式检查的结果主要来自API节点，法定数量不能超过可用的API节点数量，否则节点和IP地址状态永远不会被修改
+func (this *HealthCheckProbesConfig) ValidateQuorum() error {
+	if this.Quorum <= 1 {
+		return nil
+	}
+	countAPINodes, err := models.SharedAPINodeDAO.CountAllEnabledAndOnAPINodes(nil)
+	if err != nil {
+		return err
+	}
+	if int64(this.Quorum) > countAPINodes {
+		return errors.New("quorum '" + strconv.Itoa(this.Quorum) + "' should not be greater than the count of available api nodes '" + strconv.FormatInt(countAPINodes, 10) + "'")
+	}
+	return nil
+}
+
+// 单个探测
+type HealthCheckProbe struct {
+	Type HealthCheckProbeType `json:"type"`
+	Port int                  `json:"port"`
+
+	// TLS
+	SNI         string `json:"sni"`         // 握手时使用的域名，同时用来校验证书
+	VerifyCert  bool   `json:"verifyCert"`  // 是否校验证书链
+	MinCertDays int    `json:"minCertDays"` // 证书剩余有效天数不能少于此值
+
+	// DNS
+	Domain     string `json:"domain"`     // 查询的域名
+	RecordType string `json:"recordType"` // 查询的记录类型，默认为A
+
+	// UDP
+	Payload string `json:"payload"` // 发送的数据
+	Expect  string `json:"expect"`  // 响应中需要包含的数据，为空表示只要有响应即可
+}
+
+// 执行探测
+func (this *HealthCheckProbe) Probe(ip string, timeout time.Duration) error {
+	switch this.Type {
+	case HealthCheckProbeTypeTCP:
+		return this.probeTCP(ip, timeout)
+	case HealthCheckProbeTypeTLS:
+		return this.probeTLS(ip, timeout)
+	case HealthCheckProbeTypeDNS:
+		return this.probeDNS(ip, timeout)
+	case HealthCheckProbeTypeUDP:
+		return this.probeUDP(ip, timeout)
+	}
+	return errors.New("unsupported probe type '" + this.Type + "'")
+}
+
+// TCP连接
+func (this *HealthCheckProbe) probeTCP(ip string, timeout time.Duration) error {
+	conn, err := net.DialTimeout("tcp", this.addr(ip, 80), timeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// TLS握手
+func (this *HealthCheckProbe) probeTLS(ip string, timeout time.Duration) error {
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", this.addr(ip, 443), &tls.Config{
+		ServerName:         this.SNI,
+		InsecureSkipVerify: !this.VerifyCert,
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return errors.New("no peer certificates")
+	}
+	leaf := certs[0]
+
+	// 不校验证书链时，仍然需要检查证书中的域名
+	if !this.VerifyCert && len(this.SNI) > 0 {
+		err = leaf.VerifyHostname(this.SNI)
+		if err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	if now.After(leaf.NotAfter) {
+		return errors.New("certificate has expired at " + leaf.NotAfter.Format("2006-01-02 15:04:05"))
+	}
+	if this.MinCertDays > 0 && leaf.NotAfter.Before(now.AddDate(0, 0, this.MinCertDays)) {
+		return errors.New("certificate will expire at " + leaf.NotAfter.Format("2006-01-02 15:04:05") + ", less than " + strconv.Itoa(this.MinCertDays) + " days")
+	}
+	return nil
+}
+
+// DNS查询
+func (this *HealthCheckProbe) probeDNS(ip string, timeout time.Duration) error {
+	if len(this.Domain) == 0 {
+		return errors.New("'domain' should not be empty")
+	}
+	recordType := strings.ToUpper(this.RecordType)
+	if len(recordType) == 0 {
+		recordType = "A"
+	}
+	qType, ok := dns.StringToType[recordType]
+	if !ok {
+		return errors.New("invalid record type '" + this.RecordType + "'")
+	}
+
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(this.Domain), qType)
+	client := &dns.Client{
+		Net:     "udp",
+		Timeout: timeout,
+	}
+	resp, _, err := client.Exchange(msg, this.addr(ip, 53))
+	if err != nil {
+		return err
+	}
+	if resp.Rcode != dns.RcodeSuccess {
+		return errors.New("invalid response code '" + dns.RcodeToString[resp.Rcode] + "'")
+	}
+	return nil
+}
+
+// UDP发送和接收
+func (this *HealthCheckProbe) probeUDP(ip string, timeout time.Duration) error {
+	if this.Port <= 0 {
+		return errors.New("'port' should not be empty")
+	}
+	conn, err := net.DialTimeout("udp", this.addr(ip, 0), timeout)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+	_, err = conn.Write([]byte(this.Payload))
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, 4096)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return err
+	}
+	if len(this.Expect) > 0 && !bytes.Contains(buf[:n], []byte(this.Expect)) {
+		return errors.New("response does not contain '" + this.Expect + "'")
+	}
+	return nil
+}
+
+// 组合地址
+func (this *HealthCheckProbe) addr(ip string, defaultPort int) string {
+	port := this.Port
+	if port <= 0 {
+		port = defaultPort
+	}
+	return net.JoinHostPort(ip, strconv.Itoa(port))
+}
@@ -0,0 +1,156 @@
+package tasks
+
+import (
+	"github.com/miekg/dns"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestHealthCheckProbe_TCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	probe := &HealthCheckProbe{Type: HealthCheckProbeTypeTCP, Port: port}
+	err = probe.Probe("127.0.0.1", 2*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = listener.Close()
+	err = probe.Probe("127.0.0.1", 2*time.Second)
+	if err == nil {
+		t.Fatal("probe on closed port should fail")
+	}
+	t.Log(err)
+}
+
+func TestHealthCheckProbe_TLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {}))
+	defer server.Close()
+	port := server.Listener.Addr().(*net.TCPAddr).Port
+
+	// 测试证书中包含example.com
+	probe := &HealthCheckProbe{Type: HealthCheckProbeTypeTLS, Port: port, SNI: "example.com"}
+	err := probe.Probe("127.0.0.1", 2*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	probe.SNI = "example.org"
+	err = probe.Probe("127.0.0.1", 2*time.Second)
+	if err == nil {
+		t.Fatal("probe with mismatched SNI should fail")
+	}
+	t.Log(err)
+
+	// 自签名证书无法通过证书链校验
+	probe.SNI = "example.com"
+	probe.VerifyCert = true
+	err = probe.Probe("127.0.0.1", 2*time.Second)
+	if err == nil {
+		t.Fatal("probe with untrusted certificate should fail")
+	}
+	t.Log(err)
+
+	// 证书有效期
+	probe.VerifyCert = false
+	probe.MinCertDays = 365 * 1000
+	err = probe.Probe("127.0.0.1", 2*time.Second)
+	if err == nil {
+		t.Fatal("probe should fail for certificate expiring soon")
+	}
+	t.Log(err)
+}
+
+func TestHealthCheckProbe_DNS(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &dns.Server{
+		PacketConn: conn,
+		Handler: dns.HandlerFunc(func(writer dns.ResponseWriter, req *dns.Msg) {
+			resp := new(dns.Msg)
+			resp.SetReply(req)
+			if req.Question[0].Name != "example.com." {
+				resp.Rcode = dns.RcodeRefused
+			} else {
+				rr, _ := dns.NewRR("example.com. 60 IN A 192.168.1.100")
+				resp.Answer = append(resp.Answer, rr)
+			}
+			_ = writer.WriteMsg(resp)
+		}),
+	}
+	go func() {
+		_ = server.ActivateAndServe()
+	}()
+	defer func() {
+		_ = server.Shutdown()
+	}()
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+
+	probe := &HealthCheckProbe{Type: HealthCheckProbeTypeDNS, Port: port, Domain: "example.com"}
+	err = probe.Probe("127.0.0.1", 2*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	probe.Domain = "example.org"
+	err = probe.Probe("127.0.0.1", 2*time.Second)
+	if err == nil {
+		t.Fatal("probe should fail for refused query")
+	}
+	t.Log(err)
+}
+
+func TestHealthCheckProbe_UDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			_, _ = conn.WriteTo(append([]byte("pong:"), buf[:n]...), addr)
+		}
+	}()
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+
+	probe := &HealthCheckProbe{Type: HealthCheckProbeTypeUDP, Port: port, Payload: "ping", Expect: "pong"}
+	err = probe.Probe("127.0.0.1", 2*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	probe.Expect = "hello"
+	err = probe.Probe("127.0.0.1", 2*time.Second)
+	if err == nil {
+		t.Fatal("probe should fail for unexpected response")
+	}
+	t.Log(err)
+}
+
+func TestHealthCheckProbe_Addr(t *testing.T) {
+	probe := &HealthCheckProbe{Type: HealthCheckProbeTypeTCP}
+	for _, ip := range []string{"192.168.1.100", "::1"} {
+		t.Log(probe.addr(ip, 80))
+	}
+	probe.Port = 8080
+	if probe.addr("::1", 80) != "[::1]:"+strconv.Itoa(8080) {
+		t.Fatal("invalid addr:", probe.addr("::1", 80))
+	}
+}
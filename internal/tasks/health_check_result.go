@@ -3,9 +3,10 @@ package tasks
 import "github.com/TeaOSLab/EdgeAPI/internal/db/models"
 
 type HealthCheckResult struct {
-	Node     *models.Node
-	NodeAddr string
-	IsOk     bool
-	Error    string
-	CostMs   float64
+	Node       *models.Node
+	NodeAddr   string
+	NodeAddrId int64 // 只有在检查节点所有IP地址时才有值
+	IsOk       bool
+	Error      string
+	CostMs     float64
 }
@@ -0,0 +1,77 @@
+package tasks
+
+import (
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/iwind/TeaGo/dbs"
+	"github.com/iwind/TeaGo/logs"
+	"time"
+)
+
+func init() {
+	dbs.OnReady(func() {
+		go NewIPItemExpireTask().Start()
+	})
+}
+
+// 禁用过期IP的任务
+type IPItemExpireTask struct {
+	duration  time.Duration
+	batchSize int64
+}
+
+func NewIPItemExpireTask() *IPItemExpireTask {
+	return &IPItemExpireTask{
+		duration:  1 * time.Minute,
+		batchSize: 1000,
+	}
+}
+
+func (this *IPItemExpireTask) Start() {
+	ticker := time.NewTicker(this.duration)
+	for range ticker.C {
+		err := this.loop()
+		if err != nil {
+			logs.Println("[TASK][IP_ITEM_EXPIRE]" + err.Error())
+		}
+	}
+}
+
+// 单次执行
+func (this *IPItemExpireTask) loop() error {
+	// 防止多个API节点同时执行
+	lockerKey := "ipItemExpireTask"
+	isOk, err := models.SharedSysLockerDAO.Lock(nil, lockerKey, 600)
+	if err != nil {
+		return err
+	}
+	if !isOk {
+		return nil
+	}
+	defer func() {
+		err = models.SharedSysLockerDAO.Unlock(nil, lockerKey)
+		if err != nil {
+			logs.Println("[TASK][IP_ITEM_EXPIRE]" + err.Error())
+		}
+	}()
+
+	db, err := dbs.Default()
+	if err != nil {
+		return err
+	}
+
+	// 分批禁用，每一批使用单独的事务，防止事务过大
+	for {
+		var count int64
+		err = db.RunTx(func(tx *dbs.Tx) error {
+			count, err = models.SharedIPItemDAO.DisableExpiredIPItems(tx, this.batchSize)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if count < this.batchSize {
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package tasks
+
+import (
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/TeaOSLab/EdgeAPI/internal/errors"
+	"github.com/TeaOSLab/EdgeAPI/internal/iplists"
+	"github.com/iwind/TeaGo/dbs"
+	"github.com/iwind/TeaGo/logs"
+	"strconv"
+	"time"
+)
+
+func init() {
+	dbs.OnReady(func() {
+		go NewIPListFeedTask().Start()
+	})
+}
+
+const ipListFeedTaskLockerKey = "ipListFeedTask"
+
+// IP名单订阅源同步任务
+type IPListFeedTask struct {
+	duration time.Duration
+}
+
+func NewIPListFeedTask() *IPListFeedTask {
+	return &IPListFeedTask{
+		duration: 1 * time.Minute,
+	}
+}
+
+func (this *IPListFeedTask) Start() {
+	ticker := time.NewTicker(this.duration)
+	for range ticker.C {
+		err := this.loop()
+		if err != nil {
+			logs.Println("[TASK][IP_LIST_FEED]" + err.Error())
+		}
+	}
+}
+
+// 单次执行
+func (this *IPListFeedTask) loop() error {
+	// 防止多个API节点同时执行
+	lockerKey := ipListFeedTaskLockerKey
+	isOk, err := models.SharedSysLockerDAO.Lock(nil, lockerKey, 3600)
+	if err != nil {
+		return err
+	}
+	if !isOk {
+		return nil
+	}
+	defer func() {
+		err = models.SharedSysLockerDAO.Unlock(nil, lockerKey)
+		if err != nil {
+			logs.Println("[TASK][IP_LIST_FEED]" + err.Error())
+		}
+	}()
+
+	feeds, err := models.SharedIPListFeedDAO.FindAllFeedsToSync(nil)
+	if err != nil {
+		return err
+	}
+	for _, feed := range feeds {
+		err = this.syncFeed(feed)
+		if err != nil {
+			logs.Println("[TASK][IP_LIST_FEED]sync feed '" + strconv.FormatInt(int64(feed.Id), 10) + "' failed: " + err.Error())
+		}
+	}
+	return nil
+}
+
+// 立即同步某个订阅源
+func (this *IPListFeedTask) SyncFeed(feedId int64) error {
+	feed, err := models.SharedIPListFeedDAO.FindEnabledFeed(nil, feedId)
+	if err != nil {
+		return err
+	}
+	if feed == nil {
+		return errors.New("can not find feed '" + strconv.FormatInt(feedId, 10) + "'")
+	}
+
+	// 和定时任务使用同一个锁，防止同时同步
+	isOk, err := models.SharedSysLockerDAO.Lock(nil, ipListFeedTaskLockerKey, 3600)
+	if err != nil {
+		return err
+	}
+	if !isOk {
+		return errors.New("feeds are syncing, please try again later")
+	}
+	defer func() {
+		err := models.SharedSysLockerDAO.Unlock(nil, ipListFeedTaskLockerKey)
+		if err != nil {
+			logs.Println("[TASK][IP_LIST_FEED]" + err.Error())
+		}
+	}()
+
+	return this.syncFeed(feed)
+}
+
+// 同步订阅源，并记录同步结果
+func (this *IPListFeedTask) syncFeed(feed *models.IPListFeed) error {
+	nextSyncAt := time.Now().Unix() + feed.Interval()
+
+	result, err := iplists.Fetch(feed.Url, feed.Format)
+	if err != nil {
+		// 同步失败时保留原有的IP
+		updateErr := models.SharedIPListFeedDAO.UpdateFeedSyncResult(nil, int64(feed.Id), int64(feed.CountItems), err.Error(), nextSyncAt)
+		if updateErr != nil {
+			return updateErr
+		}
+		return err
+	}
+
+	// 结果为空或者大部分无法解析时，可能是订阅源出错，保留原有的IP
+	err = this.checkFeedResult(result)
+	if err != nil {
+		updateErr := models.SharedIPListFeedDAO.UpdateFeedSyncResult(nil, int64(feed.Id), int64(feed.CountItems), err.Error(), nextSyncAt)
+		if updateErr != nil {
+			return updateErr
+		}
+		return err
+	}
+
+	db, err := dbs.Default()
+	if err != nil {
+		return err
+	}
+	err = db.RunTx(func(tx *dbs.Tx) error {
+		_, _, err := models.SharedIPItemDAO.SyncFeedIPItems(tx, int64(feed.ListId), int64(feed.Id), result.Entries, feed.Reason)
+		return err
+	})
+	if err != nil {
+		updateErr := models.SharedIPListFeedDAO.UpdateFeedSyncResult(nil, int64(feed.Id), int64(feed.CountItems), err.Error(), nextSyncAt)
+		if updateErr != nil {
+			return updateErr
+		}
+		return err
+	}
+
+	return models.SharedIPListFeedDAO.UpdateFeedSyncResult(nil, int64(feed.Id), int64(len(result.Entries)), "", nextSyncAt)
+}
+
+// 检查订阅源结果是否可用
+func (this *IPListFeedTask) checkFeedResult(result *iplists.ParseResult) error {
+	if len(result.Entries) == 0 {
+		if len(result.Errors) > 0 {
+			return errors.New("no valid entries in feed, first error: " + result.Errors[0])
+		}
+		return errors.New("no entries in feed")
+	}
+	if len(result.Errors) > len(result.Entries) {
+		return errors.New("too many invalid lines in feed (" + strconv.Itoa(len(result.Errors)) + " invalid, " + strconv.Itoa(len(result.Entries)) + " valid), first error: " + result.Errors[0])
+	}
+	return nil
+}
@@ -1,16 +1,22 @@
 package tasks
 
 import (
+	"encoding/json"
+	"errors"
 	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/TeaOSLab/EdgeAPI/internal/notifications"
 	"github.com/TeaOSLab/EdgeAPI/internal/utils"
 	"github.com/iwind/TeaGo/dbs"
 	"github.com/iwind/TeaGo/logs"
+	"github.com/iwind/TeaGo/types"
 	"time"
 )
 
 func init() {
 	dbs.OnReady(func() {
-		go NewMessageTask().Run()
+		task := NewMessageTask()
+		go task.Run()
+		go task.RunNotify()
 	})
 }
 
@@ -34,8 +40,154 @@ func (this *MessageTask) Run() {
 	}
 }
 
+// 发送通知
+func (this *MessageTask) RunNotify() {
+	ticker := time.NewTicker(5 * time.Second)
+	for range ticker.C {
+		err := this.notifyLoop()
+		if err != nil {
+			logs.Println("[TASK][MESSAGE_NOTIFY]" + err.Error())
+		}
+	}
+}
+
 // 单次运行
 func (this *MessageTask) loop() error {
 	dayTime := time.Now().AddDate(0, 0, -30) // TODO 这个30天应该可以在界面上设置
-	return models.NewMessageDAO().DeleteMessagesBeforeDay(nil, dayTime)
+	err := models.NewMessageDAO().DeleteMessagesBeforeDay(nil, dayTime)
+	if err != nil {
+		return err
+	}
+	return models.SharedMessageDeliveryDAO.DeleteDeliveriesBeforeDay(nil, dayTime)
+}
+
+// 单次发送通知
+func (this *MessageTask) notifyLoop() error {
+	lockerKey := "messageNotifyTask"
+	isOk, err := models.SharedSysLockerDAO.Lock(nil, lockerKey, 600)
+	if err != nil {
+		return err
+	}
+	if !isOk {
+		return nil
+	}
+	defer func() {
+		err = models.SharedSysLockerDAO.Unlock(nil, lockerKey)
+		if err != nil {
+			logs.Println("[TASK][MESSAGE_NOTIFY]" + err.Error())
+		}
+	}()
+
+	// 上次发送到的消息ID
+	settingKey := "messageNotifyLastId"
+	lastIdJSON, err := models.SharedSysSettingDAO.ReadSetting(nil, settingKey)
+	if err != nil {
+		return err
+	}
+	if len(lastIdJSON) == 0 {
+		// 第一次运行时不发送以前的消息
+		maxId, err := models.SharedMessageDAO.FindMaxMessageId(nil)
+		if err != nil {
+			return err
+		}
+		return models.SharedSysSettingDAO.UpdateSetting(nil, settingKey, []byte(types.String(maxId)))
+	}
+	lastId := types.Int64(string(lastIdJSON))
+
+	messages, err := models.SharedMessageDAO.FindMessagesAfterId(nil, lastId, 100)
+	if err != nil {
+		return err
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+
+	channels, err := models.SharedMessageChannelDAO.FindAllEnabledAndOnMessageChannels(nil)
+	if err != nil {
+		return err
+	}
+	for _, message := range messages {
+		for _, channel := range channels {
+			if !channel.Match(message.Level, message.Type) {
+				continue
+			}
+			err = this.notify(channel, message)
+			if err != nil {
+				return err
+			}
+		}
+
+		// 每条消息发送后都记录位置，防止出错时重复发送
+		err = models.SharedSysSettingDAO.UpdateSetting(nil, settingKey, []byte(types.String(message.Id)))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 通过某个渠道发送消息
+// 只有数据库出错时才返回错误，发送错误记录在发送记录中
+func (this *MessageTask) notify(channel *models.MessageChannel, message *models.Message) error {
+	channelId := int64(channel.Id)
+	messageId := int64(message.Id)
+	now := time.Now().Unix()
+
+	// 去重
+	if channel.DedupSeconds > 0 && len(message.Hash) > 0 {
+		exists, err := models.SharedMessageDeliveryDAO.ExistsChannelDeliveryWithHash(nil, channelId, message.Hash, now-int64(channel.DedupSeconds))
+		if err != nil {
+			return err
+		}
+		if exists {
+			return nil
+		}
+	}
+
+	// 限速
+	if channel.RateLimit > 0 {
+		count, err := models.SharedMessageDeliveryDAO.CountChannelDeliveriesSince(nil, channelId, now-60)
+		if err != nil {
+			return err
+		}
+		if count >= int64(channel.RateLimit) {
+			return models.SharedMessageDeliveryDAO.CreateDelivery(nil, channelId, messageId, message.Hash, false, "rate limit exceeded: "+types.String(channel.RateLimit)+" messages per minute")
+		}
+	}
+
+	err := this.send(channel, message)
+	if err != nil {
+		return models.SharedMessageDeliveryDAO.CreateDelivery(nil, channelId, messageId, message.Hash, false, err.Error())
+	}
+	return models.SharedMessageDeliveryDAO.CreateDelivery(nil, channelId, messageId, message.Hash, true, "")
+}
+
+// 发送消息
+func (this *MessageTask) send(channel *models.MessageChannel, message *models.Message) error {
+	instance := notifications.FindChannel(channel.Type)
+	if instance == nil {
+		return errors.New("invalid channel type '" + channel.Type + "'")
+	}
+	params, err := channel.DecodeParams()
+	if err != nil {
+		return err
+	}
+	err = instance.Init(params)
+	if err != nil {
+		return err
+	}
+
+	msg := &notifications.Message{
+		Id:        int64(message.Id),
+		Type:      message.Type,
+		Level:     message.Level,
+		Body:      message.Body,
+		ClusterId: int64(message.ClusterId),
+		NodeId:    int64(message.NodeId),
+		CreatedAt: int64(message.CreatedAt),
+	}
+	if models.IsNotNull(message.Params) {
+		msg.Params = json.RawMessage(message.Params)
+	}
+	return instance.Send(msg)
 }
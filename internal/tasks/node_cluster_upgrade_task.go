@@ -0,0 +1,406 @@
+package tasks
+
+import (
+	"errors"
+	"github.com/TeaOSLab/EdgeAPI/internal/db/models"
+	"github.com/TeaOSLab/EdgeAPI/internal/installers"
+	"github.com/TeaOSLab/EdgeAPI/internal/utils"
+	"github.com/TeaOSLab/EdgeAPI/internal/utils/numberutils"
+	"github.com/iwind/TeaGo/dbs"
+	"github.com/iwind/TeaGo/logs"
+	"time"
+)
+
+func init() {
+	dbs.OnReadyDone(func() {
+		go NewNodeClusterUpgradeTask().Start()
+	})
+}
+
+// 安装进程最多尝试次数，API节点重启后会重新开始安装
+const nodeClusterUpgradeMaxTries = 3
+
+// 升级循环和取消升级共用的锁
+const nodeClusterUpgradeLockerKey = "node_cluster_upgrade_task"
+
+// 集群滚动升级任务
+// 所有的进度都保存在数据库中，每次循环根据保存的进度推进，所以API节点重启后可以继续升级
+type NodeClusterUpgradeTask struct {
+}
+
+func NewNodeClusterUpgradeTask() *NodeClusterUpgradeTask {
+	return &NodeClusterUpgradeTask{}
+}
+
+func (this *NodeClusterUpgradeTask) Start() {
+	ticker := time.NewTicker(10 * time.Second)
+	for range ticker.C {
+		err := this.Loop()
+		if err != nil {
+			logs.Println("[TASK][NODE_CLUSTER_UPGRADE]" + err.Error())
+		}
+	}
+}
+
+func (this *NodeClusterUpgradeTask) Loop() error {
+	lockerKey := nodeClusterUpgradeLockerKey
+	isOk, err := models.SharedSysLockerDAO.Lock(nil, lockerKey, 600)
+	if err != nil {
+		return err
+	}
+	if !isOk {
+		return nil
+	}
+	defer func() {
+		err = models.SharedSysLockerDAO.Unlock(nil, lockerKey)
+		if err != nil {
+			logs.Println("[TASK][NODE_CLUSTER_UPGRADE]" + err.Error())
+		}
+	}()
+
+	upgrades, err := models.SharedNodeClusterUpgradeDAO.FindAllActiveUpgrades(nil)
+	if err != nil {
+		return err
+	}
+	for _, upgrade := range upgrades {
+		err = this.runUpgrade(upgrade)
+		if err != nil {
+			logs.Println("[TASK][NODE_CLUSTER_UPGRADE]upgrade '" + numberutils.FormatInt64(int64(upgrade.Id)) + "': " + err.Error())
+		}
+	}
+	return nil
+}
+
+// 取消升级
+// 正在升级的节点会被标记为失败，并恢复DNS
+// 需要等待正在运行的升级循环结束，防止循环同时修改升级进度
+func (this *NodeClusterUpgradeTask) Cancel(upgradeId int64) (err error) {
+	lockerKey := nodeClusterUpgradeLockerKey
+	isOk := false
+	for i := 0; i < 30; i++ {
+		isOk, err = models.SharedSysLockerDAO.Lock(nil, lockerKey, 600)
+		if err != nil {
+			return err
+		}
+		if isOk {
+			break
+		}
+		time.Sleep(1 * time.Second)
+	}
+	if !isOk {
+		return errors.New("upgrade task is running, please try again later")
+	}
+	defer func() {
+		err1 := models.SharedSysLockerDAO.Unlock(nil, lockerKey)
+		if err1 != nil {
+			logs.Println("[TASK][NODE_CLUSTER_UPGRADE]" + err1.Error())
+		}
+	}()
+
+	upgrade, err := models.SharedNodeClusterUpgradeDAO.FindEnabledUpgrade(nil, upgradeId)
+	if err != nil {
+		return err
+	}
+	if upgrade == nil {
+		return nil
+	}
+
+	err = models.SharedNodeClusterUpgradeDAO.UpdateUpgradeStatus(nil, upgradeId, models.NodeClusterUpgradeStatusCancelled, "")
+	if err != nil {
+		return err
+	}
+	err = models.SharedNodeClusterUpgradeNodeDAO.SkipPendingUpgradeNodes(nil, upgradeId)
+	if err != nil {
+		return err
+	}
+
+	upgradingNodes, err := models.SharedNodeClusterUpgradeNodeDAO.FindAllUpgradeNodesWithStatus(nil, upgradeId, models.NodeClusterUpgradeNodeStatusUpgrading)
+	if err != nil {
+		return err
+	}
+	for _, upgradeNode := range upgradingNodes {
+		err = this.finishNode(upgrade, upgradeNode, false, "upgrade has been cancelled")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 推进单个升级任务
+func (this *NodeClusterUpgradeTask) runUpgrade(upgrade *models.NodeClusterUpgrade) error {
+	upgradeId := int64(upgrade.Id)
+
+	// 检查正在升级的节点
+	upgradingNodes, err := models.SharedNodeClusterUpgradeNodeDAO.FindAllUpgradeNodesWithStatus(nil, upgradeId, models.NodeClusterUpgradeNodeStatusUpgrading)
+	if err != nil {
+		return err
+	}
+	countUpgrading := 0
+	for _, upgradeNode := range upgradingNodes {
+		isFinished, err := this.checkNode(upgrade, upgradeNode)
+		if err != nil {
+			return err
+		}
+		if !isFinished {
+			countUpgrading++
+		}
+	}
+	if countUpgrading > 0 {
+		return nil
+	}
+
+	// 暂停时只跟踪正在升级的节点，不再开始新的批次
+	if !upgrade.IsRunning() {
+		return nil
+	}
+
+	// 当前批次结束后检查失败数，nextBatchAt=0 表示当前批次尚未结算
+	batch := int32(upgrade.Batch)
+	if upgrade.NextBatchAt == 0 {
+		countFailed, err := models.SharedNodeClusterUpgradeNodeDAO.CountFailedUpgradeNodesWithBatch(nil, upgradeId, batch)
+		if err != nil {
+			return err
+		}
+		if countFailed >= int64(upgrade.MaxFailures) {
+			errString := numberutils.FormatInt64(countFailed) + " nodes failed in batch " + numberutils.FormatInt(int(batch))
+			err = models.SharedNodeClusterUpgradeDAO.UpdateUpgradeStatus(nil, upgradeId, models.NodeClusterUpgradeStatusHalted, errString)
+			if err != nil {
+				return err
+			}
+			return models.SharedMessageDAO.CreateClusterMessage(nil, int64(upgrade.ClusterId), models.MessageTypeNodeClusterUpgradeHalted, models.MessageLevelError, "集群升级第"+numberutils.FormatInt(int(batch))+"批有"+numberutils.FormatInt64(countFailed)+"个节点升级失败，升级已停止", nil)
+		}
+
+		nextBatchAt := time.Now().Unix() + int64(upgrade.BatchDelay)
+		err = models.SharedNodeClusterUpgradeDAO.UpdateUpgradeNextBatchAt(nil, upgradeId, nextBatchAt)
+		if err != nil {
+			return err
+		}
+		upgrade.NextBatchAt = uint64(nextBatchAt)
+	}
+	if int64(upgrade.NextBatchAt) > time.Now().Unix() {
+		return nil
+	}
+
+	// 开始下一个批次
+	pendingNodes, err := models.SharedNodeClusterUpgradeNodeDAO.FindPendingUpgradeNodes(nil, upgradeId, int64(upgrade.BatchSize))
+	if err != nil {
+		return err
+	}
+	if len(pendingNodes) == 0 {
+		err = models.SharedNodeClusterUpgradeDAO.UpdateUpgradeStatus(nil, upgradeId, models.NodeClusterUpgradeStatusDone, "")
+		if err != nil {
+			return err
+		}
+		return models.SharedMessageDAO.CreateClusterMessage(nil, int64(upgrade.ClusterId), models.MessageTypeNodeClusterUpgradeDone, models.MessageLevelSuccess, "集群升级已完成", nil)
+	}
+
+	batch++
+	err = models.SharedNodeClusterUpgradeDAO.UpdateUpgradeBatch(nil, upgradeId, batch)
+	if err != nil {
+		return err
+	}
+	err = models.SharedNodeClusterUpgradeDAO.UpdateUpgradeNextBatchAt(nil, upgradeId, 0)
+	if err != nil {
+		return err
+	}
+	for _, upgradeNode := range pendingNodes {
+		err = this.startNode(upgrade, upgradeNode, batch)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 开始升级单个节点
+func (this *NodeClusterUpgradeTask) startNode(upgrade *models.NodeClusterUpgrade, upgradeNode *models.NodeClusterUpgradeNode, batch int32) error {
+	nodeId := int64(upgradeNode.NodeId)
+	node, err := models.SharedNodeDAO.FindEnabledNode(nil, nodeId)
+	if err != nil {
+		return err
+	}
+	if node == nil {
+		err = models.SharedNodeClusterUpgradeNodeDAO.StartUpgradeNode(nil, int64(upgradeNode.Id), batch, false, false)
+		if err != nil {
+			return err
+		}
+		return models.SharedNodeClusterUpgradeNodeDAO.FinishUpgradeNode(nil, int64(upgradeNode.Id), false, "node has been deleted", false)
+	}
+
+	// 从DNS中移除
+	wasUp := node.IsUp == 1
+	isRemovedFromDNS := false
+	if upgrade.RemoveFromDNS == 1 && wasUp {
+		err = models.SharedNodeDAO.UpdateNodeIsUp(nil, nodeId, false)
+		if err != nil {
+			return err
+		}
+		isRemovedFromDNS = true
+	}
+
+	err = models.SharedNodeClusterUpgradeNodeDAO.StartUpgradeNode(nil, int64(upgradeNode.Id), batch, wasUp, isRemovedFromDNS)
+	if err != nil {
+		return err
+	}
+	return this.installNode(nodeId)
+}
+
+// 检查单个节点的升级进度
+func (this *NodeClusterUpgradeTask) checkNode(upgrade *models.NodeClusterUpgrade, upgradeNode *models.NodeClusterUpgradeNode) (isFinished bool, err error) {
+	nodeId := int64(upgradeNode.NodeId)
+	node, err := models.SharedNodeDAO.FindEnabledNode(nil, nodeId)
+	if err != nil {
+		return false, err
+	}
+	if node == nil {
+		return true, this.finishNode(upgrade, upgradeNode, false, "node has been deleted")
+	}
+
+	waitingReason, failedReason, err := this.checkNodeStatus(upgrade, upgradeNode, node)
+	if err != nil {
+		return false, err
+	}
+	if len(failedReason) > 0 {
+		return true, this.finishNode(upgrade, upgradeNode, false, failedReason)
+	}
+	if len(waitingReason) == 0 {
+		return true, this.finishNode(upgrade, upgradeNode, true, "")
+	}
+
+	// 超时
+	if time.Now().Unix()-int64(upgradeNode.StartedAt) > int64(upgrade.NodeTimeout) {
+		return true, this.finishNode(upgrade, upgradeNode, false, "timeout: "+waitingReason)
+	}
+	return false, nil
+}
+
+// 检查节点状态，返回需要继续等待的原因，或者失败原因
+func (this *NodeClusterUpgradeTask) checkNodeStatus(upgrade *models.NodeClusterUpgrade, upgradeNode *models.NodeClusterUpgradeNode, node *models.Node) (waitingReason string, failedReason string, err error) {
+	nodeId := int64(node.Id)
+
+	// 安装进度
+	installStatus, err := node.DecodeInstallStatus()
+	if err != nil {
+		return "", "", err
+	}
+	if !installStatus.IsFinished {
+		// 安装进程可能因为API节点重启而中断
+		if !installStatus.IsRunning && installStatus.UpdatedAt < time.Now().Unix()-60 {
+			return this.retryInstall(upgradeNode, "install process has been interrupted")
+		}
+		return "waiting for installation", "", nil
+	}
+	if !installStatus.IsOk {
+		if installStatus.Error == "timeout" && len(installStatus.ErrorCode) == 0 {
+			return this.retryInstall(upgradeNode, "install process has been interrupted")
+		}
+		if len(installStatus.ErrorCode) > 0 {
+			return "", "install failed: [" + installStatus.ErrorCode + "]" + installStatus.Error, nil
+		}
+		return "", "install failed: " + installStatus.Error, nil
+	}
+
+	// 等待节点以新版本重新连接
+	if node.IsActive != 1 {
+		return "waiting for node to reconnect", "", nil
+	}
+	status, err := node.DecodeStatus()
+	if err != nil {
+		return "", "", err
+	}
+	if status == nil || status.UpdatedAt < int64(upgradeNode.StartedAt) {
+		return "waiting for node to report status", "", nil
+	}
+	if utils.VersionToLong(status.BuildVersion) < utils.VersionToLong(upgradeNode.NewVersion) {
+		return "waiting for node to run version '" + upgradeNode.NewVersion + "', current: '" + status.BuildVersion + "'", "", nil
+	}
+
+	// 健康检查
+	result, err := NewHealthCheckExecutor(int64(upgrade.ClusterId)).CheckNode(node)
+	if err != nil {
+		return "", "", err
+	}
+	if result != nil && !result.IsOk {
+		logs.Println("[TASK][NODE_CLUSTER_UPGRADE]node '" + numberutils.FormatInt64(nodeId) + "' health check failed: " + result.Error)
+		return "waiting for health check: " + result.Error, "", nil
+	}
+
+	return "", "", nil
+}
+
+// 重新安装
+func (this *NodeClusterUpgradeTask) retryInstall(upgradeNode *models.NodeClusterUpgradeNode, reason string) (waitingReason string, failedReason string, err error) {
+	if upgradeNode.CountTries >= nodeClusterUpgradeMaxTries {
+		return "", reason, nil
+	}
+	err = models.SharedNodeClusterUpgradeNodeDAO.IncreaseUpgradeNodeTries(nil, int64(upgradeNode.Id))
+	if err != nil {
+		return "", "", err
+	}
+	err = this.installNode(int64(upgradeNode.NodeId))
+	if err != nil {
+		return "", "", err
+	}
+	return "waiting for installation", "", nil
+}
+
+// 结束单个节点的升级
+// 成功后恢复DNS；失败时只有节点仍然在线才恢复DNS，否则等待健康检查恢复；
+// 集群没有开启自动上下线的健康检查时，不会有其他任务恢复节点，所以失败时也直接恢复DNS
+func (this *NodeClusterUpgradeTask) finishNode(upgrade *models.NodeClusterUpgrade, upgradeNode *models.NodeClusterUpgradeNode, isOk bool, errString string) error {
+	nodeId := int64(upgradeNode.NodeId)
+	isRemovedFromDNS := upgradeNode.IsRemovedFromDNS == 1
+	if isRemovedFromDNS && upgradeNode.WasUp == 1 {
+		shouldRestore := isOk
+		if !isOk {
+			isActive, err := models.SharedNodeDAO.FindNodeActive(nil, nodeId)
+			if err != nil {
+				return err
+			}
+			shouldRestore = isActive
+			if !isActive {
+				isAutoUp, err := NewHealthCheckExecutor(int64(upgrade.ClusterId)).IsAutoUp()
+				if err != nil {
+					return err
+				}
+				shouldRestore = !isAutoUp
+			}
+		}
+		if shouldRestore {
+			err := models.SharedNodeDAO.UpdateNodeIsUp(nil, nodeId, true)
+			if err != nil {
+				return err
+			}
+			isRemovedFromDNS = false
+		}
+	}
+
+	if !isOk {
+		logs.Println("[TASK][NODE_CLUSTER_UPGRADE]upgrade node '" + numberutils.FormatInt64(nodeId) + "' failed: " + errString)
+	}
+	return models.SharedNodeClusterUpgradeNodeDAO.FinishUpgradeNode(nil, int64(upgradeNode.Id), isOk, errString, isRemovedFromDNS)
+}
+
+// 启动安装进程
+func (this *NodeClusterUpgradeTask) installNode(nodeId int64) error {
+	err := models.SharedNodeDAO.UpdateNodeIsInstalled(nil, nodeId, false)
+	if err != nil {
+		return err
+	}
+
+	installStatus := models.NewNodeInstallStatus()
+	installStatus.UpdatedAt = time.Now().Unix()
+	err = models.SharedNodeDAO.UpdateNodeInstallStatus(nil, nodeId, installStatus)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		err := installers.SharedQueue().InstallNodeProcess(nodeId, true)
+		if err != nil {
+			logs.Println("[TASK][NODE_CLUSTER_UPGRADE]install node '" + numberutils.FormatInt64(nodeId) + "': " + err.Error())
+		}
+	}()
+	return nil
+}
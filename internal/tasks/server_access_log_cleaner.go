@@ -68,6 +68,12 @@ func (this *ServerAccessLogCleaner) Loop() error {
 		return err
 	}
 
+	// 分片位置记录
+	err = models.SharedHTTPAccessLogShardDAO.DeleteShardsBeforeDay(nil, endDay)
+	if err != nil {
+		return err
+	}
+
 	// 日志数据库节点
 	nodes, err := models.SharedDBNodeDAO.FindAllEnabledAndOnDBNodes(nil)
 	if err != nil {
@@ -0,0 +1,132 @@
+package hashring
+
+import (
+	"hash/crc32"
+	"sort"
+	"strconv"
+)
+
+// 每个权重单位对应的虚拟节点数量
+const replicasPerWeight = 64
+
+// 最大权重，防止虚拟节点过多
+const maxWeight = 100
+
+// 带权重的一致性Hash环
+// 增加或删除节点时，只有落在该节点上的Key会被重新分配
+type Ring struct {
+	hashes  []uint32
+	nodeMap map[uint32]int64 // hash => nodeId
+	weights map[int64]int    // nodeId => weight
+}
+
+func NewRing() *Ring {
+	return &Ring{
+		nodeMap: map[uint32]int64{},
+		weights: map[int64]int{},
+	}
+}
+
+// 添加节点，权重小于1时按1计算
+func (this *Ring) Add(nodeId int64, weight int) {
+	if weight < 1 {
+		weight = 1
+	}
+	if weight > maxWeight {
+		weight = maxWeight
+	}
+	if _, ok := this.weights[nodeId]; ok {
+		this.Remove(nodeId)
+	}
+	this.weights[nodeId] = weight
+
+	prefix := strconv.FormatInt(nodeId, 10) + "#"
+	for i := 0; i < weight*replicasPerWeight; i++ {
+		hash := crc32.ChecksumIEEE([]byte(prefix + strconv.Itoa(i)))
+
+		// 极少数情况下会有Hash冲突，此时保留ID较小的节点，以保证结果和添加顺序无关
+		oldNodeId, ok := this.nodeMap[hash]
+		if ok {
+			if oldNodeId < nodeId {
+				continue
+			}
+		} else {
+			this.hashes = append(this.hashes, hash)
+		}
+		this.nodeMap[hash] = nodeId
+	}
+	sort.Slice(this.hashes, func(i, j int) bool {
+		return this.hashes[i] < this.hashes[j]
+	})
+}
+
+// 删除节点
+// 重新构建剩余节点，以恢复被Hash冲突覆盖的虚拟节点
+func (this *Ring) Remove(nodeId int64) {
+	if _, ok := this.weights[nodeId]; !ok {
+		return
+	}
+	delete(this.weights, nodeId)
+
+	weights := this.weights
+	this.hashes = nil
+	this.nodeMap = map[uint32]int64{}
+	this.weights = map[int64]int{}
+	for otherNodeId, weight := range weights {
+		this.Add(otherNodeId, weight)
+	}
+}
+
+// 节点数量
+func (this *Ring) Len() int {
+	return len(this.weights)
+}
+
+// 节点权重
+func (this *Ring) Weights() map[int64]int {
+	result := map[int64]int{}
+	for nodeId, weight := range this.weights {
+		result[nodeId] = weight
+	}
+	return result
+}
+
+// 查找Key所属的节点，没有节点时返回0
+func (this *Ring) Lookup(key string) int64 {
+	nodeIds := this.LookupN(key, 1)
+	if len(nodeIds) == 0 {
+		return 0
+	}
+	return nodeIds[0]
+}
+
+// 按顺时针方向查找Key所属的N个不同节点，第一个为主节点，其余的可以作为故障转移节点
+func (this *Ring) LookupN(key string, n int) []int64 {
+	if len(this.hashes) == 0 || n <= 0 {
+		return nil
+	}
+	if n > len(this.weights) {
+		n = len(this.weights)
+	}
+
+	hash := crc32.ChecksumIEEE([]byte(key))
+	index := sort.Search(len(this.hashes), func(i int) bool {
+		return this.hashes[i] >= hash
+	})
+
+	result := []int64{}
+	for i := 0; i < len(this.hashes) && len(result) < n; i++ {
+		nodeId := this.nodeMap[this.hashes[(index+i)%len(this.hashes)]]
+		isAdded := false
+		for _, resultNodeId := range result {
+			if resultNodeId == nodeId {
+				isAdded = true
+				break
+			}
+		}
+		if !isAdded {
+			result = append(result, nodeId)
+		}
+	}
+	return result
+}
@@ -0,0 +1,92 @@
+package hashring
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestRing_Lookup(t *testing.T) {
+	ring := NewRing()
+	if ring.Lookup("1") != 0 {
+		t.Fatal("empty ring should return 0")
+	}
+
+	ring.Add(1, 1)
+	ring.Add(2, 1)
+	ring.Add(3, 1)
+
+	// 结果应该和添加顺序无关
+	ring2 := NewRing()
+	ring2.Add(3, 1)
+	ring2.Add(1, 1)
+	ring2.Add(2, 1)
+
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		if ring.Lookup(key) != ring2.Lookup(key) {
+			t.Fatal("key '" + key + "' should be in the same node")
+		}
+	}
+}
+
+func TestRing_LookupN(t *testing.T) {
+	ring := NewRing()
+	ring.Add(1, 1)
+	ring.Add(2, 1)
+	ring.Add(3, 1)
+
+	nodeIds := ring.LookupN("100", 5)
+	if len(nodeIds) != 3 {
+		t.Fatal("should return 3 nodes, but got", nodeIds)
+	}
+	if nodeIds[0] != ring.Lookup("100") {
+		t.Fatal("first node should be the owner")
+	}
+	if nodeIds[0] == nodeIds[1] || nodeIds[1] == nodeIds[2] || nodeIds[0] == nodeIds[2] {
+		t.Fatal("nodes should be distinct", nodeIds)
+	}
+}
+
+func TestRing_Weight(t *testing.T) {
+	ring := NewRing()
+	ring.Add(1, 1)
+	ring.Add(2, 3)
+
+	countMap := map[int64]int{}
+	for i := 0; i < 10000; i++ {
+		countMap[ring.Lookup(strconv.Itoa(i))]++
+	}
+	t.Log(countMap)
+	if countMap[2] < countMap[1]*2 {
+		t.Fatal("node with larger weight should own more keys")
+	}
+}
+
+func TestRing_Remove(t *testing.T) {
+	ring := NewRing()
+	ring.Add(1, 1)
+	ring.Add(2, 1)
+	ring.Add(3, 1)
+
+	before := map[string]int64{}
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		before[key] = ring.Lookup(key)
+	}
+
+	ring.Remove(3)
+	if ring.Len() != 2 {
+		t.Fatal("should have 2 nodes")
+	}
+
+	// 只有原来属于被删除节点的Key才会被重新分配
+	for key, nodeId := range before {
+		newNodeId := ring.Lookup(key)
+		if newNodeId == 3 {
+			t.Fatal("removed node should not be returned")
+		}
+		if nodeId != 3 && newNodeId != nodeId {
+			t.Fatal("key '" + key + "' should not be moved")
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"bytes"
 	"encoding/binary"
+	"errors"
 	"github.com/cespare/xxhash/v2"
 	"math"
 	"net"
@@ -24,3 +26,62 @@ func IP2Long(ip string) uint64 {
 	}
 	return uint64(binary.BigEndian.Uint32(s.To4()))
 }
+
+// 将IP转换为16字节的二进制，可以直接按字节比较大小
+// IPv4会转换为IPv4映射的IPv6地址（::ffff:a.b.c.d）
+func IP2Bytes(ip net.IP) []byte {
+	return []byte(ip.To16())
+}
+
+// 解析IP范围
+// ipFrom可以是单个IP或者CIDR，使用CIDR时ipTo必须为空；
+// 返回的开始IP和结束IP属于同一个IP族，并且开始IP不大于结束IP，单个IP时两者相同
+func ParseIPRange(ipFrom string, ipTo string) (from net.IP, to net.IP, err error) {
+	ipFrom = strings.TrimSpace(ipFrom)
+	ipTo = strings.TrimSpace(ipTo)
+
+	if strings.Contains(ipFrom, "/") {
+		if len(ipTo) > 0 {
+			return nil, nil, errors.New("'ipTo' should be empty when 'ipFrom' is a CIDR")
+		}
+		_, ipNet, err := net.ParseCIDR(ipFrom)
+		if err != nil {
+			return nil, nil, errors.New("invalid CIDR '" + ipFrom + "'")
+		}
+		from = ipNet.IP
+		if ip4 := from.To4(); ip4 != nil {
+			from = ip4
+		}
+		to = make(net.IP, len(from))
+		for i := range from {
+			to[i] = from[i] | ^ipNet.Mask[i]
+		}
+		return from, to, nil
+	}
+
+	from = net.ParseIP(ipFrom)
+	if from == nil {
+		return nil, nil, errors.New("invalid ip '" + ipFrom + "'")
+	}
+	if ip4 := from.To4(); ip4 != nil {
+		from = ip4
+	}
+	if len(ipTo) == 0 {
+		return from, from, nil
+	}
+
+	to = net.ParseIP(ipTo)
+	if to == nil {
+		return nil, nil, errors.New("invalid ip '" + ipTo + "'")
+	}
+	if ip4 := to.To4(); ip4 != nil {
+		to = ip4
+	}
+	if len(from) != len(to) {
+		return nil, nil, errors.New("'" + ipFrom + "' and '" + ipTo + "' should be in the same IP family")
+	}
+	if bytes.Compare(from, to) > 0 {
+		from, to = to, from
+	}
+	return from, to, nil
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+func TestParseIPRange(t *testing.T) {
+	for _, testCase := range []struct {
+		ipFrom string
+		ipTo   string
+		from   string
+		to     string
+	}{
+		{"192.168.1.1", "", "192.168.1.1", "192.168.1.1"},
+		{"192.168.1.100", "192.168.1.1", "192.168.1.1", "192.168.1.100"},
+		{"192.168.1.0/24", "", "192.168.1.0", "192.168.1.255"},
+		{"2001:db8::1", "", "2001:db8::1", "2001:db8::1"},
+		{"2001:db8:1:2::/64", "", "2001:db8:1:2::", "2001:db8:1:2:ffff:ffff:ffff:ffff"},
+		{"2001:db8::ff", "2001:db8::1", "2001:db8::1", "2001:db8::ff"},
+	} {
+		from, to, err := ParseIPRange(testCase.ipFrom, testCase.ipTo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if from.String() != testCase.from || to.String() != testCase.to {
+			t.Fatal(testCase.ipFrom, testCase.ipTo, "=>", from.String(), to.String())
+		}
+	}
+
+	for _, testCase := range [][2]string{
+		{"192.168.1.0/33", ""},
+		{"192.168.1.0/24", "192.168.1.1"},
+		{"192.168.1.1", "2001:db8::1"},
+		{"abc", ""},
+	} {
+		_, _, err := ParseIPRange(testCase[0], testCase[1])
+		if err == nil {
+			t.Fatal(testCase, "should fail")
+		}
+	}
+}
+
+func TestIP2Bytes(t *testing.T) {
+	from, to, err := ParseIPRange("2001:db8:1:2::/64", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ip := IP2Bytes(net.ParseIP("2001:db8:1:2:abcd::1"))
+	if bytes.Compare(IP2Bytes(from), ip) > 0 || bytes.Compare(IP2Bytes(to), ip) < 0 {
+		t.Fatal("ip should be in range")
+	}
+	ip = IP2Bytes(net.ParseIP("2001:db8:1:3::1"))
+	if bytes.Compare(IP2Bytes(to), ip) >= 0 {
+		t.Fatal("ip should not be in range")
+	}
+
+	// IPv4
+	from, to, err = ParseIPRange("10.0.0.0/8", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ip = IP2Bytes(net.ParseIP("10.1.2.3"))
+	if len(ip) != 16 || bytes.Compare(IP2Bytes(from), ip) > 0 || bytes.Compare(IP2Bytes(to), ip) < 0 {
+		t.Fatal("ip should be in range")
+	}
+}
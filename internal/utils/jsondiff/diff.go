@@ -0,0 +1,153 @@
+package jsondiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Op = string
+
+const (
+	OpAdd     Op = "add"     // 新增
+	OpRemove  Op = "remove"  // 删除
+	OpReplace Op = "replace" // 修改
+)
+
+// 单个变更
+type Change struct {
+	Path     string          `json:"path"` // 使用JSON Pointer格式，比如 /web/root
+	Op       Op              `json:"op"`
+	OldValue json.RawMessage `json:"oldValue,omitempty"`
+	NewValue json.RawMessage `json:"newValue,omitempty"`
+}
+
+// 比较两个JSON文档，返回按路径排序的变更列表
+// 对象按键比较，数组按下标比较
+func Diff(oldJSON []byte, newJSON []byte) ([]*Change, error) {
+	oldValue, err := decode(oldJSON)
+	if err != nil {
+		return nil, err
+	}
+	newValue, err := decode(newJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []*Change{}
+	err = diffValue("", oldValue, newValue, &changes)
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func decode(data []byte) (interface{}, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	err := decoder.Decode(&value)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func diffValue(path string, oldValue interface{}, newValue interface{}, changes *[]*Change) error {
+	oldMap, oldIsMap := oldValue.(map[string]interface{})
+	newMap, newIsMap := newValue.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		keys := []string{}
+		for key := range oldMap {
+			keys = append(keys, key)
+		}
+		for key := range newMap {
+			if _, ok := oldMap[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			childPath := path + "/" + escapeKey(key)
+			oldChild, oldOk := oldMap[key]
+			newChild, newOk := newMap[key]
+			var err error
+			switch {
+			case oldOk && newOk:
+				err = diffValue(childPath, oldChild, newChild, changes)
+			case oldOk:
+				err = appendChange(changes, childPath, OpRemove, oldChild, nil)
+			default:
+				err = appendChange(changes, childPath, OpAdd, nil, newChild)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	oldList, oldIsList := oldValue.([]interface{})
+	newList, newIsList := newValue.([]interface{})
+	if oldIsList && newIsList {
+		for i := 0; i < len(oldList) || i < len(newList); i++ {
+			childPath := path + "/" + strconv.Itoa(i)
+			var err error
+			switch {
+			case i < len(oldList) && i < len(newList):
+				err = diffValue(childPath, oldList[i], newList[i], changes)
+			case i < len(oldList):
+				err = appendChange(changes, childPath, OpRemove, oldList[i], nil)
+			default:
+				err = appendChange(changes, childPath, OpAdd, nil, newList[i])
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if reflect.DeepEqual(oldValue, newValue) {
+		return nil
+	}
+	if path == "" {
+		path = "/"
+	}
+	return appendChange(changes, path, OpReplace, oldValue, newValue)
+}
+
+func appendChange(changes *[]*Change, path string, op Op, oldValue interface{}, newValue interface{}) error {
+	change := &Change{
+		Path: path,
+		Op:   op,
+	}
+	if op != OpAdd {
+		data, err := json.Marshal(oldValue)
+		if err != nil {
+			return err
+		}
+		change.OldValue = data
+	}
+	if op != OpRemove {
+		data, err := json.Marshal(newValue)
+		if err != nil {
+			return err
+		}
+		change.NewValue = data
+	}
+	*changes = append(*changes, change)
+	return nil
+}
+
+// 按照JSON Pointer规范转义键名
+func escapeKey(key string) string {
+	key = strings.ReplaceAll(key, "~", "~0")
+	return strings.ReplaceAll(key, "/", "~1")
+}
@@ -0,0 +1,69 @@
+package jsondiff
+
+import (
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	oldJSON := []byte(`{"name":"a","web":{"root":"/www","indexes":["index.html"]},"isOn":true,"a/b":1}`)
+	newJSON := []byte(`{"name":"b","web":{"root":"/www","indexes":["index.html","index.htm"]},"description":"test","a/b":1}`)
+
+	changes, err := Diff(oldJSON, newJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, change := range changes {
+		t.Log(change.Op, change.Path, string(change.OldValue), string(change.NewValue))
+	}
+
+	expected := []struct {
+		path string
+		op   Op
+	}{
+		{"/description", OpAdd},
+		{"/isOn", OpRemove},
+		{"/name", OpReplace},
+		{"/web/indexes/1", OpAdd},
+	}
+	if len(changes) != len(expected) {
+		t.Fatal("expected", len(expected), "changes, but got", len(changes))
+	}
+	for index, e := range expected {
+		if changes[index].Path != e.path || changes[index].Op != e.op {
+			t.Fatal("unexpected change", changes[index].Op, changes[index].Path)
+		}
+	}
+	if string(changes[2].OldValue) != `"a"` || string(changes[2].NewValue) != `"b"` {
+		t.Fatal("invalid values", string(changes[2].OldValue), string(changes[2].NewValue))
+	}
+}
+
+func TestDiff_Same(t *testing.T) {
+	changes, err := Diff([]byte(`{"a":[1,2,{"b":1.0}]}`), []byte(`{"a":[1,2,{"b":1.0}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Fatal("should have no changes")
+	}
+}
+
+func TestDiff_TypeChanged(t *testing.T) {
+	changes, err := Diff([]byte(`{"a":{"b":1}}`), []byte(`{"a":[1]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Path != "/a" || changes[0].Op != OpReplace {
+		t.Fatal("should replace '/a'")
+	}
+}
+
+func TestDiff_EscapeKey(t *testing.T) {
+	changes, err := Diff([]byte(`{"a/b":1,"c~d":1}`), []byte(`{"a/b":2,"c~d":2}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[0].Path != "/a~1b" || changes[1].Path != "/c~0d" {
+		t.Fatal("invalid paths")
+	}
+}